@@ -1,10 +1,12 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/ledisdb/ledisdb/ledis"
+	"github.com/mylxsw/adanos-alert/agent/config"
 	"github.com/mylxsw/adanos-alert/agent/store"
 	"github.com/mylxsw/adanos-alert/internal/extension"
 	"github.com/mylxsw/adanos-alert/pkg/misc"
@@ -30,7 +32,12 @@ func (m *EventController) Register(router *web.Router) {
 		router.Post("/grafana/", m.AddGrafanaEvent).Name("events:add:grafana")
 		router.Post("/prometheus/api/v1/alerts", m.AddPrometheusEvent).Name("events:add:prometheus") // url 地址末尾不包含 "/"
 		router.Post("/prometheus_alertmanager/", m.AddPrometheusAlertEvent).Name("events:add:prometheus-alert")
+		router.Post("/prometheus_alertmanager/v2/", m.AddAlertmanagerV2Event).Name("events:add:alertmanager-v2")
 		router.Post("/openfalcon/im/", m.AddOpenFalconEvent).Name("events:add:openfalcon")
+		router.Post("/cloudwatch/", m.AddCloudWatchEvent).Name("events:add:cloudwatch")
+		router.Post("/sentry/", m.AddSentryEvent).Name("events:add:sentry")
+		router.Post("/zabbix/", m.AddZabbixEvent).Name("events:add:zabbix")
+		router.Post("/loki/", m.AddLokiEvent).Name("events:add:loki")
 	})
 
 	router.Group("/events", func(router *web.Router) {
@@ -39,11 +46,24 @@ func (m *EventController) Register(router *web.Router) {
 		router.Post("/grafana/", m.AddGrafanaEvent).Name("events:add:grafana")
 		router.Post("/prometheus/api/v1/alerts", m.AddPrometheusEvent).Name("events:add:prometheus") // url 地址末尾不包含 "/"
 		router.Post("/prometheus_alertmanager/", m.AddPrometheusAlertEvent).Name("events:add:prometheus-alert")
+		router.Post("/prometheus_alertmanager/v2/", m.AddAlertmanagerV2Event).Name("events:add:alertmanager-v2")
 		router.Post("/openfalcon/im/", m.AddOpenFalconEvent).Name("events:add:openfalcon")
+		router.Post("/cloudwatch/", m.AddCloudWatchEvent).Name("events:add:cloudwatch")
+		router.Post("/sentry/", m.AddSentryEvent).Name("events:add:sentry")
+		router.Post("/zabbix/", m.AddZabbixEvent).Name("events:add:zabbix")
+		router.Post("/loki/", m.AddLokiEvent).Name("events:add:loki")
 	})
 }
 
 func (m *EventController) saveEvent(msgRepo store.EventStore, commonMessage extension.CommonEvent, ctx web.Context) error {
+	if err := config.Get(m.cc).IngestLimits().Validate(commonMessage); err != nil {
+		return err
+	}
+
+	if commonMessage.Meta == nil {
+		commonMessage.Meta = make(map[string]interface{})
+	}
+
 	commonMessage.Meta["adanos_agent_version"] = m.cc.MustGet(infra.VersionKey).(string)
 	commonMessage.Meta["adanos_agent_ip"] = misc.ServerIP()
 	m.cc.MustResolve(func(db *ledis.DB) {
@@ -62,8 +82,61 @@ func (m *EventController) saveEvent(msgRepo store.EventStore, commonMessage exte
 	return nil
 }
 
+// tooManyEvents 一次请求中包含的事件数量超过限制时返回 413 响应，否则返回 nil
+func (m *EventController) tooManyEvents(ctx web.Context, count int) web.Response {
+	if err := config.Get(m.cc).IngestLimits().ValidateCount(count); err != nil {
+		return ctx.JSONError(err.Error(), http.StatusRequestEntityTooLarge)
+	}
+
+	return nil
+}
+
+// eventFailure 一个请求中包含多个事件时，单个事件保存失败的信息
+type eventFailure struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// saveEvents 依次保存一个请求中包含的多个事件，单个事件失败不影响其它事件
+// 有事件保存失败时返回错误状态码以及所有失败事件的序号和原因：全部因为超过大小限制失败时返回 422，否则返回 500
+func (m *EventController) saveEvents(ctx web.Context, messageStore store.EventStore, kind string, commonMessages []*extension.CommonEvent) web.Response {
+	failures := make([]eventFailure, 0)
+	tooLarge := 0
+	for i, cm := range commonMessages {
+		if err := m.saveEvent(messageStore, *cm, ctx); err != nil {
+			log.WithFields(log.Fields{
+				"message": cm,
+			}).Errorf("save %s message failed: %v", kind, err)
+
+			if errors.Is(err, extension.ErrEventTooLarge) {
+				tooLarge++
+			}
+
+			failures = append(failures, eventFailure{Index: i, Error: err.Error()})
+		}
+	}
+
+	if len(failures) == 0 {
+		return ctx.JSON(struct{}{})
+	}
+
+	code := http.StatusInternalServerError
+	if tooLarge == len(failures) {
+		code = http.StatusUnprocessableEntity
+	}
+
+	return ctx.JSON(web.M{
+		"error":    fmt.Sprintf("%d of %d events failed to save", len(failures), len(commonMessages)),
+		"failures": failures,
+	}).WithCode(code)
+}
+
 func (m *EventController) errorWrap(ctx web.Context, err error) web.Response {
 	if err != nil {
+		if errors.Is(err, extension.ErrEventTooLarge) {
+			return ctx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+		}
+
 		return ctx.JSONError(err.Error(), http.StatusInternalServerError)
 	}
 
@@ -106,16 +179,11 @@ func (m *EventController) AddPrometheusEvent(ctx web.Context, messageStore store
 		return m.errorWrap(ctx, err)
 	}
 
-	for _, cm := range commonMessages {
-		if err := m.saveEvent(messageStore, *cm, ctx); err != nil {
-			log.WithFields(log.Fields{
-				"message": cm,
-			}).Errorf("save prometheus message failed: %v", err)
-			continue
-		}
+	if resp := m.tooManyEvents(ctx, len(commonMessages)); resp != nil {
+		return resp
 	}
 
-	return m.errorWrap(ctx, nil)
+	return m.saveEvents(ctx, messageStore, "prometheus", commonMessages)
 }
 
 // add prometheus-alert message
@@ -128,6 +196,73 @@ func (m *EventController) AddPrometheusAlertEvent(ctx web.Context, messageStore
 	return m.errorWrap(ctx, m.saveEvent(messageStore, *commonMessage, ctx))
 }
 
+// add aws cloudwatch alarm message which is delivered by sns
+func (m *EventController) AddCloudWatchEvent(ctx web.Context, messageStore store.EventStore) web.Response {
+	commonMessage, err := extension.CloudWatchToCommonEvent(ctx.Request().Body())
+	if err != nil {
+		var confirmation *extension.SNSSubscriptionConfirmation
+		if errors.As(err, &confirmation) {
+			if err := confirmation.Confirm(ctx.Context()); err != nil {
+				return ctx.JSONError(err.Error(), http.StatusBadRequest)
+			}
+
+			return ctx.JSON(web.M{"message": "subscription confirmed"})
+		}
+
+		return ctx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	return m.errorWrap(ctx, m.saveEvent(messageStore, *commonMessage, ctx))
+}
+
+// add sentry webhook message
+func (m *EventController) AddSentryEvent(ctx web.Context, messageStore store.EventStore) web.Response {
+	commonMessage, err := extension.SentryToCommonEvent(ctx.Request().Body())
+	if err != nil {
+		return ctx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	return m.errorWrap(ctx, m.saveEvent(messageStore, *commonMessage, ctx))
+}
+
+// add zabbix webhook message
+func (m *EventController) AddZabbixEvent(ctx web.Context, messageStore store.EventStore) web.Response {
+	commonMessage, err := extension.ZabbixToCommonEvent(ctx.Request().Body())
+	if err != nil {
+		return ctx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	return m.errorWrap(ctx, m.saveEvent(messageStore, *commonMessage, ctx))
+}
+
+// add alertmanager message, every alert in it will be saved as a separate event
+func (m *EventController) AddAlertmanagerV2Event(ctx web.Context, messageStore store.EventStore) web.Response {
+	commonMessages, err := extension.AlertmanagerV2ToCommonEvents(ctx.Request().Body())
+	if err != nil {
+		return ctx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	if resp := m.tooManyEvents(ctx, len(commonMessages)); resp != nil {
+		return resp
+	}
+
+	return m.saveEvents(ctx, messageStore, "alertmanager", commonMessages)
+}
+
+// add loki/promtail push message, every log line will be saved as a separate event
+func (m *EventController) AddLokiEvent(ctx web.Context, messageStore store.EventStore) web.Response {
+	commonMessages, err := extension.LokiToCommonEvents(ctx.Request().Body(), ctx.Header("Content-Type"))
+	if err != nil {
+		return ctx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	if resp := m.tooManyEvents(ctx, len(commonMessages)); resp != nil {
+		return resp
+	}
+
+	return m.saveEvents(ctx, messageStore, "loki", commonMessages)
+}
+
 // add open-falcon message
 func (m *EventController) AddOpenFalconEvent(ctx web.Context, messageStore store.EventStore) web.Response {
 	tos := ctx.Input("tos")
@@ -2,6 +2,8 @@ package api
 
 import (
 	"github.com/gorilla/mux"
+	"github.com/mylxsw/adanos-alert/agent/config"
+	"github.com/mylxsw/adanos-alert/internal/extension"
 	"github.com/mylxsw/asteria/log"
 	"github.com/mylxsw/container"
 	"github.com/mylxsw/glacier/infra"
@@ -14,8 +16,12 @@ type ServiceProvider struct{}
 func (s ServiceProvider) Register(app container.Container) {}
 
 func (s ServiceProvider) Boot(app infra.Glacier) {
+	conf := config.Get(app.Container())
+
 	app.WebAppRouter(routers(app.Container()))
 	app.WebAppMuxRouter(func(router *mux.Router) {
+		// 限制推送事件接口的请求体大小
+		router.Use(extension.IngestBodyLimiter(conf.IngestMaxBodySize))
 		// prometheus metrics
 		router.PathPrefix("/metrics").Handler(promhttp.Handler())
 	})
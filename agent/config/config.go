@@ -1,5 +1,12 @@
 package config
 
+import (
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/extension"
+	"github.com/mylxsw/container"
+)
+
 // Config Agent 配置对象
 type Config struct {
 	// DataDir Agent 数据存储目录
@@ -9,8 +16,38 @@ type Config struct {
 	// ServerToken Adanos Server GRPC 访问秘钥
 	ServerToken string `json:"server_token"`
 
+	// StoreBackend 本地事件存储方式，ledis 或者 wal
+	StoreBackend string `json:"store_backend"`
+
+	// BatchSize 每次发送给 Server 的最大事件数量
+	BatchSize int `json:"batch_size"`
+	// FlushInterval 事件发送周期
+	FlushInterval time.Duration `json:"flush_interval"`
+
+	// IngestMaxBodySize 推送事件接口请求体的最大字节数，超过时返回 413
+	IngestMaxBodySize int64 `json:"ingest_max_body_size"`
+	// IngestMaxEvents 一次请求最多包含的事件数量（Prometheus、Loki 等）
+	IngestMaxEvents int `json:"ingest_max_events"`
+	// IngestMaxMetaKeys/IngestMaxTags 单个事件最多包含的 Meta 字段数量和标签数量
+	IngestMaxMetaKeys int `json:"ingest_max_meta_keys"`
+	IngestMaxTags     int `json:"ingest_max_tags"`
+
 	// Listen Agent 监听地址
 	Listen string `json:"listen"`
 	// LogPath Agent 日志目录
 	LogPath string `json:"log_path"`
 }
+
+// IngestLimits 推送事件的限制
+func (conf *Config) IngestLimits() extension.IngestLimits {
+	return extension.IngestLimits{
+		MaxEvents:   conf.IngestMaxEvents,
+		MaxMetaKeys: conf.IngestMaxMetaKeys,
+		MaxTags:     conf.IngestMaxTags,
+	}
+}
+
+// Get 从容器中获取配置对象
+func Get(cc container.Container) *Config {
+	return cc.MustGet(&Config{}).(*Config)
+}
@@ -9,44 +9,112 @@ import (
 	"github.com/mylxsw/adanos-alert/agent/store"
 	"github.com/mylxsw/adanos-alert/rpc/protocol"
 	"github.com/mylxsw/asteria/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
 )
 
-func eventSyncJob(eventStore store.EventStore, conf *config.Config, msgRPCServer protocol.MessageClient) error {
-	for {
-		message, err := eventStore.Dequeue()
-		if err != nil || message == nil {
+// eventSyncing 标识事件同步任务是否在执行中，避免多个任务同时执行导致事件重复发送
+var eventSyncing = make(chan interface{}, 1)
+
+func eventSyncJob(eventStore store.EventStore, conf *config.Config, msgRPCServer protocol.MessageClient, hc grpc_health_v1.HealthClient) error {
+	select {
+	case eventSyncing <- struct{}{}:
+		defer func() { <-eventSyncing }()
+	default:
+		return nil
+	}
+
+	for i := 0; ; i++ {
+		messages, err := eventStore.Peek(conf.BatchSize)
+		if err != nil || len(messages) == 0 {
 			break
 		}
 
-		if err := sendToServer(message, msgRPCServer, conf); err != nil {
-			log.Warningf("事件同步失败，重新加入队列: %s", err)
-			if err := eventStore.Enqueue(message); err != nil {
-				log.Warningf("事件重新写入队列失败: %s, 事件内容：%s", err, message.Data)
+		// 有待发送的事件时，先检查 Server 是否可用，避免无意义的请求
+		if i == 0 {
+			if err := checkServerHealth(hc); err != nil {
+				log.Warningf("Server 不可用，等待下次重试: %s", err)
+				break
 			}
+		}
+
+		// 只有 Server 确认接收之后，才从队列中移除事件
+		processed, err := sendToServer(messages, msgRPCServer, conf)
+		if err != nil {
+			log.Warningf("事件同步失败，等待下次重试: %s", err)
+			break
+		}
 
-			// 如果写入出错，则休息1s，防止重试速度过快
-			time.Sleep(1 * time.Second)
+		if err := eventStore.Ack(processed); err != nil {
+			log.Errorf("事件确认失败: %s, 事件数量：%d", err, processed)
+			break
+		}
+
+		// Server 只处理了部分事件，剩余的事件等待下个周期重新发送
+		if processed < len(messages) {
+			log.Warningf("Server 只接收了 %d/%d 个事件，剩余事件等待下次重试", processed, len(messages))
+			break
+		}
+
+		// 队列中剩余的事件不足一个批次，等待下个周期再发送
+		if len(messages) < conf.BatchSize {
+			break
 		}
 	}
 
 	return nil
 }
 
-func sendToServer(msg *protocol.MessageRequest, msgRPCServer protocol.MessageClient, conf *config.Config) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+// sendToServer 批量发送事件到 Server，返回 Server 已经处理（保存或者拒绝）的事件数量
+// Server 按顺序处理事件，已处理的事件总是 messages 的前缀
+// 旧版本的 Server 不支持 PushBatch 时，改为使用 Push 逐个发送
+func sendToServer(messages []*protocol.MessageRequest, msgRPCServer protocol.MessageClient, conf *config.Config) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	resp, err := msgRPCServer.Push(ctx, msg)
+	resp, err := msgRPCServer.PushBatch(ctx, &protocol.MessageBatchRequest{Messages: messages})
 	if err != nil {
-		return fmt.Errorf("RPC请求失败: %s", err)
+		if status.Code(err) == codes.Unimplemented {
+			log.Debugf("Server 不支持批量发送，改为逐个发送")
+			return pushOneByOne(ctx, messages, msgRPCServer)
+		}
+
+		return 0, fmt.Errorf("RPC请求失败: %s", err)
+	}
+
+	if resp.Rejected > 0 {
+		log.Warningf("%d 个事件格式错误，已被 Server 拒绝", resp.Rejected)
 	}
 
 	if log.DebugEnabled() {
 		log.WithFields(log.Fields{
-			"id":   resp.Id,
-			"body": msg.Data,
+			"ids":      resp.Ids,
+			"rejected": resp.Rejected,
 		}).Debugf("事件同步成功")
 	}
 
-	return nil
+	processed := len(resp.Ids) + int(resp.Rejected)
+	if processed > len(messages) {
+		processed = len(messages)
+	}
+
+	return processed, nil
+}
+
+// pushOneByOne 使用 Push 按顺序逐个发送事件，某个事件发送失败时停止发送，返回已经发送成功的事件数量
+// 第一个事件就发送失败时返回错误
+func pushOneByOne(ctx context.Context, messages []*protocol.MessageRequest, msgRPCServer protocol.MessageClient) (int, error) {
+	for i, msg := range messages {
+		if _, err := msgRPCServer.Push(ctx, msg); err != nil {
+			if i == 0 {
+				return 0, fmt.Errorf("RPC请求失败: %s", err)
+			}
+
+			log.Warningf("事件发送失败: %s", err)
+			return i, nil
+		}
+	}
+
+	return len(messages), nil
 }
@@ -0,0 +1,36 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// messageServiceName Server 端事件服务的 gRPC 服务名称
+const messageServiceName = "protocol.Event"
+
+// checkServerHealth 通过 grpc.health.v1.Health 服务检查 Server 是否可以接收事件
+// 旧版本的 Server 没有实现健康检查服务，这种情况下认为 Server 是健康的
+func checkServerHealth(hc grpc_health_v1.HealthClient) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	resp, err := hc.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: messageServiceName})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return nil
+		}
+
+		return fmt.Errorf("健康检查失败: %s", err)
+	}
+
+	if resp.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		return fmt.Errorf("server 当前状态为 %s", resp.Status)
+	}
+
+	return nil
+}
@@ -1,10 +1,14 @@
 package job
 
 import (
+	"fmt"
+
+	"github.com/mylxsw/adanos-alert/agent/config"
 	"github.com/mylxsw/adanos-alert/rpc/protocol"
 	"github.com/mylxsw/container"
 	"github.com/mylxsw/glacier/cron"
 	"github.com/mylxsw/glacier/infra"
+	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type ServiceProvider struct{}
@@ -12,11 +16,13 @@ type ServiceProvider struct{}
 func (s ServiceProvider) Register(app container.Container) {
 	app.MustSingleton(protocol.NewMessageClient)
 	app.MustSingleton(protocol.NewHeartbeatClient)
+	app.MustSingleton(grpc_health_v1.NewHealthClient)
 }
 
 func (s ServiceProvider) Boot(app infra.Glacier) {
 	app.Cron(func(cr cron.Manager, cc container.Container) error {
-		cc.Must(cr.Add("sync-events", "@every 5s", eventSyncJob))
+		conf := config.Get(cc)
+		cc.Must(cr.Add("sync-events", fmt.Sprintf("@every %s", conf.FlushInterval), eventSyncJob))
 		cc.Must(cr.Add("heartbeat", "@every 10s", heartbeatJob))
 
 		return nil
@@ -2,16 +2,21 @@ package store
 
 import (
 	"encoding/json"
-	"errors"
 
 	"github.com/ledisdb/ledisdb/ledis"
 	"github.com/mylxsw/adanos-alert/rpc/protocol"
 	"github.com/mylxsw/asteria/log"
 )
 
+// EventStore 本地事件存储，用于暂存需要转发到 Server 的事件
+// 事件通过 Peek 读取，只有在 Server 确认接收之后才通过 Ack 从队列中移除，保证事件至少被发送一次
 type EventStore interface {
+	// Enqueue 事件加入队列
 	Enqueue(msg *protocol.MessageRequest) error
-	Dequeue() (*protocol.MessageRequest, error)
+	// Peek 返回队列中最早的最多 n 条事件，事件不会从队列中移除
+	Peek(n int) ([]*protocol.MessageRequest, error)
+	// Ack 将队列中最早的 n 条事件从队列中移除
+	Ack(n int) error
 }
 
 // eventStore 用于本地临时存储 message
@@ -31,22 +36,29 @@ func (ms *eventStore) Enqueue(msg *protocol.MessageRequest) error {
 	return err
 }
 
-// Dequeue 从队列中读取事件
-func (ms *eventStore) Dequeue() (*protocol.MessageRequest, error) {
-	message, err := ms.db.RPop(ms.key)
+// Peek 从队列中读取最早的最多 n 条事件
+func (ms *eventStore) Peek(n int) ([]*protocol.MessageRequest, error) {
+	// 事件从队列头部写入，最早的事件位于队列尾部
+	messages, err := ms.db.LRange(ms.key, int32(-n), -1)
 	if err != nil {
 		log.Errorf("读取本地存储失败: %s", err)
 		return nil, err
 	}
 
-	if message == nil {
-		return nil, errors.New("读取失败")
+	reqs := make([]*protocol.MessageRequest, 0, len(messages))
+	for i := len(messages) - 1; i >= 0; i-- {
+		var req protocol.MessageRequest
+		ms.unserialize(messages[i], &req)
+		reqs = append(reqs, &req)
 	}
 
-	var req protocol.MessageRequest
-	ms.unserialize(message, &req)
+	return reqs, nil
+}
 
-	return &req, nil
+// Ack 从队列中移除最早的 n 条事件
+func (ms *eventStore) Ack(n int) error {
+	_, err := ms.db.LTrimBack(ms.key, int32(n))
+	return err
 }
 
 func (ms *eventStore) serialize(msg interface{}) []byte {
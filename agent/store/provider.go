@@ -1,14 +1,39 @@
 package store
 
 import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ledisdb/ledisdb/ledis"
+	"github.com/mylxsw/adanos-alert/agent/config"
 	"github.com/mylxsw/container"
 	"github.com/mylxsw/glacier/infra"
 )
 
+const (
+	// BackendLedis 使用 ledis 存储事件
+	BackendLedis = "ledis"
+	// BackendWAL 使用磁盘 WAL 存储事件
+	BackendWAL = "wal"
+)
+
 type ServiceProvider struct{}
 
 func (s ServiceProvider) Register(app container.Container) {
-	app.MustSingleton(NewEventStore)
+	app.MustSingleton(func(conf *config.Config, cc container.Container) (EventStore, error) {
+		switch conf.StoreBackend {
+		case BackendWAL:
+			return NewWALEventStore(filepath.Join(conf.DataDir, "wal"))
+		case BackendLedis, "":
+			var store EventStore
+			err := cc.Resolve(func(db *ledis.DB) {
+				store = NewEventStore(db)
+			})
+			return store, err
+		default:
+			return nil, fmt.Errorf("unsupported store backend: %s", conf.StoreBackend)
+		}
+	})
 }
 
 func (s ServiceProvider) Boot(app infra.Glacier) {
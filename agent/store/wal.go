@@ -0,0 +1,308 @@
+package store
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mylxsw/adanos-alert/rpc/protocol"
+	"github.com/mylxsw/asteria/log"
+)
+
+const (
+	walFilename    = "events.wal"
+	walAckFilename = "events.ack"
+	// walRecordHeaderSize 每条记录的头部：4 字节数据长度 + 4 字节 CRC32 校验和
+	walRecordHeaderSize = 8
+	// walCompactSize 所有事件都已确认，并且 WAL 文件超过该大小时，清空 WAL 文件
+	walCompactSize = 4 * 1024 * 1024
+)
+
+// ErrStoreClosed 存储已经关闭
+var ErrStoreClosed = errors.New("event store is closed")
+
+// walEntry WAL 中未确认的事件
+type walEntry struct {
+	msg *protocol.MessageRequest
+	end int64 // 该记录在 WAL 文件中的结束位置
+}
+
+// WALEventStore 基于磁盘的追加写日志（WAL）实现的事件存储
+// 事件写入后立即 fsync，确认的位置单独保存在 ack 文件中，启动时从确认位置开始重放未确认的事件
+type WALEventStore struct {
+	lock    sync.Mutex
+	dir     string
+	file    *os.File
+	size    int64 // WAL 文件当前大小
+	acked   int64 // 已确认的位置，该位置之前的记录都已经被 Server 接收
+	pending []walEntry
+}
+
+// NewWALEventStore 创建一个基于 WAL 的事件存储，数据存储在 dir 目录
+func NewWALEventStore(dir string) (*WALEventStore, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("create wal directory failed: %w", err)
+	}
+
+	ws := &WALEventStore{dir: dir}
+	acked, err := ws.readAckOffset()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, walFilename), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open wal file failed: %w", err)
+	}
+
+	ws.file = file
+	ws.acked = acked
+	if err := ws.replay(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+// replay 从确认位置开始读取 WAL 中未确认的事件，文件末尾不完整或者校验失败的记录（写入过程中崩溃）会被截断
+func (ws *WALEventStore) replay() error {
+	stat, err := ws.file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat wal file failed: %w", err)
+	}
+
+	if ws.acked > stat.Size() {
+		log.Warningf("wal ack offset %d exceeds wal size %d, reset to %d", ws.acked, stat.Size(), stat.Size())
+		ws.acked = stat.Size()
+	}
+
+	if _, err := ws.file.Seek(ws.acked, io.SeekStart); err != nil {
+		return fmt.Errorf("seek wal file failed: %w", err)
+	}
+
+	reader := bufio.NewReader(ws.file)
+	offset := ws.acked
+	header := make([]byte, walRecordHeaderSize)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			break
+		}
+
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		if length > stat.Size()-offset-walRecordHeaderSize {
+			break
+		}
+
+		data := make([]byte, length)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			break
+		}
+
+		if crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(header[4:]) {
+			break
+		}
+
+		var msg protocol.MessageRequest
+		if err := json.Unmarshal(data, &msg); err != nil {
+			break
+		}
+
+		offset += int64(walRecordHeaderSize + len(data))
+		ws.pending = append(ws.pending, walEntry{msg: &msg, end: offset})
+	}
+
+	if offset < stat.Size() {
+		log.Warningf("wal file has a corrupted tail, truncate from %d to %d", stat.Size(), offset)
+		if err := ws.file.Truncate(offset); err != nil {
+			return fmt.Errorf("truncate wal file failed: %w", err)
+		}
+	}
+
+	ws.size = offset
+	if _, err := ws.file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("seek wal file failed: %w", err)
+	}
+
+	return nil
+}
+
+// Enqueue 事件写入 WAL，写入成功后才返回
+func (ws *WALEventStore) Enqueue(msg *protocol.MessageRequest) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, walRecordHeaderSize+len(data))
+	binary.BigEndian.PutUint32(record[:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(data))
+	copy(record[walRecordHeaderSize:], data)
+
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	if ws.file == nil {
+		return ErrStoreClosed
+	}
+
+	if _, err := ws.file.Write(record); err != nil {
+		return fmt.Errorf("write wal failed: %w", err)
+	}
+
+	if err := ws.file.Sync(); err != nil {
+		return fmt.Errorf("sync wal failed: %w", err)
+	}
+
+	ws.size += int64(len(record))
+	ws.pending = append(ws.pending, walEntry{msg: msg, end: ws.size})
+
+	return nil
+}
+
+// Peek 返回最早的最多 n 条未确认的事件
+func (ws *WALEventStore) Peek(n int) ([]*protocol.MessageRequest, error) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	if n > len(ws.pending) {
+		n = len(ws.pending)
+	}
+
+	msgs := make([]*protocol.MessageRequest, 0, n)
+	for _, entry := range ws.pending[:n] {
+		msgs = append(msgs, entry.msg)
+	}
+
+	return msgs, nil
+}
+
+// Ack 确认最早的 n 条事件，确认位置持久化之后才从内存中移除
+func (ws *WALEventStore) Ack(n int) error {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	if ws.file == nil {
+		return ErrStoreClosed
+	}
+
+	if n > len(ws.pending) {
+		n = len(ws.pending)
+	}
+
+	if n <= 0 {
+		return nil
+	}
+
+	acked := ws.pending[n-1].end
+	if len(ws.pending) == n && ws.size >= walCompactSize {
+		return ws.compact()
+	}
+
+	if err := ws.writeAckOffset(acked); err != nil {
+		return err
+	}
+
+	ws.acked = acked
+	ws.pending = ws.pending[n:]
+
+	return nil
+}
+
+// compact 所有事件都已确认时清空 WAL 文件
+// 先截断文件再将确认位置写为 0，如果两步之间崩溃，重放时确认位置超过文件大小，会被重置为文件末尾，不会丢失或者重复发送事件
+func (ws *WALEventStore) compact() error {
+	if err := ws.file.Truncate(0); err != nil {
+		return fmt.Errorf("truncate wal file failed: %w", err)
+	}
+
+	if _, err := ws.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("seek wal file failed: %w", err)
+	}
+
+	if err := ws.file.Sync(); err != nil {
+		return fmt.Errorf("sync wal failed: %w", err)
+	}
+
+	if err := ws.writeAckOffset(0); err != nil {
+		return err
+	}
+
+	ws.size = 0
+	ws.acked = 0
+	ws.pending = nil
+
+	return nil
+}
+
+// Close 关闭存储
+func (ws *WALEventStore) Close() error {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	if ws.file == nil {
+		return nil
+	}
+
+	err := ws.file.Close()
+	ws.file = nil
+
+	return err
+}
+
+func (ws *WALEventStore) readAckOffset() (int64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(ws.dir, walAckFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+
+		return 0, fmt.Errorf("read wal ack file failed: %w", err)
+	}
+
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid wal ack file: %w", err)
+	}
+
+	return offset, nil
+}
+
+// writeAckOffset 原子的更新确认位置：写入临时文件，fsync 之后重命名
+func (ws *WALEventStore) writeAckOffset(offset int64) error {
+	tmpFile := filepath.Join(ws.dir, walAckFilename+".tmp")
+	f, err := os.OpenFile(tmpFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("write wal ack file failed: %w", err)
+	}
+
+	if _, err := f.WriteString(strconv.FormatInt(offset, 10)); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write wal ack file failed: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("sync wal ack file failed: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write wal ack file failed: %w", err)
+	}
+
+	if err := os.Rename(tmpFile, filepath.Join(ws.dir, walAckFilename)); err != nil {
+		return fmt.Errorf("write wal ack file failed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package store_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/agent/store"
+	"github.com/mylxsw/adanos-alert/rpc/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func enqueueMessages(t *testing.T, ws store.EventStore, start, count int) {
+	for i := start; i < start+count; i++ {
+		assert.NoError(t, ws.Enqueue(&protocol.MessageRequest{Data: fmt.Sprintf("message-%d", i)}))
+	}
+}
+
+func peekData(t *testing.T, ws store.EventStore, n int) []string {
+	msgs, err := ws.Peek(n)
+	assert.NoError(t, err)
+
+	data := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		data = append(data, msg.Data)
+	}
+
+	return data
+}
+
+func TestWALEventStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "adanos-wal")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ws, err := store.NewWALEventStore(dir)
+	assert.NoError(t, err)
+
+	enqueueMessages(t, ws, 0, 5)
+	assert.Equal(t, []string{"message-0", "message-1"}, peekData(t, ws, 2))
+	// Peek 不会移除事件
+	assert.Equal(t, []string{"message-0", "message-1"}, peekData(t, ws, 2))
+
+	assert.NoError(t, ws.Ack(2))
+	assert.Equal(t, []string{"message-2", "message-3", "message-4"}, peekData(t, ws, 10))
+
+	assert.NoError(t, ws.Ack(10))
+	assert.Empty(t, peekData(t, ws, 10))
+	assert.NoError(t, ws.Close())
+
+	assert.Equal(t, store.ErrStoreClosed, ws.Enqueue(&protocol.MessageRequest{Data: "closed"}))
+}
+
+func TestWALEventStore_Recovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "adanos-wal")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// 模拟崩溃：写入事件并确认一部分之后，不关闭存储直接重新打开
+	{
+		ws, err := store.NewWALEventStore(dir)
+		assert.NoError(t, err)
+
+		enqueueMessages(t, ws, 0, 3)
+		assert.NoError(t, ws.Ack(1))
+	}
+
+	{
+		ws, err := store.NewWALEventStore(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"message-1", "message-2"}, peekData(t, ws, 10))
+		assert.NoError(t, ws.Close())
+	}
+
+	// 模拟写入过程中崩溃：WAL 文件末尾包含不完整的记录
+	walFile, err := os.OpenFile(filepath.Join(dir, "events.wal"), os.O_WRONLY|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+	_, err = walFile.Write([]byte{0, 0, 0, 100, 1, 2, 3, 4, '{', '"'})
+	assert.NoError(t, err)
+	assert.NoError(t, walFile.Close())
+
+	{
+		ws, err := store.NewWALEventStore(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"message-1", "message-2"}, peekData(t, ws, 10))
+
+		// 不完整的记录被截断后，可以继续写入
+		enqueueMessages(t, ws, 3, 1)
+		assert.NoError(t, ws.Close())
+	}
+
+	{
+		ws, err := store.NewWALEventStore(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"message-1", "message-2", "message-3"}, peekData(t, ws, 10))
+		assert.NoError(t, ws.Close())
+	}
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/json"
+	"github.com/mylxsw/asteria/log"
+	"github.com/mylxsw/container"
+	"github.com/mylxsw/glacier/web"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxAuditBodySize 审计日志中记录的请求体以及操作对象状态的最大字节数，超过时截断
+const maxAuditBodySize = 4096
+
+// auditIgnoredRoutes 不修改数据的 POST 接口，不记录审计日志
+var auditIgnoredRoutes = map[string]bool{
+	"welcome:home":         true,
+	"events:matched-rules": true,
+	"rules:test-match":     true,
+	"rules:validate":       true,
+	"rules:test:check":     true,
+	"triggers:test":        true,
+	"evaluate:sample":      true,
+	"template:preview":     true,
+}
+
+// auditSnapshotLoader 按照 ID 查询操作对象当前的状态
+type auditSnapshotLoader func(id primitive.ObjectID) (interface{}, error)
+
+// newAuditSnapshotLoaders 返回资源名称（路由名称中第一个 ":" 之前的部分）对应的操作对象查询方法
+func newAuditSnapshotLoaders(cc container.Container) map[string]auditSnapshotLoader {
+	ruleRepo := cc.MustGet(new(repository.RuleRepo)).(repository.RuleRepo)
+	userRepo := cc.MustGet(new(repository.UserRepo)).(repository.UserRepo)
+	templateRepo := cc.MustGet(new(repository.TemplateRepo)).(repository.TemplateRepo)
+	robotRepo := cc.MustGet(new(repository.DingdingRobotRepo)).(repository.DingdingRobotRepo)
+	inhibitRuleRepo := cc.MustGet(new(repository.InhibitRuleRepo)).(repository.InhibitRuleRepo)
+	silenceRepo := cc.MustGet(new(repository.SilenceRepo)).(repository.SilenceRepo)
+	windowRepo := cc.MustGet(new(repository.MaintenanceWindowRepo)).(repository.MaintenanceWindowRepo)
+	scheduleRepo := cc.MustGet(new(repository.ScheduleRepo)).(repository.ScheduleRepo)
+	tokenRepo := cc.MustGet(new(repository.APITokenRepo)).(repository.APITokenRepo)
+	groupRepo := cc.MustGet(new(repository.EventGroupRepo)).(repository.EventGroupRepo)
+	eventRepo := cc.MustGet(new(repository.EventRepo)).(repository.EventRepo)
+	failedActionRepo := cc.MustGet(new(repository.FailedActionRepo)).(repository.FailedActionRepo)
+
+	return map[string]auditSnapshotLoader{
+		"rules":               func(id primitive.ObjectID) (interface{}, error) { return ruleRepo.Get(id) },
+		"users":               func(id primitive.ObjectID) (interface{}, error) { return userRepo.Get(id) },
+		"template":            func(id primitive.ObjectID) (interface{}, error) { return templateRepo.Get(id) },
+		"robots":              func(id primitive.ObjectID) (interface{}, error) { return robotRepo.Get(id) },
+		"inhibit-rules":       func(id primitive.ObjectID) (interface{}, error) { return inhibitRuleRepo.Get(id) },
+		"silences":            func(id primitive.ObjectID) (interface{}, error) { return silenceRepo.Get(id) },
+		"maintenance-windows": func(id primitive.ObjectID) (interface{}, error) { return windowRepo.Get(id) },
+		"schedules":           func(id primitive.ObjectID) (interface{}, error) { return scheduleRepo.Get(id) },
+		"api-tokens":          func(id primitive.ObjectID) (interface{}, error) { return tokenRepo.Get(id) },
+		"groups":              func(id primitive.ObjectID) (interface{}, error) { return groupRepo.Get(id) },
+		"events":              func(id primitive.ObjectID) (interface{}, error) { return eventRepo.Get(id) },
+		"failed-actions":      func(id primitive.ObjectID) (interface{}, error) { return failedActionRepo.Get(id) },
+	}
+}
+
+// auditMiddleware 为修改数据的接口记录审计日志：操作人、请求方法、路径、操作对象 ID、请求内容、响应状态码以及操作对象修改前后的状态
+// 推送事件的接口请求量较大，不记录审计日志；请求内容和操作对象中的密码、Token 等敏感字段会被脱敏
+func auditMiddleware(auditRepo repository.AuditLogRepo, loaders map[string]auditSnapshotLoader) web.HandlerDecorator {
+	return func(handler web.WebHandler) web.WebHandler {
+		return func(ctx web.Context) web.Response {
+			routeName := ""
+			if route := mux.CurrentRoute(ctx.Request().Raw()); route != nil {
+				routeName = route.GetName()
+			}
+
+			if !shouldAudit(ctx.Method(), routeName) {
+				return handler(ctx)
+			}
+
+			targetID := ctx.PathVar("id")
+			before := auditSnapshot(loaders, routeName, targetID)
+
+			resp := handler(ctx)
+
+			status := http.StatusOK
+			if resp != nil {
+				status = resp.Code()
+			}
+
+			actor := auditActor(ctx)
+			path := ctx.Request().Raw().URL.Path
+			if _, err := auditRepo.Add(repository.AuditLog{
+				Type: repository.AuditLogTypeAPI,
+				Context: map[string]interface{}{
+					"actor":            actor,
+					"claimed_operator": auditClaimedOperator(ctx),
+					"method":           ctx.Method(),
+					"path":             path,
+					"route":            routeName,
+					"target_id":        targetID,
+					"status":           status,
+					"request":          auditRequestBody(ctx.Request().Raw().Header.Get("Content-Type"), ctx.Request().Body()),
+					"before":           before,
+					"after":            auditSnapshot(loaders, routeName, targetID),
+				},
+				Body: fmt.Sprintf("[%s] %s %s %s -> %d", time.Now().Format(time.RFC3339), actor, ctx.Method(), path, status),
+			}); err != nil {
+				log.WithFields(log.Fields{
+					"method": ctx.Method(),
+					"path":   path,
+				}).Errorf("save audit log failed: %v", err)
+			}
+
+			return resp
+		}
+	}
+}
+
+// shouldAudit 判断请求是否需要记录审计日志
+func shouldAudit(method string, routeName string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return false
+	}
+
+	return !strings.HasPrefix(routeName, "events:add:") && !auditIgnoredRoutes[routeName]
+}
+
+// auditActor 返回当前请求的操作人：认证通过的 API Token 名称，没有启用认证时使用客户端 IP
+// 请求中自行声明的操作人无法验证，不作为操作人，只由 auditClaimedOperator 记录
+func auditActor(ctx web.Context) string {
+	if token, ok := ctx.Get(APITokenContextKey).(repository.APIToken); ok && token.Name != "" {
+		return "token:" + token.Name
+	}
+
+	raw := ctx.Request().Raw()
+	if host, _, err := net.SplitHostPort(raw.RemoteAddr); err == nil {
+		return host
+	}
+
+	return raw.RemoteAddr
+}
+
+// auditClaimedOperator 返回请求中通过 X-Operator 请求头或者 operator 查询参数声明的操作人，仅作为附加信息记录
+func auditClaimedOperator(ctx web.Context) string {
+	raw := ctx.Request().Raw()
+	if operator := strings.TrimSpace(raw.Header.Get("X-Operator")); operator != "" {
+		return operator
+	}
+
+	return strings.TrimSpace(raw.URL.Query().Get("operator"))
+}
+
+// auditSnapshot 返回脱敏之后的操作对象当前状态，操作对象不存在（新增之前、删除之后）或者资源不支持时返回空字符串
+func auditSnapshot(loaders map[string]auditSnapshotLoader, routeName string, targetID string) string {
+	loader, ok := loaders[strings.SplitN(routeName, ":", 2)[0]]
+	if !ok || targetID == "" {
+		return ""
+	}
+
+	id, err := primitive.ObjectIDFromHex(targetID)
+	if err != nil {
+		return ""
+	}
+
+	entity, err := loader(id)
+	if err != nil {
+		if err != repository.ErrNotFound {
+			log.WithFields(log.Fields{
+				"route":     routeName,
+				"target_id": targetID,
+			}).Errorf("query audit target failed: %v", err)
+		}
+
+		return ""
+	}
+
+	data, err := stdjson.Marshal(entity)
+	if err != nil {
+		return fmt.Sprintf("<json encode failed: %v>", err)
+	}
+
+	redacted, _ := json.Redact(data)
+	return truncateAuditContent(string(redacted))
+}
+
+// auditRequestBody 返回脱敏并且截断之后的请求体，无法脱敏的请求体只记录大小
+func auditRequestBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var summary string
+	if redacted, ok := json.Redact(body); ok {
+		summary = string(redacted)
+	} else if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return fmt.Sprintf("<%d bytes>", len(body))
+		}
+
+		for key := range values {
+			if json.IsSensitiveKey(key) {
+				values.Set(key, json.RedactedValue)
+			}
+		}
+
+		summary = values.Encode()
+	} else {
+		return fmt.Sprintf("<%d bytes>", len(body))
+	}
+
+	return truncateAuditContent(summary)
+}
+
+// truncateAuditContent 超过 maxAuditBodySize 时截断审计日志中记录的内容
+func truncateAuditContent(content string) string {
+	if len(content) > maxAuditBodySize {
+		return content[:maxAuditBodySize] + "...(truncated)"
+	}
+
+	return content
+}
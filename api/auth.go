@@ -0,0 +1,225 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mylxsw/adanos-alert/configs"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/signature"
+	"github.com/mylxsw/asteria/log"
+	"github.com/mylxsw/glacier/web"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// APITokenContextKey 请求上下文中保存当前请求使用的 API Token（repository.APIToken）
+const APITokenContextKey = "api_token"
+
+// tokenAuthenticator 使用 API Token 对请求进行认证
+// 配置文件中的 api_token 作为管理员 Token 继续有效，没有配置 api_token 并且没有启用的 Token 时不进行认证
+type tokenAuthenticator struct {
+	conf      *configs.Config
+	tokenRepo repository.APITokenRepo
+	limiter   *tokenRateLimiter
+
+	lock           sync.Mutex
+	enabled        bool
+	enabledCheckAt time.Time
+}
+
+func newTokenAuthenticator(conf *configs.Config, tokenRepo repository.APITokenRepo) *tokenAuthenticator {
+	// 在成功查询启用的 Token 数量之前，默认需要认证
+	return &tokenAuthenticator{conf: conf, tokenRepo: tokenRepo, limiter: newTokenRateLimiter(), enabled: true}
+}
+
+// Middleware 认证中间件，认证通过后将 Token 保存到请求上下文中
+func (ta *tokenAuthenticator) Middleware() web.HandlerDecorator {
+	return func(handler web.WebHandler) web.WebHandler {
+		return func(ctx web.Context) web.Response {
+			if ctx.Method() == http.MethodOptions || !ta.authEnabled() {
+				return handler(ctx)
+			}
+
+			token, err := ta.authenticate(ctx)
+			if err != nil {
+				return ctx.JSONError(fmt.Sprintf("auth failed: %s", err), http.StatusUnauthorized)
+			}
+
+			scope := requiredScope(ctx)
+			if !token.HasScope(scope) {
+				return ctx.JSONError(fmt.Sprintf("permission denied: %s scope required", scope), http.StatusForbidden)
+			}
+
+			if token.RateLimit > 0 && !ta.limiter.Allow(token.TokenHash, token.RateLimit, time.Now()) {
+				return ctx.JSONError("too many requests", http.StatusTooManyRequests)
+			}
+
+			ctx.Set(APITokenContextKey, token)
+			return handler(ctx)
+		}
+	}
+}
+
+// authEnabled 是否需要认证，启用的 Token 数量缓存 10s
+// 查询 Token 失败时需要认证，避免数据库异常时接口被公开暴露
+func (ta *tokenAuthenticator) authEnabled() bool {
+	if ta.conf.APIToken != "" {
+		return true
+	}
+
+	ta.lock.Lock()
+	defer ta.lock.Unlock()
+
+	if time.Since(ta.enabledCheckAt) > 10*time.Second {
+		count, err := ta.tokenRepo.Count(bson.M{"enabled": true})
+		if err != nil {
+			log.Errorf("query api tokens failed: %v", err)
+			return true
+		}
+
+		ta.enabled = count > 0
+		ta.enabledCheckAt = time.Now()
+	}
+
+	return ta.enabled
+}
+
+// adminOnly 只允许持有 admin 权限 Token 的请求访问，用于调试等敏感接口
+// 与 Middleware 不同的是，没有配置任何 Token 时直接拒绝访问，避免接口被公开暴露
+func (ta *tokenAuthenticator) adminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ta.authEnabled() {
+			http.Error(w, "permission denied: api token is not configured", http.StatusForbidden)
+			return
+		}
+
+		token, err := ta.authenticateHeader(r.Header.Get("Authorization"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("auth failed: %s", err), http.StatusUnauthorized)
+			return
+		}
+
+		if !token.HasScope(repository.APITokenScopeAdmin) {
+			http.Error(w, fmt.Sprintf("permission denied: %s scope required", repository.APITokenScopeAdmin), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (ta *tokenAuthenticator) authenticate(ctx web.Context) (repository.APIToken, error) {
+	return ta.authenticateHeader(ctx.Header("Authorization"))
+}
+
+// authenticateHeader 使用 Authorization 请求头进行认证
+func (ta *tokenAuthenticator) authenticateHeader(header string) (repository.APIToken, error) {
+	segs := strings.SplitN(header, " ", 2)
+	if len(segs) != 2 || segs[0] != "Bearer" {
+		return repository.APIToken{}, errors.New("invalid auth header, only support Bearer")
+	}
+
+	credential := strings.TrimSpace(segs[1])
+	if ta.conf.APIToken != "" && subtle.ConstantTimeCompare([]byte(credential), []byte(ta.conf.APIToken)) == 1 {
+		return repository.APIToken{
+			Name:      "legacy",
+			TokenHash: repository.HashAPIToken(credential),
+			Scopes:    []repository.APITokenScope{repository.APITokenScopeAdmin},
+			Enabled:   true,
+		}, nil
+	}
+
+	token, err := ta.tokenRepo.GetByToken(credential)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return token, errors.New("token not match")
+		}
+
+		return token, err
+	}
+
+	if !token.Enabled {
+		return token, errors.New("token is disabled")
+	}
+
+	return token, nil
+}
+
+// requiredScope 返回访问当前接口需要的权限，推送事件的接口只需要 ingest 权限，其它接口需要 admin 权限
+func requiredScope(ctx web.Context) repository.APITokenScope {
+	if route := mux.CurrentRoute(ctx.Request().Raw()); route != nil && strings.HasPrefix(route.GetName(), "events:add:") {
+		return repository.APITokenScopeIngest
+	}
+
+	return repository.APITokenScopeAdmin
+}
+
+// tokenRateLimiter 按照 Token 限制每分钟的请求数量（固定窗口）
+type tokenRateLimiter struct {
+	lock    sync.Mutex
+	windows map[string]*rateLimitWindow
+}
+
+type rateLimitWindow struct {
+	startAt time.Time
+	count   int64
+}
+
+func newTokenRateLimiter() *tokenRateLimiter {
+	return &tokenRateLimiter{windows: make(map[string]*rateLimitWindow)}
+}
+
+// Allow 判断 key 在 now 所在的窗口内请求数量是否超过 limit
+func (l *tokenRateLimiter) Allow(key string, limit int64, now time.Time) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	startAt := now.Truncate(time.Minute)
+	win, ok := l.windows[key]
+	if !ok || !win.startAt.Equal(startAt) {
+		win = &rateLimitWindow{startAt: startAt}
+		l.windows[key] = win
+	}
+
+	if win.count >= limit {
+		return false
+	}
+
+	win.count++
+	return true
+}
+
+// previewSignatureMiddleware 校验 /ui 下页面链接的签名，没有配置签名密钥时不校验
+// 签名无效或者已过期时返回 403
+func previewSignatureMiddleware(conf *configs.Config) web.HandlerDecorator {
+	return func(handler web.WebHandler) web.WebHandler {
+		return func(ctx web.Context) web.Response {
+			if conf.PreviewSignSecret == "" || ctx.Method() == http.MethodOptions {
+				return handler(ctx)
+			}
+
+			err := signature.Verify(
+				conf.PreviewSignSecret,
+				ctx.PathVar("id"),
+				ctx.Input(signature.ExpiresParam),
+				ctx.Input(signature.TokenParam),
+				time.Now(),
+			)
+			if err != nil {
+				if err == signature.ErrExpired {
+					return ctx.Error("link expired", http.StatusForbidden)
+				}
+
+				return ctx.Error("invalid signature", http.StatusForbidden)
+			}
+
+			return handler(ctx)
+		}
+	}
+}
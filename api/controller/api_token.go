@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/container"
+	"github.com/mylxsw/glacier/web"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type APITokenController struct {
+	cc container.Container
+}
+
+func NewAPITokenController(cc container.Container) web.Controller {
+	return &APITokenController{cc: cc}
+}
+
+func (c APITokenController) Register(router *web.Router) {
+	router.Group("/api-tokens/", func(router *web.Router) {
+		router.Get("/", c.APITokens).Name("api-tokens:all")
+		router.Post("/", c.Add).Name("api-tokens:add")
+		router.Get("/{id}/", c.APIToken).Name("api-tokens:one")
+		router.Post("/{id}/", c.Update).Name("api-tokens:update")
+		router.Delete("/{id}/", c.Delete).Name("api-tokens:delete")
+	})
+}
+
+type APITokenForm struct {
+	Name      string                     `json:"name"`
+	Scopes    []repository.APITokenScope `json:"scopes"`
+	RateLimit int64                      `json:"rate_limit"`
+	Enabled   bool                       `json:"enabled"`
+}
+
+func (form APITokenForm) Validate(req web.Request) error {
+	if form.Name == "" {
+		return errors.New("invalid argument: name is required")
+	}
+
+	if len(form.Scopes) == 0 {
+		return errors.New("invalid argument: scopes is required")
+	}
+
+	for _, s := range form.Scopes {
+		if s != repository.APITokenScopeIngest && s != repository.APITokenScopeAdmin {
+			return fmt.Errorf("invalid argument: scope %s is not supported, must be ingest/admin", s)
+		}
+	}
+
+	if form.RateLimit < 0 {
+		return errors.New("invalid argument: rate_limit must not be negative")
+	}
+
+	return nil
+}
+
+// APITokenCreatedResp 新创建的 Token，Token 明文只在创建时返回一次
+type APITokenCreatedResp struct {
+	repository.APIToken
+	Token string `json:"token"`
+}
+
+func (c APITokenController) Add(ctx web.Context, repo repository.APITokenRepo) (*APITokenCreatedResp, error) {
+	var form APITokenForm
+	if err := ctx.Unmarshal(&form); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	ctx.Validate(form, true)
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("generate token failed: %v", err), http.StatusInternalServerError)
+	}
+
+	plain := hex.EncodeToString(buf)
+	id, err := repo.Add(repository.APIToken{
+		Name:        form.Name,
+		TokenHash:   repository.HashAPIToken(plain),
+		TokenPrefix: plain[:6],
+		Scopes:      form.Scopes,
+		RateLimit:   form.RateLimit,
+		Enabled:     form.Enabled,
+	})
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	token, err := repo.Get(id)
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &APITokenCreatedResp{APIToken: token, Token: plain}, nil
+}
+
+func (c APITokenController) Update(ctx web.Context, repo repository.APITokenRepo) (*repository.APIToken, error) {
+	token, err := c.get(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var form APITokenForm
+	if err := ctx.Unmarshal(&form); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	ctx.Validate(form, true)
+
+	token.Name = form.Name
+	token.Scopes = form.Scopes
+	token.RateLimit = form.RateLimit
+	token.Enabled = form.Enabled
+
+	if err := repo.Update(token.ID, token); err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &token, nil
+}
+
+func (c APITokenController) Delete(ctx web.Context, repo repository.APITokenRepo) error {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	return repo.DeleteID(id)
+}
+
+func (c APITokenController) APIToken(ctx web.Context, repo repository.APITokenRepo) (*repository.APIToken, error) {
+	token, err := c.get(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (c APITokenController) get(ctx web.Context, repo repository.APITokenRepo) (repository.APIToken, error) {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return repository.APIToken{}, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	token, err := repo.Get(id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return token, web.WrapJSONError(errors.New("no such api token"), http.StatusNotFound)
+		}
+
+		return token, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return token, nil
+}
+
+func (c APITokenController) APITokens(ctx web.Context, repo repository.APITokenRepo) web.Response {
+	offset, limit := offsetAndLimit(ctx)
+
+	tokens, next, err := repo.Paginate(bson.M{}, offset, limit)
+	if err != nil {
+		return ctx.JSONError(fmt.Sprintf("query failed: %v", err), http.StatusInternalServerError)
+	}
+
+	return ctx.JSON(web.M{
+		"tokens": tokens,
+		"next":   next,
+	})
+}
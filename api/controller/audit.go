@@ -20,6 +20,7 @@ func NewAuditController(cc container.Container) web.Controller {
 
 func (u AuditController) Register(router *web.Router) {
 	router.Group("/audit/", func(router *web.Router) {
+		router.Get("/", u.Audits).Name("audit:all")
 		router.Get("/logs/", u.Logs).Name("audit:logs")
 	})
 }
@@ -44,3 +45,25 @@ func (u AuditController) Logs(ctx web.Context, auditRepo repository.AuditLogRepo
 		"next": next,
 	})
 }
+
+// Audits 查询通过 API 修改数据的审计日志，支持按照操作人、请求方法、路由名称以及操作对象 ID 过滤
+func (u AuditController) Audits(ctx web.Context, auditRepo repository.AuditLogRepo) web.Response {
+	offset, limit := offsetAndLimit(ctx)
+
+	filter := bson.M{"type": repository.AuditLogTypeAPI}
+	for _, key := range []string{"actor", "method", "route", "target_id"} {
+		if val := ctx.Input(key); val != "" {
+			filter["context."+key] = val
+		}
+	}
+
+	data, next, err := auditRepo.Paginate(filter, offset, limit)
+	if err != nil {
+		return ctx.JSONError(fmt.Sprintf("query audit logs failed: %v", err), http.StatusInternalServerError)
+	}
+
+	return ctx.JSON(web.M{
+		"logs": data,
+		"next": next,
+	})
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/mylxsw/adanos-alert/internal/job"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/container"
+	"github.com/mylxsw/glacier/web"
+)
+
+type ClusterController struct {
+	cc container.Container
+}
+
+func NewClusterController(cc container.Container) web.Controller {
+	return &ClusterController{cc: cc}
+}
+
+func (c ClusterController) Register(router *web.Router) {
+	router.Group("/cluster/", func(router *web.Router) {
+		router.Get("/lock/", c.Lock).Name("cluster:lock")
+	})
+}
+
+// Lock 返回定时任务分布式锁当前的持有者及过期时间，以及当前节点持有锁的状态
+func (c ClusterController) Lock(ctx web.Context, lockManager *job.DistributeLockManager, lockRepo repository.LockRepo) web.Response {
+	status := lockManager.Status()
+	owner, expiresAt, err := lockRepo.CurrentOwner(status.Resource)
+	if err != nil {
+		return ctx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	return ctx.JSON(web.M{
+		"resource":   status.Resource,
+		"owner":      owner,
+		"expires_at": expiresAt,
+		"node":       status,
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/mylxsw/adanos-alert/configs"
 	"github.com/mylxsw/adanos-alert/internal/extension"
 	"github.com/mylxsw/adanos-alert/internal/job"
 	"github.com/mylxsw/adanos-alert/internal/repository"
@@ -30,16 +31,24 @@ func NewEventController(cc container.Container) web.Controller {
 
 func (m *EventController) Register(router *web.Router) {
 	router.Group("/messages", func(router *web.Router) {
+		router.Get("/search/", m.SearchEvents).Name("events:search")
+
 		router.Post("/", m.AddCommonEvent).Name("events:add:common")
 		router.Post("/logstash/", m.AddLogstashEvent).Name("events:add:logstash")
 		router.Post("/grafana/", m.AddGrafanaEvent).Name("events:add:grafana")
 		router.Post("/prometheus/api/v1/alerts", m.AddPrometheusEvent).Name("events:add:prometheus") // url 地址末尾不包含 "/"
 		router.Post("/prometheus_alertmanager/", m.AddPrometheusAlertEvent).Name("events:add:prometheus-alert")
+		router.Post("/prometheus_alertmanager/v2/", m.AddAlertmanagerV2Event).Name("events:add:alertmanager-v2")
 		router.Post("/openfalcon/im/", m.AddOpenFalconEvent).Name("events:add:openfalcon")
+		router.Post("/cloudwatch/", m.AddCloudWatchEvent).Name("events:add:cloudwatch")
+		router.Post("/sentry/", m.AddSentryEvent).Name("events:add:sentry")
+		router.Post("/zabbix/", m.AddZabbixEvent).Name("events:add:zabbix")
+		router.Post("/loki/", m.AddLokiEvent).Name("events:add:loki")
 	})
 
 	router.Group("/events", func(router *web.Router) {
 		router.Get("/", m.Events).Name("events:all")
+		router.Get("/search/", m.SearchEvents).Name("events:search")
 		router.Get("/{id}/", m.Event).Name("events:one")
 		router.Delete("/{id}/", m.DeleteEvent).Name("events:delete")
 
@@ -47,11 +56,17 @@ func (m *EventController) Register(router *web.Router) {
 		router.Post("/{id}/reproduce/", m.ReproduceEvent).Name("events:reproduce-event")
 
 		router.Post("/", m.AddCommonEvent).Name("events:add:common")
+		router.Post("/batch/", m.AddBatchEvents).Name("events:add:batch")
 		router.Post("/logstash/", m.AddLogstashEvent).Name("events:add:logstash")
 		router.Post("/grafana/", m.AddGrafanaEvent).Name("events:add:grafana")
 		router.Post("/prometheus/api/v1/alerts", m.AddPrometheusEvent).Name("events:add:prometheus") // url 地址末尾不包含 "/"
 		router.Post("/prometheus_alertmanager/", m.AddPrometheusAlertEvent).Name("events:add:prometheus-alert")
+		router.Post("/prometheus_alertmanager/v2/", m.AddAlertmanagerV2Event).Name("events:add:alertmanager-v2")
 		router.Post("/openfalcon/im/", m.AddOpenFalconEvent).Name("events:add:openfalcon")
+		router.Post("/cloudwatch/", m.AddCloudWatchEvent).Name("events:add:cloudwatch")
+		router.Post("/sentry/", m.AddSentryEvent).Name("events:add:sentry")
+		router.Post("/zabbix/", m.AddZabbixEvent).Name("events:add:zabbix")
+		router.Post("/loki/", m.AddLokiEvent).Name("events:add:loki")
 	})
 
 	router.Group("/event-relations", func(router *web.Router) {
@@ -63,6 +78,10 @@ func (m *EventController) Register(router *web.Router) {
 	router.Group("/events-count/", func(router *web.Router) {
 		router.Get("/", m.Count).Name("events:count")
 	})
+
+	router.Group("/event-inhibits/", func(router *web.Router) {
+		router.Get("/{id}/", m.EventInhibit).Name("event-inhibits:one")
+	})
 }
 
 // eventsFilter some query conditions for messages
@@ -137,7 +156,9 @@ func (m *EventController) Count(ctx web.Context, evtRepo repository.EventRepo) w
 type EventsResp struct {
 	Events []repository.Event `json:"events"`
 	Next   int64              `json:"next"`
-	Search EventSearch        `json:"search"`
+	// NextCursor 游标分页时下一页的游标
+	NextCursor string      `json:"next_cursor,omitempty"`
+	Search     EventSearch `json:"search"`
 }
 
 // EventSearch is search conditions for messages
@@ -159,7 +180,19 @@ func (m *EventController) Events(ctx web.Context, evtRepo repository.EventRepo)
 		log.WithFields(log.Fields{"filter": filter}).Debug("events filter")
 	}
 
-	events, next, err := evtRepo.Paginate(filter, offset, limit)
+	afterID, useCursor, err := cursorPagination(ctx)
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusUnprocessableEntity)
+	}
+
+	var events []repository.Event
+	var next int64
+	var nextCursor primitive.ObjectID
+	if useCursor {
+		events, nextCursor, err = evtRepo.PaginateAfter(filter, afterID, limit)
+	} else {
+		events, next, err = evtRepo.Paginate(filter, offset, limit)
+	}
 	if err != nil {
 		return nil, web.WrapJSONError(fmt.Errorf("query failed: %v", err), http.StatusInternalServerError)
 	}
@@ -169,8 +202,9 @@ func (m *EventController) Events(ctx web.Context, evtRepo repository.EventRepo)
 	}
 
 	return &EventsResp{
-		Events: events,
-		Next:   next,
+		Events:     events,
+		Next:       next,
+		NextCursor: cursorToken(nextCursor),
 		Search: EventSearch{
 			Tags:    template.StringTags(ctx.Input("tags"), ","),
 			Meta:    ctx.Input("meta"),
@@ -181,6 +215,42 @@ func (m *EventController) Events(ctx web.Context, evtRepo repository.EventRepo)
 	}, nil
 }
 
+// SearchEventsResp is a response object for SearchEvents API
+type SearchEventsResp struct {
+	Events []repository.Event `json:"events"`
+	Total  int64              `json:"total"`
+	Next   int64              `json:"next"`
+}
+
+// SearchEvents search events by content
+// Arguments:
+//   - q: 搜索内容
+//   - offset/limit
+//   - meta/tags/origin/status/relation_id/group_id
+func (m *EventController) SearchEvents(ctx web.Context, evtRepo repository.EventRepo) (*SearchEventsResp, error) {
+	text := strings.TrimSpace(ctx.Input("q"))
+	if text == "" {
+		return nil, web.WrapJSONError(errors.New("q is required"), http.StatusUnprocessableEntity)
+	}
+
+	offset, limit := offsetAndLimit(ctx)
+	events, total, err := evtRepo.Search(text, eventsFilter(ctx), offset, limit)
+	if err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("query failed: %v", err), http.StatusInternalServerError)
+	}
+
+	for i, m := range events {
+		events[i].Content = template.JSONBeauty(m.Content)
+	}
+
+	var next int64
+	if offset+int64(len(events)) < total {
+		next = offset + limit
+	}
+
+	return &SearchEventsResp{Events: events, Total: total, Next: next}, nil
+}
+
 // Event return one message
 func (m *EventController) Event(ctx web.Context, eventRepo repository.EventRepo) (*repository.Event, error) {
 	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
@@ -225,14 +295,59 @@ func (m *EventController) ReproduceEvent(ctx web.Context, eventRepo repository.E
 
 func (m *EventController) errorWrap(ctx web.Context, id primitive.ObjectID, err error) web.Response {
 	if err != nil {
+		if errors.Is(err, extension.ErrEventTooLarge) {
+			return ctx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+		}
+
 		return ctx.JSONError(err.Error(), http.StatusInternalServerError)
 	}
 
 	return ctx.JSON(web.M{
-		"id": misc.IfElse(id != primitive.NilObjectID, id.Hex(), ""),
+		"id":     misc.IfElse(id != primitive.NilObjectID, id.Hex(), ""),
+		"status": eventAddStatus(id),
 	})
 }
 
+const (
+	// EventStatusAccepted 事件已保存
+	EventStatusAccepted = "accepted"
+	// EventStatusInhibited 事件在抑制周期内被去重丢弃
+	EventStatusInhibited = "inhibited"
+)
+
+// eventAddStatus 根据 EventService.Add 返回的事件 ID 判断事件是否被去重丢弃
+func eventAddStatus(id primitive.ObjectID) string {
+	if id == primitive.NilObjectID {
+		return EventStatusInhibited
+	}
+
+	return EventStatusAccepted
+}
+
+// EventInhibit 查询 EventControl.ID 对应的去重记录，包含抑制周期内丢弃的事件数量
+func (m *EventController) EventInhibit(ctx web.Context, inhibitRepo repository.InhibitRepo) (*repository.EventInhibit, error) {
+	inhibit, err := inhibitRepo.Get(ctx.Context(), ctx.PathVar("id"))
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, web.WrapJSONError(errors.New("no active inhibit for this id"), http.StatusNotFound)
+		}
+
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &inhibit, nil
+}
+
+// tooManyEvents 一次请求中包含的事件数量是否超过限制
+func tooManyEvents(conf *configs.Config, count int) bool {
+	return conf.IngestMaxEvents > 0 && count > conf.IngestMaxEvents
+}
+
+// tooManyEventsResponse 事件数量超过限制时的响应
+func tooManyEventsResponse(ctx web.Context, conf *configs.Config, count int) web.Response {
+	return ctx.JSONError(fmt.Sprintf("too many events: %d exceed the limit %d", count, conf.IngestMaxEvents), http.StatusRequestEntityTooLarge)
+}
+
 // Add common message
 
 func (m *EventController) AddCommonEvent(ctx web.Context, eventService service.EventService) web.Response {
@@ -245,6 +360,90 @@ func (m *EventController) AddCommonEvent(ctx web.Context, eventService service.E
 	return m.errorWrap(ctx, id, err)
 }
 
+// BatchEventFailure 批量添加事件时单个事件的失败信息
+type BatchEventFailure struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// saveEvents 依次保存一个请求中包含的多个事件，单个事件失败不影响其它事件
+// 有事件保存失败时返回错误状态码以及所有失败事件的序号和原因：全部因为超过大小限制失败时返回 422，否则返回 500
+func (m *EventController) saveEvents(ctx web.Context, eventService service.EventService, kind string, commonMessages []*extension.CommonEvent) web.Response {
+	ids := make([]string, len(commonMessages))
+	statuses := make([]string, len(commonMessages))
+	failures := make([]BatchEventFailure, 0)
+	tooLarge := 0
+	for i, cm := range commonMessages {
+		id, err := eventService.Add(ctx.Context(), *cm)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"message": cm,
+			}).Errorf("save %s message failed: %v", kind, err)
+
+			if errors.Is(err, extension.ErrEventTooLarge) {
+				tooLarge++
+			}
+
+			failures = append(failures, BatchEventFailure{Index: i, Error: err.Error()})
+			continue
+		}
+
+		ids[i] = misc.IfElse(id != primitive.NilObjectID, id.Hex(), "").(string)
+		statuses[i] = eventAddStatus(id)
+	}
+
+	if len(failures) == 0 {
+		return ctx.JSON(web.M{
+			"ids":      ids,
+			"statuses": statuses,
+		})
+	}
+
+	code := http.StatusInternalServerError
+	if tooLarge == len(failures) {
+		code = http.StatusUnprocessableEntity
+	}
+
+	return ctx.JSON(web.M{
+		"error":    fmt.Sprintf("%d of %d events failed to save", len(failures), len(commonMessages)),
+		"ids":      ids,
+		"statuses": statuses,
+		"failures": failures,
+	}).WithCode(code)
+}
+
+// AddBatchEvents 批量添加事件，事件按照请求中的顺序依次添加，单个事件失败不影响其它事件
+func (m *EventController) AddBatchEvents(ctx web.Context, conf *configs.Config, eventService service.EventService) web.Response {
+	var commonEvents []extension.CommonEvent
+	if err := ctx.Unmarshal(&commonEvents); err != nil {
+		return ctx.JSONError(fmt.Sprintf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	if tooManyEvents(conf, len(commonEvents)) {
+		return tooManyEventsResponse(ctx, conf, len(commonEvents))
+	}
+
+	ids := make([]string, len(commonEvents))
+	statuses := make([]string, len(commonEvents))
+	failures := make([]BatchEventFailure, 0)
+	for i, evt := range commonEvents {
+		id, err := eventService.Add(ctx.Context(), evt)
+		if err != nil {
+			failures = append(failures, BatchEventFailure{Index: i, Error: err.Error()})
+			continue
+		}
+
+		ids[i] = misc.IfElse(id != primitive.NilObjectID, id.Hex(), "").(string)
+		statuses[i] = eventAddStatus(id)
+	}
+
+	return ctx.JSON(web.M{
+		"ids":      ids,
+		"statuses": statuses,
+		"failures": failures,
+	})
+}
+
 // AddLogstashEvent Add logstash message
 func (m *EventController) AddLogstashEvent(ctx web.Context, eventService service.EventService) web.Response {
 	commonMessage, err := extension.LogstashToCommonEvent(ctx.Request().Body(), ctx.InputWithDefault("content-field", "message"))
@@ -268,24 +467,17 @@ func (m *EventController) AddGrafanaEvent(ctx web.Context, eventService service.
 }
 
 // AddPrometheusEvent add prometheus alert message
-func (m *EventController) AddPrometheusEvent(ctx web.Context, eventService service.EventService) web.Response {
+func (m *EventController) AddPrometheusEvent(ctx web.Context, conf *configs.Config, eventService service.EventService) web.Response {
 	commonMessages, err := extension.PrometheusToCommonEvents(ctx.Request().Body())
 	if err != nil {
 		return ctx.JSONError(err.Error(), http.StatusInternalServerError)
 	}
 
-	var lastID primitive.ObjectID
-	var lastErr error
-	for _, cm := range commonMessages {
-		lastID, lastErr = eventService.Add(ctx.Context(), *cm)
-		if lastErr != nil {
-			log.WithFields(log.Fields{
-				"message": cm,
-			}).Errorf("save prometheus message failed: %v", lastErr)
-		}
+	if tooManyEvents(conf, len(commonMessages)) {
+		return tooManyEventsResponse(ctx, conf, len(commonMessages))
 	}
 
-	return m.errorWrap(ctx, lastID, lastErr)
+	return m.saveEvents(ctx, eventService, "prometheus", commonMessages)
 }
 
 // AddPrometheusAlertEvent add prometheus-alert message
@@ -299,6 +491,76 @@ func (m *EventController) AddPrometheusAlertEvent(ctx web.Context, eventService
 	return m.errorWrap(ctx, id, err)
 }
 
+// AddCloudWatchEvent add aws cloudwatch alarm message which is delivered by sns
+func (m *EventController) AddCloudWatchEvent(ctx web.Context, eventService service.EventService) web.Response {
+	commonMessage, err := extension.CloudWatchToCommonEvent(ctx.Request().Body())
+	if err != nil {
+		var confirmation *extension.SNSSubscriptionConfirmation
+		if errors.As(err, &confirmation) {
+			if err := confirmation.Confirm(ctx.Context()); err != nil {
+				return ctx.JSONError(err.Error(), http.StatusBadRequest)
+			}
+
+			return ctx.JSON(web.M{"message": "subscription confirmed"})
+		}
+
+		return ctx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	id, err := eventService.Add(ctx.Context(), *commonMessage)
+	return m.errorWrap(ctx, id, err)
+}
+
+// AddSentryEvent add sentry webhook message
+func (m *EventController) AddSentryEvent(ctx web.Context, eventService service.EventService) web.Response {
+	commonMessage, err := extension.SentryToCommonEvent(ctx.Request().Body())
+	if err != nil {
+		return ctx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	id, err := eventService.Add(ctx.Context(), *commonMessage)
+	return m.errorWrap(ctx, id, err)
+}
+
+// AddZabbixEvent add zabbix webhook message
+func (m *EventController) AddZabbixEvent(ctx web.Context, eventService service.EventService) web.Response {
+	commonMessage, err := extension.ZabbixToCommonEvent(ctx.Request().Body())
+	if err != nil {
+		return ctx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	id, err := eventService.Add(ctx.Context(), *commonMessage)
+	return m.errorWrap(ctx, id, err)
+}
+
+// AddAlertmanagerV2Event add alertmanager message, every alert in it will be saved as a separate event
+func (m *EventController) AddAlertmanagerV2Event(ctx web.Context, conf *configs.Config, eventService service.EventService) web.Response {
+	commonMessages, err := extension.AlertmanagerV2ToCommonEvents(ctx.Request().Body())
+	if err != nil {
+		return ctx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	if tooManyEvents(conf, len(commonMessages)) {
+		return tooManyEventsResponse(ctx, conf, len(commonMessages))
+	}
+
+	return m.saveEvents(ctx, eventService, "alertmanager", commonMessages)
+}
+
+// AddLokiEvent add loki/promtail push message, every log line will be saved as a separate event
+func (m *EventController) AddLokiEvent(ctx web.Context, conf *configs.Config, eventService service.EventService) web.Response {
+	commonMessages, err := extension.LokiToCommonEvents(ctx.Request().Body(), ctx.Header("Content-Type"))
+	if err != nil {
+		return ctx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	if tooManyEvents(conf, len(commonMessages)) {
+		return tooManyEventsResponse(ctx, conf, len(commonMessages))
+	}
+
+	return m.saveEvents(ctx, eventService, "loki", commonMessages)
+}
+
 // add open-falcon message
 func (m *EventController) AddOpenFalconEvent(ctx web.Context, eventService service.EventService) web.Response {
 	tos := ctx.Input("tos")
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mylxsw/adanos-alert/internal/queue"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/container"
+	"github.com/mylxsw/glacier/web"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type FailedActionController struct {
+	cc container.Container
+}
+
+func NewFailedActionController(cc container.Container) web.Controller {
+	return &FailedActionController{cc: cc}
+}
+
+func (c FailedActionController) Register(router *web.Router) {
+	router.Group("/failed-actions/", func(router *web.Router) {
+		router.Get("/", c.FailedActions).Name("failed-actions:all")
+		router.Get("/{id}/", c.FailedAction).Name("failed-actions:one")
+		router.Post("/{id}/retry/", c.Retry).Name("failed-actions:retry")
+		router.Delete("/{id}/", c.Delete).Name("failed-actions:delete")
+	})
+}
+
+// FailedActions 查询执行失败的动作（死信）
+// Arguments:
+//   - offset/limit
+//   - status dead/retried
+//   - action
+//   - group_id
+func (c FailedActionController) FailedActions(ctx web.Context, repo repository.FailedActionRepo) web.Response {
+	offset, limit := offsetAndLimit(ctx)
+
+	filter := bson.M{}
+	if status := ctx.Input("status"); status != "" {
+		filter["status"] = status
+	}
+
+	if act := ctx.Input("action"); act != "" {
+		filter["action"] = act
+	}
+
+	if groupID, err := primitive.ObjectIDFromHex(ctx.Input("group_id")); err == nil {
+		filter["group_id"] = groupID
+	}
+
+	fas, next, err := repo.Paginate(filter, offset, limit)
+	if err != nil {
+		return ctx.JSONError(fmt.Sprintf("query failed: %v", err), http.StatusInternalServerError)
+	}
+
+	return ctx.JSON(web.M{
+		"failed_actions": fas,
+		"next":           next,
+	})
+}
+
+func (c FailedActionController) FailedAction(ctx web.Context, repo repository.FailedActionRepo) (*repository.FailedAction, error) {
+	fa, err := c.get(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fa, nil
+}
+
+// Retry 将执行失败的动作重新加入队列
+func (c FailedActionController) Retry(ctx web.Context, repo repository.FailedActionRepo, queueManager queue.Manager) (*repository.FailedAction, error) {
+	fa, err := c.get(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	if fa.Status == repository.FailedActionStatusRetried {
+		return nil, web.WrapJSONError(errors.New("failed action has been retried"), http.StatusUnprocessableEntity)
+	}
+
+	jobID, err := queueManager.Enqueue(repository.QueueJob{
+		Name:    "action",
+		Payload: fa.Payload,
+	})
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	fa.Status = repository.FailedActionStatusRetried
+	fa.RetryJobID, _ = primitive.ObjectIDFromHex(jobID)
+	if err := repo.Update(fa.ID, fa); err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &fa, nil
+}
+
+func (c FailedActionController) Delete(ctx web.Context, repo repository.FailedActionRepo) error {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	return repo.DeleteID(id)
+}
+
+func (c FailedActionController) get(ctx web.Context, repo repository.FailedActionRepo) (repository.FailedAction, error) {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return repository.FailedAction{}, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	fa, err := repo.Get(id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return fa, web.WrapJSONError(errors.New("no such failed action"), http.StatusNotFound)
+		}
+
+		return fa, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return fa, nil
+}
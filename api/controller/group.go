@@ -2,14 +2,24 @@ package controller
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/mylxsw/adanos-alert/internal/job"
 	"github.com/mylxsw/adanos-alert/internal/repository"
 	"github.com/mylxsw/adanos-alert/internal/template"
+	"github.com/mylxsw/adanos-alert/pkg/misc"
 	"github.com/mylxsw/adanos-alert/pubsub"
 	"github.com/mylxsw/adanos-alert/service"
+	"github.com/mylxsw/asteria/log"
 	"github.com/mylxsw/container"
 	"github.com/mylxsw/glacier/event"
 	"github.com/mylxsw/glacier/web"
@@ -28,12 +38,22 @@ func NewGroupController(cc container.Container) web.Controller {
 func (g GroupController) Register(router *web.Router) {
 	router.Group("/groups/", func(router *web.Router) {
 		router.Get("/", g.Groups).Name("groups:all")
+		router.Get("/stats/", g.Stats).Name("groups:stats")
+		router.Get("/timeline/", g.Timeline).Name("groups:timeline")
+		router.Post("/batch-status/", g.BatchUpdateStatus).Name("groups:batch-status")
 		router.Get("/{id}/", g.Group).Name("groups:one")
 		router.Delete("/{id}/reduce/", g.CutGroupEvents).Name("groups:reduce")
+		router.Post("/{id}/retrigger/", g.RetriggerGroup).Name("groups:retrigger")
+		router.Post("/{id}/ack/", g.AckGroup).Name("groups:ack")
+		router.Post("/{id}/assign/", g.AssignGroup).Name("groups:assign")
+		router.Get("/{id}/comments/", g.Comments).Name("groups:comments:all")
+		router.Post("/{id}/comments/", g.AddComment).Name("groups:comments:add")
+		router.Get("/{id}/export/", g.ExportGroup).Name("groups:export")
 	})
 
 	router.Group("/recoverable-groups/", func(router *web.Router) {
 		router.Get("/", g.RecoverableGroups).Name("recoverable-groups:all")
+		router.Post("/{id}/recover/", g.ForceRecover).Name("recoverable-groups:recover")
 	})
 }
 
@@ -41,6 +61,8 @@ type GroupsResp struct {
 	Groups []GroupsGroupResp `json:"groups"`
 	Users  map[string]string `json:"users"`
 	Next   int64             `json:"next"`
+	// NextCursor 游标分页时下一页的游标
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 type GroupsGroupResp struct {
@@ -65,6 +87,18 @@ func groupFilter(ctx web.Context) bson.M {
 		filter["actions.user_refs"] = userID
 	}
 
+	assignee, err := primitive.ObjectIDFromHex(ctx.Input("assignee"))
+	if err == nil {
+		filter["assignee"] = assignee
+	}
+
+	switch ctx.Input("acked") {
+	case "true":
+		filter["acked_at"] = bson.M{"$ne": nil}
+	case "false":
+		filter["acked_at"] = nil
+	}
+
 	dingID := ctx.Input("dingding_id")
 	if dingID != "" {
 		filter["actions.meta"] = bson.M{"$regex": fmt.Sprintf(`"robot_id":"%s"`, dingID)}
@@ -76,12 +110,27 @@ func groupFilter(ctx web.Context) bson.M {
 // Groups list all event groups
 // Arguments:
 //   - offset/limit
+//   - cursor/paginate=cursor 使用游标分页代替 offset
 //   - status
 //   - rule_id
 //   - user_id
+//   - acked true/false
+//   - assignee
 func (g GroupController) Groups(ctx web.Context, groupRepo repository.EventGroupRepo, userRepo repository.UserRepo) (*GroupsResp, error) {
 	offset, limit := offsetAndLimit(ctx)
-	grps, next, err := groupRepo.Paginate(groupFilter(ctx), offset, limit)
+	afterID, useCursor, err := cursorPagination(ctx)
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusUnprocessableEntity)
+	}
+
+	var grps []repository.EventGroup
+	var next int64
+	var nextCursor primitive.ObjectID
+	if useCursor {
+		grps, nextCursor, err = groupRepo.PaginateAfter(groupFilter(ctx), afterID, limit)
+	} else {
+		grps, next, err = groupRepo.Paginate(groupFilter(ctx), offset, limit)
+	}
 	if err != nil {
 		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
 	}
@@ -91,9 +140,13 @@ func (g GroupController) Groups(ctx web.Context, groupRepo repository.EventGroup
 		for _, act := range grp.Actions {
 			userIDs = append(userIDs, act.UserRefs...)
 		}
+
+		if !grp.Assignee.IsZero() {
+			userIDs = append(userIDs, grp.Assignee)
+		}
 	}
 
-	users, _ := userRepo.Find(bson.M{"_id": bson.M{"$in": userIDs}})
+	users, _ := userRepo.Find(repository.WithDeletedUsers(bson.M{"_id": bson.M{"$in": userIDs}}))
 	userRefs := make(map[string]string)
 	for _, u := range users {
 		userRefs[u.ID.Hex()] = u.Name
@@ -109,12 +162,113 @@ func (g GroupController) Groups(ctx web.Context, groupRepo repository.EventGroup
 	}
 
 	return &GroupsResp{
-		Groups: groups,
-		Users:  userRefs,
-		Next:   next,
+		Groups:     groups,
+		Users:      userRefs,
+		Next:       next,
+		NextCursor: cursorToken(nextCursor),
 	}, nil
 }
 
+// Stats 按照状态统计分组数量
+// Arguments:
+//   - rule_id
+//   - user_id
+//   - dingding_id
+func (g GroupController) Stats(ctx web.Context, groupRepo repository.EventGroupRepo) (map[string]int64, error) {
+	filter := groupFilter(ctx)
+	delete(filter, "status")
+
+	stats, err := groupRepo.CountByStatus(filter)
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	// 没有分组的状态也返回，数量为 0，方便前端展示
+	for _, status := range []repository.EventGroupStatus{
+		repository.EventGroupStatusCollecting,
+		repository.EventGroupStatusPending,
+		repository.EventGroupStatusOK,
+		repository.EventGroupStatusFailed,
+		repository.EventGroupStatusCanceled,
+	} {
+		if _, ok := stats[string(status)]; !ok {
+			stats[string(status)] = 0
+		}
+	}
+
+	return stats, nil
+}
+
+// timelineMaxRanges 时间桶统计允许的最大时间范围，避免无限制的扫描
+var timelineMaxRanges = map[string]time.Duration{
+	"minute": 24 * time.Hour,
+	"hour":   31 * 24 * time.Hour,
+	"day":    366 * 24 * time.Hour,
+}
+
+// Timeline 按照时间桶统计事件组数量
+// Arguments:
+//   - interval: minute/hour/day，默认 hour
+//   - from/to: 时间范围，支持 RFC3339 格式或者 Unix 时间戳，默认为最近 24 小时
+//   - status/rule_id/user_id/dingding_id
+func (g GroupController) Timeline(ctx web.Context, groupRepo repository.EventGroupRepo) ([]repository.EventGroupBucket, error) {
+	interval := ctx.InputWithDefault("interval", "hour")
+	maxRange, ok := timelineMaxRanges[interval]
+	if !ok {
+		return nil, web.WrapJSONError(fmt.Errorf("interval: 只支持 minute/hour/day"), http.StatusUnprocessableEntity)
+	}
+
+	to, err := parseTimeInput(ctx, "to", time.Now())
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusUnprocessableEntity)
+	}
+
+	from, err := parseTimeInput(ctx, "from", to.Add(-24*time.Hour))
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusUnprocessableEntity)
+	}
+
+	if !from.Before(to) {
+		return nil, web.WrapJSONError(fmt.Errorf("from: 开始时间必须早于结束时间"), http.StatusUnprocessableEntity)
+	}
+
+	if to.Sub(from) > maxRange {
+		return nil, web.WrapJSONError(fmt.Errorf("统计周期为 %s 时，时间范围不能超过 %s", interval, maxRange), http.StatusUnprocessableEntity)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Context(), 15*time.Second)
+	defer cancel()
+
+	filter := groupFilter(ctx)
+	filter["created_at"] = bson.M{"$gte": from, "$lt": to}
+
+	buckets, err := groupRepo.StatByTimeBucket(timeoutCtx, filter, interval)
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return buckets, nil
+}
+
+// parseTimeInput 解析请求参数中的时间，支持 RFC3339 格式或者 Unix 时间戳，参数为空时返回默认值
+func parseTimeInput(ctx web.Context, key string, def time.Time) (time.Time, error) {
+	val := ctx.Input(key)
+	if val == "" {
+		return def, nil
+	}
+
+	if ts, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.Unix(ts, 0), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return def, fmt.Errorf("%s: 时间格式错误，只支持 RFC3339 格式或者 Unix 时间戳", key)
+	}
+
+	return t, nil
+}
+
 type GroupResp struct {
 	Group  repository.EventGroup `json:"group"`
 	Events []repository.Event    `json:"events"`
@@ -160,6 +314,9 @@ func (g GroupController) Group(
 
 // CutGroupEvents 缩减事件组中包含的事件，对已经完成聚合的事件组有效，
 // 该操作不会影响事件组上对事件总数的计数
+// Arguments:
+//   - keep: 保留最新的事件数量，默认 20
+//   - before: 删除该时间之前的事件，支持 RFC3339 格式或者 Unix 时间戳，指定该参数时 keep 参数无效
 func (g GroupController) CutGroupEvents(webCtx web.Context, evtGrpRepo repository.EventGroupRepo, evtGroupSvc service.EventGroupService, em event.Manager) web.Response {
 	groupID, err := primitive.ObjectIDFromHex(webCtx.PathVar("id"))
 	if err != nil {
@@ -175,14 +332,38 @@ func (g GroupController) CutGroupEvents(webCtx web.Context, evtGrpRepo repositor
 		return webCtx.JSONError("当前事件组暂时不支持该操作", http.StatusUnprocessableEntity)
 	}
 
+	ctx, cancel := context.WithTimeout(webCtx.Context(), 10*time.Second)
+	defer cancel()
+
+	// 指定了 before 参数时，删除该时间之前的事件，否则只保留最新的 keep 条事件
+	if webCtx.Input("before") != "" {
+		before, err := parseTimeInput(webCtx, "before", time.Now())
+		if err != nil {
+			return webCtx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		deletedCount, err := evtGroupSvc.CutGroupBefore(ctx, groupID, before)
+		if err != nil {
+			return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+		}
+
+		if deletedCount > 0 {
+			em.Publish(pubsub.EventGroupReduceEvent{
+				GroupID:     grp.ID,
+				Before:      before,
+				DeleteCount: deletedCount,
+				CreatedAt:   time.Now(),
+			})
+		}
+
+		return webCtx.JSON(web.M{"deleted_count": deletedCount})
+	}
+
 	keepCount := webCtx.Int64Input("keep", 20)
 	if keepCount < 0 || keepCount > 1000 {
 		return webCtx.JSONError("keep: 保留事件数必须在 0 - 1000 之间", http.StatusUnprocessableEntity)
 	}
 
-	ctx, cancel := context.WithTimeout(webCtx.Context(), 10*time.Second)
-	defer cancel()
-
 	deletedCount, err := evtGroupSvc.CutGroup(ctx, groupID, keepCount)
 	if err != nil {
 		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
@@ -200,7 +381,512 @@ func (g GroupController) CutGroupEvents(webCtx web.Context, evtGrpRepo repositor
 	return webCtx.JSON(web.M{"deleted_count": deletedCount})
 }
 
+// RetriggerGroup 重新触发已经处理完成的事件组的所有动作，用于下游通知失败后的人工恢复
+// 事件组状态被重置为 pending，由 trigger 任务重新执行动作
+// Arguments:
+//   - operator: 操作人，为空时使用 X-Operator 请求头或者客户端 IP
+func (g GroupController) RetriggerGroup(webCtx web.Context, evtGrpRepo repository.EventGroupRepo, em event.Manager) web.Response {
+	groupID, err := primitive.ObjectIDFromHex(webCtx.PathVar("id"))
+	if err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	grp, err := evtGrpRepo.Get(groupID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return webCtx.JSONError("事件组不存在", http.StatusNotFound)
+		}
+
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	if grp.Status != repository.EventGroupStatusOK && grp.Status != repository.EventGroupStatusFailed {
+		return webCtx.JSONError("只有处理完成（ok/failed）的事件组才能重新触发", http.StatusUnprocessableEntity)
+	}
+
+	grp.Status = repository.EventGroupStatusPending
+	grp.History = append(grp.History, repository.EventGroupHistory{
+		Type:      repository.EventGroupHistoryTypeRetrigger,
+		Operator:  operatorName(webCtx),
+		CreatedAt: time.Now(),
+	})
+
+	if err := evtGrpRepo.UpdateID(grp.ID, grp); err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	em.Publish(pubsub.MessageGroupPendingEvent{
+		Group:     grp,
+		CreatedAt: time.Now(),
+	})
+
+	return webCtx.JSON(web.M{})
+}
+
+// AckGroup 确认事件组，确认后事件组不再执行升级步骤
+// Arguments:
+//   - user_id 确认人 ID，可选
+func (g GroupController) AckGroup(webCtx web.Context, evtGrpRepo repository.EventGroupRepo, userRepo repository.UserRepo) web.Response {
+	groupID, err := primitive.ObjectIDFromHex(webCtx.PathVar("id"))
+	if err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	userID := primitive.NilObjectID
+	if uid := webCtx.Input("user_id"); uid != "" {
+		userID, err = primitive.ObjectIDFromHex(uid)
+		if err != nil {
+			return webCtx.JSONError(fmt.Sprintf("user_id: %v", err), http.StatusUnprocessableEntity)
+		}
+
+		if _, err := userRepo.Get(userID); err != nil {
+			if err == repository.ErrNotFound {
+				return webCtx.JSONError("用户不存在", http.StatusUnprocessableEntity)
+			}
+
+			return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	if err := evtGrpRepo.Ack(groupID, userID); err != nil {
+		switch err {
+		case repository.ErrNotFound:
+			return webCtx.JSONError("事件组不存在", http.StatusNotFound)
+		case repository.ErrAlreadyAcked:
+			return webCtx.JSONError("事件组已经被确认", http.StatusUnprocessableEntity)
+		default:
+			return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	grp, err := evtGrpRepo.Get(groupID)
+	if err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	grp.History = append(grp.History, repository.EventGroupHistory{
+		Type:      repository.EventGroupHistoryTypeAck,
+		Operator:  operatorName(webCtx),
+		CreatedAt: time.Now(),
+	})
+
+	if err := evtGrpRepo.UpdateID(grp.ID, grp); err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	return webCtx.JSON(grp)
+}
+
+// AssignGroup 指派事件组负责人
+// Arguments:
+//   - assignee 负责人用户 ID
+func (g GroupController) AssignGroup(webCtx web.Context, evtGrpRepo repository.EventGroupRepo, userRepo repository.UserRepo, em event.Manager) web.Response {
+	groupID, err := primitive.ObjectIDFromHex(webCtx.PathVar("id"))
+	if err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	assigneeID, err := primitive.ObjectIDFromHex(webCtx.Input("assignee"))
+	if err != nil {
+		return webCtx.JSONError(fmt.Sprintf("assignee: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	assignee, err := userRepo.Get(assigneeID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return webCtx.JSONError("负责人不存在", http.StatusUnprocessableEntity)
+		}
+
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	if assignee.Status == repository.UserStatusDeleted {
+		return webCtx.JSONError("负责人已删除", http.StatusUnprocessableEntity)
+	}
+
+	grp, err := evtGrpRepo.Get(groupID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return webCtx.JSONError("事件组不存在", http.StatusNotFound)
+		}
+
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	if grp.Assignee == assignee.ID {
+		return webCtx.JSON(grp)
+	}
+
+	previous := grp.Assignee
+	operator := operatorName(webCtx)
+	grp.Assignee = assignee.ID
+	grp.History = append(grp.History, repository.EventGroupHistory{
+		Type:      repository.EventGroupHistoryTypeAssign,
+		Operator:  operator,
+		CreatedAt: time.Now(),
+	})
+
+	if err := evtGrpRepo.UpdateID(grp.ID, grp); err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	em.Publish(pubsub.EventGroupAssignedEvent{
+		Group:            grp,
+		Assignee:         assignee,
+		PreviousAssignee: previous,
+		Operator:         operator,
+		CreatedAt:        time.Now(),
+	})
+
+	return webCtx.JSON(grp)
+}
+
+// GroupCommentForm 事件组评论请求
+type GroupCommentForm struct {
+	Body string `json:"body"`
+}
+
+// AddComment 为事件组添加评论，评论人为当前操作人
+func (g GroupController) AddComment(webCtx web.Context, evtGrpRepo repository.EventGroupRepo, commentRepo repository.GroupCommentRepo) web.Response {
+	groupID, err := primitive.ObjectIDFromHex(webCtx.PathVar("id"))
+	if err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	var form GroupCommentForm
+	if err := webCtx.Unmarshal(&form); err != nil {
+		return webCtx.JSONError(fmt.Sprintf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	if strings.TrimSpace(form.Body) == "" {
+		return webCtx.JSONError("body: 评论内容不能为空", http.StatusUnprocessableEntity)
+	}
+
+	if _, err := evtGrpRepo.Get(groupID); err != nil {
+		if err == repository.ErrNotFound {
+			return webCtx.JSONError("事件组不存在", http.StatusNotFound)
+		}
+
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	id, err := commentRepo.Add(repository.GroupComment{
+		GroupID: groupID,
+		Author:  operatorName(webCtx),
+		Body:    form.Body,
+	})
+	if err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	comment, err := commentRepo.Get(id)
+	if err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	return webCtx.JSON(comment)
+}
+
+// Comments 分页查询事件组的评论，按照创建时间倒序排列
+// Arguments:
+//   - offset/limit
+func (g GroupController) Comments(webCtx web.Context, commentRepo repository.GroupCommentRepo) web.Response {
+	groupID, err := primitive.ObjectIDFromHex(webCtx.PathVar("id"))
+	if err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	offset, limit := offsetAndLimit(webCtx)
+	comments, next, err := commentRepo.Paginate(bson.M{"group_id": groupID}, offset, limit)
+	if err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	return webCtx.JSON(web.M{
+		"comments": comments,
+		"next":     next,
+	})
+}
+
+// BatchUpdateStatusForm 批量变更事件组状态请求
+type BatchUpdateStatusForm struct {
+	IDs    []string `json:"ids"`
+	Status string   `json:"status"`
+}
+
+// BatchUpdateStatusResult 单个事件组的状态变更结果
+type BatchUpdateStatusResult struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BatchUpdateStatus 批量变更事件组状态，只能变更为 ok（已解决）或者 canceled（忽略），返回每个事件组的变更结果
+func (g GroupController) BatchUpdateStatus(webCtx web.Context, evtGrpRepo repository.EventGroupRepo) web.Response {
+	var form BatchUpdateStatusForm
+	if err := webCtx.Unmarshal(&form); err != nil {
+		return webCtx.JSONError(fmt.Sprintf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	if len(form.IDs) == 0 || len(form.IDs) > 1000 {
+		return webCtx.JSONError("ids: 事件组数量必须在 1 - 1000 之间", http.StatusUnprocessableEntity)
+	}
+
+	target := repository.EventGroupStatus(form.Status)
+	if target != repository.EventGroupStatusOK && target != repository.EventGroupStatusCanceled {
+		return webCtx.JSONError("status: 只能变更为 ok 或者 canceled", http.StatusUnprocessableEntity)
+	}
+
+	results := make([]BatchUpdateStatusResult, len(form.IDs))
+	ids := make([]primitive.ObjectID, 0, len(form.IDs))
+	for i, idHex := range form.IDs {
+		results[i].ID = idHex
+		id, err := primitive.ObjectIDFromHex(idHex)
+		if err != nil {
+			results[i].Error = "invalid id"
+			continue
+		}
+
+		ids = append(ids, id)
+	}
+
+	grps, err := evtGrpRepo.Find(bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	grpStatus := make(map[string]repository.EventGroupStatus)
+	for _, grp := range grps {
+		grpStatus[grp.ID.Hex()] = grp.Status
+	}
+
+	allowedIDs := make([]primitive.ObjectID, 0, len(ids))
+	for i, res := range results {
+		if res.Error != "" {
+			continue
+		}
+
+		status, ok := grpStatus[res.ID]
+		if !ok {
+			results[i].Error = "事件组不存在"
+			continue
+		}
+
+		if !status.CanTransitTo(target) {
+			results[i].Error = fmt.Sprintf("不允许从 %s 变更为 %s", status, target)
+			continue
+		}
+
+		id, _ := primitive.ObjectIDFromHex(res.ID)
+		allowedIDs = append(allowedIDs, id)
+		results[i].Success = true
+	}
+
+	// 只更新状态仍然允许变更的事件组，避免覆盖查询之后被其它请求或者任务修改的状态
+	modified, err := evtGrpRepo.UpdateStatusMany(allowedIDs, repository.ManualTransitionSources(target), string(target))
+	if err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	if modified < int64(len(allowedIDs)) {
+		if err := markUnchangedGroups(evtGrpRepo, results, allowedIDs, target); err != nil {
+			return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	return webCtx.JSON(web.M{
+		"modified": modified,
+		"results":  results,
+	})
+}
+
+// markUnchangedGroups 将更新之后状态不是 target 的事件组标记为失败，这些事件组的状态在查询之后被修改过
+func markUnchangedGroups(evtGrpRepo repository.EventGroupRepo, results []BatchUpdateStatusResult, ids []primitive.ObjectID, target repository.EventGroupStatus) error {
+	grps, err := evtGrpRepo.Find(bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return err
+	}
+
+	grpStatus := make(map[string]repository.EventGroupStatus)
+	for _, grp := range grps {
+		grpStatus[grp.ID.Hex()] = grp.Status
+	}
+
+	for i, res := range results {
+		if !res.Success {
+			continue
+		}
+
+		if status, ok := grpStatus[res.ID]; !ok || status != target {
+			results[i].Success = false
+			results[i].Error = "事件组状态已经被修改，请刷新后重试"
+		}
+	}
+
+	return nil
+}
+
+// ExportGroup 导出事件组中的所有事件，事件通过游标逐条写入响应，不会一次性加载到内存
+// Arguments:
+//   - format: csv/json，默认为 csv，json 格式为每行一个事件（newline-delimited JSON）
+func (g GroupController) ExportGroup(webCtx web.Context, evtGrpRepo repository.EventGroupRepo, evtRepo repository.EventRepo) web.Response {
+	groupID, err := primitive.ObjectIDFromHex(webCtx.PathVar("id"))
+	if err != nil {
+		return webCtx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	format := webCtx.InputWithDefault("format", "csv")
+	if format != "csv" && format != "json" {
+		return webCtx.JSONError("format: 只支持 csv/json", http.StatusUnprocessableEntity)
+	}
+
+	grp, err := evtGrpRepo.Get(groupID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return webCtx.JSONError("事件组不存在", http.StatusNotFound)
+		}
+
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	filter := bson.M{"group_ids": grp.ID}
+
+	// CSV 格式需要先确定表头，先遍历一次事件，收集所有的 meta key
+	var metaKeys []string
+	if format == "csv" {
+		keys := make(map[string]bool)
+		if err := evtRepo.Traverse(filter, func(evt repository.Event) error {
+			for k := range evt.Meta {
+				keys[k] = true
+			}
+			return nil
+		}); err != nil {
+			return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+		}
+
+		metaKeys = make([]string, 0, len(keys))
+		for k := range keys {
+			metaKeys = append(metaKeys, k)
+		}
+		sort.Strings(metaKeys)
+	}
+
+	w := webCtx.Response().Raw()
+	filename := fmt.Sprintf("group-%d-%s.%s", grp.SeqNum, grp.ID.Hex(), misc.IfElse(format == "csv", "csv", "ndjson"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+
+		encoder := json.NewEncoder(w)
+		err = evtRepo.Traverse(filter, func(evt repository.Event) error {
+			return encoder.Encode(evt)
+		})
+	} else {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+
+		err = writeEventsCSV(w, metaKeys, func(cb func(evt repository.Event) error) error {
+			return evtRepo.Traverse(filter, cb)
+		})
+	}
+
+	if err != nil {
+		// 响应头已经发送，只能记录日志
+		log.WithFields(log.Fields{
+			"group_id": grp.ID.Hex(),
+			"format":   format,
+		}).Errorf("export group events failed: %v", err)
+	}
+
+	return webCtx.Nil()
+}
+
+// writeEventsCSV 以 CSV 格式写入事件，meta 中的每个 key 作为单独的一列
+func writeEventsCSV(w io.Writer, metaKeys []string, traverse func(cb func(evt repository.Event) error) error) error {
+	writer := csv.NewWriter(w)
+
+	header := []string{"id", "seq_num", "created_at", "status", "origin", "tags", "content"}
+	for _, k := range metaKeys {
+		header = append(header, "meta."+k)
+	}
+
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	err := traverse(func(evt repository.Event) error {
+		row := []string{
+			evt.ID.Hex(),
+			strconv.FormatInt(evt.SeqNum, 10),
+			evt.CreatedAt.Format(time.RFC3339),
+			string(evt.Status),
+			evt.Origin,
+			strings.Join(evt.Tags, ","),
+			evt.Content,
+		}
+
+		for _, k := range metaKeys {
+			row = append(row, metaValueString(evt.Meta[k]))
+		}
+
+		return writer.Write(row)
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// metaValueString 将 meta 值转换为字符串，复合类型转换为 JSON
+func metaValueString(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}, primitive.M, primitive.A, primitive.D:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
 // RecoverableGroups 当前待恢复的报警组
 func (g GroupController) RecoverableGroups(recoveryRepo repository.RecoveryRepo) ([]repository.Recovery, error) {
 	return recoveryRepo.RecoverableEvents(context.TODO(), time.Now().AddDate(1, 0, 0))
 }
+
+// ForceRecover 立即恢复报警组，路径参数 id 为恢复标识（EventControl.ID）
+// 用于运维人员确认问题已经解决的场景，立即生成恢复事件并删除恢复记录，不再等待自动恢复
+func (g GroupController) ForceRecover(webCtx web.Context, recoveryRepo repository.RecoveryRepo, eventRepo repository.EventRepo, em event.Manager) web.Response {
+	recoveryID := webCtx.PathVar("id")
+	id, err := job.ForceRecover(recoveryRepo, eventRepo, recoveryID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return webCtx.JSONError("no such recoverable group", http.StatusNotFound)
+		}
+
+		if errors.Is(err, job.ErrNotRecoverable) {
+			return webCtx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+		}
+
+		return webCtx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	em.Publish(pubsub.RecoveryForcedEvent{
+		RecoveryID:      recoveryID,
+		RecoveryEventID: id,
+		Operator:        operatorName(webCtx),
+		CreatedAt:       time.Now(),
+	})
+
+	return webCtx.JSON(web.M{
+		"recovery_id": recoveryID,
+		"id":          id.Hex(),
+	})
+}
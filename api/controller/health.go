@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/job"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/container"
+	"github.com/mylxsw/glacier/web"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// healthCheckTimeout 健康检查中 MongoDB ping 的超时时间
+const healthCheckTimeout = 3 * time.Second
+
+const (
+	healthStatusUp   = "UP"
+	healthStatusDown = "DOWN"
+)
+
+// HealthController 健康检查与就绪检查，供 Kubernetes 探针以及外部监控使用，不需要认证
+type HealthController struct {
+	cc container.Container
+}
+
+func NewHealthController(cc container.Container) web.Controller {
+	return &HealthController{cc: cc}
+}
+
+func (h HealthController) Register(router *web.Router) {
+	router.Get("/health/", h.Health).Name("health:health")
+	router.Get("/ready/", h.Ready).Name("health:ready")
+}
+
+// Health 健康检查，MongoDB 可以访问并且能够参与定时任务分布式锁的竞争时返回 200，否则返回 503
+func (h HealthController) Health(ctx web.Context, db *mongo.Database, lockManager *job.DistributeLockManager, lockRepo repository.LockRepo, aggregationJob *job.AggregationJob) web.Response {
+	checks := web.M{}
+	healthy := true
+
+	if err := pingMongo(db); err != nil {
+		checks["mongodb"] = err.Error()
+		healthy = false
+	} else {
+		checks["mongodb"] = "ok"
+	}
+
+	status := lockManager.Status()
+	lock := web.M{"resource": status.Resource, "node": status}
+	if owner, expiresAt, err := lockRepo.CurrentOwner(status.Resource); err != nil {
+		checks["lock"] = err.Error()
+		healthy = false
+	} else {
+		checks["lock"] = "ok"
+		lock["owner"] = owner
+		lock["expires_at"] = expiresAt
+	}
+
+	return ctx.JSONWithCode(web.M{
+		"status":              healthStatus(healthy),
+		"checks":              checks,
+		"lock":                lock,
+		"last_aggregation_at": lastAggregationAt(aggregationJob),
+	}, healthStatusCode(healthy))
+}
+
+// Ready 就绪检查，MongoDB 可以访问并且服务没有处于停止过程中时返回 200，否则返回 503
+func (h HealthController) Ready(ctx web.Context, db *mongo.Database, jobs *job.RunningJobs, lockManager *job.DistributeLockManager, aggregationJob *job.AggregationJob) web.Response {
+	checks := web.M{}
+	ready := true
+
+	if err := pingMongo(db); err != nil {
+		checks["mongodb"] = err.Error()
+		ready = false
+	} else {
+		checks["mongodb"] = "ok"
+	}
+
+	if jobs.Closed() {
+		checks["shutdown"] = "service is shutting down"
+		ready = false
+	}
+
+	return ctx.JSONWithCode(web.M{
+		"status":              healthStatus(ready),
+		"checks":              checks,
+		"lock":                lockManager.Status(),
+		"last_aggregation_at": lastAggregationAt(aggregationJob),
+	}, healthStatusCode(ready))
+}
+
+func pingMongo(db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	return db.Client().Ping(ctx, readpref.Primary())
+}
+
+// lastAggregationAt 返回本节点最近一次开始执行聚合任务的时间，没有执行过时返回 nil
+func lastAggregationAt(aggregationJob *job.AggregationJob) *time.Time {
+	lastRun := aggregationJob.LastRun()
+	if lastRun.IsZero() {
+		return nil
+	}
+
+	return &lastRun
+}
+
+func healthStatus(ok bool) string {
+	if ok {
+		return healthStatusUp
+	}
+
+	return healthStatusDown
+}
+
+func healthStatusCode(ok bool) int {
+	if ok {
+		return http.StatusOK
+	}
+
+	return http.StatusServiceUnavailable
+}
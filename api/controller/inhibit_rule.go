@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mylxsw/adanos-alert/internal/matcher"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/container"
+	"github.com/mylxsw/glacier/web"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type InhibitRuleController struct {
+	cc container.Container
+}
+
+func NewInhibitRuleController(cc container.Container) web.Controller {
+	return &InhibitRuleController{cc: cc}
+}
+
+func (c InhibitRuleController) Register(router *web.Router) {
+	router.Group("/inhibit-rules/", func(router *web.Router) {
+		router.Get("/", c.InhibitRules).Name("inhibit-rules:all")
+		router.Post("/", c.Add).Name("inhibit-rules:add")
+		router.Get("/{id}/", c.InhibitRule).Name("inhibit-rules:one")
+		router.Post("/{id}/", c.Update).Name("inhibit-rules:update")
+		router.Delete("/{id}/", c.Delete).Name("inhibit-rules:delete")
+	})
+}
+
+type InhibitRuleForm struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	SourceRule  string   `json:"source_rule"`
+	TargetRule  string   `json:"target_rule"`
+	Equal       []string `json:"equal"`
+	Window      int64    `json:"window"`
+	Enabled     bool     `json:"enabled"`
+}
+
+func (form InhibitRuleForm) Validate(req web.Request) error {
+	if form.Name == "" {
+		return errors.New("invalid argument: name is required")
+	}
+
+	if form.SourceRule == "" || form.TargetRule == "" {
+		return errors.New("invalid argument: source_rule and target_rule are required")
+	}
+
+	if form.Window < 0 || form.Window > 3600*24 {
+		return errors.New("invalid argument: window must between 0~24h")
+	}
+
+	if _, err := matcher.NewInhibitMatcher(form.toInhibitRule()); err != nil {
+		return fmt.Errorf("invalid argument: %w", err)
+	}
+
+	return nil
+}
+
+func (form InhibitRuleForm) toInhibitRule() repository.InhibitRule {
+	return repository.InhibitRule{
+		Name:        form.Name,
+		Description: form.Description,
+		SourceRule:  form.SourceRule,
+		TargetRule:  form.TargetRule,
+		Equal:       form.Equal,
+		Window:      form.Window,
+		Enabled:     form.Enabled,
+	}
+}
+
+func (c InhibitRuleController) Add(ctx web.Context, repo repository.InhibitRuleRepo) (*repository.InhibitRule, error) {
+	var form InhibitRuleForm
+	if err := ctx.Unmarshal(&form); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	ctx.Validate(form, true)
+
+	id, err := repo.Add(form.toInhibitRule())
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	rule, err := repo.Get(id)
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &rule, nil
+}
+
+func (c InhibitRuleController) Update(ctx web.Context, repo repository.InhibitRuleRepo) (*repository.InhibitRule, error) {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	var form InhibitRuleForm
+	if err := ctx.Unmarshal(&form); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	ctx.Validate(form, true)
+
+	original, err := repo.Get(id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, web.WrapJSONError(err, http.StatusNotFound)
+		}
+
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	rule := form.toInhibitRule()
+	rule.ID = original.ID
+	rule.CreatedAt = original.CreatedAt
+
+	if err := repo.Update(id, rule); err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &rule, nil
+}
+
+func (c InhibitRuleController) Delete(ctx web.Context, repo repository.InhibitRuleRepo) error {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	return repo.DeleteID(id)
+}
+
+func (c InhibitRuleController) InhibitRule(ctx web.Context, repo repository.InhibitRuleRepo) (*repository.InhibitRule, error) {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	rule, err := repo.Get(id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, web.WrapJSONError(errors.New("no such inhibit rule"), http.StatusNotFound)
+		}
+
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &rule, nil
+}
+
+func (c InhibitRuleController) InhibitRules(ctx web.Context, repo repository.InhibitRuleRepo) web.Response {
+	offset, limit := offsetAndLimit(ctx)
+
+	filter := bson.M{}
+	name := ctx.Input("name")
+	if name != "" {
+		filter["name"] = bson.M{"$regex": name}
+	}
+
+	rules, next, err := repo.Paginate(filter, offset, limit)
+	if err != nil {
+		return ctx.JSONError(fmt.Sprintf("query failed: %v", err), http.StatusInternalServerError)
+	}
+
+	return ctx.JSON(web.M{
+		"rules": rules,
+		"next":  next,
+		"search": web.M{
+			"name": name,
+		},
+	})
+}
@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mylxsw/adanos-alert/internal/matcher"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/container"
+	"github.com/mylxsw/glacier/web"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MaintenanceWindowController struct {
+	cc container.Container
+}
+
+func NewMaintenanceWindowController(cc container.Container) web.Controller {
+	return &MaintenanceWindowController{cc: cc}
+}
+
+func (c MaintenanceWindowController) Register(router *web.Router) {
+	router.Group("/maintenance-windows/", func(router *web.Router) {
+		router.Get("/", c.MaintenanceWindows).Name("maintenance-windows:all")
+		router.Post("/", c.Add).Name("maintenance-windows:add")
+		router.Get("/{id}/", c.MaintenanceWindow).Name("maintenance-windows:one")
+		router.Post("/{id}/", c.Update).Name("maintenance-windows:update")
+		router.Delete("/{id}/", c.Delete).Name("maintenance-windows:delete")
+	})
+}
+
+type MaintenanceWindowForm struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Schedule    string `json:"schedule"`
+	Duration    int64  `json:"duration"`
+	Matcher     string `json:"matcher"`
+	Enabled     bool   `json:"enabled"`
+}
+
+func (form MaintenanceWindowForm) Validate(req web.Request) error {
+	if form.Name == "" {
+		return errors.New("invalid argument: name is required")
+	}
+
+	if _, err := form.toMaintenanceWindow().ParseSchedule(); err != nil {
+		return fmt.Errorf("invalid argument: schedule is invalid: %w", err)
+	}
+
+	if form.Duration <= 0 || form.Duration > 3600*24*7 {
+		return errors.New("invalid argument: duration must between 1s~7d")
+	}
+
+	if _, err := matcher.NewTriggerMatcher(repository.Trigger{PreCondition: form.Matcher}); err != nil {
+		return fmt.Errorf("invalid argument: matcher is invalid: %w", err)
+	}
+
+	return nil
+}
+
+func (form MaintenanceWindowForm) toMaintenanceWindow() repository.MaintenanceWindow {
+	return repository.MaintenanceWindow{
+		Name:        form.Name,
+		Description: form.Description,
+		Schedule:    form.Schedule,
+		Duration:    form.Duration,
+		Matcher:     form.Matcher,
+		Enabled:     form.Enabled,
+	}
+}
+
+func (c MaintenanceWindowController) Add(ctx web.Context, repo repository.MaintenanceWindowRepo) (*repository.MaintenanceWindow, error) {
+	var form MaintenanceWindowForm
+	if err := ctx.Unmarshal(&form); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	ctx.Validate(form, true)
+
+	id, err := repo.Add(form.toMaintenanceWindow())
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	window, err := repo.Get(id)
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &window, nil
+}
+
+func (c MaintenanceWindowController) Update(ctx web.Context, repo repository.MaintenanceWindowRepo) (*repository.MaintenanceWindow, error) {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	var form MaintenanceWindowForm
+	if err := ctx.Unmarshal(&form); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	ctx.Validate(form, true)
+
+	original, err := repo.Get(id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, web.WrapJSONError(err, http.StatusNotFound)
+		}
+
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	window := form.toMaintenanceWindow()
+	window.ID = original.ID
+	window.CreatedAt = original.CreatedAt
+
+	if err := repo.Update(id, window); err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &window, nil
+}
+
+func (c MaintenanceWindowController) Delete(ctx web.Context, repo repository.MaintenanceWindowRepo) error {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	return repo.DeleteID(id)
+}
+
+func (c MaintenanceWindowController) MaintenanceWindow(ctx web.Context, repo repository.MaintenanceWindowRepo) (*repository.MaintenanceWindow, error) {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	window, err := repo.Get(id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, web.WrapJSONError(errors.New("no such maintenance window"), http.StatusNotFound)
+		}
+
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &window, nil
+}
+
+func (c MaintenanceWindowController) MaintenanceWindows(ctx web.Context, repo repository.MaintenanceWindowRepo) web.Response {
+	offset, limit := offsetAndLimit(ctx)
+
+	filter := bson.M{}
+	name := ctx.Input("name")
+	if name != "" {
+		filter["name"] = bson.M{"$regex": name}
+	}
+
+	windows, next, err := repo.Paginate(filter, offset, limit)
+	if err != nil {
+		return ctx.JSONError(fmt.Sprintf("query failed: %v", err), http.StatusInternalServerError)
+	}
+
+	return ctx.JSON(web.M{
+		"windows": windows,
+		"next":    next,
+		"search": web.M{
+			"name": name,
+		},
+	})
+}
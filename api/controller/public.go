@@ -6,6 +6,7 @@ import (
 
 	"github.com/mylxsw/adanos-alert/api/view"
 	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/signature"
 	"github.com/mylxsw/container"
 	"github.com/mylxsw/glacier/web"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -120,6 +121,8 @@ func (p PublicController) Group(ctx web.Context, groupRepo repository.EventGroup
 		HasPrev:     offset-limit >= 0,
 		HasNext:     next > 0,
 		PrevOffset:  offset - limit,
+		Expires:     ctx.Input(signature.ExpiresParam),
+		Token:       ctx.Input(signature.TokenParam),
 	})
 	if err != nil {
 		return ctx.Error(fmt.Sprintf("template parse failed: %v", err), http.StatusInternalServerError)
@@ -7,10 +7,12 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/antonmedv/expr/file"
 	"github.com/asaskevich/govalidator"
 	"github.com/mylxsw/adanos-alert/configs"
 	"github.com/mylxsw/adanos-alert/internal/action"
 	"github.com/mylxsw/adanos-alert/internal/extension"
+	"github.com/mylxsw/adanos-alert/internal/job"
 	"github.com/mylxsw/adanos-alert/internal/matcher"
 	"github.com/mylxsw/adanos-alert/internal/repository"
 	"github.com/mylxsw/adanos-alert/internal/template"
@@ -35,10 +37,14 @@ func NewRuleController(cc container.Container) web.Controller {
 func (r RuleController) Register(router *web.Router) {
 	router.Group("/rules/", func(router *web.Router) {
 		router.Post("/", r.Add).Name("rules:add")
+		router.Post("/test-match/", r.TestMatch).Name("rules:test-match")
+		router.Post("/validate/", r.Validate).Name("rules:validate")
 		router.Get("/", r.Rules).Name("rules:all")
 		router.Get("/{id}/", r.Rule).Name("rules:one")
 		router.Post("/{id}/", r.Update).Name("rules:update")
 		router.Delete("/{id}/", r.Delete).Name("rules:delete")
+		router.Post("/{id}/enable/", r.Enable).Name("rules:enable")
+		router.Post("/{id}/disable/", r.Disable).Name("rules:disable")
 	})
 
 	router.Group("/rules-meta/", func(router *web.Router) {
@@ -64,6 +70,51 @@ type RuleTriggerForm struct {
 	Action        string   `json:"action"`
 	Meta          string   `json:"meta"`
 	UserRefs      []string `json:"user_refs"`
+	// ScheduleID 值班表 ID，动作执行时通知当前的值班人员
+	ScheduleID string `json:"schedule_id"`
+
+	Escalations []RuleTriggerEscalationForm `json:"escalations"`
+}
+
+// RuleTriggerEscalationForm 动作的升级步骤
+type RuleTriggerEscalationForm struct {
+	Delay    int64    `json:"delay"`
+	Action   string   `json:"action"`
+	Meta     string   `json:"meta"`
+	UserRefs []string `json:"user_refs"`
+}
+
+// scheduleID 返回动作的值班表 ID，没有指定值班表时返回 primitive.NilObjectID
+func (t RuleTriggerForm) scheduleID() primitive.ObjectID {
+	scheduleID, err := primitive.ObjectIDFromHex(t.ScheduleID)
+	if err != nil {
+		return primitive.NilObjectID
+	}
+
+	return scheduleID
+}
+
+// toEscalationSteps 将升级步骤表单转换为升级步骤
+func (t RuleTriggerForm) toEscalationSteps() []repository.EscalationStep {
+	steps := make([]repository.EscalationStep, 0, len(t.Escalations))
+	for _, e := range t.Escalations {
+		users := make([]primitive.ObjectID, 0)
+		for _, u := range str.Distinct(e.UserRefs) {
+			uid, err := primitive.ObjectIDFromHex(u)
+			if err == nil {
+				users = append(users, uid)
+			}
+		}
+
+		steps = append(steps, repository.EscalationStep{
+			Delay:    e.Delay,
+			Action:   e.Action,
+			Meta:     e.Meta,
+			UserRefs: users,
+		})
+	}
+
+	return steps
 }
 
 // RuleForm is a form object using create or update rule
@@ -74,6 +125,7 @@ type RuleForm struct {
 
 	AggregateRule string `json:"aggregate_rule"`
 	RelationRule  string `json:"relation_rule"`
+	Priority      int64  `json:"priority"`
 
 	ReadyType  string                 `json:"ready_type"`
 	Interval   int64                  `json:"interval"`
@@ -84,12 +136,44 @@ type RuleForm struct {
 	IgnoreRule       string            `json:"ignore_rule"`
 	Template         string            `json:"template"`
 	SummaryTemplate  string            `json:"summary_template"`
+	RecoveryTemplate string            `json:"recovery_template"`
 	ReportTemplateID string            `json:"report_template_id"`
 	Triggers         []RuleTriggerForm `json:"triggers"`
 
+	RateLimit      repository.RuleRateLimit      `json:"rate_limit"`
+	ActiveSchedule repository.RuleActiveSchedule `json:"active_schedule"`
+
 	Status string `json:"status"`
 
 	actionManager action.Manager
+	scheduleRepo  repository.ScheduleRepo
+}
+
+// scheduleUsers 查询值班表，返回值班表中的轮值用户以及调班用户
+func (r RuleForm) scheduleUsers(scheduleID string) ([]string, error) {
+	id, err := primitive.ObjectIDFromHex(scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := r.scheduleRepo.Get(id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, errors.New("schedule not found")
+		}
+
+		return nil, err
+	}
+
+	users := make([]string, 0, len(schedule.Users)+len(schedule.Overrides))
+	for _, u := range schedule.Users {
+		users = append(users, u.Hex())
+	}
+	for _, o := range schedule.Overrides {
+		users = append(users, o.UserID.Hex())
+	}
+
+	return str.Distinct(users), nil
 }
 
 // Validate implement web.Validator interface
@@ -170,30 +254,39 @@ func (r RuleForm) Validate(req web.Request) error {
 		return errors.New("invalid readyType")
 	}
 
-	if r.Status != "" && !govalidator.IsIn(r.Status, string(repository.RuleStatusEnabled), string(repository.RuleStatusDisabled)) {
-		return errors.New("status is invalid, must be enabled/disabled")
+	if r.Status != "" && !govalidator.IsIn(r.Status, string(repository.RuleStatusEnabled), string(repository.RuleStatusDisabled), string(repository.RuleStatusInvalid)) {
+		return errors.New("status is invalid, must be enabled/disabled/invalid")
 	}
 
-	_, err := matcher.NewEventMatcher(repository.Rule{Rule: r.Rule, IgnoreRule: r.IgnoreRule})
-	if err != nil {
-		return fmt.Errorf("rule is invalid: %w", err)
+	if r.RateLimit.Capacity < 0 || r.RateLimit.RefillInterval < 0 {
+		return errors.New("rate_limit is invalid, capacity and refill_interval must not be negative")
+	}
+
+	if _, err := r.ActiveSchedule.Compile(); err != nil {
+		return fmt.Errorf("active_schedule is invalid: %v", err)
+	}
+
+	if exprErrs := r.validateExpressions(); len(exprErrs) > 0 {
+		return exprErrs[0]
 	}
 
 	for i, tr := range r.Triggers {
-		if tr.PreCondition != "" {
-			_, err := matcher.NewTriggerMatcher(repository.Trigger{
-				PreCondition: tr.PreCondition,
-			})
+		for j, u := range tr.UserRefs {
+			_, err := primitive.ObjectIDFromHex(u)
 			if err != nil {
-				return fmt.Errorf("trigger #%d is invalid: %w", i, err)
+				return fmt.Errorf("trigger #%d, user #%d with value %s: %w", i, j, u, err)
 			}
 		}
 
-		for j, u := range tr.UserRefs {
-			_, err := primitive.ObjectIDFromHex(u)
+		userRefs := tr.UserRefs
+		if tr.ScheduleID != "" {
+			scheduleUsers, err := r.scheduleUsers(tr.ScheduleID)
 			if err != nil {
-				return fmt.Errorf("trigger #%d, user #%d with value %s: %w", i, j, u, err)
+				return fmt.Errorf("trigger #%d, schedule %s: %w", i, tr.ScheduleID, err)
 			}
+
+			// 值班人员在动作执行时才能确定，使用值班表中的轮值用户校验动作
+			userRefs = append(userRefs, scheduleUsers...)
 		}
 
 		act := r.actionManager.Run(tr.Action)
@@ -201,20 +294,100 @@ func (r RuleForm) Validate(req web.Request) error {
 			return fmt.Errorf("trigger #%d, action [%s] is not support", i, tr.Action)
 		}
 
-		if err := act.Validate(tr.Meta, tr.UserRefs); err != nil {
+		if err := act.Validate(tr.Meta, userRefs); err != nil {
 			return fmt.Errorf("trigger #%d, action [%s] with invalid meta: %w", i, tr.Action, err)
 		}
+
+		var lastDelay int64
+		for j, e := range tr.Escalations {
+			if e.Delay <= lastDelay || e.Delay > int64(repository.EscalationMaxDelay/time.Second) {
+				return fmt.Errorf("trigger #%d, escalation #%d: delay must between 1s~24h and greater than the previous one", i, j)
+			}
+			lastDelay = e.Delay
+
+			escalationAct := r.actionManager.Run(e.Action)
+			if escalationAct == nil {
+				return fmt.Errorf("trigger #%d, escalation #%d: action [%s] is not support", i, j, e.Action)
+			}
+
+			if err := escalationAct.Validate(e.Meta, e.UserRefs); err != nil {
+				return fmt.Errorf("trigger #%d, escalation #%d: action [%s] with invalid meta: %w", i, j, e.Action, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ExpressionError 规则表达式编译错误，Line 和 Column 为错误在表达式中的位置（从 1 开始）
+type ExpressionError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+func (e ExpressionError) Error() string {
+	return fmt.Sprintf("%s is invalid: %s", e.Field, e.Message)
+}
+
+// newExpressionError 创建表达式编译错误，如果是 expr 的编译错误，则提取错误位置
+func newExpressionError(field string, err error) ExpressionError {
+	exprErr := ExpressionError{Field: field, Message: err.Error()}
+
+	var fileErr *file.Error
+	if errors.As(err, &fileErr) && !fileErr.Location.Empty() {
+		exprErr.Line = fileErr.Line
+		exprErr.Column = fileErr.Column + 1
+	}
+
+	return exprErr
+}
+
+// validateExpressions 编译规则中所有的表达式，返回所有编译失败的表达式
+func (r RuleForm) validateExpressions() []ExpressionError {
+	exprErrs := make([]ExpressionError, 0)
+
+	if _, err := matcher.NewEventMatcher(repository.Rule{Rule: r.Rule}); err != nil {
+		exprErrs = append(exprErrs, newExpressionError("rule", err))
+	}
+
+	if _, err := matcher.NewEventMatcher(repository.Rule{IgnoreRule: r.IgnoreRule}); err != nil {
+		exprErrs = append(exprErrs, newExpressionError("ignore_rule", err))
 	}
 
 	if _, err := matcher.NewEventFinger(r.AggregateRule); err != nil {
-		return fmt.Errorf("aggregate rule is invalid")
+		exprErrs = append(exprErrs, newExpressionError("aggregate_rule", err))
 	}
 
 	if _, err := matcher.NewEventFinger(r.RelationRule); err != nil {
-		return fmt.Errorf("relation rule is invalid")
+		exprErrs = append(exprErrs, newExpressionError("relation_rule", err))
 	}
 
-	return nil
+	for i, tr := range r.Triggers {
+		if _, err := matcher.NewTriggerMatcher(repository.Trigger{PreCondition: tr.PreCondition}); err != nil {
+			exprErrs = append(exprErrs, newExpressionError(fmt.Sprintf("triggers[%d].pre_condition", i), err))
+		}
+	}
+
+	return exprErrs
+}
+
+// RuleValidateResp 规则校验结果
+type RuleValidateResp struct {
+	Valid  bool              `json:"valid"`
+	Errors []ExpressionError `json:"errors"`
+}
+
+// Validate 校验规则中的表达式是否能够编译，用于在编辑器中即时提示错误，不会保存规则
+func (r RuleController) Validate(ctx web.Context) (*RuleValidateResp, error) {
+	var ruleForm RuleForm
+	if err := ctx.Unmarshal(&ruleForm); err != nil {
+		return nil, web.WrapJSONError(err, http.StatusUnprocessableEntity)
+	}
+
+	exprErrs := ruleForm.validateExpressions()
+	return &RuleValidateResp{Valid: len(exprErrs) == 0, Errors: exprErrs}, nil
 }
 
 // Check validate the rule
@@ -264,7 +437,7 @@ func (r RuleController) Check(ctx web.Context, conf *configs.Config, msgRepo rep
 		}
 	case repository.TemplateTypeTriggerRule:
 		_, err = matcher.NewTriggerMatcher(repository.Trigger{PreCondition: content})
-	case repository.TemplateTypeTemplate:
+	case repository.TemplateTypeTemplate, repository.TemplateTypeRecovery:
 		data, err1 := template.Parse(r.cc, content, createPayloadForTemplateCheck(r, conf, msgID, msgRepo, content))
 		if err1 != nil {
 			err = err1
@@ -369,13 +542,14 @@ func createPayloadForTemplateCheck(r RuleController, conf *configs.Config, msgID
 }
 
 // Add create a new rule
-func (r RuleController) Add(ctx web.Context, repo repository.RuleRepo, em event.Manager, manager action.Manager) (*repository.Rule, error) {
+func (r RuleController) Add(ctx web.Context, repo repository.RuleRepo, scheduleRepo repository.ScheduleRepo, em event.Manager, manager action.Manager) (*repository.Rule, error) {
 	var ruleForm RuleForm
 	if err := ctx.Unmarshal(&ruleForm); err != nil {
 		return nil, web.WrapJSONError(err, http.StatusUnprocessableEntity)
 	}
 
 	ruleForm.actionManager = manager
+	ruleForm.scheduleRepo = scheduleRepo
 	ctx.Validate(ruleForm, true)
 
 	triggers := make([]repository.Trigger, 0)
@@ -396,6 +570,8 @@ func (r RuleController) Add(ctx web.Context, repo repository.RuleRepo, em event.
 			Meta:          t.Meta,
 			IsElseTrigger: t.IsElseTrigger,
 			UserRefs:      users,
+			ScheduleID:    t.scheduleID(),
+			Escalations:   t.toEscalationSteps(),
 		})
 	}
 
@@ -413,13 +589,17 @@ func (r RuleController) Add(ctx web.Context, repo repository.RuleRepo, em event.
 		Interval:         ruleForm.Interval,
 		TimeRanges:       ruleForm.TimeRanges,
 		Rule:             ruleForm.Rule,
+		ActiveSchedule:   ruleForm.ActiveSchedule,
 		IgnoreRule:       ruleForm.IgnoreRule,
 		AggregateRule:    ruleForm.AggregateRule,
 		RelationRule:     ruleForm.RelationRule,
+		Priority:         ruleForm.Priority,
 		Template:         ruleForm.Template,
 		SummaryTemplate:  ruleForm.SummaryTemplate,
+		RecoveryTemplate: ruleForm.RecoveryTemplate,
 		ReportTemplateID: reportTempID,
 		Triggers:         triggers,
+		RateLimit:        ruleForm.RateLimit,
 		Status:           repository.RuleStatus(ruleForm.Status),
 	}
 
@@ -443,7 +623,7 @@ func (r RuleController) Add(ctx web.Context, repo repository.RuleRepo, em event.
 }
 
 // Update replace one rule for specified id
-func (r RuleController) Update(ctx web.Context, ruleRepo repository.RuleRepo, em event.Manager, manager action.Manager) (*repository.Rule, error) {
+func (r RuleController) Update(ctx web.Context, ruleRepo repository.RuleRepo, scheduleRepo repository.ScheduleRepo, em event.Manager, manager action.Manager) (*repository.Rule, error) {
 	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
 	if err != nil {
 		return nil, web.WrapJSONError(err, http.StatusUnprocessableEntity)
@@ -455,6 +635,7 @@ func (r RuleController) Update(ctx web.Context, ruleRepo repository.RuleRepo, em
 	}
 
 	ruleForm.actionManager = manager
+	ruleForm.scheduleRepo = scheduleRepo
 	ctx.Validate(ruleForm, true)
 
 	original, err := ruleRepo.Get(id)
@@ -481,6 +662,8 @@ func (r RuleController) Update(ctx web.Context, ruleRepo repository.RuleRepo, em
 			Meta:          t.Meta,
 			IsElseTrigger: t.IsElseTrigger,
 			UserRefs:      users,
+			ScheduleID:    t.scheduleID(),
+			Escalations:   t.toEscalationSteps(),
 		})
 	}
 
@@ -499,14 +682,19 @@ func (r RuleController) Update(ctx web.Context, ruleRepo repository.RuleRepo, em
 		Interval:         ruleForm.Interval,
 		TimeRanges:       ruleForm.TimeRanges,
 		Rule:             ruleForm.Rule,
+		ActiveSchedule:   ruleForm.ActiveSchedule,
 		IgnoreRule:       ruleForm.IgnoreRule,
 		AggregateRule:    ruleForm.AggregateRule,
 		RelationRule:     ruleForm.RelationRule,
+		Priority:         ruleForm.Priority,
 		Template:         ruleForm.Template,
 		SummaryTemplate:  ruleForm.SummaryTemplate,
+		RecoveryTemplate: ruleForm.RecoveryTemplate,
 		ReportTemplateID: reportTempID,
 		Triggers:         triggers,
+		RateLimit:        ruleForm.RateLimit,
 		Status:           repository.RuleStatus(ruleForm.Status),
+		LastStatusChange: original.LastStatusChange,
 		CreatedAt:        original.CreatedAt,
 		UpdatedAt:        original.CreatedAt,
 	}
@@ -599,7 +787,7 @@ func (r RuleController) Rules(ctx web.Context, ruleRepo repository.RuleRepo, use
 		}
 	}
 
-	users, _ := userRepo.Find(bson.M{"_id": bson.M{"$in": userIDs}})
+	users, _ := userRepo.Find(repository.WithDeletedUsers(bson.M{"_id": bson.M{"$in": userIDs}}))
 	userRefs := make(map[string]string)
 	for _, u := range users {
 		userRefs[u.ID.Hex()] = u.Name
@@ -653,6 +841,70 @@ func (r RuleController) Delete(ctx web.Context, em event.Manager, repo repositor
 	return repo.DeleteID(id)
 }
 
+// Enable 启用规则
+// Arguments:
+//   - operator: 操作人，为空时使用 X-Operator 请求头或者客户端 IP
+//   - reason: 启用原因
+func (r RuleController) Enable(ctx web.Context, em event.Manager, repo repository.RuleRepo) (*repository.Rule, error) {
+	return r.setStatus(ctx, em, repo, repository.RuleStatusEnabled)
+}
+
+// Disable 禁用规则，禁用后规则不再参与事件聚合
+// Arguments:
+//   - operator: 操作人，为空时使用 X-Operator 请求头或者客户端 IP
+//   - reason: 禁用原因
+func (r RuleController) Disable(ctx web.Context, em event.Manager, repo repository.RuleRepo) (*repository.Rule, error) {
+	return r.setStatus(ctx, em, repo, repository.RuleStatusDisabled)
+}
+
+func (r RuleController) setStatus(ctx web.Context, em event.Manager, repo repository.RuleRepo, status repository.RuleStatus) (*repository.Rule, error) {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusUnprocessableEntity)
+	}
+
+	if err := repo.SetStatus(id, status, operatorName(ctx), ctx.Input("reason")); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, web.WrapJSONError(err, http.StatusNotFound)
+		}
+
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	rule, err := repo.Get(id)
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	em.Publish(pubsub.RuleChangedEvent{
+		Rule:      rule,
+		Type:      pubsub.EventTypeUpdate,
+		CreatedAt: time.Now(),
+	})
+
+	return &rule, nil
+}
+
+// TestMatch 使用请求体中的事件样本测试能够匹配哪些规则，返回匹配的规则以及对应的聚合 Key
+// 该接口不会写入事件，用于在启用规则之前验证规则是否正确
+func (r RuleController) TestMatch(ctx web.Context, ruleRepo repository.RuleRepo) web.Response {
+	var evt repository.Event
+	if err := ctx.Unmarshal(&evt); err != nil {
+		return ctx.JSONError(fmt.Sprintf("invalid event: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	if evt.CreatedAt.IsZero() {
+		evt.CreatedAt = time.Now()
+	}
+
+	matchedRules, err := job.BuildEventMatchTest(ruleRepo)(evt)
+	if err != nil {
+		return ctx.JSONError(fmt.Sprintf("test match failed: %v", err), http.StatusInternalServerError)
+	}
+
+	return ctx.JSON(matchedRules)
+}
+
 func (r RuleController) getEventByID(messageID string, msgRepo repository.EventRepo) (repository.Event, error) {
 	msgID, err := primitive.ObjectIDFromHex(messageID)
 	if err != nil {
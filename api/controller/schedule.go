@@ -0,0 +1,305 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/container"
+	"github.com/mylxsw/glacier/web"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ScheduleController struct {
+	cc container.Container
+}
+
+func NewScheduleController(cc container.Container) web.Controller {
+	return &ScheduleController{cc: cc}
+}
+
+func (c ScheduleController) Register(router *web.Router) {
+	router.Group("/schedules/", func(router *web.Router) {
+		router.Get("/", c.Schedules).Name("schedules:all")
+		router.Post("/", c.Add).Name("schedules:add")
+		router.Get("/{id}/", c.Schedule).Name("schedules:one")
+		router.Post("/{id}/", c.Update).Name("schedules:update")
+		router.Delete("/{id}/", c.Delete).Name("schedules:delete")
+		router.Get("/{id}/oncall/", c.OnCall).Name("schedules:oncall")
+		router.Post("/{id}/overrides/", c.AddOverride).Name("schedules:overrides:add")
+	})
+}
+
+type ScheduleForm struct {
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description"`
+	Users         []string               `json:"users"`
+	StartAt       time.Time              `json:"start_at"`
+	ShiftDuration int64                  `json:"shift_duration"`
+	Overrides     []ScheduleOverrideForm `json:"overrides"`
+}
+
+func (form ScheduleForm) Validate(req web.Request) error {
+	if form.Name == "" {
+		return errors.New("invalid argument: name is required")
+	}
+
+	if len(form.Users) == 0 {
+		return errors.New("invalid argument: users is required")
+	}
+
+	for i, u := range form.Users {
+		if _, err := primitive.ObjectIDFromHex(u); err != nil {
+			return fmt.Errorf("invalid argument: user #%d with value %s: %w", i, u, err)
+		}
+	}
+
+	if form.StartAt.IsZero() {
+		return errors.New("invalid argument: start_at is required")
+	}
+
+	if form.ShiftDuration < 3600 {
+		return errors.New("invalid argument: shift_duration must not less than 1h")
+	}
+
+	for i, o := range form.Overrides {
+		if err := o.Validate(req); err != nil {
+			return fmt.Errorf("override #%d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (form ScheduleForm) toSchedule() repository.Schedule {
+	users := make([]primitive.ObjectID, 0, len(form.Users))
+	for _, u := range form.Users {
+		uid, _ := primitive.ObjectIDFromHex(u)
+		users = append(users, uid)
+	}
+
+	overrides := make([]repository.ScheduleOverride, 0, len(form.Overrides))
+	for _, o := range form.Overrides {
+		overrides = append(overrides, o.toScheduleOverride())
+	}
+
+	return repository.Schedule{
+		Name:          form.Name,
+		Description:   form.Description,
+		Users:         users,
+		StartAt:       form.StartAt,
+		ShiftDuration: form.ShiftDuration,
+		Overrides:     overrides,
+	}
+}
+
+// ScheduleOverrideForm 临时调班表单
+type ScheduleOverrideForm struct {
+	UserID  string    `json:"user_id"`
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
+	Comment string    `json:"comment"`
+}
+
+func (form ScheduleOverrideForm) Validate(req web.Request) error {
+	if _, err := primitive.ObjectIDFromHex(form.UserID); err != nil {
+		return fmt.Errorf("invalid argument: user_id is invalid: %w", err)
+	}
+
+	if form.StartAt.IsZero() || !form.EndAt.After(form.StartAt) {
+		return errors.New("invalid argument: end_at must after start_at")
+	}
+
+	return nil
+}
+
+func (form ScheduleOverrideForm) toScheduleOverride() repository.ScheduleOverride {
+	userID, _ := primitive.ObjectIDFromHex(form.UserID)
+	return repository.ScheduleOverride{
+		UserID:  userID,
+		StartAt: form.StartAt,
+		EndAt:   form.EndAt,
+		Comment: form.Comment,
+	}
+}
+
+func (c ScheduleController) Add(ctx web.Context, repo repository.ScheduleRepo) (*repository.Schedule, error) {
+	var form ScheduleForm
+	if err := ctx.Unmarshal(&form); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	ctx.Validate(form, true)
+
+	id, err := repo.Add(form.toSchedule())
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	schedule, err := repo.Get(id)
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &schedule, nil
+}
+
+func (c ScheduleController) Update(ctx web.Context, repo repository.ScheduleRepo) (*repository.Schedule, error) {
+	original, err := c.get(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var form ScheduleForm
+	if err := ctx.Unmarshal(&form); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	ctx.Validate(form, true)
+
+	schedule := form.toSchedule()
+	schedule.ID = original.ID
+	schedule.CreatedAt = original.CreatedAt
+
+	if err := repo.Update(schedule.ID, schedule); err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &schedule, nil
+}
+
+// AddOverride 为值班表添加临时调班
+func (c ScheduleController) AddOverride(ctx web.Context, repo repository.ScheduleRepo) (*repository.Schedule, error) {
+	schedule, err := c.get(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var form ScheduleOverrideForm
+	if err := ctx.Unmarshal(&form); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	ctx.Validate(form, true)
+
+	// 已经结束的调班不再保留
+	now := time.Now()
+	overrides := make([]repository.ScheduleOverride, 0, len(schedule.Overrides)+1)
+	for _, o := range schedule.Overrides {
+		if o.EndAt.After(now) {
+			overrides = append(overrides, o)
+		}
+	}
+
+	schedule.Overrides = append(overrides, form.toScheduleOverride())
+	if err := repo.Update(schedule.ID, schedule); err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &schedule, nil
+}
+
+// OnCall 查询值班表当前（或者 at 参数指定时间）的值班人员
+func (c ScheduleController) OnCall(ctx web.Context, repo repository.ScheduleRepo, userRepo repository.UserRepo) web.Response {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return ctx.JSONError(fmt.Sprintf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	at := time.Now()
+	if atStr := ctx.Input("at"); atStr != "" {
+		at, err = time.Parse(time.RFC3339, atStr)
+		if err != nil {
+			return ctx.JSONError(fmt.Sprintf("invalid argument: at must be RFC3339 format: %v", err), http.StatusUnprocessableEntity)
+		}
+	}
+
+	userID, err := repo.CurrentOnCall(id, at)
+	if err != nil {
+		switch err {
+		case repository.ErrNotFound:
+			return ctx.JSONError("no such schedule", http.StatusNotFound)
+		case repository.ErrNoOnCall:
+			return ctx.JSON(web.M{"at": at, "user": nil})
+		default:
+			return ctx.JSONError(err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	user, err := userRepo.Get(userID)
+	if err != nil && err != repository.ErrNotFound {
+		return ctx.JSONError(err.Error(), http.StatusInternalServerError)
+	}
+
+	return ctx.JSON(web.M{
+		"at": at,
+		"user": web.M{
+			"id":    userID,
+			"name":  user.Name,
+			"email": user.Email,
+			"phone": user.Phone,
+		},
+	})
+}
+
+func (c ScheduleController) Delete(ctx web.Context, repo repository.ScheduleRepo) error {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	return repo.DeleteID(id)
+}
+
+func (c ScheduleController) Schedule(ctx web.Context, repo repository.ScheduleRepo) (*repository.Schedule, error) {
+	schedule, err := c.get(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+func (c ScheduleController) get(ctx web.Context, repo repository.ScheduleRepo) (repository.Schedule, error) {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return repository.Schedule{}, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	schedule, err := repo.Get(id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return schedule, web.WrapJSONError(errors.New("no such schedule"), http.StatusNotFound)
+		}
+
+		return schedule, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return schedule, nil
+}
+
+func (c ScheduleController) Schedules(ctx web.Context, repo repository.ScheduleRepo) web.Response {
+	offset, limit := offsetAndLimit(ctx)
+
+	filter := bson.M{}
+	name := ctx.Input("name")
+	if name != "" {
+		filter["name"] = bson.M{"$regex": name}
+	}
+
+	schedules, next, err := repo.Paginate(filter, offset, limit)
+	if err != nil {
+		return ctx.JSONError(fmt.Sprintf("query failed: %v", err), http.StatusInternalServerError)
+	}
+
+	return ctx.JSON(web.M{
+		"schedules": schedules,
+		"next":      next,
+		"search": web.M{
+			"name": name,
+		},
+	})
+}
@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/matcher"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/container"
+	"github.com/mylxsw/glacier/web"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SilenceController struct {
+	cc container.Container
+}
+
+func NewSilenceController(cc container.Container) web.Controller {
+	return &SilenceController{cc: cc}
+}
+
+func (c SilenceController) Register(router *web.Router) {
+	router.Group("/silences/", func(router *web.Router) {
+		router.Get("/", c.Silences).Name("silences:all")
+		router.Post("/", c.Add).Name("silences:add")
+		router.Get("/{id}/", c.Silence).Name("silences:one")
+		router.Post("/{id}/", c.Update).Name("silences:update")
+		router.Post("/{id}/expire/", c.Expire).Name("silences:expire")
+		router.Delete("/{id}/", c.Delete).Name("silences:delete")
+	})
+}
+
+type SilenceForm struct {
+	Matcher  string    `json:"matcher"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Comment  string    `json:"comment"`
+}
+
+func (form *SilenceForm) Validate(req web.Request) error {
+	if form.Matcher == "" {
+		return errors.New("invalid argument: matcher is required")
+	}
+
+	if _, err := matcher.NewTriggerMatcher(repository.Trigger{PreCondition: form.Matcher}); err != nil {
+		return fmt.Errorf("invalid argument: matcher is invalid: %w", err)
+	}
+
+	if form.StartsAt.IsZero() {
+		form.StartsAt = time.Now()
+	}
+
+	if form.EndsAt.IsZero() || !form.EndsAt.After(form.StartsAt) {
+		return errors.New("invalid argument: ends_at must after starts_at")
+	}
+
+	return nil
+}
+
+func (c SilenceController) Add(ctx web.Context, repo repository.SilenceRepo) (*repository.Silence, error) {
+	var form SilenceForm
+	if err := ctx.Unmarshal(&form); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	ctx.Validate(&form, true)
+
+	id, err := repo.Add(repository.Silence{
+		Matcher:  form.Matcher,
+		StartsAt: form.StartsAt,
+		EndsAt:   form.EndsAt,
+		Creator:  operatorName(ctx),
+		Comment:  form.Comment,
+	})
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	silence, err := repo.Get(id)
+	if err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &silence, nil
+}
+
+func (c SilenceController) Update(ctx web.Context, repo repository.SilenceRepo) (*repository.Silence, error) {
+	silence, err := c.get(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var form SilenceForm
+	if err := ctx.Unmarshal(&form); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	ctx.Validate(&form, true)
+
+	silence.Matcher = form.Matcher
+	silence.StartsAt = form.StartsAt
+	silence.EndsAt = form.EndsAt
+	silence.Comment = form.Comment
+
+	if err := repo.Update(silence.ID, silence); err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &silence, nil
+}
+
+// Expire 立即结束静默
+func (c SilenceController) Expire(ctx web.Context, repo repository.SilenceRepo) (*repository.Silence, error) {
+	silence, err := c.get(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !silence.EndsAt.After(now) {
+		return &silence, nil
+	}
+
+	silence.EndsAt = now
+	if silence.StartsAt.After(now) {
+		silence.StartsAt = now
+	}
+
+	if err := repo.Update(silence.ID, silence); err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return &silence, nil
+}
+
+func (c SilenceController) Delete(ctx web.Context, repo repository.SilenceRepo) error {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	return repo.DeleteID(id)
+}
+
+func (c SilenceController) Silence(ctx web.Context, repo repository.SilenceRepo) (*repository.Silence, error) {
+	silence, err := c.get(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &silence, nil
+}
+
+func (c SilenceController) get(ctx web.Context, repo repository.SilenceRepo) (repository.Silence, error) {
+	id, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return repository.Silence{}, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	silence, err := repo.Get(id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return silence, web.WrapJSONError(errors.New("no such silence"), http.StatusNotFound)
+		}
+
+		return silence, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return silence, nil
+}
+
+// Silences 查询静默规则，active=true 时只返回生效中的静默规则
+func (c SilenceController) Silences(ctx web.Context, repo repository.SilenceRepo) web.Response {
+	offset, limit := offsetAndLimit(ctx)
+
+	filter := bson.M{}
+	if ctx.InputWithDefault("active", "false") == "true" {
+		filter = repository.ActiveSilenceFilter(time.Now())
+	}
+
+	silences, next, err := repo.Paginate(filter, offset, limit)
+	if err != nil {
+		return ctx.JSONError(fmt.Sprintf("query failed: %v", err), http.StatusInternalServerError)
+	}
+
+	return ctx.JSON(web.M{
+		"silences": silences,
+		"next":     next,
+	})
+}
@@ -4,9 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/asaskevich/govalidator"
+	"github.com/mylxsw/adanos-alert/api/view"
+	"github.com/mylxsw/adanos-alert/configs"
+	"github.com/mylxsw/adanos-alert/internal/action"
 	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/internal/template"
 	"github.com/mylxsw/container"
 	"github.com/mylxsw/glacier/web"
 	"go.mongodb.org/mongo-driver/bson"
@@ -25,6 +30,7 @@ func (t *TemplateController) Register(router *web.Router) {
 	router.Group("/templates/", func(router *web.Router) {
 		router.Get("/", t.Templates).Name("template:all")
 		router.Post("/", t.Add).Name("template:add")
+		router.Post("/preview/", t.Preview).Name("template:preview")
 		router.Get("/{id}/", t.Get).Name("template:one")
 		router.Post("/{id}/", t.Update).Name("template:update")
 		router.Delete("/{id}/", t.Delete).Name("template:delete")
@@ -171,3 +177,91 @@ func (t *TemplateController) Delete(ctx web.Context, repo repository.TemplateRep
 
 	return nil
 }
+
+// TemplatePreviewForm 模板预览请求，Group 和 Messages 为模板渲染时使用的样本数据
+type TemplatePreviewForm struct {
+	Content  string                `json:"content"`
+	Type     string                `json:"type"`
+	Rule     repository.Rule       `json:"rule"`
+	Group    repository.EventGroup `json:"group"`
+	Messages []repository.Event    `json:"messages"`
+}
+
+// TemplatePreviewResp 模板预览结果，模板解析失败时 Error 为错误信息
+type TemplatePreviewResp struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Preview 使用样本数据渲染模板，与发送报警时使用相同的模板引擎，模板不会被保存
+func (t *TemplateController) Preview(ctx web.Context, conf *configs.Config) (*TemplatePreviewResp, error) {
+	var form TemplatePreviewForm
+	if err := ctx.Unmarshal(&form); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	if form.Type == "" {
+		form.Type = string(repository.TemplateTypeTemplate)
+	}
+
+	grp := form.Group
+	if grp.ID.IsZero() {
+		grp.ID = primitive.NewObjectID()
+	}
+	if grp.CreatedAt.IsZero() {
+		grp.CreatedAt = time.Now()
+		grp.UpdatedAt = grp.CreatedAt
+	}
+	if grp.MessageCount == 0 {
+		grp.MessageCount = int64(len(form.Messages))
+	}
+
+	var output string
+	var err error
+	switch repository.TemplateType(form.Type) {
+	case repository.TemplateTypeTemplate, repository.TemplateTypeDingdingTemplate, repository.TemplateTypeRecovery:
+		if repository.TemplateType(form.Type) == repository.TemplateTypeRecovery && grp.Type == "" {
+			grp.Type = repository.EventTypeRecovery
+		}
+
+		rule := form.Rule
+		if rule.ID.IsZero() {
+			rule.ID = grp.Rule.ID
+			rule.Name = grp.Rule.Name
+		}
+
+		trigger := repository.Trigger{ID: primitive.NewObjectID(), Name: "preview", Action: "preview"}
+		payload := action.CreatePayload(conf, previewEventQuerier(form.Messages), trigger.Action, rule, trigger, grp)
+		if ruleTemplate := action.RuleTemplate(rule, grp); ruleTemplate != "" {
+			payload.RuleTemplateParsed, _ = template.Parse(t.cc, ruleTemplate, payload)
+		}
+
+		output, err = template.Parse(t.cc, form.Content, payload)
+	case repository.TemplateTypeReport:
+		output, err = view.ReportView(t.cc, form.Content, view.GroupData{
+			Group:       grp,
+			Events:      form.Messages,
+			EventsCount: int64(len(form.Messages)),
+			Limit:       int64(len(form.Messages)),
+		})
+	default:
+		return nil, web.WrapJSONError(fmt.Errorf("invalid argument: template type %s can not be previewed", form.Type), http.StatusUnprocessableEntity)
+	}
+
+	if err != nil {
+		return &TemplatePreviewResp{Error: err.Error()}, nil
+	}
+
+	return &TemplatePreviewResp{Output: output}, nil
+}
+
+// previewEventQuerier 返回从样本事件中查询事件的 EventQuerier
+func previewEventQuerier(events []repository.Event) action.EventQuerier {
+	return func(groupID primitive.ObjectID, limit int64) []repository.Event {
+		if limit >= 0 && int64(len(events)) > limit {
+			return events[:limit]
+		}
+
+		return events
+	}
+}
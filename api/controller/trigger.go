@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mylxsw/adanos-alert/internal/matcher"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/asteria/log"
+	"github.com/mylxsw/container"
+	"github.com/mylxsw/glacier/web"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TriggerController struct {
+	cc container.Container
+}
+
+func NewTriggerController(cc container.Container) web.Controller {
+	return &TriggerController{cc: cc}
+}
+
+func (c TriggerController) Register(router *web.Router) {
+	router.Group("/triggers/", func(router *web.Router) {
+		router.Post("/test/", c.Test).Name("triggers:test")
+	})
+}
+
+// TriggerTestForm 触发条件测试请求
+type TriggerTestForm struct {
+	// PreCondition 需要测试的触发条件表达式
+	PreCondition string `json:"pre_condition"`
+	// GroupID 用于测试的事件组
+	GroupID string `json:"group_id"`
+	// TriggerID 可选，事件组规则中已有的动作 ID，TriggeredTimesInPeriod 等函数使用该动作的历史执行记录
+	TriggerID string `json:"trigger_id"`
+}
+
+// TriggerTestResp 触发条件测试结果
+type TriggerTestResp struct {
+	Matched bool   `json:"matched"`
+	Error   string `json:"error"`
+}
+
+// Test 使用真实的事件组测试触发条件是否匹配，表达式编译或者执行失败时在 error 中返回错误信息
+func (c TriggerController) Test(ctx web.Context, groupRepo repository.EventGroupRepo, eventRepo repository.EventRepo) (*TriggerTestResp, error) {
+	var form TriggerTestForm
+	if err := ctx.Unmarshal(&form); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	groupID, err := primitive.ObjectIDFromHex(form.GroupID)
+	if err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid argument: group_id is invalid: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	grp, err := groupRepo.Get(groupID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, web.WrapJSONError(errors.New("no such group"), http.StatusNotFound)
+		}
+
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	trigger := repository.Trigger{PreCondition: form.PreCondition}
+	if form.TriggerID != "" {
+		triggerID, err := primitive.ObjectIDFromHex(form.TriggerID)
+		if err != nil {
+			return nil, web.WrapJSONError(fmt.Errorf("invalid argument: trigger_id is invalid: %v", err), http.StatusUnprocessableEntity)
+		}
+
+		trigger.ID = triggerID
+	}
+
+	tm, err := matcher.NewTriggerMatcher(trigger)
+	if err != nil {
+		return &TriggerTestResp{Error: err.Error()}, nil
+	}
+
+	eventsCallback := func() []repository.Event {
+		events, err := eventRepo.Find(bson.M{"group_ids": grp.ID})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"grp_id": grp.ID.Hex(),
+			}).Errorf("trigger test: fetch events from group failed: %v", err)
+		}
+
+		return events
+	}
+
+	matched, err := tm.Match(matcher.NewTriggerContext(c.cc, trigger, grp, eventsCallback))
+	if err != nil {
+		return &TriggerTestResp{Error: err.Error()}, nil
+	}
+
+	return &TriggerTestResp{Matched: matched}, nil
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/asaskevich/govalidator"
+	"github.com/mylxsw/adanos-alert/internal/action"
 	"github.com/mylxsw/adanos-alert/internal/repository"
 	"github.com/mylxsw/adanos-alert/pubsub"
 	"github.com/mylxsw/container"
@@ -32,10 +33,14 @@ func (u UserController) Register(router *web.Router) {
 		router.Post("/{id}/", u.Update).Name("users:update")
 		router.Get("/{id}/", u.User).Name("users:one")
 		router.Delete("/{id}/", u.Delete).Name("users:delete")
+		router.Delete("/{id}/purge/", u.Purge).Name("users:purge")
+		router.Get("/{id}/notify-channels/", u.NotifyChannels).Name("users:notify-channels")
+		router.Post("/{id}/notify-channels/", u.UpdateNotifyChannels).Name("users:notify-channels:update")
 	})
 
 	router.Group("/users-helper/", func(router *web.Router) {
 		router.Get("/names/", u.UserNames).Name("users-helper:names")
+		router.Get("/metas/", u.UserMetas).Name("users-helper:metas")
 	})
 }
 
@@ -115,6 +120,28 @@ func (userForm *UserForm) verifyUserExists(req web.Request) error {
 	return nil
 }
 
+// UserMetas 查询用户元信息，用于输入自动补全
+// 参数 key/value 为用户的过滤条件，field 为要返回的字段，fuzzy=true 时 value 模糊匹配
+func (u UserController) UserMetas(ctx web.Context, userRepo repository.UserRepo) ([]string, error) {
+	field := ctx.Input("field")
+	if field == "" {
+		return nil, web.WrapJSONError(errors.New("invalid argument: field is required"), http.StatusUnprocessableEntity)
+	}
+
+	limit := ctx.Int64Input("limit", 20)
+	if limit <= 0 || limit > 1000 {
+		limit = 20
+	}
+
+	return userRepo.QueryUserMetas(repository.UserMetaQuery{
+		QueryK: ctx.Input("key"),
+		QueryV: ctx.Input("value"),
+		Field:  field,
+		Fuzzy:  ctx.InputWithDefault("fuzzy", "false") == "true",
+		Limit:  limit,
+	})
+}
+
 type UserNameResp struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
@@ -206,7 +233,7 @@ func (u UserController) Update(ctx web.Context, userRepo repository.UserRepo, em
 	user.Metas = userForm.GetMetas()
 	user.Status = repository.UserStatus(userForm.Status)
 
-	if user.Password != "" {
+	if userForm.Password != "" {
 		user.Password = userForm.Password
 	}
 
@@ -243,6 +270,29 @@ func (u UserController) Delete(ctx web.Context, userRepo repository.UserRepo, em
 	return userRepo.DeleteID(userID)
 }
 
+// Purge 彻底删除用户，只有已经删除（软删除）的用户才能被彻底删除
+func (u UserController) Purge(ctx web.Context, userRepo repository.UserRepo) error {
+	userID, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	user, err := userRepo.Get(userID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return web.WrapJSONError(errors.New("no such user"), http.StatusNotFound)
+		}
+
+		return web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	if user.Status != repository.UserStatusDeleted {
+		return web.WrapJSONError(errors.New("only deleted user can be purged"), http.StatusUnprocessableEntity)
+	}
+
+	return userRepo.PurgeID(userID)
+}
+
 func (u UserController) User(ctx web.Context, userRepo repository.UserRepo) (*repository.User, error) {
 	userID, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
 	if err != nil {
@@ -293,7 +343,19 @@ func (u UserController) Users(ctx web.Context, userRepo repository.UserRepo) web
 		filter["metas.value"] = meta
 	}
 
-	users, next, err := userRepo.Paginate(filter, offset, limit)
+	afterID, useCursor, err := cursorPagination(ctx)
+	if err != nil {
+		return ctx.JSONError(err.Error(), http.StatusUnprocessableEntity)
+	}
+
+	var users []repository.User
+	var next int64
+	var nextCursor primitive.ObjectID
+	if useCursor {
+		users, nextCursor, err = userRepo.PaginateAfter(filter, afterID, limit)
+	} else {
+		users, next, err = userRepo.Paginate(filter, offset, limit)
+	}
 	if err != nil {
 		return ctx.JSONError(fmt.Sprintf("query failed: %v", err), http.StatusInternalServerError)
 	}
@@ -303,8 +365,9 @@ func (u UserController) Users(ctx web.Context, userRepo repository.UserRepo) web
 	}
 
 	return ctx.JSON(web.M{
-		"users": users,
-		"next":  next,
+		"users":       users,
+		"next":        next,
+		"next_cursor": cursorToken(nextCursor),
 		"search": web.M{
 			"name":  name,
 			"phone": phone,
@@ -312,3 +375,83 @@ func (u UserController) Users(ctx web.Context, userRepo repository.UserRepo) web
 		},
 	})
 }
+
+// NotifyChannels 查询用户的通知渠道偏好
+func (u UserController) NotifyChannels(ctx web.Context, userRepo repository.UserRepo) (repository.NotifyChannels, error) {
+	user, err := u.get(ctx, userRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.NotifyChannels == nil {
+		return repository.NotifyChannels{}, nil
+	}
+
+	return user.NotifyChannels, nil
+}
+
+// NotifyChannelsForm 用户通知渠道偏好表单，渠道按照优先级排序
+type NotifyChannelsForm struct {
+	manager  action.Manager
+	Channels []repository.NotifyChannel `json:"channels"`
+}
+
+func (form *NotifyChannelsForm) Validate(req web.Request) error {
+	for i, ch := range form.Channels {
+		if ch.Type == action.UserChannelActionName || form.manager.Run(ch.Type) == nil {
+			return fmt.Errorf("invalid argument: channel #%d type %s is not supported", i, ch.Type)
+		}
+
+		if ch.Type == "dingding" {
+			if _, err := primitive.ObjectIDFromHex(ch.Address); err != nil {
+				return fmt.Errorf("invalid argument: channel #%d address must be a dingding robot id", i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// UpdateNotifyChannels 更新用户的通知渠道偏好
+func (u UserController) UpdateNotifyChannels(ctx web.Context, userRepo repository.UserRepo, manager action.Manager) (repository.NotifyChannels, error) {
+	user, err := u.get(ctx, userRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	form := NotifyChannelsForm{manager: manager}
+	if err := ctx.Unmarshal(&form); err != nil {
+		return nil, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	ctx.Validate(&form, true)
+
+	user.NotifyChannels = form.Channels
+	if user.NotifyChannels == nil {
+		user.NotifyChannels = repository.NotifyChannels{}
+	}
+
+	if err := userRepo.Update(user.ID, user); err != nil {
+		return nil, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return user.NotifyChannels, nil
+}
+
+func (u UserController) get(ctx web.Context, userRepo repository.UserRepo) (repository.User, error) {
+	userID, err := primitive.ObjectIDFromHex(ctx.PathVar("id"))
+	if err != nil {
+		return repository.User{}, web.WrapJSONError(fmt.Errorf("invalid request: %v", err), http.StatusUnprocessableEntity)
+	}
+
+	user, err := userRepo.Get(userID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return user, web.WrapJSONError(errors.New("no such user"), http.StatusNotFound)
+		}
+
+		return user, web.WrapJSONError(err, http.StatusInternalServerError)
+	}
+
+	return user, nil
+}
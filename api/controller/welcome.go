@@ -1,8 +1,13 @@
 package controller
 
 import (
+	"fmt"
+	"net"
+	"strings"
+
 	"github.com/mylxsw/container"
 	"github.com/mylxsw/glacier/web"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type WelcomeController struct {
@@ -42,3 +47,46 @@ func offsetAndLimit(ctx web.Context) (offset int64, limit int64) {
 
 	return
 }
+
+// cursorPagination 判断是否使用游标分页，cursor 参数不为空或者 paginate=cursor 时使用游标分页
+// 游标分页不使用 skip，深分页时性能更好，afterID 为空时查询第一页
+func cursorPagination(ctx web.Context) (afterID primitive.ObjectID, useCursor bool, err error) {
+	cursor := ctx.Input("cursor")
+	if cursor == "" {
+		return primitive.NilObjectID, ctx.Input("paginate") == "cursor", nil
+	}
+
+	afterID, err = primitive.ObjectIDFromHex(cursor)
+	if err != nil {
+		return primitive.NilObjectID, true, fmt.Errorf("invalid argument: cursor is invalid: %v", err)
+	}
+
+	return afterID, true, nil
+}
+
+// cursorToken 返回下一页的游标，没有更多数据时为空
+func cursorToken(next primitive.ObjectID) string {
+	if next.IsZero() {
+		return ""
+	}
+
+	return next.Hex()
+}
+
+// operatorName 返回当前请求的操作人，优先使用 operator 参数或者 X-Operator 请求头，都为空时使用客户端 IP
+func operatorName(ctx web.Context) string {
+	if operator := strings.TrimSpace(ctx.Input("operator")); operator != "" {
+		return operator
+	}
+
+	if operator := strings.TrimSpace(ctx.Header("X-Operator")); operator != "" {
+		return operator
+	}
+
+	remoteAddr := ctx.Request().Raw().RemoteAddr
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+
+	return remoteAddr
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/asteria/log"
+)
+
+// gzipRequestDecoder 请求体使用 gzip 压缩时（Content-Encoding: gzip），自动解压请求体
+func gzipRequestDecoder(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, "invalid gzip request body", http.StatusBadRequest)
+			return
+		}
+		defer reader.Close()
+
+		r.Body = reader
+		r.Header.Del("Content-Encoding")
+		r.Header.Del("Content-Length")
+		r.ContentLength = -1
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// IdempotencyKeyHeader 推送事件接口的幂等请求头
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength Idempotency-Key 的最大长度
+const maxIdempotencyKeyLength = 255
+
+// ingestIdempotency 推送事件接口支持 Idempotency-Key 请求头，有效期内相同 Key 的请求直接返回第一次请求的响应，不会重复创建事件
+// 第一次请求仍在处理中时返回 409，请求处理失败（非 2xx）时不保存响应，客户端可以使用相同的 Key 重试
+func ingestIdempotency(repo repository.IdempotencyRepo, ttl time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := strings.TrimSpace(r.Header.Get(IdempotencyKeyHeader))
+			route := mux.CurrentRoute(r)
+			if key == "" || ttl <= 0 || route == nil || !strings.HasPrefix(route.GetName(), "events:add:") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "idempotency key too long", http.StatusBadRequest)
+				return
+			}
+
+			// Key 的作用域包含认证信息，避免未认证的请求通过相同的 Key 获取到其它客户端的响应
+			id := repository.HashIdempotencyKey(route.GetName()+":"+r.Header.Get("Authorization"), key)
+			record, reserved, err := repo.Reserve(r.Context(), id, ttl)
+			if err != nil {
+				log.Errorf("reserve idempotency key failed: %v", err)
+				http.Error(w, "check idempotency key failed", http.StatusInternalServerError)
+				return
+			}
+
+			if !reserved {
+				if !record.Completed {
+					http.Error(w, "a request with the same idempotency key is in progress", http.StatusConflict)
+					return
+				}
+
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(record.StatusCode)
+				_, _ = w.Write(record.Response)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			// 客户端断开连接时 r.Context() 会被取消，保存结果使用独立的 context
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			if recorder.statusCode >= 200 && recorder.statusCode < 300 {
+				err = repo.Complete(ctx, id, recorder.statusCode, recorder.body.Bytes())
+			} else {
+				err = repo.Release(ctx, id)
+			}
+
+			if err != nil {
+				log.WithFields(log.Fields{
+					"route": route.GetName(),
+				}).Errorf("save idempotency record failed: %v", err)
+			}
+		})
+	}
+}
+
+// responseRecorder 在写入响应的同时记录响应状态码及响应体
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
@@ -2,10 +2,13 @@ package api
 
 import (
 	"net/http"
+	"net/http/pprof"
 
 	"github.com/gorilla/mux"
 	"github.com/mylxsw/adanos-alert/configs"
 	_ "github.com/mylxsw/adanos-alert/docs"
+	"github.com/mylxsw/adanos-alert/internal/extension"
+	"github.com/mylxsw/adanos-alert/internal/repository"
 	"github.com/mylxsw/container"
 	"github.com/mylxsw/glacier/infra"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -30,13 +33,29 @@ type ServiceProvider struct{}
 func (s ServiceProvider) Register(app container.Container) {}
 
 func (s ServiceProvider) Boot(app infra.Glacier) {
-	app.MustResolve(func(conf *configs.Config) {
+	app.MustResolve(func(conf *configs.Config, tokenRepo repository.APITokenRepo, idempotencyRepo repository.IdempotencyRepo) {
+		auth := newTokenAuthenticator(conf, tokenRepo)
+
 		app.WebAppRouter(routers(app.Container()))
 		app.WebAppMuxRouter(func(router *mux.Router) {
+			// 支持 gzip 压缩的请求体
+			router.Use(gzipRequestDecoder)
+			// 限制推送事件接口的请求体大小
+			router.Use(extension.IngestBodyLimiter(conf.IngestMaxBodySize))
+			// 推送事件接口支持 Idempotency-Key 请求头，避免客户端重试时重复创建事件
+			router.Use(ingestIdempotency(idempotencyRepo, conf.IngestIdempotencyTTL))
 			// Swagger doc
 			router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler).Name("swagger")
-			// prometheus metrics
-			router.PathPrefix("/metrics").Handler(promhttp.Handler())
+			// prometheus metrics，包含 Go runtime 指标以及任务指标，默认需要 admin 权限的 API Token
+			if conf.MetricsPublic {
+				router.PathPrefix("/metrics").Handler(promhttp.Handler())
+			} else {
+				router.PathPrefix("/metrics").Handler(auth.adminOnly(promhttp.Handler()))
+			}
+			// pprof，通过 --enable_debug_endpoints 或者环境变量 ADANOS_ENABLE_DEBUG_ENDPOINTS 启用
+			if conf.DebugEndpoints {
+				router.PathPrefix("/debug/pprof/").Handler(auth.adminOnly(pprofHandler())).Name("pprof")
+			}
 			// health check
 			router.PathPrefix("/health").Handler(HealthCheck{})
 			// Dashboard
@@ -45,6 +64,18 @@ func (s ServiceProvider) Boot(app infra.Glacier) {
 	})
 }
 
+// pprofHandler 返回 net/http/pprof 提供的性能分析接口
+func pprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return mux
+}
+
 type HealthCheck struct{}
 
 func (h HealthCheck) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
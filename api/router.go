@@ -1,10 +1,9 @@
 package api
 
 import (
-	"errors"
-
 	"github.com/mylxsw/adanos-alert/api/controller"
 	"github.com/mylxsw/adanos-alert/configs"
+	"github.com/mylxsw/adanos-alert/internal/repository"
 	"github.com/mylxsw/asteria/log"
 	"github.com/mylxsw/container"
 	"github.com/mylxsw/glacier/web"
@@ -12,42 +11,45 @@ import (
 
 func routers(cc container.Container) func(router *web.Router, mw web.RequestMiddleware) {
 	conf := cc.MustGet(&configs.Config{}).(*configs.Config)
+	tokenRepo := cc.MustGet(new(repository.APITokenRepo)).(repository.APITokenRepo)
+	auditRepo := cc.MustGet(new(repository.AuditLogRepo)).(repository.AuditLogRepo)
 	return func(router *web.Router, mw web.RequestMiddleware) {
 		mws := make([]web.HandlerDecorator, 0)
 		mws = append(mws, mw.AccessLog(log.Module("api")), mw.CORS("*"))
-		if conf.APIToken != "" {
-			authMiddleware := mw.AuthHandler(func(ctx web.Context, typ string, credential string) error {
-				if typ != "Bearer" {
-					return errors.New("invalid auth type, only support Bearer")
-				}
-
-				if credential != conf.APIToken {
-					return errors.New("token not match")
-				}
-
-				return nil
-			})
-
-			mws = append(mws, authMiddleware)
-		}
+		mws = append(mws, newTokenAuthenticator(conf, tokenRepo).Middleware())
+		mws = append(mws, auditMiddleware(auditRepo, newAuditSnapshotLoaders(cc)))
 
 		router.WithMiddleware(mws...).Controllers(
 			"/api",
 			controller.NewWelcomeController(cc),
 			controller.NewEventController(cc),
 			controller.NewQueueController(cc),
+			controller.NewFailedActionController(cc),
 			controller.NewUserController(cc),
 			controller.NewGroupController(cc),
 			controller.NewRuleController(cc),
+			controller.NewTriggerController(cc),
 			controller.NewTemplateController(cc),
 			controller.NewDingdingRobotController(cc),
+			controller.NewInhibitRuleController(cc),
+			controller.NewSilenceController(cc),
+			controller.NewMaintenanceWindowController(cc),
+			controller.NewScheduleController(cc),
 			controller.NewAgentController(cc),
 			controller.NewStatisticsController(cc),
 			controller.NewAuditController(cc),
+			controller.NewClusterController(cc),
 			controller.NewJiraController(cc),
+			controller.NewAPITokenController(cc),
 		)
 
+		// 健康检查接口供探针使用，不需要认证
 		router.WithMiddleware(mw.AccessLog(log.Module("api")), mw.CORS("*")).Controllers(
+			"/api",
+			controller.NewHealthController(cc),
+		)
+
+		router.WithMiddleware(mw.AccessLog(log.Module("api")), mw.CORS("*"), previewSignatureMiddleware(conf)).Controllers(
 			"/ui",
 			controller.NewPublicController(cc),
 		)
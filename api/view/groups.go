@@ -23,6 +23,9 @@ type GroupData struct {
 	HasPrev     bool
 	HasNext     bool
 	PrevOffset  int64
+	// Expires, Token 通过签名链接访问时的签名参数，分页链接中需要保留
+	Expires string
+	Token   string
 }
 
 var defaultTemplateContent string
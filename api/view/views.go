@@ -212,23 +212,23 @@ var _escData = map[string]*_escFile{
 	"/groups.html": {
 		name:    "groups.html",
 		local:   "api/view/groups.html",
-		size:    3116,
-		modtime: 1602473543,
+		size:    3380,
+		modtime: 1792036984,
 		compressed: `
-H4sIAAAAAAAC/+xWzW7bRhC+6ykGW/VmyrJdF6hAsSiMAgWK2gZa9CosxaG01nJX3l2xFmgBvQVILnmB
-AMkhxxwDGDnkaQwHeYtgV6Ql0SRNJ9f4QHj/5vvmm29W2/EjlsKYU62HZE4nTFAjFQk6AABZBiyG3h9U
-nytMYbVys/bPp8WZ0AgIjfCYiCWBqcJ4SLIMeufUTGG1+lXGsUYzdFMK0zM3hNWKgJIchyRcGCMFCW5v
-nt/e/P/5zXt/n96jo4gsaonMKV6ZZjJzxRKqlo/wyeM8ZPKilom/H7E06GQZKComCF22B11MDQyG0Ps9
-RWF0wWxXWIEc3NeLMKYLbnKJK3d6U6QRExMS+HpOxe6axksCLHJZWeje33h5ukhsIoFP84x/qFh8OGcT
-9PctRAB+GGQZRNSgYQkCOez3f/b6B17/EA6OB/2fBv1jsj5+opAajH4zLkIY5JrUZxPKaLmVbnlTgoaW
-lstbxlIYygQqL+YLFlXs3jiEo1jz/IdONFzDxEB/2y1NOEr+VxO7mhX3dOIdlcrEaYgc3NfTi/EYtSbB
-3etnn959zMUuKdYS55dHqOUSbBkzsbbcaNEgwg50XTKuxa1LumvzuFzaUMqbpxG0WZKG5eb47av7xMoW
-V0xw9+rt3YeX7Sr7FVUtmvZMsQkTjUaux26Wr+wYLZUZJXQ+mi4SmjfTX2hoE/i692KpEmqA/JgS6Ca9
-fylfIFyDQCDkUQfsVgpoRIXU3pgKL5Y8amH+J1Zwy869P3G5sXSL9vyGFnVH5wqLo6kVyQvlleNSo6DC
-RKY4wmRuliPOBDq2c4UtaLa4bJq3NHdY/WpF2KqpLRkpR2Wqfi9qnHqhpRjFnBpTXPonUhgUBo7aXAj2
-uGfVbLoWtu3jDsxwWWmbTptquwiu5C4GiwEvi0hEGzqe2UbZpHqxB93Zw1QLZxy0udQt11nOFQb5cH3e
-Qa3LZ//hOp+6B9ja0OC3GgNtQnbqdLEi2NR2q3cNU5Pwesxqz5UfIpvh5tlWvCi/P3uf/uz9EgAA//+L
-hOjvLAwAAA==
+H4sIAAAAAAAC/+xWQW/rRBC+51eMlsDpOU3f4yEROUaoqoSEaCtRcY3W8TjZZr3r7m5MI9cSNyS48AeQ
+4MCRI1LFgV9TFfEvkNd247i264I4IL1L5N2dnZnvm28mO3IDlsCSU63nJKYrJqiRingjAIA0BRbC5DOq
+LxQmkGUjAAAAAJdWd3wjwDfCYSKUBNYKwzlJU5hcULOGLPtEhqFGM7dbCpNzu4QsK31fyg0KyLIP8CZm
+CrU1PC2+822Tn9vNyjJNAUUAWUZASY5z4m+NkYJ493ff3d9989fPv7lH9DH/wnTUgHOGN6YfTqxYRNXu
+GUSln/8Cy/edWNyjgCXeKE1BUbFCGLNXMMbEwGwOk9MEhdEVtsPiCuRgf50AQ7rlpixzq6WzRhowsSKe
+q2MqDs80XhNggeUlDz35Eq/PtlEOxHNpydl7LYdP93KA7lEewgPX99IUAmrQsAiBvJ5OP3Kmx870NRy/
+nU0/nE3fkuL6iUJqMPjUWA++V3LSjcaXwa4Gt2kUoaGN46bJUgpDmUDlhHzLghbrvcY4iiLPS7rScAsr
+A9O63vriKPk18Totn2bFHR05bxpl4tRHDvbX0dvlErUm3sNP3/756x8l2Q3GBsb5+JnUSgpqwoxyWe65
+6CHhIHQXGDtmcpWMC/FYLENSKpunN2g/JT3H/f6HV/eFla2GlPfw4y8Pv/8wrLL/oKpV054rtmKiV8jd
+sfvpaypGS2UWEY0X621Ey2b6Ag3tC170XihVRA2Q9xMC42jyFeVbhFsQCIQ8q4DDSgENqJDaWVLhhJIH
+A8T/wgrW5Dz5HHd7SQ9oz3/RogAAbqywuprkJDm+vLG5dDCoMJIJLjCKzW7BmUCbbaxwQJoDhk2/SX+H
+dZ+2uG3bqtFIOSrT9n/RodQrLcUi5NSYauifSGFQGHgzZCDk152czb6xUJePvbDBXatsRkOqbT3Yklsf
+LAS8rjwRbehyQ4q3SQn16hWMN0+hVso4HjLU81w3Za4wK5fF/dozKP/gutx6DFAz6NFbh4D2LkddvOQk
+5NAOq3cLaxPx7pjtmms+RPbL/bOtvPbu6f0/fXr/PQCxGzAeNA0AAA==
 `,
 	},
 
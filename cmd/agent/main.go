@@ -59,6 +59,48 @@ func main() {
 		Usage: "本地数据库存储目录",
 		Value: "/tmp/adanos-agent",
 	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "store_backend",
+		Usage:  "本地事件存储方式，支持 ledis/wal",
+		EnvVar: "ADANOS_AGENT_STORE_BACKEND",
+		Value:  "ledis",
+	}))
+	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
+		Name:   "batch_size",
+		Usage:  "每次发送给 Server 的最大事件数量",
+		EnvVar: "ADANOS_AGENT_BATCH_SIZE",
+		Value:  100,
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "flush_interval",
+		Usage:  "事件发送周期",
+		EnvVar: "ADANOS_AGENT_FLUSH_INTERVAL",
+		Value:  "5s",
+	}))
+	app.AddFlags(altsrc.NewInt64Flag(cli.Int64Flag{
+		Name:   "ingest_max_body_size",
+		Usage:  "推送事件接口请求体的最大字节数",
+		EnvVar: "ADANOS_AGENT_INGEST_MAX_BODY_SIZE",
+		Value:  2 * 1024 * 1024,
+	}))
+	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
+		Name:   "ingest_max_events",
+		Usage:  "推送事件接口一次请求最多包含的事件数量",
+		EnvVar: "ADANOS_AGENT_INGEST_MAX_EVENTS",
+		Value:  500,
+	}))
+	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
+		Name:   "ingest_max_meta_keys",
+		Usage:  "单个事件最多包含的 Meta 字段数量",
+		EnvVar: "ADANOS_AGENT_INGEST_MAX_META_KEYS",
+		Value:  200,
+	}))
+	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
+		Name:   "ingest_max_tags",
+		Usage:  "单个事件最多包含的标签数量",
+		EnvVar: "ADANOS_AGENT_INGEST_MAX_TAGS",
+		Value:  50,
+	}))
 	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
 		Name:   "listen",
 		Usage:  "listen address",
@@ -100,12 +142,32 @@ func main() {
 
 	// Config
 	app.Singleton(func(c infra.FlagContext) *config.Config {
+		flushInterval, err := time.ParseDuration(c.String("flush_interval"))
+		if err != nil || flushInterval <= 0 {
+			log.Warningf("invalid argument [flush_interval: %s], using default value", c.String("flush_interval"))
+			flushInterval = 5 * time.Second
+		}
+
+		batchSize := c.Int("batch_size")
+		if batchSize <= 0 {
+			log.Warningf("invalid argument [batch_size: %d], using default value", batchSize)
+			batchSize = 100
+		}
+
 		return &config.Config{
-			DataDir:     c.String("data_dir"),
-			ServerAddr:  c.String("server_addr"),
-			ServerToken: c.String("server_token"),
-			Listen:      c.String("listen"),
-			LogPath:     c.String("log_path"),
+			DataDir:       c.String("data_dir"),
+			ServerAddr:    c.String("server_addr"),
+			ServerToken:   c.String("server_token"),
+			StoreBackend:  c.String("store_backend"),
+			BatchSize:     batchSize,
+			FlushInterval: flushInterval,
+			Listen:        c.String("listen"),
+			LogPath:       c.String("log_path"),
+
+			IngestMaxBodySize: c.Int64("ingest_max_body_size"),
+			IngestMaxEvents:   c.Int("ingest_max_events"),
+			IngestMaxMetaKeys: c.Int("ingest_max_meta_keys"),
+			IngestMaxTags:     c.Int("ingest_max_tags"),
 		}
 	})
 
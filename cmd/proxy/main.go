@@ -87,7 +87,8 @@ func main() {
 				WithCtl(ctl)
 
 			ctx, _ := context.WithTimeout(context.TODO(), 5*time.Second)
-			return connector.NewConnector(c.String("adanos-token"), adanosServers...).Send(ctx, evt)
+			_, err := connector.NewConnector(c.String("adanos-token"), adanosServers...).Send(ctx, evt)
+			return err
 		},
 	}
 
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/mylxsw/adanos-alert/pubsub"
@@ -27,6 +28,7 @@ import (
 	"github.com/mylxsw/adanos-alert/internal/queue"
 	"github.com/mylxsw/adanos-alert/internal/repository"
 	"github.com/mylxsw/adanos-alert/internal/repository/impl"
+	"github.com/mylxsw/adanos-alert/internal/sink"
 	"github.com/mylxsw/adanos-alert/migrate"
 	"github.com/mylxsw/asteria/level"
 	"github.com/mylxsw/asteria/log"
@@ -65,6 +67,18 @@ func main() {
 		EnvVar: "ADANOS_PREVIEW_URL",
 		Value:  "http://localhost:19999/ui/groups/%s.html",
 	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "preview_sign_secret",
+		Usage:  "Secret for signing preview/report url, the ui pages require a valid signature when set",
+		EnvVar: "ADANOS_PREVIEW_SIGN_SECRET",
+		Value:  "",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "preview_sign_ttl",
+		Usage:  "Signed preview/report url expiration",
+		EnvVar: "ADANOS_PREVIEW_SIGN_TTL",
+		Value:  "72h",
+	}))
 	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
 		Name:   "report_url",
 		Usage:  "Alert report page url",
@@ -85,7 +99,7 @@ func main() {
 	}))
 	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
 		Name:   "api_token",
-		Usage:  "API Token for api access control",
+		Usage:  "API Token for api access control, works as an admin token along with the tokens managed in /api/api-tokens/",
 		EnvVar: "ADANOS_API_TOKEN",
 		Value:  "",
 	}))
@@ -93,6 +107,16 @@ func main() {
 		Name:  "use_local_dashboard",
 		Usage: "whether using local dashboard, this is used when development",
 	}))
+	app.AddFlags(altsrc.NewBoolFlag(cli.BoolFlag{
+		Name:   "enable_debug_endpoints",
+		Usage:  "whether enable /debug/pprof/ endpoints, only requests with an admin api token are allowed",
+		EnvVar: "ADANOS_ENABLE_DEBUG_ENDPOINTS",
+	}))
+	app.AddFlags(altsrc.NewBoolFlag(cli.BoolFlag{
+		Name:   "metrics_public",
+		Usage:  "whether /metrics can be accessed without an api token, an admin api token is required by default",
+		EnvVar: "ADANOS_METRICS_PUBLIC",
+	}))
 	app.AddFlags(altsrc.NewBoolFlag(cli.BoolFlag{
 		Name:  "enable_migrate",
 		Usage: "whether enable database migrate when app run",
@@ -107,12 +131,60 @@ func main() {
 		EnvVar: "ADANOS_AGGREGATION_PERIOD",
 		Value:  "5s",
 	}))
+	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
+		Name:   "aggregation_max_concurrent",
+		Usage:  "max concurrent aggregation job runs, a new run starts only when the running one exceeded aggregation_soft_deadline",
+		EnvVar: "ADANOS_AGGREGATION_MAX_CONCURRENT",
+		Value:  1,
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "aggregation_soft_deadline",
+		Usage:  "soft deadline for aggregation job, used with aggregation_max_concurrent",
+		EnvVar: "ADANOS_AGGREGATION_SOFT_DEADLINE",
+		Value:  "1m",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "rule_match_mode",
+		Usage:  "rule match mode for grouping events: all (event joins every matched rule's group), first (only the highest priority matched rule)",
+		EnvVar: "ADANOS_RULE_MATCH_MODE",
+		Value:  configs.RuleMatchModeAll,
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "rule_match_mode_by_type",
+		Usage:  "override rule_match_mode per event type, for example: plain=first,recovery=all",
+		EnvVar: "ADANOS_RULE_MATCH_MODE_BY_TYPE",
+		Value:  "",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "aggregation_deadline",
+		Usage:  "hard deadline for a single aggregation job run, the remaining events are handled in the next run, 0 for no limit",
+		EnvVar: "ADANOS_AGGREGATION_DEADLINE",
+		Value:  "10m",
+	}))
 	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
 		Name:   "action_trigger_period",
 		Usage:  "action trigger job execute period",
 		EnvVar: "ADANOS_ACTION_TRIGGER_PERIOD",
 		Value:  "5s",
 	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "lock_ttl",
+		Usage:  "distribute lock ttl for cron jobs in cluster",
+		EnvVar: "ADANOS_LOCK_TTL",
+		Value:  "90s",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "lock_renew_interval",
+		Usage:  "distribute lock renew interval, must be less than lock_ttl",
+		EnvVar: "ADANOS_LOCK_RENEW_INTERVAL",
+		Value:  "30s",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "job_shutdown_timeout",
+		Usage:  "max time to wait for running aggregation/trigger jobs before releasing the distribute lock on shutdown, should be less than shutdown_timeout",
+		EnvVar: "ADANOS_JOB_SHUTDOWN_TIMEOUT",
+		Value:  "4s",
+	}))
 	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
 		Name:   "queue_job_max_retry_times",
 		Usage:  "set queue job max retry times",
@@ -122,10 +194,40 @@ func main() {
 
 	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
 		Name:   "keep_period",
-		Usage:  "保留多长时间的报警，如果全部保留，设置为0，单位为天，Adanos-Alert 会自动清理超过 keep_period 天的报警",
+		Usage:  "[deprecated] 保留多长时间的报警，单位为天，由 retention 任务清理；retention_events、retention_groups 没有设置时作为它们的默认值，设置之后以 retention_* 为准",
 		EnvVar: "ADANOS_KEEP_PERIOD",
 		Value:  0,
 	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "retention_events",
+		Usage:  "how long events are retained, such as 720h, 0 means retain forever",
+		EnvVar: "ADANOS_RETENTION_EVENTS",
+		Value:  "0",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "retention_groups",
+		Usage:  "how long finished event groups are retained, such as 2160h, 0 means retain forever",
+		EnvVar: "ADANOS_RETENTION_GROUPS",
+		Value:  "0",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "retention_recoveries",
+		Usage:  "how long recovery records are retained after the expected recovery time, 0 means retain forever",
+		EnvVar: "ADANOS_RETENTION_RECOVERIES",
+		Value:  "0",
+	}))
+	app.AddFlags(altsrc.NewInt64Flag(cli.Int64Flag{
+		Name:   "retention_batch_size",
+		Usage:  "how many records are deleted in a batch by the retention job",
+		EnvVar: "ADANOS_RETENTION_BATCH_SIZE",
+		Value:  500,
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "retention_period",
+		Usage:  "retention job execute period",
+		EnvVar: "ADANOS_RETENTION_PERIOD",
+		Value:  "1h",
+	}))
 	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
 		Name:   "audit_keep_period",
 		Usage:  "保留多长时间的审计日志，如果全部保留，设置为0，单位为天，Adanos-Alert 会自动清理超过 audit_keep_period 天的审计日志",
@@ -133,12 +235,48 @@ func main() {
 		Value:  0,
 	}))
 
+	app.AddFlags(altsrc.NewStringSliceFlag(cli.StringSliceFlag{
+		Name:   "holiday",
+		Usage:  "节假日日期，格式为 2006-01-02，可以指定多个，规则中的 IsWorkday 会将这些日期视为非工作日",
+		EnvVar: "ADANOS_HOLIDAYS",
+	}))
+
 	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
 		Name:   "queue_worker_num",
 		Usage:  "set queue worker numbers",
 		EnvVar: "ADANOS_QUEUE_WORKER_NUM",
 		Value:  3,
 	}))
+	app.AddFlags(altsrc.NewInt64Flag(cli.Int64Flag{
+		Name:   "ingest_max_body_size",
+		Usage:  "max body size in bytes for event ingest api, decompressed size is used for gzip request",
+		EnvVar: "ADANOS_INGEST_MAX_BODY_SIZE",
+		Value:  2 * 1024 * 1024,
+	}))
+	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
+		Name:   "ingest_max_events",
+		Usage:  "max events in one request for event ingest api",
+		EnvVar: "ADANOS_INGEST_MAX_EVENTS",
+		Value:  500,
+	}))
+	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
+		Name:   "ingest_max_meta_keys",
+		Usage:  "max meta keys of an event",
+		EnvVar: "ADANOS_INGEST_MAX_META_KEYS",
+		Value:  200,
+	}))
+	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
+		Name:   "ingest_max_tags",
+		Usage:  "max tags of an event",
+		EnvVar: "ADANOS_INGEST_MAX_TAGS",
+		Value:  50,
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "ingest_idempotency_ttl",
+		Usage:  "how long an Idempotency-Key of ingest request is remembered",
+		EnvVar: "ADANOS_INGEST_IDEMPOTENCY_TTL",
+		Value:  "24h",
+	}))
 	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
 		Name:   "query_timeout",
 		Usage:  "query timeout for backend service",
@@ -191,6 +329,97 @@ func main() {
 		EnvVar: "ADANOS_JIRA_PASSWORD",
 		Usage:  "Jira 连接密码",
 	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "es_endpoint",
+		EnvVar: "ADANOS_ES_ENDPOINT",
+		Usage:  "Elasticsearch/OpenSearch 服务器地址，如 http://127.0.0.1:9200，设置后触发的事件组会归档到 Elasticsearch",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "es_index",
+		EnvVar: "ADANOS_ES_INDEX",
+		Usage:  "Elasticsearch 索引名称，支持 {2006.01.02} 形式的日期格式",
+		Value:  "adanos-groups-{2006.01}",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "es_username",
+		EnvVar: "ADANOS_ES_USERNAME",
+		Usage:  "Elasticsearch 账号",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "es_password",
+		EnvVar: "ADANOS_ES_PASSWORD",
+		Usage:  "Elasticsearch 密码",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "es_api_key",
+		EnvVar: "ADANOS_ES_API_KEY",
+		Usage:  "Elasticsearch API Key（base64 编码的 id:api_key），设置后优先于账号密码",
+	}))
+	app.AddFlags(altsrc.NewInt64Flag(cli.Int64Flag{
+		Name:  "es_sample_size",
+		Usage: "每个事件组归档到 Elasticsearch 的事件样本数量",
+		Value: 10,
+	}))
+	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
+		Name:  "es_batch_size",
+		Usage: "每次 bulk 请求最多包含的文档数量",
+		Value: 100,
+	}))
+	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
+		Name:  "es_queue_size",
+		Usage: "等待归档的事件组队列长度，队列满时新的事件组被丢弃",
+		Value: 1000,
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:  "es_flush_interval",
+		Usage: "归档到 Elasticsearch 的时间间隔",
+		Value: "5s",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "kafka_brokers",
+		EnvVar: "ADANOS_KAFKA_BROKERS",
+		Usage:  "Kafka broker 地址，多个地址使用英文逗号分隔，如 127.0.0.1:9092，设置后触发的事件组会发送到 Kafka，支持 Kafka 0.10.1 及以上版本",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "kafka_topic",
+		EnvVar: "ADANOS_KAFKA_TOPIC",
+		Usage:  "事件组发送到的 Kafka topic",
+		Value:  "adanos-alerts",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "kafka_sasl_mechanism",
+		EnvVar: "ADANOS_KAFKA_SASL_MECHANISM",
+		Usage:  "Kafka SASL 认证机制：PLAIN、SCRAM-SHA-256、SCRAM-SHA-512",
+		Value:  "PLAIN",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "kafka_sasl_username",
+		EnvVar: "ADANOS_KAFKA_SASL_USERNAME",
+		Usage:  "Kafka SASL 认证账号，使用 PLAIN 认证机制时建议同时启用 kafka_tls 避免密码明文传输",
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:   "kafka_sasl_password",
+		EnvVar: "ADANOS_KAFKA_SASL_PASSWORD",
+		Usage:  "Kafka SASL 认证密码",
+	}))
+	app.AddFlags(altsrc.NewBoolFlag(cli.BoolFlag{
+		Name:  "kafka_tls",
+		Usage: "是否使用 TLS 连接 Kafka",
+	}))
+	app.AddFlags(altsrc.NewBoolFlag(cli.BoolFlag{
+		Name:  "kafka_tls_skip_verify",
+		Usage: "使用 TLS 连接 Kafka 时不校验证书",
+	}))
+	app.AddFlags(altsrc.NewIntFlag(cli.IntFlag{
+		Name:  "kafka_buffer_size",
+		Usage: "Kafka 发送缓冲区大小",
+		Value: 1000,
+	}))
+	app.AddFlags(altsrc.NewStringFlag(cli.StringFlag{
+		Name:  "kafka_overflow_policy",
+		Usage: "Kafka 发送缓冲区满时的处理策略：drop-oldest 丢弃最早的消息，block 阻塞直到有空间",
+		Value: "drop-oldest",
+	}))
 
 	app.WithHttpServer(listener.FlagContext("listen"))
 
@@ -227,12 +456,113 @@ func main() {
 			aggregationPeriod = 30 * time.Second
 		}
 
+		retentionDuration := func(name string) time.Duration {
+			val, err := time.ParseDuration(c.String(name))
+			if err != nil || val < 0 {
+				log.Warningf("invalid argument [%s: %s], data will be retained forever", name, c.String(name))
+				return 0
+			}
+
+			return val
+		}
+
+		// keep_period 已废弃，retention_events、retention_groups 没有设置时使用 keep_period 作为保留时长，都设置时以 retention_* 为准
+		keepPeriodFallback := func(name string) time.Duration {
+			val := retentionDuration(name)
+			if val > 0 || c.Int("keep_period") <= 0 {
+				return val
+			}
+
+			return time.Duration(c.Int("keep_period")) * 24 * time.Hour
+		}
+
+		retentionPeriod, err := time.ParseDuration(c.String("retention_period"))
+		if err != nil || retentionPeriod <= 0 {
+			log.Warningf("invalid argument [retention_period: %s], using default value", c.String("retention_period"))
+			retentionPeriod = time.Hour
+		}
+
+		ingestIdempotencyTTL, err := time.ParseDuration(c.String("ingest_idempotency_ttl"))
+		if err != nil || ingestIdempotencyTTL <= 0 {
+			log.Warningf("invalid argument [ingest_idempotency_ttl: %s], using default value", c.String("ingest_idempotency_ttl"))
+			ingestIdempotencyTTL = 24 * time.Hour
+		}
+
+		previewSignTTL, err := time.ParseDuration(c.String("preview_sign_ttl"))
+		if err != nil || previewSignTTL <= 0 {
+			log.Warningf("invalid argument [preview_sign_ttl: %s], using default value", c.String("preview_sign_ttl"))
+			previewSignTTL = 72 * time.Hour
+		}
+
+		aggregationSoftDeadline, err := time.ParseDuration(c.String("aggregation_soft_deadline"))
+		if err != nil {
+			log.Warningf("invalid argument [aggregation_soft_deadline: %s], using default value", c.String("aggregation_soft_deadline"))
+			aggregationSoftDeadline = time.Minute
+		}
+
+		aggregationDeadline, err := time.ParseDuration(c.String("aggregation_deadline"))
+		if err != nil {
+			log.Warningf("invalid argument [aggregation_deadline: %s], using default value", c.String("aggregation_deadline"))
+			aggregationDeadline = 10 * time.Minute
+		}
+
+		validMatchMode := func(mode string) bool {
+			return mode == configs.RuleMatchModeAll || mode == configs.RuleMatchModeFirst
+		}
+
+		ruleMatchMode := c.String("rule_match_mode")
+		if !validMatchMode(ruleMatchMode) {
+			log.Warningf("invalid argument [rule_match_mode: %s], using default value", ruleMatchMode)
+			ruleMatchMode = configs.RuleMatchModeAll
+		}
+
+		ruleMatchModeByType := make(map[string]string)
+		for _, item := range str.FilterEmpty(str.Map(strings.Split(c.String("rule_match_mode_by_type"), ","), strings.TrimSpace)) {
+			segs := strings.SplitN(item, "=", 2)
+			if len(segs) != 2 || !validMatchMode(strings.TrimSpace(segs[1])) {
+				log.Warningf("invalid argument [rule_match_mode_by_type: %s], ignored", item)
+				continue
+			}
+
+			ruleMatchModeByType[strings.TrimSpace(segs[0])] = strings.TrimSpace(segs[1])
+		}
+
 		actionTriggerPeriod, err := time.ParseDuration(c.String("action_trigger_period"))
 		if err != nil {
 			log.Warningf("invalid argument [action_trigger_period: %s], using default value", c.String("action_trigger_period"))
 			actionTriggerPeriod = 5 * time.Second
 		}
 
+		lockTTL, err := time.ParseDuration(c.String("lock_ttl"))
+		if err != nil {
+			log.Warningf("invalid argument [lock_ttl: %s], using default value", c.String("lock_ttl"))
+			lockTTL = 90 * time.Second
+		}
+
+		lockRenewInterval, err := time.ParseDuration(c.String("lock_renew_interval"))
+		if err != nil || lockRenewInterval >= lockTTL {
+			log.Warningf("invalid argument [lock_renew_interval: %s], using default value", c.String("lock_renew_interval"))
+			lockRenewInterval = lockTTL / 3
+		}
+
+		jobShutdownTimeout, err := time.ParseDuration(c.String("job_shutdown_timeout"))
+		if err != nil {
+			log.Warningf("invalid argument [job_shutdown_timeout: %s], using default value", c.String("job_shutdown_timeout"))
+			jobShutdownTimeout = 4 * time.Second
+		}
+
+		kafkaOverflowPolicy := c.String("kafka_overflow_policy")
+		if kafkaOverflowPolicy != configs.KafkaOverflowDropOldest && kafkaOverflowPolicy != configs.KafkaOverflowBlock {
+			log.Warningf("invalid argument [kafka_overflow_policy: %s], using default value", kafkaOverflowPolicy)
+			kafkaOverflowPolicy = configs.KafkaOverflowDropOldest
+		}
+
+		esFlushInterval, err := time.ParseDuration(c.String("es_flush_interval"))
+		if err != nil || esFlushInterval <= 0 {
+			log.Warningf("invalid argument [es_flush_interval: %s], using default value", c.String("es_flush_interval"))
+			esFlushInterval = 5 * time.Second
+		}
+
 		queryTimeout, err := time.ParseDuration(c.String("query_timeout"))
 		if err != nil {
 			log.Warningf("invalid argument [query_timeout: %s], using default value", c.String("query_timeout"))
@@ -240,24 +570,42 @@ func main() {
 		}
 
 		return &configs.Config{
-			Listen:                c.String("listen"),
-			GRPCListen:            c.String("grpc_listen"),
-			GRPCToken:             c.String("grpc_token"),
-			MongoURI:              c.String("mongo_uri"),
-			MongoDB:               c.String("mongo_db"),
-			UseLocalDashboard:     c.Bool("use_local_dashboard"),
-			APIToken:              c.String("api_token"),
-			AggregationPeriod:     aggregationPeriod,
-			ActionTriggerPeriod:   actionTriggerPeriod,
-			QueueJobMaxRetryTimes: c.Int("queue_job_max_retry_times"),
-			QueueWorkerNum:        c.Int("queue_worker_num"),
-			QueryTimeout:          queryTimeout,
-			Migrate:               c.Bool("enable_migrate"),
-			ReMigrate:             c.Bool("re_migrate"),
-			PreviewURL:            c.String("preview_url"),
-			ReportURL:             c.String("report_url"),
-			KeepPeriod:            c.Int("keep_period"),
-			AuditKeepPeriod:       c.Int("audit_keep_period"),
+			Listen:                   c.String("listen"),
+			GRPCListen:               c.String("grpc_listen"),
+			GRPCToken:                c.String("grpc_token"),
+			MongoURI:                 c.String("mongo_uri"),
+			MongoDB:                  c.String("mongo_db"),
+			UseLocalDashboard:        c.Bool("use_local_dashboard"),
+			DebugEndpoints:           c.Bool("enable_debug_endpoints"),
+			MetricsPublic:            c.Bool("metrics_public"),
+			APIToken:                 c.String("api_token"),
+			AggregationPeriod:        aggregationPeriod,
+			AggregationMaxConcurrent: c.Int("aggregation_max_concurrent"),
+			AggregationSoftDeadline:  aggregationSoftDeadline,
+			AggregationDeadline:      aggregationDeadline,
+			RuleMatchMode:            ruleMatchMode,
+			RuleMatchModeByType:      ruleMatchModeByType,
+			ActionTriggerPeriod:      actionTriggerPeriod,
+			LockTTL:                  lockTTL,
+			LockRenewInterval:        lockRenewInterval,
+			JobShutdownTimeout:       jobShutdownTimeout,
+			QueueJobMaxRetryTimes:    c.Int("queue_job_max_retry_times"),
+			QueueWorkerNum:           c.Int("queue_worker_num"),
+			QueryTimeout:             queryTimeout,
+			IngestMaxBodySize:        c.Int64("ingest_max_body_size"),
+			IngestMaxEvents:          c.Int("ingest_max_events"),
+			IngestMaxMetaKeys:        c.Int("ingest_max_meta_keys"),
+			IngestMaxTags:            c.Int("ingest_max_tags"),
+			IngestIdempotencyTTL:     ingestIdempotencyTTL,
+			Migrate:                  c.Bool("enable_migrate"),
+			ReMigrate:                c.Bool("re_migrate"),
+			PreviewURL:               c.String("preview_url"),
+			ReportURL:                c.String("report_url"),
+			PreviewSignSecret:        c.String("preview_sign_secret"),
+			PreviewSignTTL:           previewSignTTL,
+			KeepPeriod:               c.Int("keep_period"),
+			AuditKeepPeriod:          c.Int("audit_keep_period"),
+			Holidays:                 c.StringSlice("holiday"),
 			AliyunVoiceCall: configs.AliyunVoiceCall{
 				BaseURI:            "http://dyvmsapi.aliyuncs.com/",
 				AccessKey:          c.String("aliyun_access_key"),
@@ -271,6 +619,35 @@ func main() {
 				Username: c.String("jira_username"),
 				Password: c.String("jira_password"),
 			},
+			Elasticsearch: configs.Elasticsearch{
+				Endpoint:      c.String("es_endpoint"),
+				Index:         c.String("es_index"),
+				Username:      c.String("es_username"),
+				Password:      c.String("es_password"),
+				APIKey:        c.String("es_api_key"),
+				SampleSize:    c.Int64("es_sample_size"),
+				BatchSize:     c.Int("es_batch_size"),
+				QueueSize:     c.Int("es_queue_size"),
+				FlushInterval: esFlushInterval,
+			},
+			Retention: configs.Retention{
+				Events:     keepPeriodFallback("retention_events"),
+				Groups:     keepPeriodFallback("retention_groups"),
+				Recoveries: retentionDuration("retention_recoveries"),
+				BatchSize:  c.Int64("retention_batch_size"),
+				Period:     retentionPeriod,
+			},
+			Kafka: configs.Kafka{
+				Brokers:        str.FilterEmpty(str.Map(strings.Split(c.String("kafka_brokers"), ","), strings.TrimSpace)),
+				Topic:          c.String("kafka_topic"),
+				SASLMechanism:  c.String("kafka_sasl_mechanism"),
+				SASLUsername:   c.String("kafka_sasl_username"),
+				SASLPassword:   c.String("kafka_sasl_password"),
+				TLS:            c.Bool("kafka_tls"),
+				TLSSkipVerify:  c.Bool("kafka_tls_skip_verify"),
+				BufferSize:     c.Int("kafka_buffer_size"),
+				OverflowPolicy: kafkaOverflowPolicy,
+			},
 		}
 	})
 
@@ -331,6 +708,7 @@ func main() {
 	app.Provider(rpc.ServiceProvider{})
 	app.Provider(service.ServiceProvider{})
 	app.Provider(pubsub.ServiceProvider{})
+	app.Provider(sink.ServiceProvider{})
 
 	if err := app.Run(os.Args); err != nil {
 		log.Errorf("exit with error: %s", err)
@@ -14,26 +14,70 @@ type Config struct {
 	GRPCListen string `json:"grpc_listen"`
 	GRPCToken  string `json:"-"`
 
+	// PreviewSignSecret 预览链接签名密钥，为空时不签名，/ui 下的页面无需签名即可访问
+	PreviewSignSecret string `json:"-"`
+	// PreviewSignTTL 签名预览链接的有效期
+	PreviewSignTTL time.Duration `json:"preview_sign_ttl"`
+
 	MongoURI          string `json:"mongo_uri"`
 	MongoDB           string `json:"mongo_db"`
 	APIToken          string `json:"-"`
 	UseLocalDashboard bool   `json:"use_local_dashboard"`
 
+	// DebugEndpoints 是否启用 /debug/pprof/ 性能分析接口，启用后只允许 admin 权限的 API Token 访问
+	DebugEndpoints bool `json:"debug_endpoints"`
+	// MetricsPublic 是否允许不提供 API Token 访问 /metrics，默认需要 admin 权限的 API Token
+	MetricsPublic bool `json:"metrics_public"`
+
 	AggregationPeriod     time.Duration `json:"aggregation_period"`
 	ActionTriggerPeriod   time.Duration `json:"action_trigger_period"`
 	QueueJobMaxRetryTimes int           `json:"queue_job_max_retry_times"`
 	QueueWorkerNum        int           `json:"queue_worker_num"`
 	QueryTimeout          time.Duration `json:"query_timeout"`
 
+	// AggregationMaxConcurrent 聚合任务最大并发执行数，默认为 1，上一次执行未完成时跳过本次执行
+	// 大于 1 时，如果正在执行的任务已经超过了 AggregationSoftDeadline，则允许启动新的执行
+	AggregationMaxConcurrent int           `json:"aggregation_max_concurrent"`
+	AggregationSoftDeadline  time.Duration `json:"aggregation_soft_deadline"`
+	// RuleMatchMode 事件匹配规则的模式，all 时事件会分配到所有匹配规则的事件组，first 时只分配到优先级最高的匹配规则
+	RuleMatchMode string `json:"rule_match_mode"`
+	// RuleMatchModeByType 按照事件类型覆盖 RuleMatchMode
+	RuleMatchModeByType map[string]string `json:"rule_match_mode_by_type"`
+	// AggregationDeadline 单次聚合任务执行的最长时间，超过之后中断遍历，剩余的事件在下次执行时处理，为 0 时不限制
+	AggregationDeadline time.Duration `json:"aggregation_deadline"`
+
+	// LockTTL 分布式锁有效期，LockRenewInterval 为后台续期分布式锁的时间间隔
+	LockTTL           time.Duration `json:"lock_ttl"`
+	LockRenewInterval time.Duration `json:"lock_renew_interval"`
+	// JobShutdownTimeout 服务停止时等待执行中的聚合/触发任务完成的最长时间，之后释放分布式锁
+	JobShutdownTimeout time.Duration `json:"job_shutdown_timeout"`
+
+	// IngestMaxBodySize 推送事件接口请求体的最大字节数（gzip 解压之后），超过时返回 413
+	IngestMaxBodySize int64 `json:"ingest_max_body_size"`
+	// IngestMaxEvents 一次请求最多包含的事件数量（批量、Prometheus、Loki 等）
+	IngestMaxEvents int `json:"ingest_max_events"`
+	// IngestMaxMetaKeys/IngestMaxTags 单个事件最多包含的 Meta 字段数量和标签数量
+	IngestMaxMetaKeys int `json:"ingest_max_meta_keys"`
+	IngestMaxTags     int `json:"ingest_max_tags"`
+	// IngestIdempotencyTTL 推送事件接口 Idempotency-Key 的有效期，有效期内相同 Key 的请求直接返回第一次请求的响应
+	IngestIdempotencyTTL time.Duration `json:"ingest_idempotency_ttl"`
+
+	// KeepPeriod 已废弃，只作为 Retention.Events、Retention.Groups 没有设置时的默认值（单位为天），两者都设置时以 Retention 为准
 	KeepPeriod      int `json:"keep_period"`
 	AuditKeepPeriod int `json:"audit_keep_period"`
 
+	// Holidays 节假日日历，格式为 2006-01-02，用于规则中的 IsWorkday 判断
+	Holidays []string `json:"holidays"`
+
 	Migrate         bool            `json:"migrate"`
 	ReMigrate       bool            `json:"re_migrate"`
 
 	AliyunVoiceCall AliyunVoiceCall `json:"aliyun_voice_call"`
 	EmailSMTP       EmailSMTP       `json:"email_smtp"`
 	Jira            Jira            `json:"jira"`
+	Elasticsearch   Elasticsearch   `json:"elasticsearch"`
+	Kafka           Kafka           `json:"kafka"`
+	Retention       Retention       `json:"retention"`
 }
 
 type EmailSMTP struct {
@@ -58,6 +102,94 @@ type Jira struct {
 	Password string `json:"-"`
 }
 
+// Elasticsearch 事件组归档到 Elasticsearch/OpenSearch 的配置，Endpoint 为空时不归档
+type Elasticsearch struct {
+	Endpoint string `json:"endpoint"`
+	// Index 索引名称，可以包含 {2006.01.02} 形式的日期格式，按照事件组创建时间生成索引名
+	Index    string `json:"index"`
+	Username string `json:"username"`
+	Password string `json:"-"`
+	APIKey   string `json:"-"`
+	// SampleSize 每个事件组归档的事件样本数量
+	SampleSize int64 `json:"sample_size"`
+	// BatchSize 每次 bulk 请求最多包含的文档数量
+	BatchSize int `json:"batch_size"`
+	// QueueSize 等待归档的事件组队列长度，队列满时新的事件组被丢弃，避免 Elasticsearch 变慢时阻塞触发任务
+	QueueSize     int           `json:"queue_size"`
+	FlushInterval time.Duration `json:"flush_interval"`
+}
+
+// Enabled 是否启用 Elasticsearch 归档
+func (es Elasticsearch) Enabled() bool {
+	return es.Endpoint != ""
+}
+
+// KafkaOverflowDropOldest/KafkaOverflowBlock Kafka 发送缓冲区满时的处理策略
+const (
+	KafkaOverflowDropOldest = "drop-oldest"
+	KafkaOverflowBlock      = "block"
+)
+
+// Kafka 触发的事件组发送到 Kafka 的配置，Brokers 或者 Topic 为空时不发送
+type Kafka struct {
+	Brokers      []string `json:"brokers"`
+	Topic        string   `json:"topic"`
+	SASLUsername string   `json:"sasl_username"`
+	SASLPassword string   `json:"-"`
+	TLS          bool     `json:"tls"`
+	// SASLMechanism SASL 认证机制：PLAIN、SCRAM-SHA-256、SCRAM-SHA-512，为空时使用 PLAIN
+	SASLMechanism string `json:"sasl_mechanism"`
+	// TLSSkipVerify 不校验 broker 证书，只用于测试环境
+	TLSSkipVerify bool `json:"tls_skip_verify"`
+	// BufferSize 内存缓冲区大小，OverflowPolicy 为缓冲区满时的处理策略：drop-oldest 丢弃最早的消息，block 阻塞直到有空间
+	BufferSize     int    `json:"buffer_size"`
+	OverflowPolicy string `json:"overflow_policy"`
+}
+
+// Enabled 是否启用 Kafka 输出
+func (k Kafka) Enabled() bool {
+	return len(k.Brokers) > 0 && k.Topic != ""
+}
+
+// Retention 数据保留策略，超过保留时长的数据由定时任务分批删除，保留时长为 0 时不删除
+type Retention struct {
+	// Events 事件的保留时长，不包含还未聚合的事件
+	Events time.Duration `json:"events"`
+	// Groups 已经结束（ok/failed/canceled）的事件组保留时长，事件组的评论一起删除
+	Groups time.Duration `json:"groups"`
+	// Recoveries 恢复记录的保留时长，以预计恢复时间计算
+	Recoveries time.Duration `json:"recoveries"`
+	// BatchSize 每批删除的数量，分批删除避免长时间占用分布式锁
+	BatchSize int64 `json:"batch_size"`
+	// Period 清理任务的执行间隔
+	Period time.Duration `json:"period"`
+}
+
+// Enabled 是否需要执行清理任务
+func (r Retention) Enabled() bool {
+	return r.Events > 0 || r.Groups > 0 || r.Recoveries > 0
+}
+
+const (
+	// RuleMatchModeAll 事件与所有规则匹配，可能被分配到多个事件组
+	RuleMatchModeAll = "all"
+	// RuleMatchModeFirst 按照优先级从高到低匹配规则，第一个匹配的规则生效之后停止匹配
+	RuleMatchModeFirst = "first"
+)
+
+// MatchMode 返回事件类型对应的规则匹配模式
+func (conf *Config) MatchMode(eventType string) string {
+	if mode, ok := conf.RuleMatchModeByType[eventType]; ok {
+		return mode
+	}
+
+	if conf.RuleMatchMode == "" {
+		return RuleMatchModeAll
+	}
+
+	return conf.RuleMatchMode
+}
+
 func (conf *Config) Serialize() string {
 	rs, _ := json.Marshal(conf)
 	return string(rs)
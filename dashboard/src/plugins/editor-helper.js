@@ -20,6 +20,14 @@ let helpers = {
         {text: "EventsCount()", displayText: "EventsCount() int64 | 获取事件组中 Events 数量"},
         {text: "TriggeredTimesInPeriod(PERIOD_IN_MINUTES, TRIGGER_STATUS)", displayText: "TriggeredTimesInPeriod(periodInMinutes int, triggerStatus string) int64 当前规则在指定时间范围内，状态为 triggerStatus 的触发次数"},
         {text: "LastTriggeredGroup(TRIGGER_STATUS)", displayText: "LastTriggeredGroup(triggerStatus string) repository.MessageGroup 最后一次触发该规则的状态为 triggerStatus 的事件组"},
+        {text: "GroupAge().Minutes()", displayText: "GroupAge() time.Duration 事件组创建至今的时长，可以使用 .Minutes()、.Seconds() 转换为数字"},
+        {text: "FirstEventAge().Minutes()", displayText: "FirstEventAge() time.Duration 事件组中最早的事件产生至今的时长，没有事件时返回 0"},
+        {text: "LastEventAge().Minutes()", displayText: "LastEventAge() time.Duration 事件组中最近的事件产生至今的时长，没有事件时返回 0"},
+        {text: "MetaNumericCount(\"KEY\")", displayText: "MetaNumericCount(key string) int64 事件组中 meta[key] 为数字的事件数量"},
+        {text: "MetaSum(\"KEY\")", displayText: "MetaSum(key string) float64 事件组中 meta[key] 数值之和，非数字的值会被忽略，没有数值时返回 0"},
+        {text: "MetaAvg(\"KEY\")", displayText: "MetaAvg(key string) float64 事件组中 meta[key] 数值的平均值，非数字的值会被忽略，没有数值时返回 0"},
+        {text: "MetaMax(\"KEY\")", displayText: "MetaMax(key string) float64 事件组中 meta[key] 数值的最大值，非数字的值会被忽略，没有数值时返回 0"},
+        {text: "MetaMin(\"KEY\")", displayText: "MetaMin(key string) float64 事件组中 meta[key] 数值的最小值，非数字的值会被忽略，没有数值时返回 0"},
         {text: "collecting", displayText: "collecting  | TriggerStatus：collecting"},
         {text: "pending", displayText: "pending | TriggerStatus：pending"},
         {text: "ok", displayText: "ok | TriggerStatus：ok"},
@@ -79,6 +87,7 @@ let helpers = {
         {text: "Now()", displayText: "Now() time.Time  | 当前时间"},
         {text: "ParseTime(LAYOUT, VALUE)", displayText: "ParseTime(layout string, value string) time.Time | 时间字符串转时间对象"},
         {text: "DailyTimeBetween(START_TIME_STR, END_TIME_STR)", displayText: "DailyTimeBetween(startTime, endTime string) bool  | 判断当前时间是否在 startTime 和 endTime 之间（每天），时间格式为 15:04"},
+        {text: "DailyTimeBetweenTZ(START_TIME_STR, END_TIME_STR, \"Asia/Shanghai\")", displayText: "DailyTimeBetweenTZ(startTime, endTime string, tz string) bool  | 判断 tz 时区的当前时间是否在 startTime 和 endTime 之间（每天），时间格式为 15:04"},
         {text: 'SQLFinger(SQL_STR)', displayText: "SQLFinger(sqlStr string) string | 创建 SQL 指纹"},
         {text: 'TrimSuffix(STR, SUFFIX)', displayText: 'TrimSuffix(str, suffix string) string | 去除字符串后缀'},
         {text: 'TrimPrefix(STR, PREFIX)', displayText: 'TrimPrefix(str, prefix string) string | 去除字符串前缀'},
@@ -94,6 +103,10 @@ let helpers = {
         {text: ".IsPlain", displayText: "IsPlain() bool | 判断当前事件组中的事件是否是普通事件"},
         {text: ".EventType", displayText: "EventType() string | 判断当前事件组中的事件类型：recovery/plain/recoverable"},
         {text: ".FirstEvent", displayText: "FirstEvent() repository.Event | 从当前事件组中获取第一个事件"},
+        {text: '.MessageCountByMeta "KEY" "VALUE"', displayText: 'MessageCountByMeta(key, value string) int64 | 统计事件组中 Meta[KEY] 等于 VALUE 的事件数量'},
+        {text: ".FirstMessage", displayText: "FirstMessage() repository.Event | 获取事件组中最早的事件"},
+        {text: ".LastMessage", displayText: "LastMessage() repository.Event | 获取事件组中最新的事件"},
+        {text: ".Duration", displayText: "Duration() time.Duration | 事件组中最新事件与最早事件的时间差"},
         {text: '{{ }}', displayText: '{{ }} |  Golang 代码块'},
         {text: '{{ range $i, $msg := ARRAY }}\n {{ $i }} {{ $msg }} \n{{ end }}', displayText: '{{ range }}  | Golang 遍历对象'},
         {text: '{{ range $i, $msg := .Messages 4 }} {{ end }}', displayText: '{{ range $i, $msg := .Messages 4 }} {{ end }} | Golang 遍历 Messages，只取 4 条作为摘要'},
@@ -3,6 +3,7 @@ package action
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,6 +11,8 @@ import (
 	"github.com/mylxsw/adanos-alert/internal/queue"
 	"github.com/mylxsw/adanos-alert/internal/repository"
 	"github.com/mylxsw/adanos-alert/internal/template"
+	"github.com/mylxsw/adanos-alert/pkg/messager"
+	"github.com/mylxsw/adanos-alert/pkg/signature"
 	"github.com/mylxsw/adanos-alert/pubsub"
 	"github.com/mylxsw/asteria/log"
 	"github.com/mylxsw/container"
@@ -24,6 +27,55 @@ type Action interface {
 	Handle(rule repository.Rule, trigger repository.Trigger, grp repository.EventGroup) error
 }
 
+// TemplateOverrider 动作实现该接口时，可以替换元数据中配置的通知模板
+// 用于频率限制汇总等系统通知，避免动作使用元数据中的模板渲染原始的告警内容
+type TemplateOverrider interface {
+	// OverrideTemplate 返回使用 tmpl 替换通知模板之后的元数据
+	OverrideTemplate(meta string, tmpl string) (string, error)
+}
+
+// OverrideTemplate 使用 tmpl 替换触发动作元数据中的通知模板，动作没有实现 TemplateOverrider 时元数据保持不变
+func OverrideTemplate(manager Manager, trigger repository.Trigger, tmpl string) (repository.Trigger, error) {
+	overrider, ok := manager.Run(trigger.Action).(TemplateOverrider)
+	if !ok {
+		return trigger, nil
+	}
+
+	meta, err := overrider.OverrideTemplate(trigger.Meta, tmpl)
+	if err != nil {
+		return trigger, fmt.Errorf("override template for %s failed: %w", trigger.Action, err)
+	}
+
+	trigger.Meta = meta
+	return trigger, nil
+}
+
+// setMetaField 修改 JSON 格式的元数据中 path 指定的字段，其它字段保持不变
+func setMetaField(meta string, value string, path ...string) (string, error) {
+	data := make(map[string]interface{})
+	if strings.TrimSpace(meta) != "" {
+		if err := json.Unmarshal([]byte(meta), &data); err != nil {
+			return "", err
+		}
+	}
+
+	current := data
+	for _, key := range path[:len(path)-1] {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[key] = next
+		}
+
+		current = next
+	}
+
+	current[path[len(path)-1]] = value
+
+	res, err := json.Marshal(data)
+	return string(res), err
+}
+
 // Manager 动作管理器接口
 type Manager interface {
 	Resolve(f interface{}) error
@@ -66,11 +118,21 @@ func (manager *actionManager) Dispatch(action string) Action {
 }
 
 // Run execute a action
+// 没有注册同名动作时，从 messager 注册表中查找消息通道，新增通知通道无需修改动作分发逻辑
 func (manager *actionManager) Run(action string) Action {
 	manager.lock.RLock()
-	defer manager.lock.RUnlock()
+	act, ok := manager.actions[action]
+	manager.lock.RUnlock()
+
+	if ok {
+		return act
+	}
+
+	if m, ok := messager.Get(action); ok {
+		return NewMessagerAction(manager, m)
+	}
 
-	return manager.actions[action]
+	return nil
 }
 
 // Register register a new action
@@ -105,8 +167,15 @@ type Payload struct {
 	RuleTemplateParsed string                `json:"rule_template_parsed"`
 	PreviewURL         string                `json:"preview_url"`
 	ReportURL          string                `json:"report_url"`
+
+	// aggregateEvents 统计类模板方法使用的事件，首次使用时才会加载
+	aggregateEvents       []repository.Event
+	aggregateEventsLoaded bool
 }
 
+// aggregateEventsLimit 模板中统计类方法最多统计的事件数量
+const aggregateEventsLimit int64 = 1000
+
 // Init initialize a payload
 func (payload *Payload) Init(eventQuerier EventQuerier) {
 	payload.eventQuerier = eventQuerier
@@ -154,6 +223,62 @@ func (payload *Payload) FirstEvent() repository.Event {
 	return payload.Events(1)[0]
 }
 
+// loadAggregateEvents 加载用于统计的事件（按照时间倒序），只在首次调用时查询
+func (payload *Payload) loadAggregateEvents() []repository.Event {
+	if !payload.aggregateEventsLoaded {
+		payload.aggregateEvents = payload.Events(aggregateEventsLimit)
+		payload.aggregateEventsLoaded = true
+	}
+
+	return payload.aggregateEvents
+}
+
+// MessageCountByMeta 统计事件组中 meta[key] 等于 value 的事件数量
+func (payload *Payload) MessageCountByMeta(key, value string) int64 {
+	return payload.EventCountByMeta(key, value)
+}
+
+// EventCountByMeta 统计事件组中 meta[key] 等于 value 的事件数量，最多统计最近的 aggregateEventsLimit 个事件
+func (payload *Payload) EventCountByMeta(key, value string) int64 {
+	var count int64
+	for _, evt := range payload.loadAggregateEvents() {
+		if v, ok := evt.Meta[key]; ok && fmt.Sprintf("%v", v) == value {
+			count++
+		}
+	}
+
+	return count
+}
+
+// FirstMessage 返回事件组中最早的事件，事件组为空时返回空事件
+func (payload *Payload) FirstMessage() repository.Event {
+	var first repository.Event
+	for i, evt := range payload.loadAggregateEvents() {
+		if i == 0 || evt.CreatedAt.Before(first.CreatedAt) {
+			first = evt
+		}
+	}
+
+	return first
+}
+
+// LastMessage 返回事件组中最新的事件，事件组为空时返回空事件
+func (payload *Payload) LastMessage() repository.Event {
+	var last repository.Event
+	for i, evt := range payload.loadAggregateEvents() {
+		if i == 0 || evt.CreatedAt.After(last.CreatedAt) {
+			last = evt
+		}
+	}
+
+	return last
+}
+
+// Duration 返回事件组中最新事件与最早事件的时间差
+func (payload *Payload) Duration() time.Duration {
+	return payload.LastMessage().CreatedAt.Sub(payload.FirstMessage().CreatedAt)
+}
+
 // CreateRepositoryEventQuerier 创建仓库事件查询器
 func CreateRepositoryEventQuerier(msgRepo repository.EventRepo) func(groupID primitive.ObjectID, limit int64) []repository.Event {
 	return func(groupID primitive.ObjectID, limit int64) []repository.Event {
@@ -183,7 +308,11 @@ func (payload *Payload) Decode(data []byte) error {
 
 // Handle 动作处理
 func (q *QueueAction) Handle(rule repository.Rule, trigger repository.Trigger, grp repository.EventGroup) error {
-	return q.manager.Resolve(func(queueManager queue.Manager, em event.Manager) error {
+	return q.manager.Resolve(func(queueManager queue.Manager, em event.Manager, scheduleRepo repository.ScheduleRepo) error {
+		if !trigger.ScheduleID.IsZero() {
+			trigger.UserRefs = appendOnCallUser(scheduleRepo, trigger.ScheduleID, trigger.UserRefs)
+		}
+
 		payload := Payload{
 			Action:  q.action,
 			Trigger: trigger,
@@ -218,6 +347,26 @@ func (q *QueueAction) Handle(rule repository.Rule, trigger repository.Trigger, g
 	})
 }
 
+// appendOnCallUser 将值班表当前的值班人员添加到动作的通知人员中
+// 值班人员在动作加入队列时确定，避免队列重试时通知到换班之后的人员
+func appendOnCallUser(scheduleRepo repository.ScheduleRepo, scheduleID primitive.ObjectID, userRefs []primitive.ObjectID) []primitive.ObjectID {
+	userID, err := scheduleRepo.CurrentOnCall(scheduleID, time.Now())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"schedule_id": scheduleID.Hex(),
+		}).Warningf("resolve on-call user failed: %v", err)
+		return userRefs
+	}
+
+	for _, u := range userRefs {
+		if u == userID {
+			return userRefs
+		}
+	}
+
+	return append(append([]primitive.ObjectID{}, userRefs...), userID)
+}
+
 // CreatePayload 创建一个 Payload
 func CreatePayload(conf *configs.Config, eventQuerier EventQuerier, action string, rule repository.Rule, trigger repository.Trigger, grp repository.EventGroup) *Payload {
 	payload := &Payload{
@@ -229,23 +378,50 @@ func CreatePayload(conf *configs.Config, eventQuerier EventQuerier, action strin
 	payload.Init(eventQuerier)
 
 	if conf.PreviewURL != "" {
-		payload.PreviewURL = fmt.Sprintf(conf.PreviewURL, grp.ID.Hex())
+		payload.PreviewURL = signPreviewURL(conf, fmt.Sprintf(conf.PreviewURL, grp.ID.Hex()), grp.ID.Hex())
 	}
 	if conf.ReportURL != "" {
-		payload.ReportURL = fmt.Sprintf(conf.ReportURL, grp.ID.Hex())
+		payload.ReportURL = signPreviewURL(conf, fmt.Sprintf(conf.ReportURL, grp.ID.Hex()), grp.ID.Hex())
 	}
 
 	return payload
 }
 
+// signPreviewURL 配置了签名密钥时，为预览链接追加有效期和签名，接收者无需登录即可在有效期内访问
+func signPreviewURL(conf *configs.Config, previewURL string, groupID string) string {
+	if conf.PreviewSignSecret == "" {
+		return previewURL
+	}
+
+	signed, err := signature.SignURL(previewURL, conf.PreviewSignSecret, groupID, conf.PreviewSignTTL, time.Now())
+	if err != nil {
+		log.WithFields(log.Fields{
+			"url":      previewURL,
+			"group_id": groupID,
+		}).Errorf("sign preview url failed: %v", err)
+		return previewURL
+	}
+
+	return signed
+}
+
 // createPayloadAndSummary 创建 Payload 并且生成 summary
 func createPayloadAndSummary(cc template.SimpleContainer, actionName string, conf *configs.Config, evtRepo repository.EventRepo, rule repository.Rule, trigger repository.Trigger, grp repository.EventGroup) (*Payload, string) {
 	payload := CreatePayload(conf, CreateRepositoryEventQuerier(evtRepo), actionName, rule, trigger, grp)
-	payload.RuleTemplateParsed = parseTemplate(cc, rule.Template, payload)
+	payload.RuleTemplateParsed = parseTemplate(cc, RuleTemplate(rule, grp), payload)
 
 	return payload, payload.RuleTemplateParsed
 }
 
+// RuleTemplate 返回事件组使用的规则模板，恢复事件组优先使用规则的恢复通知模板
+func RuleTemplate(rule repository.Rule, grp repository.EventGroup) string {
+	if grp.Type == repository.EventTypeRecovery && strings.TrimSpace(rule.RecoveryTemplate) != "" {
+		return rule.RecoveryTemplate
+	}
+
+	return rule.Template
+}
+
 // parseTemplate 模板解释
 func parseTemplate(cc template.SimpleContainer, temp string, payload *Payload) string {
 	summary, err := template.Parse(cc, temp, payload)
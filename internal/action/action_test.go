@@ -0,0 +1,101 @@
+package action_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/configs"
+	"github.com/mylxsw/adanos-alert/internal/action"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/messager"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPayload_Aggregates(t *testing.T) {
+	now := time.Now()
+	events := []repository.Event{
+		{Meta: repository.EventMeta{"severity": "warning"}, CreatedAt: now},
+		{Meta: repository.EventMeta{"severity": "critical"}, CreatedAt: now.Add(-5 * time.Minute)},
+		{Meta: repository.EventMeta{"severity": "critical"}, CreatedAt: now.Add(-15 * time.Minute)},
+		{Meta: repository.EventMeta{}, CreatedAt: now.Add(-10 * time.Minute)},
+	}
+
+	var queried int
+	querier := func(groupID primitive.ObjectID, limit int64) []repository.Event {
+		queried++
+		return events
+	}
+
+	payload := action.CreatePayload(&configs.Config{}, querier, "dingding", repository.Rule{}, repository.Trigger{}, repository.EventGroup{})
+	assert.Equal(t, 0, queried)
+
+	assert.EqualValues(t, 2, payload.MessageCountByMeta("severity", "critical"))
+	assert.EqualValues(t, 1, payload.MessageCountByMeta("severity", "warning"))
+	assert.EqualValues(t, 0, payload.MessageCountByMeta("severity", "info"))
+	assert.Equal(t, now.Add(-15*time.Minute), payload.FirstMessage().CreatedAt)
+	assert.Equal(t, now, payload.LastMessage().CreatedAt)
+	assert.Equal(t, 15*time.Minute, payload.Duration())
+
+	// 统计事件只加载一次
+	assert.Equal(t, 1, queried)
+}
+
+func TestPayload_AggregatesEmptyGroup(t *testing.T) {
+	querier := func(groupID primitive.ObjectID, limit int64) []repository.Event {
+		return []repository.Event{}
+	}
+
+	payload := action.CreatePayload(&configs.Config{}, querier, "dingding", repository.Rule{}, repository.Trigger{}, repository.EventGroup{})
+	assert.EqualValues(t, 0, payload.MessageCountByMeta("severity", "critical"))
+	assert.True(t, payload.FirstMessage().CreatedAt.IsZero())
+	assert.Equal(t, time.Duration(0), payload.Duration())
+}
+
+func TestMessagersRegistered(t *testing.T) {
+	for _, name := range []string{"feishu", "opsgenie", "pagerduty", "slack", "teams", "telegram", "webhook", "wecom"} {
+		m, ok := messager.Get(name)
+		if assert.True(t, ok, name) {
+			assert.Equal(t, name, m.Name())
+		}
+	}
+
+	// 通道配置缺失时，保存规则时校验失败
+	slack, _ := messager.Get("slack")
+	act := action.NewMessagerAction(nil, slack)
+	assert.Error(t, act.Validate(`{"template":"{{ .Rule.Name }}"}`, nil))
+	assert.NoError(t, act.Validate(`{"template":"{{ .Rule.Name }}","webhook_url":"https://hooks.slack.com/services/x"}`, nil))
+}
+
+func TestOverrideTemplate(t *testing.T) {
+	manager := action.NewManager(nil)
+	manager.Register("http", action.NewHTTPAction(manager))
+	manager.Register("jira", action.NewJiraAction(manager))
+	manager.Register(action.UserChannelActionName, action.NewUserChannelAction(manager))
+
+	testcases := []struct {
+		action   string
+		meta     string
+		expected string
+	}{
+		{action: "http", meta: `{"url":"http://localhost","method":"POST","body":"{{ .Rule.Name }}"}`, expected: `{"url":"http://localhost","method":"POST","body":"summary"}`},
+		{action: "jira", meta: `{"issue":{"project_key":"OPS","description":"full"}}`, expected: `{"issue":{"project_key":"OPS","description":"summary"}}`},
+		{action: action.UserChannelActionName, meta: ``, expected: `{"template":"summary"}`},
+		{action: "slack", meta: `{"template":"full","webhook_url":"https://hooks.slack.com/services/x"}`, expected: `{"template":"summary","webhook_url":"https://hooks.slack.com/services/x"}`},
+	}
+
+	for _, tc := range testcases {
+		trigger, err := action.OverrideTemplate(manager, repository.Trigger{Action: tc.action, Meta: tc.meta}, "summary")
+		assert.NoError(t, err, tc.action)
+		assert.JSONEq(t, tc.expected, trigger.Meta, tc.action)
+	}
+
+	// 动作没有实现 TemplateOverrider 时元数据保持不变
+	manager.Register("email", action.NewEmailAction(manager))
+	trigger, err := action.OverrideTemplate(manager, repository.Trigger{Action: "email", Meta: `{"receivers":["a@example.com"]}`}, "summary")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"receivers":["a@example.com"]}`, trigger.Meta)
+
+	_, err = action.OverrideTemplate(manager, repository.Trigger{Action: "http", Meta: `{`}, "summary")
+	assert.Error(t, err)
+}
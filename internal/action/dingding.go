@@ -41,6 +41,11 @@ func (d DingdingAction) Validate(meta string, userRefs []string) error {
 	return nil
 }
 
+// OverrideTemplate 替换钉钉消息模板
+func (d DingdingAction) OverrideTemplate(meta string, tmpl string) (string, error) {
+	return setMetaField(meta, tmpl, "template")
+}
+
 // NewDingdingAction create a new dingdingAction
 func NewDingdingAction(manager Manager) *DingdingAction {
 	dingdingAction := DingdingAction{manager: manager}
@@ -1,9 +1,13 @@
 package action
 
 import (
+	"encoding/json"
+
 	"github.com/mylxsw/adanos-alert/configs"
 	"github.com/mylxsw/adanos-alert/internal/repository"
 	"github.com/mylxsw/asteria/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type EmailAction struct {
@@ -19,18 +23,52 @@ func NewEmailAction(manager Manager) *EmailAction {
 }
 
 func (e EmailAction) Handle(rule repository.Rule, trigger repository.Trigger, grp repository.EventGroup) error {
-	return e.manager.Resolve(func(conf *configs.Config) error {
+	return e.manager.Resolve(func(conf *configs.Config, userRepo repository.UserRepo) error {
+		var meta ReceiverMeta
+		_ = json.Unmarshal([]byte(trigger.Meta), &meta)
+
+		// 元数据中指定了接收地址时直接发送到这些地址，否则发送到关联用户的邮箱
+		receivers := meta.Receivers
+		if len(receivers) == 0 {
+			receivers = extractEmailsFromUserRefs(userRepo, trigger.UserRefs)
+		}
+
 		//client := email.NewClient(conf.EmailSMTP.Host, conf.EmailSMTP.Port, conf.EmailSMTP.Username, conf.EmailSMTP.Password)
-		//if err := client.Send(subject, body ,users...); err != nil {
+		//if err := client.Send(subject, body ,receivers...); err != nil {
 		//
 		//}
 
 		if log.DebugEnabled() {
 			log.WithFields(log.Fields{
-				"title":   rule.Name,
-			}).Debug("send message to dingding succeed")
+				"title":     rule.Name,
+				"receivers": receivers,
+			}).Debug("send message to email succeed")
 		}
 
 		return nil
 	})
 }
+
+func extractEmailsFromUserRefs(userRepo repository.UserRepo, userRefs []primitive.ObjectID) []string {
+	emails := make([]string, 0)
+	if len(userRefs) == 0 {
+		return emails
+	}
+
+	users, err := userRepo.Find(bson.M{"_id": bson.M{"$in": userRefs}})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"err":      err.Error(),
+			"userRefs": userRefs,
+		}).Errorf("load user from repo failed: %s", err)
+		return emails
+	}
+
+	for _, user := range users {
+		if user.Email != "" {
+			emails = append(emails, user.Email)
+		}
+	}
+
+	return emails
+}
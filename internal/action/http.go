@@ -52,6 +52,11 @@ func NewHTTPAction(manager Manager) *HTTPAction {
 	return &HTTPAction{manager: manager}
 }
 
+// OverrideTemplate 替换请求体模板
+func (act HTTPAction) OverrideTemplate(meta string, tmpl string) (string, error) {
+	return setMetaField(meta, tmpl, "body")
+}
+
 // Handle 动作处理
 func (act HTTPAction) Handle(rule repository.Rule, trigger repository.Trigger, grp repository.EventGroup) error {
 	var meta HTTPMeta
@@ -60,6 +60,11 @@ func NewJiraAction(manager Manager) *JiraAction {
 	return &JiraAction{manager: manager}
 }
 
+// OverrideTemplate 替换 Issue 描述的模板
+func (act JiraAction) OverrideTemplate(meta string, tmpl string) (string, error) {
+	return setMetaField(meta, tmpl, "issue", "description")
+}
+
 // JiraMeta Jira 动作元数据
 type JiraMeta struct {
 	Issue       jira.Issue         `json:"issue"`
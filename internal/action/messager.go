@@ -0,0 +1,89 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/configs"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/messager"
+
+	// 消息通道在 init 函数中注册到 messager 注册表
+	_ "github.com/mylxsw/adanos-alert/pkg/messager/feishu"
+	_ "github.com/mylxsw/adanos-alert/pkg/messager/opsgenie"
+	_ "github.com/mylxsw/adanos-alert/pkg/messager/pagerduty"
+	_ "github.com/mylxsw/adanos-alert/pkg/messager/slack"
+	_ "github.com/mylxsw/adanos-alert/pkg/messager/teams"
+	_ "github.com/mylxsw/adanos-alert/pkg/messager/telegram"
+	_ "github.com/mylxsw/adanos-alert/pkg/messager/webhook"
+	_ "github.com/mylxsw/adanos-alert/pkg/messager/wecom"
+)
+
+// messagerSendTimeout 通过消息通道发送通知的超时时间
+const messagerSendTimeout = 10 * time.Second
+
+// MessagerAction 将注册到 messager 注册表中的消息通道适配为触发动作
+type MessagerAction struct {
+	manager  Manager
+	messager messager.Messager
+}
+
+// MessagerMeta 消息通道动作元数据
+type MessagerMeta struct {
+	Template string `json:"template"`
+}
+
+// NewMessagerAction create a new MessagerAction
+func NewMessagerAction(manager Manager, m messager.Messager) *MessagerAction {
+	return &MessagerAction{manager: manager, messager: m}
+}
+
+// Validate 校验动作参数，消息通道实现了 messager.Validator 时，同时校验通道的配置
+func (act MessagerAction) Validate(meta string, userRefs []string) error {
+	if strings.TrimSpace(meta) != "" {
+		var messagerMeta MessagerMeta
+		if err := json.Unmarshal([]byte(meta), &messagerMeta); err != nil {
+			return err
+		}
+	}
+
+	if validator, ok := act.messager.(messager.Validator); ok {
+		return validator.Validate(meta)
+	}
+
+	return nil
+}
+
+// OverrideTemplate 替换消息通道的消息模板
+func (act MessagerAction) OverrideTemplate(meta string, tmpl string) (string, error) {
+	return setMetaField(meta, tmpl, "template")
+}
+
+// Handle 使用规则模板渲染事件组之后，通过消息通道发送
+func (act MessagerAction) Handle(rule repository.Rule, trigger repository.Trigger, grp repository.EventGroup) error {
+	var meta MessagerMeta
+	if strings.TrimSpace(trigger.Meta) != "" {
+		if err := json.Unmarshal([]byte(trigger.Meta), &meta); err != nil {
+			return fmt.Errorf("parse %s meta failed: %w", act.messager.Name(), err)
+		}
+	}
+
+	return act.manager.Resolve(func(conf *configs.Config, evtRepo repository.EventRepo) error {
+		payload, rendered := createPayloadAndSummary(act.manager, act.messager.Name(), conf, evtRepo, rule, trigger, grp)
+		if strings.TrimSpace(meta.Template) != "" {
+			rendered = parseTemplate(act.manager, meta.Template, payload)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), messagerSendTimeout)
+		defer cancel()
+
+		if err := act.messager.Send(ctx, trigger.Meta, grp, rendered); err != nil {
+			return fmt.Errorf("send to %s failed: %w", act.messager.Name(), err)
+		}
+
+		return nil
+	})
+}
@@ -29,6 +29,8 @@ func NewPhoneCallAliyunAction(manager Manager) *AliyunVoiceCallAction {
 
 type VoiceCallMeta struct {
 	Title string `json:"title"`
+	// Receivers 接收通知的手机号，不为空时不再使用 UserRefs 中用户的手机号
+	Receivers []string `json:"receivers"`
 }
 
 func (w AliyunVoiceCallAction) Handle(rule repository.Rule, trigger repository.Trigger, grp repository.EventGroup) error {
@@ -49,7 +51,10 @@ func (w AliyunVoiceCallAction) Handle(rule repository.Rule, trigger repository.T
 			title = rule.Name
 		}
 
-		mobiles := extractPhonesFromUserRefs(userRepo, trigger.UserRefs)
+		mobiles := meta.Receivers
+		if len(mobiles) == 0 {
+			mobiles = extractPhonesFromUserRefs(userRepo, trigger.UserRefs)
+		}
 		if err := voiceCall.Send(title, mobiles); err != nil {
 			log.WithFields(log.Fields{
 				"title":   title,
@@ -16,7 +16,7 @@ func (s ServiceProvider) Register(app container.Container) {
 }
 
 func (s ServiceProvider) Boot(app infra.Glacier) {
-	app.MustResolve(func(manager Manager, queueManager queue.Manager) {
+	app.MustResolve(func(manager Manager, queueManager queue.Manager, failedActionRepo repository.FailedActionRepo) {
 		manager.Register("http", NewHTTPAction(manager))
 		manager.Register("dingding", NewDingdingAction(manager))
 		manager.Register("email", NewEmailAction(manager))
@@ -25,6 +25,7 @@ func (s ServiceProvider) Boot(app infra.Glacier) {
 		manager.Register("sms_aliyun", NewSmsAliyunAction(manager))
 		manager.Register("sms_yunxin", NewSmsYunxinAction(manager))
 		manager.Register("jira", NewJiraAction(manager))
+		manager.Register(UserChannelActionName, NewUserChannelAction(manager))
 
 		queueManager.RegisterHandler("action", func(item repository.QueueJob) error {
 			var payload Payload
@@ -38,5 +39,29 @@ func (s ServiceProvider) Boot(app infra.Glacier) {
 
 			return manager.Run(payload.Action).Handle(payload.Rule, payload.Trigger, payload.Group)
 		})
+
+		// 超过最大重试次数仍然失败的动作保存为死信，可以人工重新执行
+		queueManager.RegisterFailedHandler("action", func(item repository.QueueJob) {
+			fa := repository.FailedAction{
+				QueueJobID: item.ID,
+				Payload:    item.Payload,
+				Attempts:   item.RequeueTimes + 1,
+				LastError:  item.LastError,
+			}
+
+			var payload Payload
+			if err := payload.Decode([]byte(item.Payload)); err == nil {
+				fa.Action = payload.Action
+				fa.RuleID = payload.Rule.ID
+				fa.GroupID = payload.Group.ID
+				fa.TriggerID = payload.Trigger.ID
+			}
+
+			if _, err := failedActionRepo.Add(fa); err != nil {
+				log.WithFields(log.Fields{
+					"item": item,
+				}).Errorf("save failed action failed: %v", err)
+			}
+		})
 	})
 }
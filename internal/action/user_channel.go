@@ -0,0 +1,147 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/asteria/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserChannelActionName 按照用户通知渠道偏好发送通知的动作名称
+const UserChannelActionName = "user_channel"
+
+// UserChannelAction 按照用户设置的通知渠道发送通知，而不是使用规则中指定的渠道
+type UserChannelAction struct {
+	manager Manager
+}
+
+// UserChannelMeta 用户通知渠道动作元数据
+type UserChannelMeta struct {
+	Template string `json:"template"`
+}
+
+// ReceiverMeta 邮件、短信、语音通知等动作的元数据，Receivers 不为空时直接发送到这些地址，不再使用 UserRefs 中用户的联系方式
+type ReceiverMeta struct {
+	Template  string   `json:"template"`
+	Receivers []string `json:"receivers"`
+}
+
+// receiverChannels 支持在用户通知渠道中指定接收地址（邮箱、手机号）的渠道
+var receiverChannels = map[string]bool{
+	"email":             true,
+	"sms_aliyun":        true,
+	"sms_yunxin":        true,
+	"phone_call_aliyun": true,
+}
+
+// NewUserChannelAction create a new UserChannelAction
+func NewUserChannelAction(manager Manager) *UserChannelAction {
+	return &UserChannelAction{manager: manager}
+}
+
+// Validate 校验动作参数
+func (u UserChannelAction) Validate(meta string, userRefs []string) error {
+	if strings.TrimSpace(meta) != "" {
+		var userChannelMeta UserChannelMeta
+		if err := json.Unmarshal([]byte(meta), &userChannelMeta); err != nil {
+			return err
+		}
+	}
+
+	if len(userRefs) == 0 {
+		return errors.New("users required")
+	}
+
+	return nil
+}
+
+// OverrideTemplate 替换发送到用户通知渠道的消息模板
+func (u UserChannelAction) OverrideTemplate(meta string, tmpl string) (string, error) {
+	return setMetaField(meta, tmpl, "template")
+}
+
+// Handle 查询每个用户接收当前告警级别的通知渠道，依次通过对应的动作发送
+func (u UserChannelAction) Handle(rule repository.Rule, trigger repository.Trigger, grp repository.EventGroup) error {
+	var meta UserChannelMeta
+	if strings.TrimSpace(trigger.Meta) != "" {
+		if err := json.Unmarshal([]byte(trigger.Meta), &meta); err != nil {
+			return fmt.Errorf("parse user channel meta failed: %w", err)
+		}
+	}
+
+	return u.manager.Resolve(func(userRepo repository.UserRepo, evtRepo repository.EventRepo) error {
+		severity := groupSeverity(evtRepo, grp)
+
+		failed := make([]string, 0)
+		for _, userID := range trigger.UserRefs {
+			channels, err := userRepo.ResolveChannels(userID, severity)
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("user %s: %v", userID.Hex(), err))
+				continue
+			}
+
+			if len(channels) == 0 {
+				log.WithFields(log.Fields{
+					"user_id":  userID.Hex(),
+					"severity": severity,
+				}).Warningf("no notify channel for user")
+				continue
+			}
+
+			for _, channel := range channels {
+				if err := u.send(rule, trigger, grp, userID, channel, meta); err != nil {
+					failed = append(failed, fmt.Sprintf("user %s, channel %s: %v", userID.Hex(), channel.Type, err))
+				}
+			}
+		}
+
+		if len(failed) > 0 {
+			return fmt.Errorf("send to user channels failed: %s", strings.Join(failed, "; "))
+		}
+
+		return nil
+	})
+}
+
+// send 通过用户的通知渠道发送通知
+func (u UserChannelAction) send(rule repository.Rule, trigger repository.Trigger, grp repository.EventGroup, userID primitive.ObjectID, channel repository.NotifyChannel, meta UserChannelMeta) error {
+	act := u.manager.Run(channel.Type)
+	if act == nil || channel.Type == UserChannelActionName {
+		return fmt.Errorf("unsupported channel: %s", channel.Type)
+	}
+
+	trigger.Action = channel.Type
+	trigger.UserRefs = []primitive.ObjectID{userID}
+
+	// 钉钉渠道的地址为机器人 ID，邮件、短信等渠道的地址为接收地址，没有指定地址时使用用户自身的联系方式
+	if channel.Type == "dingding" {
+		data, _ := json.Marshal(DingdingMeta{Template: meta.Template, RobotID: channel.Address})
+		trigger.Meta = string(data)
+	} else if receiverChannels[channel.Type] && channel.Address != "" {
+		data, _ := json.Marshal(ReceiverMeta{Template: meta.Template, Receivers: []string{channel.Address}})
+		trigger.Meta = string(data)
+		trigger.UserRefs = nil
+	}
+
+	return act.Handle(rule, trigger, grp)
+}
+
+// groupSeverity 返回事件组的告警级别，使用事件组中第一个事件的 severity 元数据
+func groupSeverity(evtRepo repository.EventRepo, grp repository.EventGroup) string {
+	events, _, err := evtRepo.Paginate(bson.M{"group_ids": grp.ID}, 0, 1)
+	if err != nil || len(events) == 0 {
+		return ""
+	}
+
+	severity, ok := events[0].Meta["severity"]
+	if !ok || severity == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%v", severity)
+}
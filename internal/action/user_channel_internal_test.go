@@ -0,0 +1,51 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// userChannelTestAction 记录动作执行时的 Trigger
+type userChannelTestAction struct {
+	triggers []repository.Trigger
+}
+
+func (a *userChannelTestAction) Validate(meta string, userRefs []string) error { return nil }
+
+func (a *userChannelTestAction) Handle(rule repository.Rule, trigger repository.Trigger, grp repository.EventGroup) error {
+	a.triggers = append(a.triggers, trigger)
+	return nil
+}
+
+type userChannelTestManager struct {
+	Manager
+	act *userChannelTestAction
+}
+
+func (m userChannelTestManager) Run(name string) Action { return m.act }
+
+func TestUserChannelAction_send(t *testing.T) {
+	act := &userChannelTestAction{}
+	u := NewUserChannelAction(userChannelTestManager{act: act})
+	userID := primitive.NewObjectID()
+	meta := UserChannelMeta{Template: "{{ .Rule.Name }}"}
+
+	// 指定了接收地址时发送到该地址
+	assert.NoError(t, u.send(repository.Rule{}, repository.Trigger{}, repository.EventGroup{}, userID, repository.NotifyChannel{Type: "email", Address: "oncall@example.com"}, meta))
+	assert.Equal(t, "email", act.triggers[0].Action)
+	assert.Empty(t, act.triggers[0].UserRefs)
+	assert.JSONEq(t, `{"template":"{{ .Rule.Name }}","receivers":["oncall@example.com"]}`, act.triggers[0].Meta)
+
+	assert.NoError(t, u.send(repository.Rule{}, repository.Trigger{}, repository.EventGroup{}, userID, repository.NotifyChannel{Type: "sms_aliyun", Address: "13800000000"}, meta))
+	assert.JSONEq(t, `{"template":"{{ .Rule.Name }}","receivers":["13800000000"]}`, act.triggers[1].Meta)
+
+	// 没有指定接收地址时使用用户自身的联系方式
+	assert.NoError(t, u.send(repository.Rule{}, repository.Trigger{}, repository.EventGroup{}, userID, repository.NotifyChannel{Type: "email"}, meta))
+	assert.Equal(t, []primitive.ObjectID{userID}, act.triggers[2].UserRefs)
+	assert.Empty(t, act.triggers[2].Meta)
+
+	assert.Error(t, u.send(repository.Rule{}, repository.Trigger{}, repository.EventGroup{}, userID, repository.NotifyChannel{Type: UserChannelActionName}, meta))
+}
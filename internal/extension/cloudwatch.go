@@ -0,0 +1,171 @@
+package extension
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+)
+
+// SNSMessage AWS SNS 推送消息的信封
+type SNSMessage struct {
+	Type         string `json:"Type"`
+	MessageID    string `json:"MessageId"`
+	TopicArn     string `json:"TopicArn"`
+	Subject      string `json:"Subject"`
+	Message      string `json:"Message"`
+	Timestamp    string `json:"Timestamp"`
+	SubscribeURL string `json:"SubscribeURL"`
+}
+
+const (
+	snsTypeNotification             = "Notification"
+	snsTypeSubscriptionConfirmation = "SubscriptionConfirmation"
+)
+
+// SNSSubscriptionConfirmation 收到 SNS 订阅确认请求时返回该错误，调用方可以通过 Confirm 方法确认订阅
+type SNSSubscriptionConfirmation struct {
+	TopicArn     string
+	SubscribeURL string
+}
+
+func (s *SNSSubscriptionConfirmation) Error() string {
+	return fmt.Sprintf("sns subscription confirmation required for topic %s", s.TopicArn)
+}
+
+// Confirm 访问 SubscribeURL 确认 SNS 订阅，只允许访问 amazonaws.com 域名下的 https 地址
+func (s *SNSSubscriptionConfirmation) Confirm(ctx context.Context) error {
+	u, err := url.Parse(s.SubscribeURL)
+	if err != nil {
+		return fmt.Errorf("invalid subscribe url: %w", err)
+	}
+
+	if u.Scheme != "https" || !strings.HasSuffix(u.Hostname(), ".amazonaws.com") {
+		return fmt.Errorf("untrusted subscribe url: %s", s.SubscribeURL)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, s.SubscribeURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("confirm sns subscription failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("confirm sns subscription failed: unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// CloudWatchAlarm AWS CloudWatch 告警消息
+type CloudWatchAlarm struct {
+	AlarmName        string                 `json:"AlarmName"`
+	AlarmDescription string                 `json:"AlarmDescription"`
+	AWSAccountID     string                 `json:"AWSAccountId"`
+	NewStateValue    string                 `json:"NewStateValue"`
+	NewStateReason   string                 `json:"NewStateReason"`
+	StateChangeTime  string                 `json:"StateChangeTime"`
+	Region           string                 `json:"Region"`
+	OldStateValue    string                 `json:"OldStateValue"`
+	Trigger          CloudWatchAlarmTrigger `json:"Trigger"`
+}
+
+// CloudWatchAlarmTrigger CloudWatch 告警触发条件
+type CloudWatchAlarmTrigger struct {
+	MetricName         string                `json:"MetricName"`
+	Namespace          string                `json:"Namespace"`
+	Statistic          string                `json:"Statistic"`
+	ComparisonOperator string                `json:"ComparisonOperator"`
+	Threshold          float64               `json:"Threshold"`
+	Period             int                   `json:"Period"`
+	EvaluationPeriods  int                   `json:"EvaluationPeriods"`
+	Dimensions         []CloudWatchDimension `json:"Dimensions"`
+}
+
+// CloudWatchDimension CloudWatch 指标维度
+type CloudWatchDimension struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// cloudWatchStatus 将 CloudWatch 告警状态转换为事件状态
+func cloudWatchStatus(state string) string {
+	switch strings.ToUpper(state) {
+	case "ALARM":
+		return "firing"
+	case "OK":
+		return "resolved"
+	default:
+		return "insufficient_data"
+	}
+}
+
+// CloudWatchToCommonEvent 解析通过 SNS 推送的 CloudWatch 告警消息
+// 请求为 SNS 订阅确认时，返回 *SNSSubscriptionConfirmation 错误
+func CloudWatchToCommonEvent(content []byte) (*CommonEvent, error) {
+	var snsMessage SNSMessage
+	if err := json.Unmarshal(content, &snsMessage); err != nil {
+		return nil, errors.New("invalid request")
+	}
+
+	alarmContent := content
+	switch snsMessage.Type {
+	case snsTypeSubscriptionConfirmation:
+		return nil, &SNSSubscriptionConfirmation{TopicArn: snsMessage.TopicArn, SubscribeURL: snsMessage.SubscribeURL}
+	case snsTypeNotification:
+		alarmContent = []byte(snsMessage.Message)
+	case "":
+		// 没有 SNS 信封，直接是 CloudWatch 告警消息
+	default:
+		return nil, fmt.Errorf("unsupported sns message type: %s", snsMessage.Type)
+	}
+
+	var alarm CloudWatchAlarm
+	if err := json.Unmarshal(alarmContent, &alarm); err != nil || alarm.AlarmName == "" {
+		return nil, errors.New("invalid request: not a cloudwatch alarm")
+	}
+
+	meta := repository.EventMeta{
+		"alarm_name":   alarm.AlarmName,
+		"state":        alarm.NewStateValue,
+		"old_state":    alarm.OldStateValue,
+		"status":       cloudWatchStatus(alarm.NewStateValue),
+		"reason":       alarm.NewStateReason,
+		"region":       alarm.Region,
+		"account_id":   alarm.AWSAccountID,
+		"metric_name":  alarm.Trigger.MetricName,
+		"namespace":    alarm.Trigger.Namespace,
+		"state_change": alarm.StateChangeTime,
+	}
+	if snsMessage.TopicArn != "" {
+		meta["topic_arn"] = snsMessage.TopicArn
+	}
+	for _, dim := range alarm.Trigger.Dimensions {
+		meta["dimension_"+dim.Name] = dim.Value
+	}
+	setSeverity(meta, "cloudwatch", alarm.NewStateValue)
+
+	return &CommonEvent{
+		Content: string(alarmContent),
+		Meta:    meta,
+		Tags:    []string{"cloudwatch", strings.ToLower(alarm.NewStateValue)},
+		Origin:  "cloudwatch",
+	}, nil
+}
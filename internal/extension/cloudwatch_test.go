@@ -0,0 +1,55 @@
+package extension_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/internal/extension"
+	"github.com/stretchr/testify/assert"
+)
+
+var cloudWatchAlarm = `{"AlarmName":"high-cpu","AlarmDescription":"CPU too high","AWSAccountId":"123456789012","NewStateValue":"ALARM","NewStateReason":"Threshold Crossed","StateChangeTime":"2020-11-10T08:00:00.000+0000","Region":"US East (N. Virginia)","OldStateValue":"OK","Trigger":{"MetricName":"CPUUtilization","Namespace":"AWS/EC2","Statistic":"AVERAGE","ComparisonOperator":"GreaterThanThreshold","Threshold":80.0,"Period":300,"EvaluationPeriods":1,"Dimensions":[{"value":"i-0123456789","name":"InstanceId"}]}}`
+
+func TestCloudWatchToCommonEvent(t *testing.T) {
+	envelope, _ := json.Marshal(extension.SNSMessage{
+		Type:     "Notification",
+		TopicArn: "arn:aws:sns:us-east-1:123456789012:alarms",
+		Message:  cloudWatchAlarm,
+	})
+
+	evt, err := extension.CloudWatchToCommonEvent(envelope)
+	assert.NoError(t, err)
+	assert.Equal(t, "cloudwatch", evt.Origin)
+	assert.Equal(t, cloudWatchAlarm, evt.Content)
+	assert.Equal(t, "high-cpu", evt.Meta["alarm_name"])
+	assert.Equal(t, "firing", evt.Meta["status"])
+	assert.Equal(t, "i-0123456789", evt.Meta["dimension_InstanceId"])
+	assert.Equal(t, "arn:aws:sns:us-east-1:123456789012:alarms", evt.Meta["topic_arn"])
+	assert.Contains(t, evt.Tags, "alarm")
+
+	// 不包含 SNS 信封的告警消息
+	evt, err = extension.CloudWatchToCommonEvent([]byte(cloudWatchAlarm))
+	assert.NoError(t, err)
+	assert.Equal(t, "CPUUtilization", evt.Meta["metric_name"])
+
+	_, err = extension.CloudWatchToCommonEvent([]byte(`{"foo": "bar"}`))
+	assert.Error(t, err)
+}
+
+func TestCloudWatchToCommonEvent_SubscriptionConfirmation(t *testing.T) {
+	envelope, _ := json.Marshal(extension.SNSMessage{
+		Type:         "SubscriptionConfirmation",
+		TopicArn:     "arn:aws:sns:us-east-1:123456789012:alarms",
+		SubscribeURL: "http://example.com/confirm",
+	})
+
+	_, err := extension.CloudWatchToCommonEvent(envelope)
+
+	var confirmation *extension.SNSSubscriptionConfirmation
+	assert.True(t, errors.As(err, &confirmation))
+	assert.Equal(t, "http://example.com/confirm", confirmation.SubscribeURL)
+	// 非 amazonaws.com 域名的确认地址会被拒绝
+	assert.Error(t, confirmation.Confirm(context.Background()))
+}
@@ -93,9 +93,12 @@ func LogstashToCommonEvent(content []byte, contentField string) (*CommonEvent, e
 		evt = "None"
 	}
 
+	meta = logstashMetaFilter(meta)
+	setSeverity(meta, "logstash", metaValue(meta, "severity", "level", "log.level", "fields.level"))
+
 	return &CommonEvent{
 		Content: fmt.Sprintf("%v", evt),
-		Meta:    logstashMetaFilter(meta),
+		Meta:    meta,
 		Tags:    nil,
 		Origin:  "logstash",
 	}, nil
@@ -169,6 +172,8 @@ func GrafanaToCommonEvent(content []byte) (*CommonEvent, error) {
 	}
 
 	repoMessage := grafanaMessage.ToRepo()
+	setSeverity(repoMessage.Meta, "grafana", grafanaMessage.State)
+
 	return &CommonEvent{
 		Content: repoMessage.Content,
 		Meta:    repoMessage.Meta,
@@ -232,9 +237,16 @@ func PrometheusToCommonEvents(content []byte) ([]*CommonEvent, error) {
 	commonMessages := make([]*CommonEvent, 0)
 	for _, pm := range prometheusMessages {
 		repoMessage := pm.CreateRepoEvent()
+
+		meta := make(repository.EventMeta)
+		for k, v := range repoMessage.Meta {
+			meta[k] = v
+		}
+		setSeverity(meta, "prometheus", metaValue(pm.Labels, "severity"))
+
 		commonMessages = append(commonMessages, &CommonEvent{
 			Content: repoMessage.Content,
-			Meta:    repoMessage.Meta,
+			Meta:    meta,
 			Tags:    repoMessage.Tags,
 			Origin:  repoMessage.Origin,
 			Control: pm.GetControl(),
@@ -287,6 +299,8 @@ func PrometheusAlertToCommonEvent(content []byte) (*CommonEvent, error) {
 	}
 
 	repoMessage := prometheusMessage.ToRepo()
+	setSeverity(repoMessage.Meta, "prometheus", metaValue(prometheusMessage.CommonLabels, "severity"))
+
 	return &CommonEvent{
 		Content: repoMessage.Content,
 		Meta:    repoMessage.Meta,
@@ -295,6 +309,43 @@ func PrometheusAlertToCommonEvent(content []byte) (*CommonEvent, error) {
 	}, nil
 }
 
+// AlertmanagerV2ToCommonEvents 解析 Alertmanager webhook 推送的告警，与 PrometheusAlertToCommonEvent 不同，
+// 每一个告警都会创建为一个独立的事件，由 adanos 自己的规则重新分组，Alertmanager 的分组标签以 group_ 前缀保存在 meta 中
+func AlertmanagerV2ToCommonEvents(content []byte) ([]*CommonEvent, error) {
+	var alertMessage PrometheusAlertEvent
+	if err := json.Unmarshal(content, &alertMessage); err != nil {
+		return nil, errors.New("invalid request")
+	}
+
+	commonMessages := make([]*CommonEvent, 0, len(alertMessage.Alerts))
+	for _, alert := range alertMessage.Alerts {
+		meta := make(repository.EventMeta)
+		for k, v := range alert.Labels {
+			meta[k] = v
+		}
+
+		for k, v := range alertMessage.GroupLabels {
+			meta["group_"+k] = v
+		}
+
+		meta["status"] = misc.IfElse(alert.Status != "", alert.Status, alertMessage.Status)
+		meta["receiver"] = alertMessage.Receiver
+		meta["group_key"] = alertMessage.GroupKey
+		setSeverity(meta, "prometheus", metaValue(alert.Labels, "severity"))
+
+		repoMessage := alert.CreateRepoEvent()
+		commonMessages = append(commonMessages, &CommonEvent{
+			Content: repoMessage.Content,
+			Meta:    meta,
+			Tags:    repoMessage.Tags,
+			Origin:  "alertmanager",
+			Control: alert.GetControl(),
+		})
+	}
+
+	return commonMessages, nil
+}
+
 func OpenFalconToCommonEvent(tos, content string) *CommonEvent {
 	meta := make(repository.EventMeta)
 	im := template.ParseOpenFalconImMessage(content)
@@ -304,6 +355,8 @@ func OpenFalconToCommonEvent(tos, content string) *CommonEvent {
 	meta["current_step"] = strconv.Itoa(im.CurrentStep)
 	meta["body"] = im.Body
 	meta["format_time"] = im.FormatTime
+	// 消息解析失败时 Priority 为 0，不能作为 P0 处理
+	setSeverity(meta, "openfalcon", misc.IfElse(im.Status != "", fmt.Sprintf("P%d", im.Priority), "").(string))
 
 	return &CommonEvent{
 		Content: content,
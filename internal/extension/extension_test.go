@@ -0,0 +1,37 @@
+package extension_test
+
+import (
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/internal/extension"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertmanagerV2ToCommonEvents(t *testing.T) {
+	payload := `{
+		"version": "4", "groupKey": "{}:{alertname=\"HighLatency\"}", "receiver": "adanos", "status": "firing",
+		"groupLabels": {"alertname": "HighLatency"},
+		"commonLabels": {"alertname": "HighLatency", "severity": "warning"},
+		"alerts": [
+			{"status": "firing", "labels": {"alertname": "HighLatency", "severity": "warning", "instance": "web-01"}, "annotations": {"summary": "latency is high"}},
+			{"status": "resolved", "labels": {"alertname": "HighLatency", "severity": "warning", "instance": "web-02", "adanos_id": "web-02", "adanos_recovery_after": "5m"}}
+		]
+	}`
+
+	events, err := extension.AlertmanagerV2ToCommonEvents([]byte(payload))
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	assert.Equal(t, "web-01", events[0].Meta["instance"])
+	assert.Equal(t, "HighLatency", events[0].Meta["group_alertname"])
+	assert.Equal(t, "firing", events[0].Meta["status"])
+	assert.Equal(t, "adanos", events[0].Meta["receiver"])
+	assert.Contains(t, events[0].Content, "latency is high")
+
+	assert.Equal(t, "web-02", events[1].Meta["instance"])
+	assert.Equal(t, "resolved", events[1].Meta["status"])
+	assert.Equal(t, "web-02", events[1].Control.ID)
+
+	_, err = extension.AlertmanagerV2ToCommonEvents([]byte(`not json`))
+	assert.Error(t, err)
+}
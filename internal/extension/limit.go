@@ -0,0 +1,79 @@
+package extension
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// ErrEventTooLarge 事件超过了推送接口的限制
+var ErrEventTooLarge = errors.New("event too large")
+
+// IngestLimits 推送事件的限制，值为 0 时不限制
+type IngestLimits struct {
+	// MaxEvents 一次请求最多包含的事件数量
+	MaxEvents   int
+	MaxMetaKeys int
+	MaxTags     int
+}
+
+// ValidateCount 检查一次请求中的事件数量是否超过限制，超过时返回的错误包含 ErrEventTooLarge
+func (l IngestLimits) ValidateCount(count int) error {
+	if l.MaxEvents > 0 && count > l.MaxEvents {
+		return fmt.Errorf("%w: too many events: %d exceed the limit %d", ErrEventTooLarge, count, l.MaxEvents)
+	}
+
+	return nil
+}
+
+// Validate 检查事件的 Meta 字段数量和标签数量是否超过限制，超过时返回的错误包含 ErrEventTooLarge
+func (l IngestLimits) Validate(evt CommonEvent) error {
+	if l.MaxMetaKeys > 0 && len(evt.Meta) > l.MaxMetaKeys {
+		return fmt.Errorf("%w: meta keys %d exceed the limit %d", ErrEventTooLarge, len(evt.Meta), l.MaxMetaKeys)
+	}
+
+	if l.MaxTags > 0 && len(evt.Tags) > l.MaxTags {
+		return fmt.Errorf("%w: tags %d exceed the limit %d", ErrEventTooLarge, len(evt.Tags), l.MaxTags)
+	}
+
+	return nil
+}
+
+// IngestBodyLimiter 限制推送事件接口（路由名称以 events:add: 开头）的请求体大小，超过 maxSize 字节时返回 413
+// 请求体使用 gzip 压缩时，需要在解压之后执行，这样限制的是解压之后的大小，避免压缩炸弹
+func IngestBodyLimiter(maxSize int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := mux.CurrentRoute(r)
+			if maxSize <= 0 || route == nil || !strings.HasPrefix(route.GetName(), "events:add:") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > maxSize {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxSize+1))
+			if err != nil {
+				http.Error(w, "read request body failed", http.StatusBadRequest)
+				return
+			}
+
+			if int64(len(body)) > maxSize {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
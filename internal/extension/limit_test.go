@@ -0,0 +1,73 @@
+package extension_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mylxsw/adanos-alert/internal/extension"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIngestLimits_Validate(t *testing.T) {
+	limits := extension.IngestLimits{MaxMetaKeys: 2, MaxTags: 1}
+
+	assert.NoError(t, limits.Validate(extension.CommonEvent{
+		Meta: repository.EventMeta{"a": 1, "b": 2},
+		Tags: []string{"x"},
+	}))
+
+	err := limits.Validate(extension.CommonEvent{Meta: repository.EventMeta{"a": 1, "b": 2, "c": 3}})
+	assert.True(t, errors.Is(err, extension.ErrEventTooLarge))
+
+	err = limits.Validate(extension.CommonEvent{Tags: []string{"x", "y"}})
+	assert.True(t, errors.Is(err, extension.ErrEventTooLarge))
+
+	assert.NoError(t, extension.IngestLimits{}.Validate(extension.CommonEvent{Tags: []string{"x", "y"}}))
+}
+
+func TestIngestLimits_ValidateCount(t *testing.T) {
+	limits := extension.IngestLimits{MaxEvents: 2}
+
+	assert.NoError(t, limits.ValidateCount(2))
+	assert.True(t, errors.Is(limits.ValidateCount(3), extension.ErrEventTooLarge))
+	assert.NoError(t, extension.IngestLimits{}.ValidateCount(1000))
+}
+
+func TestIngestBodyLimiter(t *testing.T) {
+	router := mux.NewRouter()
+	router.Use(extension.IngestBodyLimiter(8))
+	router.HandleFunc("/events/", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}).Name("events:add:common")
+	router.HandleFunc("/rules/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}).Name("rules:add")
+
+	testcases := []struct {
+		path   string
+		body   string
+		status int
+	}{
+		{path: "/events/", body: "12345678", status: http.StatusOK},
+		{path: "/events/", body: "123456789", status: http.StatusRequestEntityTooLarge},
+		{path: "/rules/", body: "123456789", status: http.StatusOK},
+	}
+
+	for _, tc := range testcases {
+		// 不设置 Content-Length，模拟分块传输的请求
+		req := httptest.NewRequest(http.MethodPost, tc.path, ioutil.NopCloser(strings.NewReader(tc.body)))
+		req.ContentLength = -1
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, tc.status, recorder.Code, tc.path+" "+tc.body)
+	}
+}
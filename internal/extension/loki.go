@@ -0,0 +1,289 @@
+package extension
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// LokiPushRequest Loki push API 的 JSON 格式请求
+type LokiPushRequest struct {
+	Streams []LokiStream `json:"streams"`
+}
+
+// LokiStream Loki 日志流，Values 中每一项为 [纳秒时间戳, 日志内容]
+type LokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// lokiEntry 单条日志
+type lokiEntry struct {
+	labels    map[string]string
+	timestamp time.Time
+	line      string
+}
+
+// LokiToCommonEvents 解析 Loki/Promtail push API 推送的日志，每一行日志创建为一个事件
+// contentType 为 application/x-protobuf 时，请求体为 snappy 压缩的 protobuf 格式，否则为 JSON 格式
+func LokiToCommonEvents(content []byte, contentType string) ([]*CommonEvent, error) {
+	var entries []lokiEntry
+	var err error
+	if strings.HasPrefix(contentType, "application/x-protobuf") {
+		entries, err = parseLokiProtobuf(content)
+	} else {
+		entries, err = parseLokiJSON(content)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	commonMessages := make([]*CommonEvent, 0, len(entries))
+	for _, entry := range entries {
+		meta := make(repository.EventMeta)
+		for k, v := range entry.labels {
+			meta[k] = v
+		}
+		meta["timestamp"] = entry.timestamp.Format(time.RFC3339Nano)
+		setSeverity(meta, "loki", metaValue(meta, "severity", "level"))
+
+		commonMessages = append(commonMessages, &CommonEvent{
+			Content: entry.line,
+			Meta:    meta,
+			Tags:    []string{"loki"},
+			Origin:  "loki",
+		})
+	}
+
+	return commonMessages, nil
+}
+
+func parseLokiJSON(content []byte) ([]lokiEntry, error) {
+	var req LokiPushRequest
+	if err := json.Unmarshal(content, &req); err != nil {
+		return nil, errors.New("invalid request")
+	}
+
+	entries := make([]lokiEntry, 0)
+	for _, stream := range req.Streams {
+		for _, value := range stream.Values {
+			ts, err := strconv.ParseInt(value[0], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid request: invalid timestamp %s", value[0])
+			}
+
+			entries = append(entries, lokiEntry{labels: stream.Stream, timestamp: time.Unix(0, ts), line: value[1]})
+		}
+	}
+
+	return entries, nil
+}
+
+// parseLokiProtobuf 解析 snappy 压缩的 logproto.PushRequest
+//
+//	message PushRequest { repeated StreamAdapter streams = 1; }
+//	message StreamAdapter { string labels = 1; repeated EntryAdapter entries = 2; }
+//	message EntryAdapter { google.protobuf.Timestamp timestamp = 1; string line = 2; }
+func parseLokiProtobuf(content []byte) ([]lokiEntry, error) {
+	data, err := snappy.Decode(nil, content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: snappy decode failed: %v", err)
+	}
+
+	entries := make([]lokiEntry, 0)
+	err = consumeProtoFields(data, func(num protowire.Number, stream []byte) error {
+		if num != 1 {
+			return nil
+		}
+
+		streamEntries, err := parseLokiProtoStream(stream)
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, streamEntries...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// parseLokiProtoStream 解析 StreamAdapter，labels 字段先于 entries 字段出现
+func parseLokiProtoStream(stream []byte) ([]lokiEntry, error) {
+	var labels map[string]string
+	entries := make([]lokiEntry, 0)
+
+	err := consumeProtoFields(stream, func(num protowire.Number, value []byte) error {
+		switch num {
+		case 1:
+			parsed, err := parseLokiLabels(string(value))
+			if err != nil {
+				return err
+			}
+
+			labels = parsed
+		case 2:
+			entry, err := parseLokiProtoEntry(value)
+			if err != nil {
+				return err
+			}
+
+			entry.labels = labels
+			entries = append(entries, entry)
+		}
+
+		return nil
+	})
+
+	return entries, err
+}
+
+// parseLokiProtoEntry 解析 EntryAdapter
+func parseLokiProtoEntry(data []byte) (lokiEntry, error) {
+	var entry lokiEntry
+	err := consumeProtoFields(data, func(num protowire.Number, value []byte) error {
+		switch num {
+		case 1:
+			ts, err := parseProtoTimestamp(value)
+			if err != nil {
+				return err
+			}
+
+			entry.timestamp = ts
+		case 2:
+			entry.line = string(value)
+		}
+
+		return nil
+	})
+
+	return entry, err
+}
+
+// consumeProtoFields 遍历 protobuf 消息中所有 length-delimited 类型的字段，其它类型的字段会被忽略
+func consumeProtoFields(data []byte, cb func(num protowire.Number, value []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errors.New("invalid request: malformed protobuf")
+		}
+		data = data[n:]
+
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return errors.New("invalid request: malformed protobuf")
+			}
+			data = data[n:]
+			continue
+		}
+
+		value, n := protowire.ConsumeBytes(data)
+		if n < 0 {
+			return errors.New("invalid request: malformed protobuf")
+		}
+		data = data[n:]
+
+		if err := cb(num, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseProtoTimestamp 解析 google.protobuf.Timestamp { int64 seconds = 1; int32 nanos = 2; }
+func parseProtoTimestamp(data []byte) (time.Time, error) {
+	var seconds, nanos int64
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return time.Time{}, errors.New("invalid request: malformed timestamp")
+		}
+		data = data[n:]
+
+		if typ != protowire.VarintType {
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return time.Time{}, errors.New("invalid request: malformed timestamp")
+			}
+			data = data[n:]
+			continue
+		}
+
+		v, n := protowire.ConsumeVarint(data)
+		if n < 0 {
+			return time.Time{}, errors.New("invalid request: malformed timestamp")
+		}
+		data = data[n:]
+
+		switch num {
+		case 1:
+			seconds = int64(v)
+		case 2:
+			nanos = int64(int32(v))
+		}
+	}
+
+	return time.Unix(seconds, nanos), nil
+}
+
+// parseLokiLabels 解析 Prometheus 格式的标签字符串，如 {job="varlogs", host="web-01"}
+func parseLokiLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("invalid request: invalid labels %s", s)
+	}
+	s = strings.TrimSpace(s[1 : len(s)-1])
+
+	for s != "" {
+		eq := strings.Index(s, "=")
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid request: invalid labels %s", s)
+		}
+
+		name := strings.TrimSpace(s[:eq])
+		value, rest, err := unquoteLabelValue(strings.TrimSpace(s[eq+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid request: invalid label %s: %v", name, err)
+		}
+
+		labels[name] = value
+		s = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+		s = strings.TrimSpace(s)
+	}
+
+	return labels, nil
+}
+
+// unquoteLabelValue 从 s 的开头解析一个双引号包裹的字符串，返回解析后的值以及剩余的内容
+func unquoteLabelValue(s string) (value string, rest string, err error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", "", errors.New("label value must be quoted")
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			value, err = strconv.Unquote(s[:i+1])
+			return value, s[i+1:], err
+		}
+	}
+
+	return "", "", errors.New("unterminated label value")
+}
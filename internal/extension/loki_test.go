@@ -0,0 +1,61 @@
+package extension_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/mylxsw/adanos-alert/internal/extension"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestLokiToCommonEvents_JSON(t *testing.T) {
+	payload := `{"streams": [{"stream": {"job": "varlogs", "host": "web-01"}, "values": [["1605000000000000000", "error: connection refused"], ["1605000001000000000", "error: timeout"]]}]}`
+
+	events, err := extension.LokiToCommonEvents([]byte(payload), "application/json")
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "error: connection refused", events[0].Content)
+	assert.Equal(t, "varlogs", events[0].Meta["job"])
+	assert.Equal(t, "web-01", events[1].Meta["host"])
+	assert.Equal(t, time.Unix(1605000001, 0).Format(time.RFC3339Nano), events[1].Meta["timestamp"])
+
+	_, err = extension.LokiToCommonEvents([]byte(`{"streams": [{"values": [["abc", "line"]]}]}`), "application/json")
+	assert.Error(t, err)
+}
+
+func TestLokiToCommonEvents_Protobuf(t *testing.T) {
+	var timestamp []byte
+	timestamp = protowire.AppendTag(timestamp, 1, protowire.VarintType)
+	timestamp = protowire.AppendVarint(timestamp, 1605000000)
+	timestamp = protowire.AppendTag(timestamp, 2, protowire.VarintType)
+	timestamp = protowire.AppendVarint(timestamp, 500)
+
+	var entry []byte
+	entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+	entry = protowire.AppendBytes(entry, timestamp)
+	entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+	entry = protowire.AppendString(entry, "panic: nil pointer")
+
+	var stream []byte
+	stream = protowire.AppendTag(stream, 1, protowire.BytesType)
+	stream = protowire.AppendString(stream, `{job="app", msg="say \"hi\", ok"}`)
+	stream = protowire.AppendTag(stream, 2, protowire.BytesType)
+	stream = protowire.AppendBytes(stream, entry)
+
+	var req []byte
+	req = protowire.AppendTag(req, 1, protowire.BytesType)
+	req = protowire.AppendBytes(req, stream)
+
+	events, err := extension.LokiToCommonEvents(snappy.Encode(nil, req), "application/x-protobuf")
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "panic: nil pointer", events[0].Content)
+	assert.Equal(t, "app", events[0].Meta["job"])
+	assert.Equal(t, `say "hi", ok`, events[0].Meta["msg"])
+	assert.Equal(t, time.Unix(1605000000, 500).Format(time.RFC3339Nano), events[0].Meta["timestamp"])
+
+	_, err = extension.LokiToCommonEvents([]byte("not snappy"), "application/x-protobuf")
+	assert.Error(t, err)
+}
@@ -0,0 +1,190 @@
+package extension
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+)
+
+// sentryMaxStackFrames 事件内容中最多包含的堆栈帧数量
+const sentryMaxStackFrames = 5
+
+// SentryEvent Sentry webhook 推送的事件
+// 兼容旧版 webhook 插件（顶层包含 project、culprit、event 等字段）与新版集成平台（data.event）两种格式
+type SentryEvent struct {
+	Project     string           `json:"project"`
+	ProjectName string           `json:"project_name"`
+	Culprit     string           `json:"culprit"`
+	Level       string           `json:"level"`
+	URL         string           `json:"url"`
+	Message     string           `json:"message"`
+	Event       *SentryEventBody `json:"event"`
+
+	Action string `json:"action"`
+	Data   *struct {
+		Event *SentryEventBody `json:"event"`
+	} `json:"data"`
+}
+
+// SentryEventBody Sentry 事件详情
+type SentryEventBody struct {
+	EventID   string          `json:"event_id"`
+	Title     string          `json:"title"`
+	Message   string          `json:"message"`
+	Culprit   string          `json:"culprit"`
+	Level     string          `json:"level"`
+	Project   json.RawMessage `json:"project"`
+	WebURL    string          `json:"web_url"`
+	Tags      sentryTags      `json:"tags"`
+	Exception *struct {
+		Values []SentryException `json:"values"`
+	} `json:"exception"`
+}
+
+// sentryTags Sentry 事件标签，兼容 [["key", "value"]] 与 [{"key": "key", "value": "value"}] 两种格式
+type sentryTags [][2]string
+
+func (tags *sentryTags) UnmarshalJSON(data []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil
+	}
+
+	for _, item := range items {
+		var pair []string
+		if err := json.Unmarshal(item, &pair); err == nil {
+			if len(pair) == 2 {
+				*tags = append(*tags, [2]string{pair[0], pair[1]})
+			}
+			continue
+		}
+
+		var kv struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		}
+		if err := json.Unmarshal(item, &kv); err == nil && kv.Key != "" {
+			*tags = append(*tags, [2]string{kv.Key, kv.Value})
+		}
+	}
+
+	return nil
+}
+
+// SentryException Sentry 异常信息
+type SentryException struct {
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Stacktrace *struct {
+		Frames []SentryStackFrame `json:"frames"`
+	} `json:"stacktrace"`
+}
+
+// SentryStackFrame Sentry 异常堆栈帧
+type SentryStackFrame struct {
+	Filename string `json:"filename"`
+	Function string `json:"function"`
+	LineNo   int    `json:"lineno"`
+}
+
+// body 返回事件详情，同时兼容新旧两种格式
+func (se SentryEvent) body() *SentryEventBody {
+	if se.Event != nil {
+		return se.Event
+	}
+
+	if se.Data != nil {
+		return se.Data.Event
+	}
+
+	return nil
+}
+
+// content 创建事件内容，包含异常信息以及最内层的几个堆栈帧
+func (body *SentryEventBody) content(title string) string {
+	var sb strings.Builder
+	sb.WriteString(title)
+
+	if body.Exception == nil {
+		return sb.String()
+	}
+
+	for _, exp := range body.Exception.Values {
+		sb.WriteString(fmt.Sprintf("\n\n%s: %s", exp.Type, exp.Value))
+		if exp.Stacktrace == nil {
+			continue
+		}
+
+		// Sentry 的堆栈帧按照调用顺序排列，最后一帧为异常发生的位置
+		frames := exp.Stacktrace.Frames
+		for i := len(frames) - 1; i >= 0 && i >= len(frames)-sentryMaxStackFrames; i-- {
+			sb.WriteString(fmt.Sprintf("\n  at %s (%s:%d)", frames[i].Function, frames[i].Filename, frames[i].LineNo))
+		}
+	}
+
+	return sb.String()
+}
+
+// SentryToCommonEvent 解析 Sentry webhook 推送的事件
+func SentryToCommonEvent(content []byte) (*CommonEvent, error) {
+	var sentryEvent SentryEvent
+	if err := json.Unmarshal(content, &sentryEvent); err != nil {
+		return nil, errors.New("invalid request")
+	}
+
+	body := sentryEvent.body()
+	if body == nil {
+		return nil, errors.New("invalid request: unknown sentry payload")
+	}
+
+	project := sentryEvent.Project
+	if project == "" && len(body.Project) > 0 {
+		project = strings.Trim(string(body.Project), `"`)
+	}
+
+	level := firstNonEmpty(sentryEvent.Level, body.Level)
+	meta := repository.EventMeta{
+		"project":  project,
+		"culprit":  firstNonEmpty(sentryEvent.Culprit, body.Culprit),
+		"level":    level,
+		"url":      firstNonEmpty(sentryEvent.URL, body.WebURL),
+		"event_id": body.EventID,
+	}
+	if sentryEvent.ProjectName != "" {
+		meta["project_name"] = sentryEvent.ProjectName
+	}
+
+	setSeverity(meta, "sentry", level)
+
+	for _, tag := range body.Tags {
+		// Sentry 的标签不覆盖事件的基础信息
+		if _, ok := meta[tag[0]]; !ok {
+			meta[tag[0]] = tag[1]
+		}
+	}
+
+	tags := []string{"sentry"}
+	if level != "" {
+		tags = append(tags, level)
+	}
+
+	return &CommonEvent{
+		Content: body.content(firstNonEmpty(body.Title, sentryEvent.Message, body.Message)),
+		Meta:    meta,
+		Tags:    tags,
+		Origin:  "sentry",
+	}, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
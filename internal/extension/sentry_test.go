@@ -0,0 +1,50 @@
+package extension_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/internal/extension"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSentryToCommonEvent(t *testing.T) {
+	legacy := `{
+		"project": "backend", "project_name": "Backend", "culprit": "app.views in divide", "level": "error",
+		"url": "https://sentry.io/organizations/demo/issues/1/", "message": "ZeroDivisionError: division by zero",
+		"event": {
+			"event_id": "abc123", "title": "ZeroDivisionError: division by zero",
+			"tags": [["environment", "production"], ["level", "warning"]],
+			"exception": {"values": [{"type": "ZeroDivisionError", "value": "division by zero", "stacktrace": {"frames": [
+				{"filename": "app/main.py", "function": "main", "lineno": 1},
+				{"filename": "app/a.py", "function": "a", "lineno": 2},
+				{"filename": "app/b.py", "function": "b", "lineno": 3},
+				{"filename": "app/c.py", "function": "c", "lineno": 4},
+				{"filename": "app/d.py", "function": "d", "lineno": 5},
+				{"filename": "app/views.py", "function": "divide", "lineno": 6}
+			]}}]}
+		}
+	}`
+
+	evt, err := extension.SentryToCommonEvent([]byte(legacy))
+	assert.NoError(t, err)
+	assert.Equal(t, "sentry", evt.Origin)
+	assert.Equal(t, "backend", evt.Meta["project"])
+	assert.Equal(t, "app.views in divide", evt.Meta["culprit"])
+	assert.Equal(t, "error", evt.Meta["level"])
+	assert.Equal(t, "production", evt.Meta["environment"])
+	assert.Equal(t, []string{"sentry", "error"}, evt.Tags)
+	assert.True(t, strings.HasPrefix(evt.Content, "ZeroDivisionError: division by zero\n\nZeroDivisionError: division by zero\n  at divide (app/views.py:6)"))
+	assert.NotContains(t, evt.Content, "app/main.py")
+
+	integration := `{"action": "triggered", "data": {"event": {"event_id": "def456", "title": "TypeError", "level": "fatal", "project": 42, "web_url": "https://sentry.io/issues/2/", "tags": [{"key": "release", "value": "1.0.0"}]}}}`
+	evt, err = extension.SentryToCommonEvent([]byte(integration))
+	assert.NoError(t, err)
+	assert.Equal(t, "42", evt.Meta["project"])
+	assert.Equal(t, "https://sentry.io/issues/2/", evt.Meta["url"])
+	assert.Equal(t, "1.0.0", evt.Meta["release"])
+	assert.Equal(t, "TypeError", evt.Content)
+
+	_, err = extension.SentryToCommonEvent([]byte(`{"foo": "bar"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package extension
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+)
+
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// SeverityMetaKey 归一化之后的严重程度在事件 Meta 中的字段名
+const SeverityMetaKey = "severity"
+
+// severityMapping 各来源的严重程度（小写）与归一化严重程度的对应关系
+var severityMapping = map[string]string{
+	// 通用
+	"critical":  SeverityCritical,
+	"crit":      SeverityCritical,
+	"fatal":     SeverityCritical,
+	"emergency": SeverityCritical,
+	"emerg":     SeverityCritical,
+	"alert":     SeverityCritical,
+	"page":      SeverityCritical,
+	"error":     SeverityCritical,
+	"err":       SeverityCritical,
+	"warning":   SeverityWarning,
+	"warn":      SeverityWarning,
+	"notice":    SeverityInfo,
+	"info":      SeverityInfo,
+	"debug":     SeverityInfo,
+	"none":      SeverityInfo,
+	// Zabbix
+	"disaster":       SeverityCritical,
+	"high":           SeverityCritical,
+	"average":        SeverityWarning,
+	"information":    SeverityInfo,
+	"not classified": SeverityInfo,
+	// Grafana 告警状态
+	"alerting": SeverityCritical,
+	"no_data":  SeverityWarning,
+	"ok":       SeverityInfo,
+	"paused":   SeverityInfo,
+	"pending":  SeverityInfo,
+	// CloudWatch 告警状态
+	"alarm":             SeverityCritical,
+	"insufficient_data": SeverityWarning,
+	// Open-Falcon 告警级别 P0~P5
+	"p0": SeverityCritical,
+	"p1": SeverityCritical,
+	"p2": SeverityWarning,
+	"p3": SeverityWarning,
+	"p4": SeverityInfo,
+	"p5": SeverityInfo,
+}
+
+// NormalizeSeverity 将来源的严重程度转换为 critical/warning/info，无法识别时返回 info
+func NormalizeSeverity(severity string) string {
+	if normalized, ok := severityMapping[strings.ToLower(strings.TrimSpace(severity))]; ok {
+		return normalized
+	}
+
+	return SeverityInfo
+}
+
+// setSeverity 将归一化之后的严重程度写入 meta 的 severity 字段，来源的原始严重程度保存在 {source}_severity 字段中
+func setSeverity(meta repository.EventMeta, source string, severity string) string {
+	severity = strings.TrimSpace(severity)
+	if severity != "" {
+		meta[source+"_severity"] = severity
+	}
+
+	normalized := NormalizeSeverity(severity)
+	meta[SeverityMetaKey] = normalized
+
+	return normalized
+}
+
+// metaValue 返回 meta 中第一个非空的字段值
+func metaValue(meta repository.EventMeta, keys ...string) string {
+	for _, key := range keys {
+		if val, ok := meta[key]; ok && val != nil {
+			if s := strings.TrimSpace(fmt.Sprintf("%v", val)); s != "" {
+				return s
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,54 @@
+package extension_test
+
+import (
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/internal/extension"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSeverity(t *testing.T) {
+	testcases := map[string]string{
+		"critical":  extension.SeverityCritical,
+		"Disaster":  extension.SeverityCritical,
+		"P1":        extension.SeverityCritical,
+		"alerting":  extension.SeverityCritical,
+		" WARN ":    extension.SeverityWarning,
+		"average":   extension.SeverityWarning,
+		"no_data":   extension.SeverityWarning,
+		"debug":     extension.SeverityInfo,
+		"":          extension.SeverityInfo,
+		"something": extension.SeverityInfo,
+	}
+
+	for severity, expected := range testcases {
+		assert.Equal(t, expected, extension.NormalizeSeverity(severity), severity)
+	}
+}
+
+func TestParsersSetSeverity(t *testing.T) {
+	prom, err := extension.PrometheusToCommonEvents([]byte(`[{"status": "firing", "labels": {"alertname": "HighLatency", "severity": "page"}}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, extension.SeverityCritical, prom[0].Meta["severity"])
+	assert.Equal(t, "page", prom[0].Meta["prometheus_severity"])
+
+	grafana, err := extension.GrafanaToCommonEvent([]byte(`{"ruleName": "cpu", "state": "no_data"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, extension.SeverityWarning, grafana.Meta["severity"])
+	assert.Equal(t, "no_data", grafana.Meta["grafana_severity"])
+
+	logstash, err := extension.LogstashToCommonEvent([]byte(`{"message": "oops", "level": "ERROR"}`), "message")
+	assert.NoError(t, err)
+	assert.Equal(t, extension.SeverityCritical, logstash.Meta["severity"])
+	assert.Equal(t, "ERROR", logstash.Meta["logstash_severity"])
+
+	falcon := extension.OpenFalconToCommonEvent("ops", "[P3][PROBLEM][192.168.200.4][][ all(#1) agent.alive  1==1][O1 2019-07-08 23:35:00]")
+	assert.Equal(t, extension.SeverityWarning, falcon.Meta["severity"])
+	assert.Equal(t, "P3", falcon.Meta["openfalcon_severity"])
+
+	// 来源没有严重程度时，默认为 info，并且不保存原始严重程度
+	noSeverity, err := extension.PrometheusToCommonEvents([]byte(`[{"status": "firing", "labels": {"alertname": "HighLatency"}}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, extension.SeverityInfo, noSeverity[0].Meta["severity"])
+	assert.NotContains(t, noSeverity[0].Meta, "prometheus_severity")
+}
@@ -0,0 +1,68 @@
+package extension
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+)
+
+// ZabbixEvent Zabbix 通过 webhook 媒介脚本推送的告警
+type ZabbixEvent struct {
+	EventID     string      `json:"event_id"`
+	TriggerID   string      `json:"trigger_id"`
+	TriggerName string      `json:"trigger_name"`
+	Severity    interface{} `json:"severity"` // 严重程度名称（Disaster）或者编号（5）
+	Host        string      `json:"host"`
+	HostIP      string      `json:"host_ip"`
+	Status      string      `json:"status"` // PROBLEM/RESOLVED
+	Message     string      `json:"message"`
+	EventTime   string      `json:"event_time"`
+}
+
+// zabbixSeverities Zabbix 严重程度编号与名称的对应关系
+var zabbixSeverities = []string{"Not classified", "Information", "Warning", "Average", "High", "Disaster"}
+
+// zabbixSeverityName 返回 Zabbix 严重程度的名称，编号会被转换为对应的名称
+func zabbixSeverityName(severity interface{}) string {
+	name := strings.TrimSpace(fmt.Sprintf("%v", severity))
+	for i, s := range zabbixSeverities {
+		if strings.EqualFold(name, s) || name == fmt.Sprintf("%d", i) {
+			return s
+		}
+	}
+
+	return name
+}
+
+// ZabbixToCommonEvent 解析 Zabbix 推送的告警
+func ZabbixToCommonEvent(content []byte) (*CommonEvent, error) {
+	var zabbixEvent ZabbixEvent
+	if err := json.Unmarshal(content, &zabbixEvent); err != nil {
+		return nil, errors.New("invalid request")
+	}
+
+	if zabbixEvent.TriggerName == "" {
+		return nil, errors.New("invalid request: trigger_name required")
+	}
+
+	meta := repository.EventMeta{
+		"event_id":     zabbixEvent.EventID,
+		"trigger_id":   zabbixEvent.TriggerID,
+		"trigger_name": zabbixEvent.TriggerName,
+		"host":         zabbixEvent.Host,
+		"host_ip":      zabbixEvent.HostIP,
+		"status":       strings.ToLower(zabbixEvent.Status),
+		"event_time":   zabbixEvent.EventTime,
+	}
+	severity := setSeverity(meta, "zabbix", zabbixSeverityName(zabbixEvent.Severity))
+
+	return &CommonEvent{
+		Content: firstNonEmpty(zabbixEvent.Message, zabbixEvent.TriggerName),
+		Meta:    meta,
+		Tags:    []string{"zabbix", severity},
+		Origin:  "zabbix",
+	}, nil
+}
@@ -0,0 +1,39 @@
+package extension_test
+
+import (
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/internal/extension"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZabbixToCommonEvent(t *testing.T) {
+	testcases := []struct {
+		Payload          string
+		Severity         string
+		OriginalSeverity string
+	}{
+		{Payload: `{"event_id": "1001", "trigger_name": "Disk is full", "severity": "Disaster", "host": "web-01", "status": "PROBLEM", "message": "/ is 99% full"}`, Severity: "critical", OriginalSeverity: "Disaster"},
+		{Payload: `{"event_id": "1002", "trigger_name": "Load is high", "severity": 4, "host": "web-01"}`, Severity: "critical", OriginalSeverity: "High"},
+		{Payload: `{"event_id": "1003", "trigger_name": "Load is high", "severity": "average", "host": "web-01"}`, Severity: "warning", OriginalSeverity: "Average"},
+		{Payload: `{"event_id": "1004", "trigger_name": "Agent restarted", "severity": "0", "host": "web-01"}`, Severity: "info", OriginalSeverity: "Not classified"},
+	}
+
+	for _, tc := range testcases {
+		evt, err := extension.ZabbixToCommonEvent([]byte(tc.Payload))
+		assert.NoError(t, err)
+		assert.Equal(t, "zabbix", evt.Origin)
+		assert.Equal(t, "web-01", evt.Meta["host"])
+		assert.Equal(t, tc.Severity, evt.Meta["severity"])
+		assert.Equal(t, tc.OriginalSeverity, evt.Meta["zabbix_severity"])
+		assert.Contains(t, evt.Tags, tc.Severity)
+	}
+
+	evt, err := extension.ZabbixToCommonEvent([]byte(testcases[0].Payload))
+	assert.NoError(t, err)
+	assert.Equal(t, "/ is 99% full", evt.Content)
+	assert.Equal(t, "problem", evt.Meta["status"])
+
+	_, err = extension.ZabbixToCommonEvent([]byte(`{"foo": "bar"}`))
+	assert.Error(t, err)
+}
@@ -3,119 +3,180 @@ package job
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/mylxsw/adanos-alert/configs"
 	"github.com/mylxsw/adanos-alert/internal/matcher"
 	"github.com/mylxsw/adanos-alert/internal/repository"
 	"github.com/mylxsw/adanos-alert/pkg/misc"
 	"github.com/mylxsw/adanos-alert/pubsub"
 	"github.com/mylxsw/asteria/log"
-	"github.com/mylxsw/coll"
 	"github.com/mylxsw/container"
 	"github.com/mylxsw/glacier/event"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const AggregationJobName = "aggregation"
 
 type AggregationJob struct {
-	app       container.Container
-	executing chan interface{} // 标识当前Job是否在执行中
+	app container.Container
+
+	lock          sync.Mutex
+	running       int       // 当前正在执行中的任务数
+	lastStartedAt time.Time // 最近一次开始执行的时间
 }
 
 func NewAggregationJob(app container.Container) *AggregationJob {
-	return &AggregationJob{app: app, executing: make(chan interface{}, 1)}
+	return &AggregationJob{app: app}
 }
 
 // Handle do two things:
 // 1. message grouping, delivery all ungrouped messages to message group
 // 2. change the message groups that satisfied the conditions to pending status
 func (a *AggregationJob) Handle() {
-	select {
-	case a.executing <- struct{}{}:
-		defer func() { <-a.executing }()
-		// traverse all ungrouped events to group
-		a.app.MustResolve(a.groupingEvents)
-		// change event group status to pending when it reach the aggregate condition
-		a.app.MustResolve(a.pendingEventGroup)
-	default:
+	conf := configs.Get(a.app)
+	if !a.tryStart(conf.AggregationMaxConcurrent, conf.AggregationSoftDeadline) {
+		aggregationSkippedRuns.Inc()
 		log.Warningf("the last aggregation job is not finished yet, skip for this time")
+		return
+	}
+
+	timer := prometheus.NewTimer(aggregationRunDuration)
+	success := false
+	defer func() {
+		timer.ObserveDuration()
+		a.finish(success)
+	}()
+
+	ctx, cancel := a.runContext(conf)
+	defer cancel()
+
+	// traverse all ungrouped events to group
+	if err := a.app.ResolveWithError(func(em event.Manager, eventRepo repository.EventRepo, evtRelRepo repository.EventRelationRepo, groupRepo repository.EventGroupRepo, ruleRepo repository.RuleRepo) error {
+		return a.groupingEvents(ctx, conf, em, eventRepo, evtRelRepo, groupRepo, ruleRepo)
+	}); err != nil {
+		log.Errorf("aggregation job grouping events failed: %v", err)
+		return
+	}
+
+	// change event group status to pending when it reach the aggregate condition
+	if err := a.app.ResolveWithError(func(groupRepo repository.EventGroupRepo, evtRepo repository.EventRepo, em event.Manager) error {
+		return a.pendingEventGroup(ctx, groupRepo, evtRepo, em)
+	}); err != nil {
+		log.Errorf("aggregation job change event group status failed: %v", err)
+		return
+	}
+
+	success = true
+}
+
+// runContext 返回本次执行使用的 context，服务停止或者执行时间超过 AggregationDeadline 时被取消
+func (a *AggregationJob) runContext(conf *configs.Config) (context.Context, context.CancelFunc) {
+	ctx := context.Background()
+	a.app.MustResolve(func(jobs *RunningJobs) {
+		ctx = jobs.Context()
+	})
+
+	if conf.AggregationDeadline > 0 {
+		return context.WithTimeout(ctx, conf.AggregationDeadline)
 	}
+
+	return context.WithCancel(ctx)
 }
 
-func (a *AggregationJob) groupingEvents(eventRepo repository.EventRepo, evtRelRepo repository.EventRelationRepo, groupRepo repository.EventGroupRepo, ruleRepo repository.RuleRepo) error {
-	matchers, err := initializeMatchers(ruleRepo)
+// tryStart 判断是否能够开始一次新的执行
+// 没有正在执行的任务时直接开始，否则只有在并发数未达到 maxConcurrent，并且最近一次执行已经超过 softDeadline 时才开始
+func (a *AggregationJob) tryStart(maxConcurrent int, softDeadline time.Duration) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+
+	if a.running > 0 && (a.running >= maxConcurrent || time.Since(a.lastStartedAt) < softDeadline) {
+		return false
+	}
+
+	a.running++
+	a.lastStartedAt = time.Now()
+
+	return true
+}
+
+// LastRun 返回本节点最近一次开始执行聚合任务的时间，本节点没有执行过时返回零值
+// 聚合任务只在持有分布式锁的节点上执行
+func (a *AggregationJob) LastRun() time.Time {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.lastStartedAt
+}
+
+// finish 标识一次执行结束
+func (a *AggregationJob) finish(success bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.running--
+	if success {
+		atomic.StoreInt64(&aggregationLastSuccess, time.Now().UnixNano())
+	}
+}
+
+func (a *AggregationJob) groupingEvents(ctx context.Context, conf *configs.Config, em event.Manager, eventRepo repository.EventRepo, evtRelRepo repository.EventRelationRepo, groupRepo repository.EventGroupRepo, ruleRepo repository.RuleRepo) error {
+	matchers, ruleErrs, err := initializeMatchers(ruleRepo)
 	if err != nil {
 		log.Error(err.Error())
 		return err
 	}
 
-	collectingGroups := make(map[string]repository.EventGroup)
-	err = eventRepo.Traverse(bson.M{"status": repository.EventStatusPending}, func(evt repository.Event) error {
-		messageCanIgnore := false
-		for _, m := range matchers {
-			matched, ignored, err := m.Match(evt)
-			if err != nil {
-				continue
-			}
+	markInvalidRules(ruleRepo, em, ruleErrs)
 
-			// if the message matched a rule, update message's group_id and skip to next message
-			if matched {
-				// 对于匹配规则的消息，首先判断是否能够为消息建立关联
-				if m.Rule().RelationRule != "" {
-					if relationSummary := BuildEventFinger(m.Rule().RelationRule, evt); relationSummary != "" {
-						if evtRel, err := evtRelRepo.AddOrUpdateEventRelation(context.TODO(), relationSummary, m.Rule().ID); err != nil {
-							log.WithFields(log.Fields{
-								"evt":  evt,
-								"rule": m.Rule(),
-								"err":  err,
-							}).Errorf("create event relation failed: %v", err)
-						} else {
-							evt.RelationID = append(evt.RelationID, evtRel.ID)
-						}
-					}
-				}
+	grouper := &eventGrouper{
+		conf:             conf,
+		matchers:         matchers,
+		groupRepo:        groupRepo,
+		evtRelRepo:       evtRelRepo,
+		collectingGroups: make(map[string]repository.EventGroup),
+	}
 
-				// 为消息分组
-				if ignored {
-					messageCanIgnore = true
-				} else {
-					aggregateKey := BuildEventFinger(m.Rule().AggregateRule, evt)
-					key := fmt.Sprintf("%s:%s:%s", m.Rule().ID.Hex(), aggregateKey, evt.Type)
-					if _, ok := collectingGroups[key]; !ok {
-						grp, err := groupRepo.CollectingGroup(m.Rule().ToGroupRule(aggregateKey, evt.Type))
-						if err != nil {
-							log.WithFields(log.Fields{
-								"evt":  evt,
-								"rule": m.Rule(),
-								"err":  err.Error(),
-							}).Errorf("create collecting group failed: %v", err)
-							return err
-						}
-
-						collectingGroups[key] = grp
-					}
+	updates := newEventStatusBatcher(eventRepo, eventBulkUpdateSize)
+	// 事件关联的事件数量在事件状态写入之后再增加，聚合中断时事件仍然是 pending 状态，下次聚合不会重复计数
+	updates.afterWrite = func(written []repository.EventStatusUpdate) {
+		incrRelationEventCount(evtRelRepo, written)
+	}
+	// 允许多个聚合任务并发执行时，每个事件先认领再分组，避免同一个事件被多次分组
+	claimer := ""
+	if conf.AggregationMaxConcurrent > 1 {
+		claimer = primitive.NewObjectID().Hex()
+	}
 
-					evt.GroupID = append(evt.GroupID, collectingGroups[key].ID)
-					evt.Status = repository.EventStatusGrouped
-				}
+	err = traverseEventsParallel(ctx, conf.QueueWorkerNum, eventRepo, bson.M{"status": repository.EventStatusPending}, func(evt repository.Event) error {
+		if claimer != "" {
+			claimed, err := eventRepo.ClaimPending(ctx, evt.ID, claimer, time.Now().Add(-eventClaimTTL(conf)))
+			if err != nil {
+				return err
+			}
+
+			if !claimed {
+				return nil
 			}
 		}
 
-		// messageCanIgnore 和 message 状态 变换规则
-		// true  | pending  -> ignore
-		// false | pending  -> canceled
-		// true  | grouped  -> grouped
-		// false | grouped  -> grouped
-
-		// if message not match any rules, set message as canceled
-		if evt.Status == repository.EventStatusPending {
-			evt.Status = misc.IfElse(messageCanIgnore,
-				repository.EventStatusIgnored,
-				repository.EventStatusCanceled,
-			).(repository.EventStatus)
+		evt, err := grouper.grouping(evt)
+		if err != nil {
+			return err
 		}
 
+		aggregationEventsProcessed.Inc()
+		aggregationEventsByStatus.WithLabelValues(string(evt.Status)).Inc()
+
 		if log.DebugEnabled() {
 			log.WithFields(log.Fields{
 				"evt_id": evt.ID.Hex(),
@@ -123,14 +184,19 @@ func (a *AggregationJob) groupingEvents(eventRepo repository.EventRepo, evtRelRe
 			}).Debug("change message status")
 		}
 
-		return eventRepo.UpdateID(evt.ID, evt)
+		return updates.Add(evt)
 	})
+	// 遍历中断时，已经处理的事件也需要写入
+	if err := updates.Flush(); err != nil {
+		return err
+	}
 	if err != nil {
 		return err
 	}
 
 	// 将能够与规则匹配的 Canceled 的 message 转换为 Expired
-	return eventRepo.Traverse(bson.M{"status": repository.EventStatusCanceled}, func(msg repository.Event) error {
+	expiredUpdates := newEventStatusBatcher(eventRepo, eventBulkUpdateSize)
+	err = traverseEventsParallel(ctx, conf.QueueWorkerNum, eventRepo, bson.M{"status": repository.EventStatusCanceled}, func(msg repository.Event) error {
 		for _, m := range matchers {
 			matched, _, err := m.Match(msg)
 			if err != nil {
@@ -144,35 +210,372 @@ func (a *AggregationJob) groupingEvents(eventRepo repository.EventRepo, evtRelRe
 			}
 		}
 
-		return eventRepo.UpdateID(msg.ID, msg)
+		if msg.Status != repository.EventStatusExpired {
+			return nil
+		}
+
+		return expiredUpdates.Add(msg)
+	})
+	if err := expiredUpdates.Flush(); err != nil {
+		return err
+	}
+
+	return err
+}
+
+// defaultEventClaimTTL 没有设置 AggregationDeadline 时事件认领的有效期，超过有效期还没有更新状态的事件可以被其它聚合任务重新认领
+const defaultEventClaimTTL = 10 * time.Minute
+
+// eventClaimTTL 事件认领的有效期，聚合任务超过 AggregationDeadline 会被取消，认领在此之后失效
+func eventClaimTTL(conf *configs.Config) time.Duration {
+	if conf.AggregationDeadline > 0 {
+		return conf.AggregationDeadline
+	}
+
+	return defaultEventClaimTTL
+}
+
+// eventBulkUpdateSize 聚合时批量更新事件状态，每累计这么多个事件写入一次
+const eventBulkUpdateSize = 200
+
+// eventStatusBatcher 累计事件状态的变更，达到 size 时批量写入，可以被多个 worker 并发使用
+type eventStatusBatcher struct {
+	eventRepo repository.EventRepo
+	size      int
+
+	// afterWrite 状态变更写入之后执行，只包含写入成功的状态变更
+	afterWrite func(written []repository.EventStatusUpdate)
+
+	lock    sync.Mutex
+	pending []repository.EventStatusUpdate
+}
+
+func newEventStatusBatcher(eventRepo repository.EventRepo, size int) *eventStatusBatcher {
+	if size < 1 {
+		size = 1
+	}
+
+	return &eventStatusBatcher{eventRepo: eventRepo, size: size, pending: make([]repository.EventStatusUpdate, 0, size)}
+}
+
+// Add 添加一个事件的状态变更，累计数量达到 size 时批量写入
+func (b *eventStatusBatcher) Add(evt repository.Event) error {
+	b.lock.Lock()
+	b.pending = append(b.pending, repository.EventStatusUpdate{
+		ID:         evt.ID,
+		Status:     evt.Status,
+		GroupID:    evt.GroupID,
+		RelationID: evt.RelationID,
+	})
+
+	if len(b.pending) < b.size {
+		b.lock.Unlock()
+		return nil
+	}
+
+	updates := b.pending
+	b.pending = make([]repository.EventStatusUpdate, 0, b.size)
+	b.lock.Unlock()
+
+	return b.write(updates)
+}
+
+// Flush 写入所有累计的状态变更
+func (b *eventStatusBatcher) Flush() error {
+	b.lock.Lock()
+	updates := b.pending
+	b.pending = make([]repository.EventStatusUpdate, 0, b.size)
+	b.lock.Unlock()
+
+	return b.write(updates)
+}
+
+func (b *eventStatusBatcher) write(updates []repository.EventStatusUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	err := b.eventRepo.BulkUpdateStatus(updates)
+	written := updates
+	if bulkErr, ok := err.(repository.EventBulkUpdateError); ok {
+		failed := make(map[primitive.ObjectID]bool, len(bulkErr.Failed))
+		for _, f := range bulkErr.Failed {
+			failed[f.ID] = true
+			log.WithFields(log.Fields{
+				"evt_id": f.ID.Hex(),
+				"reason": f.Reason,
+			}).Errorf("update event status failed: %s", f.Reason)
+		}
+
+		written = make([]repository.EventStatusUpdate, 0, len(updates))
+		for _, u := range updates {
+			if !failed[u.ID] {
+				written = append(written, u)
+			}
+		}
+	} else if err != nil {
+		written = nil
+	}
+
+	if b.afterWrite != nil && len(written) > 0 {
+		b.afterWrite(written)
+	}
+
+	return err
+}
+
+// incrRelationEventCount 按照写入成功的事件增加事件关联的事件数量
+func incrRelationEventCount(evtRelRepo repository.EventRelationRepo, written []repository.EventStatusUpdate) {
+	counts := make(map[primitive.ObjectID]int64)
+	for _, u := range written {
+		for _, relID := range u.RelationID {
+			counts[relID]++
+		}
+	}
+
+	if err := evtRelRepo.IncrEventCount(context.TODO(), counts); err != nil {
+		log.WithFields(log.Fields{
+			"counts": counts,
+		}).Errorf("increase event relation count failed: %v", err)
+	}
+}
+
+// traverseEventsParallel 遍历所有匹配 filter 的事件，使用 workerNum 个 worker 并发执行 handler
+// 任意一个 handler 返回错误或者 ctx 被取消时，停止遍历并返回第一个错误
+func traverseEventsParallel(ctx context.Context, workerNum int, eventRepo repository.EventRepo, filter bson.M, handler func(evt repository.Event) error) error {
+	if workerNum < 1 {
+		workerNum = 1
+	}
+
+	events := make(chan repository.Event, workerNum)
+	stopped := make(chan interface{})
+
+	var firstErr error
+	var stopOnce sync.Once
+	stop := func(err error) {
+		stopOnce.Do(func() {
+			firstErr = err
+			close(stopped)
+		})
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workerNum)
+	for i := 0; i < workerNum; i++ {
+		go func() {
+			defer wg.Done()
+			for evt := range events {
+				select {
+				case <-stopped:
+					continue
+				default:
+				}
+
+				if err := handler(evt); err != nil {
+					stop(err)
+				}
+			}
+		}()
+	}
+
+	traverseErr := eventRepo.TraverseCtx(ctx, filter, func(evt repository.Event) error {
+		select {
+		case events <- evt:
+			return nil
+		case <-stopped:
+			return firstErr
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	})
+
+	close(events)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	return traverseErr
+}
+
+// eventGrouper 负责将事件与规则匹配并分配到对应的事件组，可以被多个 worker 并发使用
+type eventGrouper struct {
+	conf       *configs.Config
+	matchers   []*matcher.EventMatcher
+	groupRepo  repository.EventGroupRepo
+	evtRelRepo repository.EventRelationRepo
+
+	lock             sync.Mutex
+	collectingGroups map[string]repository.EventGroup
+}
+
+// collectingGroup 返回 key 对应的收集中的事件组，同一个 key 只会查询（创建）一次
+func (g *eventGrouper) collectingGroup(key string, rule repository.EventGroupRule) (repository.EventGroup, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if grp, ok := g.collectingGroups[key]; ok {
+		return grp, nil
+	}
+
+	grp, err := g.groupRepo.CollectingGroup(rule)
+	if err != nil {
+		return grp, err
+	}
+
+	g.collectingGroups[key] = grp
+	return grp, nil
 }
 
-func initializeMatchers(ruleRepo repository.RuleRepo) ([]*matcher.EventMatcher, error) {
+// grouping 将事件与规则进行匹配，返回更新了分组和状态之后的事件
+// 规则按照优先级从高到低匹配，all 模式下事件会分配到所有匹配规则的事件组；
+// first 模式下第一个匹配的规则生效之后停止匹配：该规则忽略了事件时，事件状态为 ignored，不再匹配其它规则，
+// 否则事件只分配到该规则的事件组。没有任何规则匹配时两种模式相同，事件状态为 canceled
+func (g *eventGrouper) grouping(evt repository.Event) (repository.Event, error) {
+	firstMatch := g.conf.MatchMode(string(evt.Type)) == configs.RuleMatchModeFirst
+
+	messageCanIgnore := false
+	for _, m := range g.matchers {
+		matched, ignored, err := m.Match(evt)
+		if err != nil {
+			continue
+		}
+
+		// if the message matched a rule, update message's group_id and skip to next message
+		if matched {
+			// 对于匹配规则的消息，首先判断是否能够为消息建立关联
+			if m.Rule().RelationRule != "" {
+				if relationSummary := BuildEventFinger(m.Rule().RelationRule, evt); relationSummary != "" {
+					if evtRel, err := g.evtRelRepo.EnsureEventRelation(context.TODO(), relationSummary, m.Rule().ID); err != nil {
+						log.WithFields(log.Fields{
+							"evt":  evt,
+							"rule": m.Rule(),
+							"err":  err,
+						}).Errorf("create event relation failed: %v", err)
+					} else {
+						evt.RelationID = append(evt.RelationID, evtRel.ID)
+					}
+				}
+			}
+
+			// 为消息分组
+			if ignored {
+				messageCanIgnore = true
+			} else {
+				aggregateKey := BuildEventFinger(m.Rule().AggregateRule, evt)
+				key := fmt.Sprintf("%s:%s:%s", m.Rule().ID.Hex(), aggregateKey, evt.Type)
+				grp, err := g.collectingGroup(key, m.Rule().ToGroupRule(aggregateKey, evt.Type))
+				if err != nil {
+					log.WithFields(log.Fields{
+						"evt":  evt,
+						"rule": m.Rule(),
+						"err":  err.Error(),
+					}).Errorf("create collecting group failed: %v", err)
+					return evt, err
+				}
+
+				evt.GroupID = append(evt.GroupID, grp.ID)
+				evt.Status = repository.EventStatusGrouped
+			}
+
+			if firstMatch {
+				break
+			}
+		}
+	}
+
+	// messageCanIgnore 和 message 状态 变换规则
+	// true  | pending  -> ignore
+	// false | pending  -> canceled
+	// true  | grouped  -> grouped
+	// false | grouped  -> grouped
+
+	// if message not match any rules, set message as canceled
+	if evt.Status == repository.EventStatusPending {
+		evt.Status = misc.IfElse(messageCanIgnore,
+			repository.EventStatusIgnored,
+			repository.EventStatusCanceled,
+		).(repository.EventStatus)
+	}
+
+	return evt, nil
+}
+
+// eventMatcherCache 在多次聚合之间缓存已经编译的规则匹配器
+var eventMatcherCache = matcher.NewEventMatcherCache()
+
+// RuleError 规则编译失败的错误信息
+type RuleError struct {
+	RuleID   primitive.ObjectID `json:"rule_id"`
+	RuleName string             `json:"rule_name"`
+	Reason   string             `json:"reason"`
+}
+
+func (e RuleError) Error() string {
+	return fmt.Sprintf("rule %s(%s) is invalid: %s", e.RuleName, e.RuleID.Hex(), e.Reason)
+}
+
+// initializeMatchers 为所有启用的规则创建匹配器，编译失败的规则不会包含在返回的匹配器中，而是以 RuleError 的形式返回
+func initializeMatchers(ruleRepo repository.RuleRepo) ([]*matcher.EventMatcher, []RuleError, error) {
 	// get all rules
 	rules, err := ruleRepo.Find(bson.M{"status": repository.RuleStatusEnabled})
 	if err != nil {
-		return nil, fmt.Errorf("aggregate message failed because rules query failed: %s", err)
+		return nil, nil, fmt.Errorf("aggregate message failed because rules query failed: %s", err)
 	}
 
+	eventMatcherCache.Retain(rules)
+
+	// 按照优先级从高到低排序，优先级相同时保持原有顺序
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Priority > rules[j].Priority
+	})
+
 	// create matchers from rules
-	var matchers []*matcher.EventMatcher
-	if err := coll.MustNew(rules).Map(func(ru repository.Rule) *matcher.EventMatcher {
-		mat, err := matcher.NewEventMatcher(ru)
+	matchers := make([]*matcher.EventMatcher, 0, len(rules))
+	ruleErrs := make([]RuleError, 0)
+	for _, ru := range rules {
+		mat, err := eventMatcherCache.Get(ru)
 		if err != nil {
-			log.Errorf("invalid rule: %v", err)
+			ruleErrs = append(ruleErrs, RuleError{RuleID: ru.ID, RuleName: ru.Name, Reason: err.Error()})
+			continue
 		}
 
-		return mat
-	}).All(&matchers); err != nil {
-		return nil, fmt.Errorf("create message matchers failed: %s", err)
+		matchers = append(matchers, mat)
 	}
 
-	return matchers, nil
+	return matchers, ruleErrs, nil
 }
 
-func (a *AggregationJob) pendingEventGroup(groupRepo repository.EventGroupRepo, evtRepo repository.EventRepo, em event.Manager) error {
-	return groupRepo.Traverse(bson.M{"status": repository.EventGroupStatusCollecting}, func(grp repository.EventGroup) error {
+// markInvalidRules 将编译失败的规则标记为 RuleStatusInvalid，避免规则在没有任何提示的情况下失效
+func markInvalidRules(ruleRepo repository.RuleRepo, em event.Manager, ruleErrs []RuleError) {
+	for _, ruleErr := range ruleErrs {
+		log.WithFields(log.Fields{
+			"rule_id": ruleErr.RuleID.Hex(),
+		}).Error(ruleErr.Error())
+
+		if err := ruleRepo.SetStatus(ruleErr.RuleID, repository.RuleStatusInvalid, "system", ruleErr.Reason); err != nil {
+			log.WithFields(log.Fields{
+				"rule_id": ruleErr.RuleID.Hex(),
+			}).Errorf("mark rule as invalid failed: %v", err)
+			continue
+		}
+
+		rule, err := ruleRepo.Get(ruleErr.RuleID)
+		if err != nil {
+			continue
+		}
+
+		em.Publish(pubsub.RuleChangedEvent{
+			Rule:      rule,
+			Type:      pubsub.EventTypeUpdate,
+			CreatedAt: time.Now(),
+		})
+	}
+}
+
+func (a *AggregationJob) pendingEventGroup(ctx context.Context, groupRepo repository.EventGroupRepo, evtRepo repository.EventRepo, em event.Manager) error {
+	return groupRepo.TraverseCtx(ctx, bson.M{"status": repository.EventGroupStatusCollecting}, func(grp repository.EventGroup) error {
 		if !grp.Ready() {
 			return nil
 		}
@@ -203,6 +606,7 @@ func (a *AggregationJob) pendingEventGroup(groupRepo repository.EventGroupRepo,
 		err = groupRepo.UpdateID(grp.ID, grp)
 
 		if evtCount > 0 {
+			aggregationGroupsPending.Inc()
 			em.Publish(pubsub.MessageGroupPendingEvent{
 				Group:     grp,
 				CreatedAt: time.Now(),
@@ -242,12 +646,16 @@ func BuildEventMatchTest(ruleRepo repository.RuleRepo) func(msg repository.Event
 	return func(msg repository.Event) ([]MatchedRule, error) {
 		matchedRules := make([]MatchedRule, 0)
 
-		matchers, err := initializeMatchers(ruleRepo)
+		matchers, ruleErrs, err := initializeMatchers(ruleRepo)
 		if err != nil {
 			log.Error(err.Error())
 			return matchedRules, err
 		}
 
+		for _, ruleErr := range ruleErrs {
+			log.Warning(ruleErr.Error())
+		}
+
 		for _, m := range matchers {
 			matched, _, err := m.Match(msg)
 			if err != nil {
@@ -0,0 +1,51 @@
+package job
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	mockRepo "github.com/mylxsw/adanos-alert/test/mock/repository"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEventStatusBatcher_RelationEventCount(t *testing.T) {
+	eventRepo := mockRepo.NewMessageRepo().(*mockRepo.MessageRepo)
+	relRepo := mockRepo.NewEventRelationRepo().(*mockRepo.EventRelationRepo)
+
+	rel, err := relRepo.EnsureEventRelation(context.TODO(), "host-1", primitive.NewObjectID())
+	assert.NoError(t, err)
+
+	evt := repository.Event{ID: primitive.NewObjectID(), Status: repository.EventStatusPending}
+	eventRepo.Messages = append(eventRepo.Messages, evt)
+
+	newBatcher := func() *eventStatusBatcher {
+		b := newEventStatusBatcher(eventRepo, eventBulkUpdateSize)
+		b.afterWrite = func(written []repository.EventStatusUpdate) {
+			incrRelationEventCount(relRepo, written)
+		}
+		return b
+	}
+
+	// 聚合中断，状态没有写入时事件关联数量不变，重复执行 EnsureEventRelation 也不会重复计数
+	evt.Status = repository.EventStatusGrouped
+	evt.RelationID = []primitive.ObjectID{rel.ID}
+	assert.NoError(t, newBatcher().Add(evt))
+	_, err = relRepo.EnsureEventRelation(context.TODO(), "host-1", rel.MatchedRuleID)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, relRepo.Relations[0].EventCount)
+
+	// 状态写入之后增加事件数量
+	b := newBatcher()
+	assert.NoError(t, b.Add(evt))
+	assert.NoError(t, b.Flush())
+	assert.EqualValues(t, 1, relRepo.Relations[0].EventCount)
+
+	// 写入失败的事件不计数
+	missing := repository.Event{ID: primitive.NewObjectID(), Status: repository.EventStatusGrouped, RelationID: []primitive.ObjectID{rel.ID}}
+	b = newBatcher()
+	assert.NoError(t, b.Add(missing))
+	assert.Error(t, b.Flush())
+	assert.EqualValues(t, 1, relRepo.Relations[0].EventCount)
+}
@@ -1,15 +1,19 @@
 package job_test
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"testing"
 	"time"
 
+	"github.com/mylxsw/adanos-alert/configs"
 	"github.com/mylxsw/adanos-alert/internal/job"
 	"github.com/mylxsw/adanos-alert/internal/repository"
 	mockRepo "github.com/mylxsw/adanos-alert/test/mock/repository"
 	"github.com/mylxsw/container"
+	"github.com/mylxsw/glacier/event"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"go.mongodb.org/mongo-driver/bson"
 )
@@ -21,9 +25,13 @@ type AggregationTestSuite struct {
 
 func (a *AggregationTestSuite) SetupTest() {
 	cc := container.New()
+	cc.MustSingleton(func() *configs.Config { return &configs.Config{QueueWorkerNum: 4} })
 	cc.MustSingleton(mockRepo.NewMessageRepo)
 	cc.MustSingleton(mockRepo.NewMessageGroupRepo)
 	cc.MustSingleton(mockRepo.NewRuleRepo)
+	cc.MustSingleton(mockRepo.NewEventRelationRepo)
+	cc.MustSingleton(func() event.Manager { return event.NewEventManager(event.NewMemoryEventStore(false)) })
+	cc.MustSingleton(job.NewRunningJobs)
 
 	a.app = cc
 }
@@ -51,7 +59,7 @@ func (a *AggregationTestSuite) TestAggregationJob() {
 		})
 		a.NoError(err)
 
-		// add some messages 
+		// add some messages
 		for i := 0; i < 10; i++ {
 			_, err = mockMsgRepo.Add(repository.Event{
 				Content: fmt.Sprintf("Hello, world #%d", i),
@@ -93,18 +101,180 @@ func (a *AggregationTestSuite) TestAggregationJob() {
 		a.EqualValues(5, canceledMsgCount)
 
 		// message grouping
-		// change create_at -10s
-		mockMsgGroupRepo.Groups[0].CreatedAt = mockMsgGroupRepo.Groups[0].CreatedAt.Add(-10 * time.Second)
+		// change expect_ready_at -10s
+		mockMsgGroupRepo.Groups[0].Rule.ExpectReadyAt = mockMsgGroupRepo.Groups[0].Rule.ExpectReadyAt.Add(-10 * time.Second)
 		job.NewAggregationJob(a.app).Handle()
 		a.Equal(repository.EventGroupStatusCollecting, mockMsgGroupRepo.Groups[0].Status)
 
-		// change created_at -30s, reach grouping condition
-		mockMsgGroupRepo.Groups[0].CreatedAt = mockMsgGroupRepo.Groups[0].CreatedAt.Add(-20 * time.Second)
+		// change expect_ready_at -30s, reach grouping condition
+		mockMsgGroupRepo.Groups[0].Rule.ExpectReadyAt = mockMsgGroupRepo.Groups[0].Rule.ExpectReadyAt.Add(-20 * time.Second)
 		job.NewAggregationJob(a.app).Handle()
 		a.Equal(repository.EventGroupStatusPending, mockMsgGroupRepo.Groups[0].Status)
 	})
 }
 
+func (a *AggregationTestSuite) TestAggregationJobCanceled() {
+	a.app.MustResolve(func(msgRepo repository.EventRepo, ruleRepo repository.RuleRepo, jobs *job.RunningJobs) {
+		_, err := ruleRepo.Add(repository.Rule{
+			Name:     "test",
+			Rule:     `"php" in Tags`,
+			Interval: 30,
+			Status:   repository.RuleStatusEnabled,
+		})
+		a.NoError(err)
+
+		for i := 0; i < 5; i++ {
+			_, err = msgRepo.Add(repository.Event{
+				Content: fmt.Sprintf("Hello, world #%d", i),
+				Tags:    []string{"php"},
+				Status:  repository.EventStatusPending,
+			})
+			a.NoError(err)
+		}
+
+		// 服务停止之后，执行中的聚合任务中断遍历，事件保持 pending 状态，等待下次执行
+		a.True(jobs.Shutdown(time.Second))
+		job.NewAggregationJob(a.app).Handle()
+
+		pendingCount, err := msgRepo.Count(bson.M{"status": repository.EventStatusPending})
+		a.NoError(err)
+		a.EqualValues(5, pendingCount)
+	})
+}
+
 func TestAggregationJob_Handle(t *testing.T) {
 	suite.Run(t, new(AggregationTestSuite))
 }
+
+func BenchmarkAggregationJob_Handle(b *testing.B) {
+	for _, workerNum := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("worker-%d", workerNum), func(b *testing.B) {
+			cc := container.New()
+			cc.MustSingleton(func() *configs.Config { return &configs.Config{QueueWorkerNum: workerNum} })
+			cc.MustSingleton(mockRepo.NewMessageRepo)
+			cc.MustSingleton(mockRepo.NewMessageGroupRepo)
+			cc.MustSingleton(mockRepo.NewRuleRepo)
+			cc.MustSingleton(mockRepo.NewEventRelationRepo)
+			cc.MustSingleton(func() event.Manager { return event.NewEventManager(event.NewMemoryEventStore(false)) })
+			cc.MustSingleton(job.NewRunningJobs)
+
+			cc.MustResolve(func(msgRepo repository.EventRepo, ruleRepo repository.RuleRepo) {
+				for i := 0; i < 20; i++ {
+					_, _ = ruleRepo.Add(repository.Rule{
+						Name:          fmt.Sprintf("rule-%d", i),
+						Rule:          fmt.Sprintf(`JsonGet("service", "") == "service-%d" and Content matches "timeout|refused"`, i),
+						AggregateRule: `JsonGet("host", "")`,
+						Interval:      30,
+						Status:        repository.RuleStatusEnabled,
+					})
+				}
+
+				job := job.NewAggregationJob(cc)
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					_ = msgRepo.Delete(bson.M{})
+					// synthetic backlog of pending events
+					for j := 0; j < 2000; j++ {
+						_, _ = msgRepo.Add(repository.Event{
+							Content: fmt.Sprintf(`{"service": "service-%d", "host": "host-%d", "message": "connect to upstream: timeout"}`, j%40, j%5),
+							Status:  repository.EventStatusPending,
+						})
+					}
+					b.StartTimer()
+
+					job.Handle()
+				}
+			})
+		})
+	}
+}
+
+func TestAggregationJob_FirstMatchMode(t *testing.T) {
+	cc := container.New()
+	cc.MustSingleton(func() *configs.Config {
+		return &configs.Config{
+			QueueWorkerNum:      1,
+			RuleMatchMode:       configs.RuleMatchModeFirst,
+			RuleMatchModeByType: map[string]string{string(repository.EventTypeRecovery): configs.RuleMatchModeAll},
+		}
+	})
+	cc.MustSingleton(mockRepo.NewMessageRepo)
+	cc.MustSingleton(mockRepo.NewMessageGroupRepo)
+	cc.MustSingleton(mockRepo.NewRuleRepo)
+	cc.MustSingleton(mockRepo.NewEventRelationRepo)
+	cc.MustSingleton(func() event.Manager { return event.NewEventManager(event.NewMemoryEventStore(false)) })
+	cc.MustSingleton(job.NewRunningJobs)
+
+	cc.MustResolve(func(msgRepo repository.EventRepo, groupRepo repository.EventGroupRepo, ruleRepo repository.RuleRepo) {
+		_, err := ruleRepo.Add(repository.Rule{Name: "low", Rule: `"php" in Tags`, Interval: 30, Status: repository.RuleStatusEnabled})
+		assert.NoError(t, err)
+		highID, err := ruleRepo.Add(repository.Rule{Name: "high", Rule: `"php" in Tags`, Priority: 10, Interval: 30, Status: repository.RuleStatusEnabled})
+		assert.NoError(t, err)
+
+		plainID, err := msgRepo.Add(repository.Event{Tags: []string{"php"}, Type: repository.EventTypePlain, Status: repository.EventStatusPending})
+		assert.NoError(t, err)
+		recoveryID, err := msgRepo.Add(repository.Event{Tags: []string{"php"}, Type: repository.EventTypeRecovery, Status: repository.EventStatusPending})
+		assert.NoError(t, err)
+
+		job.NewAggregationJob(cc).Handle()
+
+		// first 模式下只分配到优先级最高的规则的事件组
+		plain, err := msgRepo.Get(plainID)
+		assert.NoError(t, err)
+		assert.Equal(t, repository.EventStatusGrouped, plain.Status)
+		if assert.Len(t, plain.GroupID, 1) {
+			for _, grp := range groupRepo.(*mockRepo.EventGroupRepo).Groups {
+				if grp.ID == plain.GroupID[0] {
+					assert.Equal(t, highID, grp.Rule.ID)
+				}
+			}
+		}
+
+		// recovery 类型的事件覆盖为 all 模式，分配到所有匹配规则的事件组
+		recovery, err := msgRepo.Get(recoveryID)
+		assert.NoError(t, err)
+		assert.Len(t, recovery.GroupID, 2)
+	})
+}
+
+func TestAggregationJob_ClaimEvents(t *testing.T) {
+	cc := container.New()
+	cc.MustSingleton(func() *configs.Config {
+		return &configs.Config{QueueWorkerNum: 2, AggregationMaxConcurrent: 2}
+	})
+	cc.MustSingleton(mockRepo.NewMessageRepo)
+	cc.MustSingleton(mockRepo.NewMessageGroupRepo)
+	cc.MustSingleton(mockRepo.NewRuleRepo)
+	cc.MustSingleton(mockRepo.NewEventRelationRepo)
+	cc.MustSingleton(func() event.Manager { return event.NewEventManager(event.NewMemoryEventStore(false)) })
+	cc.MustSingleton(job.NewRunningJobs)
+
+	cc.MustResolve(func(msgRepo repository.EventRepo, ruleRepo repository.RuleRepo) {
+		_, err := ruleRepo.Add(repository.Rule{Name: "test", Rule: `"php" in Tags`, Interval: 30, Status: repository.RuleStatusEnabled})
+		assert.NoError(t, err)
+
+		claimedID, err := msgRepo.Add(repository.Event{Tags: []string{"php"}, Status: repository.EventStatusPending})
+		assert.NoError(t, err)
+		freeID, err := msgRepo.Add(repository.Event{Tags: []string{"php"}, Status: repository.EventStatusPending})
+		assert.NoError(t, err)
+
+		// 模拟另一个正在执行的聚合任务已经认领了事件
+		claimed, err := msgRepo.ClaimPending(context.TODO(), claimedID, "another-run", time.Now().Add(-time.Minute))
+		assert.NoError(t, err)
+		assert.True(t, claimed)
+
+		job.NewAggregationJob(cc).Handle()
+
+		// 被其它任务认领的事件不会被重复分组
+		evt, err := msgRepo.Get(claimedID)
+		assert.NoError(t, err)
+		assert.Equal(t, repository.EventStatusPending, evt.Status)
+		assert.Empty(t, evt.GroupID)
+
+		evt, err = msgRepo.Get(freeID)
+		assert.NoError(t, err)
+		assert.Equal(t, repository.EventStatusGrouped, evt.Status)
+	})
+}
@@ -0,0 +1,87 @@
+package job
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/action"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/asteria/log"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// escalationCheckWindow 检查升级的时间范围，就绪时间超过该时间的事件组不再检查升级
+// 在最大延迟时间的基础上预留一段余量，确保延迟接近或者等于最大延迟时间的步骤有足够的检查机会
+const escalationCheckWindow = repository.EscalationMaxDelay + time.Hour
+
+// escalateEventGroups 为动作执行成功但是一直没有被确认的事件组执行到期的升级步骤
+// 每次只执行每个动作的下一个升级步骤，后续步骤在之后的任务中继续检查
+func (a TriggerJob) escalateEventGroups(groupRepo repository.EventGroupRepo, ruleRepo repository.RuleRepo, manager action.Manager) {
+	now := time.Now()
+	filter := bson.M{
+		"status":                repository.EventGroupStatusOK,
+		"acked_at":              nil,
+		"actions.escalations.0": bson.M{"$exists": true},
+		"rule.expect_ready_at":  bson.M{"$gt": now.Add(-escalationCheckWindow)},
+	}
+
+	err := groupRepo.Traverse(filter, func(grp repository.EventGroup) error {
+		age := now.Sub(grp.Rule.ExpectReadyAt)
+
+		var rule *repository.Rule
+		for i, act := range grp.Actions {
+			if act.Status != repository.TriggerStatusOK {
+				continue
+			}
+
+			step, due := act.DueEscalation(age)
+			if !due {
+				continue
+			}
+
+			if rule == nil {
+				r, err := ruleRepo.Get(grp.Rule.ID)
+				if err != nil {
+					log.WithFields(log.Fields{
+						"rule_id": grp.Rule.ID.Hex(),
+						"grp_id":  grp.ID.Hex(),
+					}).Errorf("rule not exist: %v", err)
+					return nil
+				}
+
+				rule = &r
+			}
+
+			stepTrigger := repository.Trigger{
+				ID:       act.ID,
+				Name:     fmt.Sprintf("%s (escalation #%d)", act.Name, act.EscalatedSteps+1),
+				Action:   step.Action,
+				Meta:     step.Meta,
+				UserRefs: step.UserRefs,
+				Status:   repository.TriggerStatusOK,
+			}
+
+			if err := manager.Dispatch(step.Action).Handle(*rule, stepTrigger, grp); err != nil {
+				log.WithFields(log.Fields{
+					"grp_id":     grp.ID.Hex(),
+					"trigger_id": act.ID.Hex(),
+					"step":       act.EscalatedSteps + 1,
+				}).Errorf("escalate event group failed: %v", err)
+				continue
+			}
+
+			// 只更新该动作的升级步骤数量，事件组在读取之后被确认时不更新，避免覆盖确认信息
+			if _, err := groupRepo.MarkEscalated(grp.ID, i, act.EscalatedSteps); err != nil {
+				log.WithFields(log.Fields{
+					"grp_id":     grp.ID.Hex(),
+					"trigger_id": act.ID.Hex(),
+				}).Errorf("update escalated steps failed: %v", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Errorf("escalate event groups failed: %v", err)
+	}
+}
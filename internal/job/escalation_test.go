@@ -0,0 +1,85 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/action"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	mockRepo "github.com/mylxsw/adanos-alert/test/mock/repository"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// escalationTestAction 记录升级动作的执行次数，onHandle 用于模拟动作执行期间事件组被确认
+type escalationTestAction struct {
+	handled  int
+	onHandle func(grp repository.EventGroup)
+}
+
+func (a *escalationTestAction) Validate(meta string, userRefs []string) error { return nil }
+
+func (a *escalationTestAction) Handle(rule repository.Rule, trigger repository.Trigger, grp repository.EventGroup) error {
+	a.handled++
+	if a.onHandle != nil {
+		a.onHandle(grp)
+	}
+
+	return nil
+}
+
+type escalationTestManager struct {
+	action.Manager
+	act *escalationTestAction
+}
+
+func (m escalationTestManager) Dispatch(name string) action.Action { return m.act }
+
+func newEscalationTestRepos(t *testing.T) (*mockRepo.EventGroupRepo, repository.RuleRepo, primitive.ObjectID) {
+	ruleRepo := mockRepo.NewRuleRepo()
+	ruleID, err := ruleRepo.Add(repository.Rule{Name: "test"})
+	assert.NoError(t, err)
+
+	groupRepo := mockRepo.NewMessageGroupRepo().(*mockRepo.EventGroupRepo)
+	groupID := primitive.NewObjectID()
+	groupRepo.Groups = append(groupRepo.Groups, repository.EventGroup{
+		ID:     groupID,
+		Status: repository.EventGroupStatusOK,
+		Rule:   repository.EventGroupRule{ID: ruleID, ExpectReadyAt: time.Now().Add(-10 * time.Minute)},
+		Actions: []repository.Trigger{{
+			ID:          primitive.NewObjectID(),
+			Status:      repository.TriggerStatusOK,
+			Escalations: []repository.EscalationStep{{Delay: 60, Action: "test"}, {Delay: 3600, Action: "test"}},
+		}},
+	})
+
+	return groupRepo, ruleRepo, groupID
+}
+
+func TestTriggerJob_EscalateEventGroups(t *testing.T) {
+	groupRepo, ruleRepo, _ := newEscalationTestRepos(t)
+	act := &escalationTestAction{}
+
+	TriggerJob{}.escalateEventGroups(groupRepo, ruleRepo, escalationTestManager{act: act})
+	assert.Equal(t, 1, act.handled)
+	assert.Equal(t, 1, groupRepo.Groups[0].Actions[0].EscalatedSteps)
+
+	// 第二个步骤还没有到期
+	TriggerJob{}.escalateEventGroups(groupRepo, ruleRepo, escalationTestManager{act: act})
+	assert.Equal(t, 1, act.handled)
+	assert.Equal(t, 1, groupRepo.Groups[0].Actions[0].EscalatedSteps)
+}
+
+func TestTriggerJob_EscalateEventGroupsAckedConcurrently(t *testing.T) {
+	groupRepo, ruleRepo, groupID := newEscalationTestRepos(t)
+
+	// 升级动作执行期间事件组被确认，确认信息不能被覆盖
+	act := &escalationTestAction{onHandle: func(grp repository.EventGroup) {
+		assert.NoError(t, groupRepo.Ack(groupID, primitive.NewObjectID()))
+	}}
+
+	TriggerJob{}.escalateEventGroups(groupRepo, ruleRepo, escalationTestManager{act: act})
+	assert.Equal(t, 1, act.handled)
+	assert.NotNil(t, groupRepo.Groups[0].AckedAt)
+	assert.Equal(t, 0, groupRepo.Groups[0].Actions[0].EscalatedSteps)
+}
@@ -0,0 +1,159 @@
+package job
+
+import (
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/matcher"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/asteria/log"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// eventInhibitor 缓存一次调度中启用的抑制规则以及匹配源规则的活跃事件
+// 源事件只在有事件组匹配目标规则时查询一次，不再为每个 pending 事件组遍历全部事件
+type eventInhibitor struct {
+	eventRepo repository.EventRepo
+	matchers  []*matcher.InhibitMatcher
+	now       time.Time
+
+	loaded  bool
+	sources [][]repository.Event // 与 matchers 一一对应
+}
+
+// newEventInhibitor 加载启用的抑制规则，加载失败时返回的 eventInhibitor 不会抑制任何事件组
+func newEventInhibitor(inhibitRuleRepo repository.InhibitRuleRepo, eventRepo repository.EventRepo, now time.Time) (*eventInhibitor, error) {
+	inhibitor := &eventInhibitor{eventRepo: eventRepo, now: now}
+
+	rules, err := inhibitRuleRepo.Find(bson.M{"enabled": true})
+	if err != nil {
+		return inhibitor, err
+	}
+
+	for _, rule := range rules {
+		m, err := matcher.NewInhibitMatcher(rule)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"inhibit_rule_id": rule.ID.Hex(),
+			}).Errorf("invalid inhibit rule: %v", err)
+			continue
+		}
+
+		inhibitor.matchers = append(inhibitor.matchers, m)
+	}
+
+	return inhibitor, nil
+}
+
+// loadSources 查询所有抑制规则有效时间内产生的事件，按抑制规则保留匹配源规则的事件
+func (in *eventInhibitor) loadSources() error {
+	if in.loaded {
+		return nil
+	}
+
+	var window time.Duration
+	for _, m := range in.matchers {
+		if m.Rule().ActiveWindow() > window {
+			window = m.Rule().ActiveWindow()
+		}
+	}
+
+	sources := make([][]repository.Event, len(in.matchers))
+	err := in.eventRepo.Traverse(bson.M{"created_at": bson.M{"$gte": in.now.Add(-window)}}, func(evt repository.Event) error {
+		for i, m := range in.matchers {
+			if m.MatchSource(evt) {
+				sources[i] = append(sources[i], evt)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	in.sources = sources
+	in.loaded = true
+	return nil
+}
+
+// inhibitedBy 检查事件组是否被抑制规则抑制，被抑制时返回抑制信息，否则返回 nil
+// 使用事件组中的第一个事件作为目标事件
+func (in *eventInhibitor) inhibitedBy(grp repository.EventGroup) (*repository.EventGroupInhibition, error) {
+	if len(in.matchers) == 0 {
+		return nil, nil
+	}
+
+	samples, _, err := in.eventRepo.Paginate(bson.M{"group_ids": grp.ID}, 0, 1)
+	if err != nil || len(samples) == 0 {
+		return nil, err
+	}
+
+	target := samples[0]
+	for i, m := range in.matchers {
+		if !m.MatchTarget(target) {
+			continue
+		}
+
+		if err := in.loadSources(); err != nil {
+			return nil, err
+		}
+
+		for _, source := range in.sources[i] {
+			// 事件组自身的事件不能作为源事件
+			if belongsToGroup(source, grp) {
+				continue
+			}
+
+			if m.Inhibits(source, target, in.now) {
+				return &repository.EventGroupInhibition{
+					RuleID:        m.Rule().ID,
+					RuleName:      m.Rule().Name,
+					SourceEventID: source.ID,
+					CreatedAt:     in.now,
+				}, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// holdInhibitedGroup 事件组被抑制时保持 pending 状态并记录抑制信息，每次调度时重新检查，抑制解除后清空抑制信息，继续执行动作
+// 返回 true 时事件组被抑制，本次调度不执行动作
+func holdInhibitedGroup(groupRepo repository.EventGroupRepo, inhibitor *eventInhibitor, grp *repository.EventGroup) (bool, error) {
+	inhibition, err := inhibitor.inhibitedBy(*grp)
+	if err != nil {
+		// 检查失败时保持事件组原来的抑制状态
+		log.WithFields(log.Fields{
+			"grp_id": grp.ID,
+		}).Errorf("check inhibit rules failed: %v", err)
+		return grp.Inhibition != nil, nil
+	}
+
+	if inhibition == nil {
+		grp.Inhibition = nil
+		return false, nil
+	}
+
+	if grp.Inhibition != nil && grp.Inhibition.RuleID == inhibition.RuleID {
+		return true, nil
+	}
+
+	log.WithFields(log.Fields{
+		"grp_id":     grp.ID,
+		"inhibition": inhibition,
+	}).Debug("event group is inhibited")
+
+	grp.Inhibition = inhibition
+	return true, groupRepo.UpdateID(grp.ID, *grp)
+}
+
+func belongsToGroup(evt repository.Event, grp repository.EventGroup) bool {
+	for _, gid := range evt.GroupID {
+		if gid == grp.ID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,109 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	mockRepo "github.com/mylxsw/adanos-alert/test/mock/repository"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type inhibitTestRuleRepo struct {
+	repository.InhibitRuleRepo
+	rules []repository.InhibitRule
+}
+
+func (r inhibitTestRuleRepo) Find(filter bson.M) ([]repository.InhibitRule, error) {
+	return r.rules, nil
+}
+
+// inhibitTestEventRepo 记录遍历事件的次数
+type inhibitTestEventRepo struct {
+	*mockRepo.MessageRepo
+	traversed int
+}
+
+func (r *inhibitTestEventRepo) Traverse(filter interface{}, cb func(msg repository.Event) error) error {
+	r.traversed++
+	return r.MessageRepo.Traverse(filter, cb)
+}
+
+func TestHoldInhibitedGroup(t *testing.T) {
+	ruleRepo := inhibitTestRuleRepo{rules: []repository.InhibitRule{{
+		ID:         primitive.NewObjectID(),
+		Name:       "service down",
+		SourceRule: `Meta["alertname"] == "ServiceDown"`,
+		TargetRule: `Meta["alertname"] == "HighLatency"`,
+		Equal:      []string{"service"},
+		Enabled:    true,
+	}}}
+
+	groupRepo := mockRepo.NewMessageGroupRepo().(*mockRepo.EventGroupRepo)
+	eventRepo := &inhibitTestEventRepo{MessageRepo: mockRepo.NewMessageRepo().(*mockRepo.MessageRepo)}
+
+	now := time.Now()
+	targets := make([]repository.EventGroup, 0)
+	for _, service := range []string{"order", "user"} {
+		grp := repository.EventGroup{ID: primitive.NewObjectID(), Status: repository.EventGroupStatusPending}
+		groupRepo.Groups = append(groupRepo.Groups, grp)
+		eventRepo.Messages = append(eventRepo.Messages, repository.Event{
+			ID:        primitive.NewObjectID(),
+			GroupID:   []primitive.ObjectID{grp.ID},
+			Meta:      repository.EventMeta{"alertname": "HighLatency", "service": service},
+			CreatedAt: now,
+		})
+		targets = append(targets, grp)
+	}
+
+	source := repository.Event{
+		ID:        primitive.NewObjectID(),
+		GroupID:   []primitive.ObjectID{primitive.NewObjectID()},
+		Meta:      repository.EventMeta{"alertname": "ServiceDown", "service": "order"},
+		CreatedAt: now.Add(-time.Minute),
+	}
+	eventRepo.Messages = append(eventRepo.Messages, source)
+
+	inhibitor, err := newEventInhibitor(ruleRepo, eventRepo, now)
+	assert.NoError(t, err)
+
+	// 被抑制的事件组保持 pending 状态，并记录抑制信息
+	grp := targets[0]
+	held, err := holdInhibitedGroup(groupRepo, inhibitor, &grp)
+	assert.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, repository.EventGroupStatusPending, groupRepo.Groups[0].Status)
+	assert.NotNil(t, groupRepo.Groups[0].Inhibition)
+	assert.Equal(t, source.ID, groupRepo.Groups[0].Inhibition.SourceEventID)
+
+	// Equal 中的 Meta 不同，不被抑制
+	other := targets[1]
+	held, err = holdInhibitedGroup(groupRepo, inhibitor, &other)
+	assert.NoError(t, err)
+	assert.False(t, held)
+	assert.Nil(t, groupRepo.Groups[1].Inhibition)
+
+	// 同一次调度中源事件只查询一次
+	assert.Equal(t, 1, eventRepo.traversed)
+
+	// 源事件仍然活跃时，下一次调度事件组继续被抑制
+	inhibitor, err = newEventInhibitor(ruleRepo, eventRepo, now.Add(time.Minute))
+	assert.NoError(t, err)
+
+	grp = groupRepo.Groups[0]
+	held, err = holdInhibitedGroup(groupRepo, inhibitor, &grp)
+	assert.NoError(t, err)
+	assert.True(t, held)
+
+	// 源事件超过有效时间后抑制解除，事件组继续执行动作
+	inhibitor, err = newEventInhibitor(ruleRepo, eventRepo, now.Add(time.Duration(repository.DefaultInhibitWindow)*time.Second))
+	assert.NoError(t, err)
+
+	grp = groupRepo.Groups[0]
+	held, err = holdInhibitedGroup(groupRepo, inhibitor, &grp)
+	assert.NoError(t, err)
+	assert.False(t, held)
+	assert.Nil(t, grp.Inhibition)
+}
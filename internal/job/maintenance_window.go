@@ -0,0 +1,49 @@
+package job
+
+import (
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/matcher"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/asteria/log"
+	"github.com/mylxsw/container"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// heldByMaintenanceWindow 返回当前处于开启状态并且与事件组匹配的维护窗口，没有时返回 nil
+func heldByMaintenanceWindow(cc container.Container, windowRepo repository.MaintenanceWindowRepo, grp repository.EventGroup, eventCallback func() []repository.Event) (*repository.MaintenanceWindow, error) {
+	windows, err := windowRepo.Find(bson.M{"enabled": true})
+	if err != nil || len(windows) == 0 {
+		return nil, err
+	}
+
+	now := time.Now()
+	triggerCtx := matcher.NewTriggerContext(cc, repository.Trigger{}, grp, eventCallback)
+	for _, w := range windows {
+		open, err := w.OpenAt(now)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"maintenance_window_id": w.ID.Hex(),
+			}).Errorf("invalid maintenance window schedule: %v", err)
+			continue
+		}
+
+		if !open {
+			continue
+		}
+
+		m, err := matcher.NewTriggerMatcher(repository.Trigger{PreCondition: w.Matcher})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"maintenance_window_id": w.ID.Hex(),
+			}).Errorf("invalid maintenance window matcher: %v", err)
+			continue
+		}
+
+		if matched, err := m.Match(triggerCtx); err == nil && matched {
+			return &w, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,98 @@
+package job
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "adanos"
+
+var (
+	// aggregationEventsProcessed 聚合任务处理的事件总数
+	aggregationEventsProcessed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "aggregation",
+		Name:      "events_processed_total",
+		Help:      "Total number of pending events processed by the aggregation job",
+	})
+	// aggregationEventsByStatus 聚合任务处理后的事件数量，按照事件的最终状态（grouped/canceled/ignored）区分
+	aggregationEventsByStatus = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "aggregation",
+		Name:      "events_total",
+		Help:      "Total number of events processed by the aggregation job, partitioned by the resulting status",
+	}, []string{"status"})
+	// aggregationGroupsPending 聚合任务中转换为 pending 状态的事件组数量
+	aggregationGroupsPending = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "aggregation",
+		Name:      "groups_pending_total",
+		Help:      "Total number of event groups transitioned to pending by the aggregation job",
+	})
+	// aggregationRunDuration 聚合任务每次执行的耗时
+	aggregationRunDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "aggregation",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of each aggregation job run in seconds",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	})
+	// aggregationSkippedRuns 因为上一次执行未完成而跳过的执行次数
+	aggregationSkippedRuns = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "aggregation",
+		Name:      "skipped_runs_total",
+		Help:      "Total number of aggregation job runs skipped because the previous run is not finished yet",
+	})
+	// aggregationSinceLastSuccess 距离最近一次成功执行完成的时间，从未成功执行时从进程启动开始计算
+	aggregationSinceLastSuccess = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "aggregation",
+		Name:      "seconds_since_last_success",
+		Help:      "Seconds since the last successful completion of the aggregation job",
+	}, func() float64 {
+		return time.Since(time.Unix(0, atomic.LoadInt64(&aggregationLastSuccess))).Seconds()
+	})
+)
+
+var (
+	// retentionPurged 数据清理任务删除的记录总数，按照数据类型（events/groups/recoveries）区分
+	retentionPurged = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "retention",
+		Name:      "purged_total",
+		Help:      "Total number of records purged by the retention job, partitioned by kind",
+	}, []string{"kind"})
+	// retentionLastRunPurged 数据清理任务最近一次执行删除的记录数量
+	retentionLastRunPurged = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "retention",
+		Name:      "last_run_purged",
+		Help:      "Number of records purged by the last retention job run, partitioned by kind",
+	}, []string{"kind"})
+)
+
+// aggregationLastSuccess 聚合任务最近一次成功执行完成的时间（UnixNano），使用 atomic 读写
+var aggregationLastSuccess = time.Now().UnixNano()
+
+// aggregationCollectors 返回聚合任务的所有指标采集器
+func aggregationCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		aggregationEventsProcessed,
+		aggregationEventsByStatus,
+		aggregationGroupsPending,
+		aggregationRunDuration,
+		aggregationSkippedRuns,
+		aggregationSinceLastSuccess,
+	}
+}
+
+// retentionCollectors 返回数据清理任务的所有指标采集器
+func retentionCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		retentionPurged,
+		retentionLastRunPurged,
+	}
+}
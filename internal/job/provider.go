@@ -1,14 +1,18 @@
 package job
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/mylxsw/adanos-alert/internal/matcher"
 	"github.com/mylxsw/adanos-alert/internal/repository"
 	"github.com/mylxsw/asteria/log"
 	"github.com/mylxsw/glacier/infra"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/mylxsw/adanos-alert/configs"
@@ -22,42 +26,178 @@ func (s ServiceProvider) Register(app container.Container) {
 	app.MustSingleton(NewAggregationJob)
 	app.MustSingleton(NewTrigger)
 	app.MustSingleton(NewRecoveryJob)
+	app.MustSingleton(NewRetentionJob)
+	app.MustSingleton(NewRunningJobs)
+	app.MustSingleton(func(conf *configs.Config, lockRepo repository.LockRepo, jobs *RunningJobs) *DistributeLockManager {
+		hostname, _ := os.Hostname()
+		lockManager := NewDistributeLockManager(lockRepo, fmt.Sprintf("%s(%s)", hostname, conf.Listen), conf.LockTTL, conf.LockRenewInterval)
+		lockManager.WaitJobsBeforeUnlock(jobs, conf.JobShutdownTimeout)
+
+		return lockManager
+	})
+
+	// 聚合任务指标，通过 /metrics 暴露
+	prometheus.MustRegister(aggregationCollectors()...)
+	prometheus.MustRegister(retentionCollectors()...)
 }
 
 func (s ServiceProvider) Boot(app infra.Glacier) {
+	app.MustResolve(func(conf *configs.Config) {
+		if err := matcher.SetHolidays(conf.Holidays); err != nil {
+			log.Errorf("load holidays failed: %v", err)
+		}
+	})
+
 	app.Cron(func(cr cron.Manager, cc container.Container) error {
 
-		return cc.Resolve(func(conf *configs.Config, aggregationJob *AggregationJob, alertJob *TriggerJob, recoveryJob *RecoveryJob, lockRepo repository.LockRepo) {
-			hostname, _ := os.Hostname()
-			cr.DistributeLockManager(NewDistributeLockManager(lockRepo, fmt.Sprintf("%s(%s)", hostname, conf.Listen)))
+		return cc.Resolve(func(conf *configs.Config, aggregationJob *AggregationJob, alertJob *TriggerJob, recoveryJob *RecoveryJob, retentionJob *RetentionJob, lockManager *DistributeLockManager, jobs *RunningJobs) {
+			cr.DistributeLockManager(lockManager)
 
-			_ = cr.Add(AggregationJobName, fmt.Sprintf("@every %s", conf.AggregationPeriod), aggregationJob.Handle)
-			_ = cr.Add(TriggerJobName, fmt.Sprintf("@every %s", conf.ActionTriggerPeriod), alertJob.Handle)
+			_ = cr.Add(AggregationJobName, fmt.Sprintf("@every %s", conf.AggregationPeriod), jobs.Wrap(aggregationJob.Handle))
+			_ = cr.Add(TriggerJobName, fmt.Sprintf("@every %s", conf.ActionTriggerPeriod), jobs.Wrap(alertJob.Handle))
 			_ = cr.Add(RecoveryJobName, fmt.Sprintf("@every %s", conf.AggregationPeriod), recoveryJob.Handle)
+
+			if conf.Retention.Enabled() {
+				_ = cr.Add(RetentionJobName, fmt.Sprintf("@every %s", conf.Retention.Period), jobs.Wrap(retentionJob.Handle))
+			}
 		})
 	})
 }
 
+// Daemon 在后台定期续期分布式锁，避免执行时间较长的任务运行期间锁过期
+func (s ServiceProvider) Daemon(ctx context.Context, app infra.Glacier) {
+	app.MustResolve(func(lockManager *DistributeLockManager) {
+		lockManager.RenewLoop(ctx)
+	})
+}
+
+const (
+	// defaultLockTTL 默认的分布式锁有效期
+	defaultLockTTL = 90 * time.Second
+)
+
 type DistributeLockManager struct {
-	syncLock sync.RWMutex
-	lockRepo repository.LockRepo
-	lockID   primitive.ObjectID
-	locked   bool
-	owner    string
+	syncLock      sync.RWMutex
+	lockRepo      repository.LockRepo
+	lockID        primitive.ObjectID
+	locked        bool
+	owner         string
+	expiredAt     time.Time
+	ttl           time.Duration
+	renewInterval time.Duration
+
+	// jobs 释放锁之前需要等待执行完成的任务，shutdownTimeout 为最长等待时间
+	jobs            *RunningJobs
+	shutdownTimeout time.Duration
 }
 
-func NewDistributeLockManager(lockRepo repository.LockRepo, owner string) cron.DistributeLockManager {
-	return &DistributeLockManager{lockRepo: lockRepo, locked: false, owner: owner}
+// LockStatus 当前节点持有分布式锁的状态
+type LockStatus struct {
+	Resource  string    `json:"resource"`
+	Owner     string    `json:"owner"`
+	Locked    bool      `json:"locked"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// NewDistributeLockManager create a new DistributeLockManager
+// ttl 为锁的有效期，renewInterval 为后台续期的时间间隔，不合法时使用 ttl 的 1/3
+func NewDistributeLockManager(lockRepo repository.LockRepo, owner string, ttl time.Duration, renewInterval time.Duration) *DistributeLockManager {
+	if ttl < time.Second {
+		ttl = defaultLockTTL
+	}
+
+	if renewInterval <= 0 || renewInterval >= ttl {
+		renewInterval = ttl / 3
+	}
+
+	return &DistributeLockManager{lockRepo: lockRepo, locked: false, owner: owner, ttl: ttl, renewInterval: renewInterval}
+}
+
+var _ cron.DistributeLockManager = (*DistributeLockManager)(nil)
+
+// WaitJobsBeforeUnlock 设置释放锁之前需要等待执行完成的任务
+// 服务停止时，等待执行中的任务完成（最长等待 timeout）之后再释放锁，避免其它节点获取锁之后与当前节点同时执行任务
+func (d *DistributeLockManager) WaitJobsBeforeUnlock(jobs *RunningJobs, timeout time.Duration) {
+	d.syncLock.Lock()
+	defer d.syncLock.Unlock()
+
+	d.jobs = jobs
+	d.shutdownTimeout = timeout
 }
 
 var lockResource = "crontab-lock"
 
+// Status return the lock status of current node
+func (d *DistributeLockManager) Status() LockStatus {
+	d.syncLock.RLock()
+	defer d.syncLock.RUnlock()
+
+	return LockStatus{
+		Resource:  lockResource,
+		Owner:     d.owner,
+		Locked:    d.locked,
+		ExpiredAt: d.expiredAt,
+	}
+}
+
+// RenewLoop 每隔 renewInterval 续期一次当前节点持有的锁，直到 ctx 结束
+func (d *DistributeLockManager) RenewLoop(ctx context.Context) {
+	ticker := time.NewTicker(d.renewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.renew(); err != nil {
+				log.Errorf("renew distribute lock failed: %v", err)
+			}
+		}
+	}
+}
+
+// renew 续期当前节点持有的锁，没有持有锁时不做任何处理
+func (d *DistributeLockManager) renew() error {
+	d.syncLock.Lock()
+	defer d.syncLock.Unlock()
+
+	if !d.locked {
+		return nil
+	}
+
+	lock, err := d.lockRepo.Renew(d.lockID, d.ttlSeconds())
+	if err != nil {
+		if err == repository.ErrLockNotFound {
+			// 锁已经被其它节点获取
+			d.locked = false
+			d.lockID = primitive.NilObjectID
+			return nil
+		}
+
+		return err
+	}
+
+	d.expiredAt = lock.ExpiredAt
+	return nil
+}
+
+func (d *DistributeLockManager) ttlSeconds() uint {
+	return uint(d.ttl / time.Second)
+}
+
 func (d *DistributeLockManager) TryLock() error {
 	d.syncLock.Lock()
 	defer d.syncLock.Unlock()
 
+	// 服务正在停止，不再获取锁
+	if d.jobs != nil && d.jobs.Closed() {
+		return nil
+	}
+
 	if d.locked {
-		if _, err := d.lockRepo.Renew(d.lockID, 90); err != nil {
+		lock, err := d.lockRepo.Renew(d.lockID, d.ttlSeconds())
+		if err != nil {
 			if err == repository.ErrLockNotFound {
 				if err := d.lock(); err != nil {
 					return err
@@ -65,6 +205,8 @@ func (d *DistributeLockManager) TryLock() error {
 			} else {
 				return errors.Wrap(err, "renew lock failed")
 			}
+		} else {
+			d.expiredAt = lock.ExpiredAt
 		}
 	} else {
 		if err := d.lock(); err != nil {
@@ -76,11 +218,12 @@ func (d *DistributeLockManager) TryLock() error {
 }
 
 func (d *DistributeLockManager) lock() error {
-	lock, err := d.lockRepo.Lock(lockResource, d.owner, 90)
+	lock, err := d.lockRepo.Lock(lockResource, d.owner, d.ttlSeconds())
 	if err != nil {
 		if err == repository.ErrAlreadyLocked {
 			d.lockID = primitive.NilObjectID
 			d.locked = false
+			d.expiredAt = time.Time{}
 			return nil
 		}
 
@@ -89,6 +232,7 @@ func (d *DistributeLockManager) lock() error {
 
 	d.lockID = lock.LockID
 	d.locked = true
+	d.expiredAt = lock.ExpiredAt
 
 	if log.DebugEnabled() {
 		log.Debugf("got distribute lock, owner=%s", d.owner)
@@ -97,7 +241,17 @@ func (d *DistributeLockManager) lock() error {
 	return nil
 }
 
+// TryUnLock 释放锁，在定时任务管理器停止（服务停止）时调用
+// 释放之前会等待执行中的任务完成，等待期间锁会在后台继续续期
 func (d *DistributeLockManager) TryUnLock() error {
+	d.syncLock.RLock()
+	jobs, timeout := d.jobs, d.shutdownTimeout
+	d.syncLock.RUnlock()
+
+	if jobs != nil && !jobs.Shutdown(timeout) {
+		log.Warningf("running jobs are not finished in %v, release distribute lock anyway", timeout)
+	}
+
 	d.syncLock.Lock()
 	defer d.syncLock.Unlock()
 
@@ -111,6 +265,7 @@ func (d *DistributeLockManager) TryUnLock() error {
 
 	d.locked = false
 	d.lockID = primitive.NilObjectID
+	d.expiredAt = time.Time{}
 
 	if log.DebugEnabled() {
 		log.Debugf("distribute lock has been released")
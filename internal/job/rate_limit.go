@@ -0,0 +1,153 @@
+package job
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/action"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/asteria/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// rateLimitSummaryTemplate 频率限制汇总通知模板
+const rateLimitSummaryTemplate = `## {{ .Rule.Name }}
+
+通知过于频繁，触发了规则的频率限制，期间共有 %d 条通知没有发送。
+
+{{ if .PreviewURL }}[查看最后一条]({{ .PreviewURL }}){{ end }}`
+
+// rateLimitAllower 返回检查事件组是否允许发送通知的函数，只在第一次调用时消耗令牌，同一个事件组的多个动作共享一个令牌
+func rateLimitAllower(rateLimitRepo repository.RateLimitRepo, rule repository.Rule, grp repository.EventGroup) func() bool {
+	var once sync.Once
+	allowed := true
+
+	return func() bool {
+		once.Do(func() {
+			allowed = takeRateLimitToken(rateLimitRepo, rule, grp)
+		})
+
+		return allowed
+	}
+}
+
+// takeRateLimitToken 从规则的令牌桶中取出一个令牌，没有可用令牌时记录被抑制的通知，返回 false
+func takeRateLimitToken(rateLimitRepo repository.RateLimitRepo, rule repository.Rule, grp repository.EventGroup) bool {
+	if !rule.RateLimit.Enabled() {
+		return true
+	}
+
+	now := time.Now()
+	bucket, err := rateLimitRepo.Get(rule.ID)
+	if err != nil {
+		if err != repository.ErrNotFound {
+			// 查询失败时不限制，避免丢失通知
+			log.WithFields(log.Fields{
+				"rule_id": rule.ID.Hex(),
+			}).Errorf("query rate limit bucket failed: %v", err)
+			return true
+		}
+
+		bucket = repository.NewRateLimitBucket(rule.ID, rule.RateLimit, now)
+	}
+
+	allowed := bucket.Take(rule.RateLimit, now)
+	if !allowed {
+		bucket.Suppressed++
+		bucket.LastSuppressedGroupID = grp.ID
+	}
+
+	if err := rateLimitRepo.Save(bucket); err != nil {
+		log.WithFields(log.Fields{
+			"rule_id": rule.ID.Hex(),
+		}).Errorf("save rate limit bucket failed: %v", err)
+	}
+
+	return allowed
+}
+
+// flushRateLimitSummaries 令牌恢复后，为存在被抑制通知的规则发送一条汇总通知
+func (a TriggerJob) flushRateLimitSummaries(rateLimitRepo repository.RateLimitRepo, ruleRepo repository.RuleRepo, groupRepo repository.EventGroupRepo, manager action.Manager) {
+	buckets, err := rateLimitRepo.Find(bson.M{"suppressed": bson.M{"$gt": 0}})
+	if err != nil {
+		log.Errorf("query rate limit buckets failed: %v", err)
+		return
+	}
+
+	for _, bucket := range buckets {
+		rule, err := ruleRepo.Get(bucket.RuleID)
+		if err != nil {
+			if err == repository.ErrNotFound {
+				_ = rateLimitRepo.DeleteID(bucket.RuleID)
+			}
+
+			continue
+		}
+
+		if !bucket.Take(rule.RateLimit, time.Now()) {
+			continue
+		}
+
+		if sendRateLimitSummary(groupRepo, manager, rule, bucket) {
+			bucket.Suppressed = 0
+			bucket.LastSuppressedGroupID = primitive.NilObjectID
+		}
+
+		if err := rateLimitRepo.Save(bucket); err != nil {
+			log.WithFields(log.Fields{
+				"rule_id": rule.ID.Hex(),
+			}).Errorf("save rate limit bucket failed: %v", err)
+		}
+	}
+}
+
+// sendRateLimitSummary 使用最后一个被抑制的事件组中因为频率限制没有执行的动作发送汇总通知，全部发送成功时返回 true
+func sendRateLimitSummary(groupRepo repository.EventGroupRepo, manager action.Manager, rule repository.Rule, bucket repository.RateLimitBucket) bool {
+	grp, err := groupRepo.Get(bucket.LastSuppressedGroupID)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"rule_id": rule.ID.Hex(),
+			"grp_id":  bucket.LastSuppressedGroupID.Hex(),
+		}).Errorf("query last suppressed group failed: %v", err)
+
+		// 事件组已经被删除，无法发送汇总通知
+		return err == repository.ErrNotFound
+	}
+
+	summaryTemplate := fmt.Sprintf(rateLimitSummaryTemplate, bucket.Suppressed)
+	summaryRule := rule
+	summaryRule.Template = summaryTemplate
+	summaryRule.RecoveryTemplate = ""
+
+	ok := true
+	for _, act := range grp.Actions {
+		if act.Status != repository.TriggerStatusRateLimited {
+			continue
+		}
+
+		// 动作元数据中配置的模板会覆盖规则模板，需要一起替换为汇总通知模板
+		trigger, err := action.OverrideTemplate(manager, act, summaryTemplate)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"rule_id":    rule.ID.Hex(),
+				"grp_id":     grp.ID.Hex(),
+				"trigger_id": act.ID.Hex(),
+			}).Errorf("send rate limit summary failed: %v", err)
+			ok = false
+			continue
+		}
+
+		if err := manager.Dispatch(act.Action).Handle(summaryRule, trigger, grp); err != nil {
+			log.WithFields(log.Fields{
+				"rule_id":    rule.ID.Hex(),
+				"grp_id":     grp.ID.Hex(),
+				"trigger_id": act.ID.Hex(),
+			}).Errorf("send rate limit summary failed: %v", err)
+			ok = false
+		}
+	}
+
+	return ok
+}
@@ -1,7 +1,10 @@
 package job
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/mylxsw/adanos-alert/internal/repository"
@@ -34,42 +37,15 @@ func (a *RecoveryJob) Handle() {
 				return
 			}
 
+			// 同一个恢复标识可能对应多条恢复记录，每个标识只处理一次
+			handled := make(map[string]bool)
 			for _, m := range events {
-				(func(m repository.Recovery) {
-					defer func() {
-						if err := recover(); err != nil {
-							log.With(m).Errorf("add recovery event failed: %v", err)
-						} else {
-							if err := recoveryRepo.Delete(context.TODO(), m.RecoveryID); err != nil {
-								log.With(m).Errorf("remove recovery event from mongodb failed: %v", err)
-							}
-						}
-					}()
-					if len(m.RefIDs) == 0 {
-						return
-					}
-
-					msgSample, err := eventRepo.Get(m.RefIDs[len(m.RefIDs)-1])
-					if err != nil {
-						log.With(m).Errorf("get recovery event sample failed: %v", err)
-					}
-
-					msgSample.Type = repository.EventTypeRecovery
-					msgSample.ID = primitive.NilObjectID
-					msgSample.GroupID = nil
-					msgSample.CreatedAt = time.Now()
-					msgSample.Status = ""
-					msgSample.Meta["recovery-refs"] = m.RefIDs
-					msgSample.Tags = append(misc.IfElse(
-						msgSample.Tags == nil,
-						make([]string, 0),
-						msgSample.Tags,
-					).([]string), "adanos-recovery")
-
-					if _, err := eventRepo.AddWithContext(context.TODO(), msgSample); err != nil {
-						log.With(m).Errorf("add recovery event failed: %v", err)
-					}
-				})(m)
+				if handled[m.RecoveryID] {
+					continue
+				}
+
+				handled[m.RecoveryID] = true
+				recoverEvent(recoveryRepo, eventRepo, m.RecoveryID)
 			}
 		})
 
@@ -77,3 +53,114 @@ func (a *RecoveryJob) Handle() {
 		log.Warningf("the last recovery job is not finished yet, skip for this time")
 	}
 }
+
+// ErrNotRecoverable 恢复记录没有关联任何事件，无法生成恢复事件
+var ErrNotRecoverable = errors.New("recovery has no related events")
+
+// recoverEvent 为恢复标识创建恢复事件
+// 只有该标识对应的所有恢复记录都已经到达恢复时间（该报警不再触发）时才恢复，不同标识的报警之间互不影响
+func recoverEvent(recoveryRepo repository.RecoveryRepo, eventRepo repository.EventRepo, recoveryID string) {
+	recs, err := recoveryRepo.FindByIdentifier(context.TODO(), recoveryID)
+	if err != nil {
+		log.WithFields(log.Fields{"recovery_id": recoveryID}).Errorf("query recovery events failed: %v", err)
+		return
+	}
+
+	if len(recs) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for _, rec := range recs {
+		// 查询可恢复事件之后，同一个报警再次触发，延后了恢复时间
+		if !rec.RecoveryAt.Before(now) {
+			return
+		}
+	}
+
+	if _, err := emitRecovery(recoveryRepo, eventRepo, recs); err != nil {
+		log.WithFields(log.Fields{"recovery_id": recoveryID}).Errorf("add recovery event failed: %v", err)
+	}
+}
+
+// ForceRecover 不等待恢复时间，立即为恢复标识创建恢复事件并删除恢复记录
+// 恢复标识没有恢复记录时返回 repository.ErrNotFound，恢复记录没有关联事件时返回 ErrNotRecoverable
+func ForceRecover(recoveryRepo repository.RecoveryRepo, eventRepo repository.EventRepo, recoveryID string) (primitive.ObjectID, error) {
+	recs, err := recoveryRepo.FindByIdentifier(context.TODO(), recoveryID)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("query recovery events failed: %w", err)
+	}
+
+	if len(recs) == 0 {
+		return primitive.NilObjectID, repository.ErrNotFound
+	}
+
+	hasRefs := false
+	for _, rec := range recs {
+		if len(rec.RefIDs) > 0 {
+			hasRefs = true
+			break
+		}
+	}
+
+	if !hasRefs {
+		return primitive.NilObjectID, ErrNotRecoverable
+	}
+
+	return emitRecovery(recoveryRepo, eventRepo, recs)
+}
+
+// emitRecovery 合并同一个恢复标识的所有恢复记录，创建恢复事件，成功后删除恢复记录
+// 恢复记录没有关联事件时只删除恢复记录
+func emitRecovery(recoveryRepo repository.RecoveryRepo, eventRepo repository.EventRepo, recs []repository.Recovery) (id primitive.ObjectID, err error) {
+	refIDs := make([]primitive.ObjectID, 0)
+	for _, rec := range recs {
+		refIDs = append(refIDs, rec.RefIDs...)
+	}
+
+	m := recs[0]
+	m.RefIDs = refIDs
+
+	defer func() {
+		if err == nil {
+			if err := recoveryRepo.Delete(context.TODO(), m.RecoveryID); err != nil {
+				log.With(m).Errorf("remove recovery event from mongodb failed: %v", err)
+			}
+		}
+	}()
+
+	if len(m.RefIDs) == 0 {
+		return primitive.NilObjectID, nil
+	}
+
+	// 使用该报警最近一次触发的事件作为恢复事件的样本
+	latestRefID := m.RefIDs[0]
+	for _, refID := range m.RefIDs[1:] {
+		if bytes.Compare(refID[:], latestRefID[:]) > 0 {
+			latestRefID = refID
+		}
+	}
+
+	msgSample, err := eventRepo.Get(latestRefID)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("get recovery event sample failed: %w", err)
+	}
+
+	msgSample.Type = repository.EventTypeRecovery
+	msgSample.ID = primitive.NilObjectID
+	msgSample.GroupID = nil
+	msgSample.CreatedAt = time.Now()
+	msgSample.Status = ""
+	if msgSample.Meta == nil {
+		msgSample.Meta = make(repository.EventMeta)
+	}
+	msgSample.Meta["recovery-refs"] = m.RefIDs
+	msgSample.Meta["recovery-id"] = m.RecoveryID
+	msgSample.Tags = append(misc.IfElse(
+		msgSample.Tags == nil,
+		make([]string, 0),
+		msgSample.Tags,
+	).([]string), "adanos-recovery")
+
+	return eventRepo.AddWithContext(context.TODO(), msgSample)
+}
@@ -0,0 +1,48 @@
+package job_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/job"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	mockRepo "github.com/mylxsw/adanos-alert/test/mock/repository"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestForceRecover(t *testing.T) {
+	recoveryRepo := mockRepo.NewRecoveryRepo()
+	eventRepo := mockRepo.NewMessageRepo()
+
+	evtID, err := eventRepo.Add(repository.Event{Content: "disk full", Meta: repository.EventMeta{"host": "web-1"}})
+	assert.NoError(t, err)
+
+	ctx := context.TODO()
+	assert.NoError(t, recoveryRepo.Register(ctx, time.Now().Add(time.Hour), "disk-full", evtID))
+	assert.NoError(t, recoveryRepo.Register(ctx, time.Now().Add(time.Hour), "empty", primitive.NilObjectID))
+
+	// 恢复标识不存在
+	_, err = job.ForceRecover(recoveryRepo, eventRepo, "not-exist")
+	assert.Equal(t, repository.ErrNotFound, err)
+
+	// 恢复记录没有关联事件，不能强制恢复，恢复记录保留
+	_, err = job.ForceRecover(recoveryRepo, eventRepo, "empty")
+	assert.Equal(t, job.ErrNotRecoverable, err)
+	recs, _ := recoveryRepo.FindByIdentifier(ctx, "empty")
+	assert.Len(t, recs, 1)
+
+	// 未到恢复时间也能立即恢复，恢复记录被删除
+	id, err := job.ForceRecover(recoveryRepo, eventRepo, "disk-full")
+	assert.NoError(t, err)
+
+	recovered, err := eventRepo.Get(id)
+	assert.NoError(t, err)
+	assert.Equal(t, repository.EventTypeRecovery, recovered.Type)
+	assert.Equal(t, "disk-full", recovered.Meta["recovery-id"])
+	assert.Contains(t, recovered.Tags, "adanos-recovery")
+
+	recs, _ = recoveryRepo.FindByIdentifier(ctx, "disk-full")
+	assert.Empty(t, recs)
+}
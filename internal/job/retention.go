@@ -0,0 +1,204 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/configs"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/asteria/log"
+	"github.com/mylxsw/coll"
+	"github.com/mylxsw/container"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const RetentionJobName = "retention"
+
+// defaultRetentionBatchSize 默认每批删除的数量
+const defaultRetentionBatchSize = 500
+
+// RetentionJob 按照保留策略分批删除过期的事件、已经结束的事件组以及恢复记录
+type RetentionJob struct {
+	app       container.Container
+	executing chan interface{} // 标识当前Job是否在执行中
+}
+
+func NewRetentionJob(app container.Container) *RetentionJob {
+	return &RetentionJob{app: app, executing: make(chan interface{}, 1)}
+}
+
+func (j *RetentionJob) Handle() {
+	select {
+	case j.executing <- struct{}{}:
+		defer func() { <-j.executing }()
+
+		conf := configs.Get(j.app)
+		now := time.Now()
+
+		var purger retentionPurger
+		j.app.MustResolve(func(lockManager *DistributeLockManager) {
+			// 每批删除之前检查是否仍然持有分布式锁，锁被其它节点获取之后停止删除
+			purger = retentionPurger{batchSize: conf.Retention.BatchSize, next: lockManager.HasLock}
+		})
+
+		if conf.Retention.Events > 0 {
+			j.app.MustResolve(func(eventRepo repository.EventRepo, groupRepo repository.EventGroupRepo) {
+				purged, err := purger.purgeEvents(eventRepo, groupRepo, now.Add(-conf.Retention.Events))
+				recordRetentionPurged("events", purged, err)
+			})
+		}
+
+		if conf.Retention.Groups > 0 {
+			j.app.MustResolve(func(groupRepo repository.EventGroupRepo, commentRepo repository.GroupCommentRepo) {
+				purged, err := purger.purgeGroups(groupRepo, commentRepo, now.Add(-conf.Retention.Groups))
+				recordRetentionPurged("groups", purged, err)
+			})
+		}
+
+		if conf.Retention.Recoveries > 0 {
+			j.app.MustResolve(func(recoveryRepo repository.RecoveryRepo) {
+				purged, err := purger.purgeRecoveries(recoveryRepo, now.Add(-conf.Retention.Recoveries))
+				recordRetentionPurged("recoveries", purged, err)
+			})
+		}
+
+	default:
+		log.Warningf("the last retention job is not finished yet, skip for this time")
+	}
+}
+
+// recordRetentionPurged 记录删除数量指标
+func recordRetentionPurged(kind string, purged int64, err error) {
+	retentionPurged.WithLabelValues(kind).Add(float64(purged))
+	retentionLastRunPurged.WithLabelValues(kind).Set(float64(purged))
+
+	if err != nil {
+		log.WithFields(log.Fields{
+			"kind":   kind,
+			"purged": purged,
+		}).Errorf("retention job purge %s failed: %v", kind, err)
+		return
+	}
+
+	if purged > 0 {
+		log.Infof("retention job purged %d %s", purged, kind)
+	}
+}
+
+// retentionPurger 分批删除数据，每批删除之前调用 next 判断是否继续
+type retentionPurger struct {
+	batchSize int64
+	next      func() bool
+}
+
+func (p retentionPurger) limit() int64 {
+	if p.batchSize <= 0 {
+		return defaultRetentionBatchSize
+	}
+
+	return p.batchSize
+}
+
+// purge 循环执行 batch 直到删除的数量小于批次大小
+func (p retentionPurger) purge(batch func(limit int64) (int64, error)) (int64, error) {
+	var total int64
+	for p.next == nil || p.next() {
+		deleted, err := batch(p.limit())
+		total += deleted
+		if err != nil || deleted < p.limit() {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// purgeEvents 删除创建时间早于 before 的事件，还未聚合（pending）的事件以及所属事件组还未结束（collecting、pending）的事件不删除，
+// 避免事件组发送通知时缺少事件
+func (p retentionPurger) purgeEvents(eventRepo repository.EventRepo, groupRepo repository.EventGroupRepo, before time.Time) (int64, error) {
+	return p.purge(func(limit int64) (int64, error) {
+		// 每批删除之前重新查询未结束的事件组，避免期间新创建的事件组中的事件被删除
+		activeGroupIDs, err := activeEventGroupIDs(groupRepo)
+		if err != nil {
+			return 0, err
+		}
+
+		filter := bson.M{
+			"status":     bson.M{"$ne": repository.EventStatusPending},
+			"created_at": bson.M{"$lt": before},
+			"group_ids":  bson.M{"$nin": activeGroupIDs},
+		}
+
+		ids, err := eventRepo.FindIDs(context.TODO(), filter, limit)
+		if err != nil || len(ids) == 0 {
+			return 0, err
+		}
+
+		if err := eventRepo.Delete(bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+			return 0, err
+		}
+
+		return int64(len(ids)), nil
+	})
+}
+
+// activeEventGroupIDs 返回所有还未结束（collecting、pending）的事件组 ID
+func activeEventGroupIDs(groupRepo repository.EventGroupRepo) ([]primitive.ObjectID, error) {
+	groups, err := groupRepo.Find(bson.M{
+		"status": bson.M{"$in": []repository.EventGroupStatus{
+			repository.EventGroupStatusCollecting,
+			repository.EventGroupStatusPending,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(groups))
+	for _, grp := range groups {
+		ids = append(ids, grp.ID)
+	}
+
+	return ids, nil
+}
+
+// purgeGroups 删除最后更新时间早于 before 并且已经结束的事件组，以及事件组的评论
+func (p retentionPurger) purgeGroups(groupRepo repository.EventGroupRepo, commentRepo repository.GroupCommentRepo, before time.Time) (int64, error) {
+	filter := bson.M{
+		"status": bson.M{"$in": []repository.EventGroupStatus{
+			repository.EventGroupStatusOK,
+			repository.EventGroupStatusFailed,
+			repository.EventGroupStatusCanceled,
+		}},
+		"updated_at": bson.M{"$lt": before},
+	}
+
+	return p.purge(func(limit int64) (int64, error) {
+		groups, _, err := groupRepo.Paginate(filter, 0, limit)
+		if err != nil || len(groups) == 0 {
+			return 0, err
+		}
+
+		groupIDs := coll.MustNew(groups).Map(func(grp repository.EventGroup) primitive.ObjectID {
+			return grp.ID
+		}).Items()
+
+		if err := commentRepo.Delete(bson.M{"group_id": bson.M{"$in": groupIDs}}); err != nil {
+			return 0, err
+		}
+
+		if err := groupRepo.Delete(bson.M{"_id": bson.M{"$in": groupIDs}}); err != nil {
+			return 0, err
+		}
+
+		return int64(len(groups)), nil
+	})
+}
+
+// purgeRecoveries 删除预计恢复时间早于 before 的恢复记录
+func (p retentionPurger) purgeRecoveries(recoveryRepo repository.RecoveryRepo, before time.Time) (int64, error) {
+	return p.purge(func(limit int64) (int64, error) {
+		return recoveryRepo.DeleteBefore(context.TODO(), before, limit)
+	})
+}
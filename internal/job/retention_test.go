@@ -0,0 +1,63 @@
+package job_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/configs"
+	"github.com/mylxsw/adanos-alert/internal/job"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	mockRepo "github.com/mylxsw/adanos-alert/test/mock/repository"
+	"github.com/mylxsw/container"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newRetentionContainer(t *testing.T, locked bool) (container.Container, *mockRepo.RecoveryRepo) {
+	cc := container.New()
+	cc.MustSingleton(func() *configs.Config {
+		return &configs.Config{Retention: configs.Retention{Recoveries: time.Hour, BatchSize: 2}}
+	})
+	cc.MustSingleton(mockRepo.NewRecoveryRepo)
+	cc.MustSingleton(func() *job.DistributeLockManager {
+		lockManager := job.NewDistributeLockManager(mockRepo.NewLockRepo(), "node-1", 90*time.Second, 30*time.Second)
+		if locked {
+			assert.NoError(t, lockManager.TryLock())
+		}
+
+		return lockManager
+	})
+
+	var recoveryRepo *mockRepo.RecoveryRepo
+	cc.MustResolve(func(repo repository.RecoveryRepo) {
+		recoveryRepo = repo.(*mockRepo.RecoveryRepo)
+
+		for i := 0; i < 5; i++ {
+			assert.NoError(t, repo.Register(context.TODO(), time.Now().Add(-2*time.Hour), fmt.Sprintf("expired-%d", i), primitive.NewObjectID()))
+		}
+		assert.NoError(t, repo.Register(context.TODO(), time.Now().Add(time.Hour), "pending", primitive.NewObjectID()))
+	})
+
+	return cc, recoveryRepo
+}
+
+func TestRetentionJob_PurgeRecoveries(t *testing.T) {
+	cc, recoveryRepo := newRetentionContainer(t, true)
+
+	// 批次大小为 2，需要分三批才能删除全部 5 条过期记录
+	job.NewRetentionJob(cc).Handle()
+
+	assert.Len(t, recoveryRepo.Recoveries, 1)
+	_, ok := recoveryRepo.Recoveries["pending"]
+	assert.True(t, ok)
+}
+
+func TestRetentionJob_SkipWithoutLock(t *testing.T) {
+	cc, recoveryRepo := newRetentionContainer(t, false)
+
+	job.NewRetentionJob(cc).Handle()
+
+	assert.Len(t, recoveryRepo.Recoveries, 6)
+}
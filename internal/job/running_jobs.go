@@ -0,0 +1,89 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RunningJobs 记录正在执行中的定时任务，服务停止时等待执行中的任务完成
+type RunningJobs struct {
+	lock   sync.Mutex
+	wg     sync.WaitGroup
+	closed bool
+
+	// ctx 在服务停止时被取消，用于中断执行中的任务
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewRunningJobs create a new RunningJobs
+func NewRunningJobs() *RunningJobs {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RunningJobs{ctx: ctx, cancel: cancel}
+}
+
+// Context 返回任务执行使用的 context，服务停止（Shutdown）时被取消
+func (r *RunningJobs) Context() context.Context {
+	return r.ctx
+}
+
+// Start 开始执行一个任务，服务正在停止时返回 false，任务不应该再执行
+func (r *RunningJobs) Start() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.closed {
+		return false
+	}
+
+	r.wg.Add(1)
+	return true
+}
+
+// Done 标识一个任务执行完成
+func (r *RunningJobs) Done() {
+	r.wg.Done()
+}
+
+// Wrap 包装任务处理函数，使其执行过程被记录
+func (r *RunningJobs) Wrap(handler func()) func() {
+	return func() {
+		if !r.Start() {
+			return
+		}
+		defer r.Done()
+
+		handler()
+	}
+}
+
+// Closed 是否已经停止接受新的任务
+func (r *RunningJobs) Closed() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.closed
+}
+
+// Shutdown 停止接受新的任务，通知执行中的任务尽快结束，并且等待执行中的任务完成，超过 timeout 仍未完成时返回 false
+func (r *RunningJobs) Shutdown(timeout time.Duration) bool {
+	r.lock.Lock()
+	r.closed = true
+	r.lock.Unlock()
+
+	r.cancel()
+
+	finished := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
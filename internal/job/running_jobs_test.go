@@ -0,0 +1,78 @@
+package job_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/job"
+	mockRepo "github.com/mylxsw/adanos-alert/test/mock/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistributeLockManager_TryUnLockWaitsRunningJobs(t *testing.T) {
+	lockRepo := mockRepo.NewLockRepo()
+	jobs := job.NewRunningJobs()
+
+	lockManager := job.NewDistributeLockManager(lockRepo, "node-1", 90*time.Second, 30*time.Second)
+	lockManager.WaitJobsBeforeUnlock(jobs, 5*time.Second)
+
+	assert.NoError(t, lockManager.TryLock())
+	assert.True(t, lockManager.HasLock())
+
+	// 模拟正在执行中的触发任务
+	started, release := make(chan struct{}), make(chan struct{})
+	go jobs.Wrap(func() {
+		close(started)
+		<-release
+	})()
+	<-started
+
+	unlocked := make(chan error)
+	go func() { unlocked <- lockManager.TryUnLock() }()
+
+	// 服务停止过程中，任务未完成之前不释放锁，也不再执行新的任务
+	time.Sleep(100 * time.Millisecond)
+	assert.True(t, lockManager.HasLock())
+	owner, _, _ := lockRepo.CurrentOwner("crontab-lock")
+	assert.Equal(t, "node-1", owner)
+
+	executed := false
+	jobs.Wrap(func() { executed = true })()
+	assert.False(t, executed)
+
+	close(release)
+	assert.NoError(t, <-unlocked)
+	assert.False(t, lockManager.HasLock())
+
+	owner, _, _ = lockRepo.CurrentOwner("crontab-lock")
+	assert.Empty(t, owner)
+
+	// 停止之后不再获取锁，其它节点可以立即接管
+	assert.NoError(t, lockManager.TryLock())
+	assert.False(t, lockManager.HasLock())
+
+	standby := job.NewDistributeLockManager(lockRepo, "node-2", 90*time.Second, 30*time.Second)
+	assert.NoError(t, standby.TryLock())
+	assert.True(t, standby.HasLock())
+}
+
+func TestDistributeLockManager_TryUnLockTimeout(t *testing.T) {
+	lockRepo := mockRepo.NewLockRepo()
+	jobs := job.NewRunningJobs()
+
+	lockManager := job.NewDistributeLockManager(lockRepo, "node-1", 90*time.Second, 30*time.Second)
+	lockManager.WaitJobsBeforeUnlock(jobs, 100*time.Millisecond)
+	assert.NoError(t, lockManager.TryLock())
+
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	go jobs.Wrap(func() {
+		close(started)
+		<-release
+	})()
+	<-started
+
+	// 任务超时未完成时，仍然释放锁
+	assert.NoError(t, lockManager.TryUnLock())
+	assert.False(t, lockManager.HasLock())
+}
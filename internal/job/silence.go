@@ -0,0 +1,36 @@
+package job
+
+import (
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/matcher"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/asteria/log"
+	"github.com/mylxsw/container"
+)
+
+// matchedSilence 返回与事件组匹配的生效中的静默规则，没有匹配的静默规则时返回 nil，已经过期的静默规则会被忽略
+func matchedSilence(cc container.Container, silenceRepo repository.SilenceRepo, grp repository.EventGroup, eventCallback func() []repository.Event) (*repository.Silence, error) {
+	silences, err := silenceRepo.Find(repository.ActiveSilenceFilter(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	// 多个静默规则共享同一个 TriggerContext，事件组中的事件只查询一次
+	triggerCtx := matcher.NewTriggerContext(cc, repository.Trigger{}, grp, eventCallback)
+	for _, silence := range silences {
+		m, err := matcher.NewTriggerMatcher(repository.Trigger{PreCondition: silence.Matcher})
+		if err != nil {
+			log.WithFields(log.Fields{
+				"silence_id": silence.ID.Hex(),
+			}).Errorf("invalid silence matcher: %v", err)
+			continue
+		}
+
+		if matched, err := m.Match(triggerCtx); err == nil && matched {
+			return &silence, nil
+		}
+	}
+
+	return nil, nil
+}
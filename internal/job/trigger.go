@@ -1,12 +1,15 @@
 package job
 
 import (
+	"time"
+
 	"github.com/mylxsw/adanos-alert/internal/action"
 	"github.com/mylxsw/adanos-alert/internal/matcher"
 	"github.com/mylxsw/adanos-alert/internal/repository"
 	"github.com/mylxsw/asteria/log"
 	"github.com/mylxsw/container"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const TriggerJobName = "trigger"
@@ -24,13 +27,20 @@ func (a TriggerJob) Handle() {
 	select {
 	case a.executing <- struct{}{}:
 		defer func() { <-a.executing }()
+		a.app.MustResolve(a.flushRateLimitSummaries)
 		a.app.MustResolve(a.processEventGroups)
+		a.app.MustResolve(a.escalateEventGroups)
 	default:
 		log.Warningf("the last trigger job is not finished yet, skip for this time")
 	}
 }
 
-func (a TriggerJob) processEventGroups(groupRepo repository.EventGroupRepo, eventRepo repository.EventRepo, ruleRepo repository.RuleRepo, manager action.Manager) error {
+func (a TriggerJob) processEventGroups(groupRepo repository.EventGroupRepo, eventRepo repository.EventRepo, ruleRepo repository.RuleRepo, inhibitRuleRepo repository.InhibitRuleRepo, silenceRepo repository.SilenceRepo, windowRepo repository.MaintenanceWindowRepo, rateLimitRepo repository.RateLimitRepo, manager action.Manager) error {
+	inhibitor, err := newEventInhibitor(inhibitRuleRepo, eventRepo, time.Now())
+	if err != nil {
+		log.Errorf("load inhibit rules failed: %v", err)
+	}
+
 	return groupRepo.Traverse(bson.M{"status": repository.EventGroupStatusPending}, func(grp repository.EventGroup) error {
 		rule, err := ruleRepo.Get(grp.Rule.ID)
 		if err != nil {
@@ -42,6 +52,56 @@ func (a TriggerJob) processEventGroups(groupRepo repository.EventGroupRepo, even
 			return err
 		}
 
+		// 存在活跃的源事件时，事件组被抑制，保持 pending 状态，抑制解除后再执行动作
+		if held, err := holdInhibitedGroup(groupRepo, inhibitor, &grp); err != nil || held {
+			return err
+		}
+
+		eventsCallback := func() []repository.Event {
+			messages, err := eventRepo.Find(bson.M{"group_ids": grp.ID})
+			if err != nil {
+				log.WithFields(log.Fields{
+					"err": err.Error(),
+					"grp": grp,
+				}).Errorf("trigger callback: fetch messages from group failed: %v", err)
+			}
+
+			return messages
+		}
+
+		// 维护窗口开启期间，事件组保持 pending 状态，动作暂缓执行，窗口关闭后再执行
+		window, err := heldByMaintenanceWindow(a.app, windowRepo, grp, eventsCallback)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"grp_id": grp.ID,
+			}).Errorf("check maintenance windows failed: %v", err)
+		} else if window != nil {
+			if grp.MaintenanceWindowID == window.ID {
+				return nil
+			}
+
+			log.WithFields(log.Fields{
+				"grp_id":                grp.ID,
+				"maintenance_window_id": window.ID.Hex(),
+			}).Debug("event group is held by maintenance window")
+
+			grp.MaintenanceWindowID = window.ID
+			return groupRepo.UpdateID(grp.ID, grp)
+		}
+
+		grp.MaintenanceWindowID = primitive.NilObjectID
+
+		// 与生效中的静默规则匹配时，动作不会执行，动作状态标记为 silenced
+		silence, err := matchedSilence(a.app, silenceRepo, grp, eventsCallback)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"grp_id": grp.ID,
+			}).Errorf("check silences failed: %v", err)
+		}
+
+		// 规则的通知超出频率限制时，动作不会执行，动作状态标记为 rate_limited
+		allowed := rateLimitAllower(rateLimitRepo, rule, grp)
+
 		hasError := false
 		maxFailedCount := 0
 		matchedTriggers := make([]repository.Trigger, 0)
@@ -69,17 +129,7 @@ func (a TriggerJob) processEventGroups(groupRepo repository.EventGroupRepo, even
 				continue
 			}
 
-			matched, err := tm.Match(matcher.NewTriggerContext(a.app, trigger, grp, func() []repository.Event {
-				messages, err := eventRepo.Find(bson.M{"group_ids": grp.ID})
-				if err != nil {
-					log.WithFields(log.Fields{
-						"err": err.Error(),
-						"grp": grp,
-					}).Errorf("trigger callback: fetch messages from group failed: %v", err)
-				}
-
-				return messages
-			}))
+			matched, err := tm.Match(matcher.NewTriggerContext(a.app, trigger, grp, eventsCallback))
 			if err != nil {
 				continue
 			}
@@ -90,6 +140,8 @@ func (a TriggerJob) processEventGroups(groupRepo repository.EventGroupRepo, even
 					manager,
 					trigger,
 					rule,
+					silence,
+					allowed,
 					matchedTriggers,
 					maxFailedCount,
 				)
@@ -104,6 +156,8 @@ func (a TriggerJob) processEventGroups(groupRepo repository.EventGroupRepo, even
 					manager,
 					trigger,
 					rule,
+					silence,
+					allowed,
 					matchedTriggers,
 					maxFailedCount,
 				)
@@ -131,9 +185,14 @@ func (a TriggerJob) processEventGroups(groupRepo repository.EventGroupRepo, even
 	})
 }
 
-func (a TriggerJob) matchedTriggerAction(grp repository.EventGroup, manager action.Manager, trigger repository.Trigger, rule repository.Rule, matchedTriggers []repository.Trigger, maxFailedCount int) (bool, []repository.Trigger, int) {
+func (a TriggerJob) matchedTriggerAction(grp repository.EventGroup, manager action.Manager, trigger repository.Trigger, rule repository.Rule, silence *repository.Silence, allowed func() bool, matchedTriggers []repository.Trigger, maxFailedCount int) (bool, []repository.Trigger, int) {
 	hasError := false
-	if err := manager.Dispatch(trigger.Action).Handle(rule, trigger, grp); err != nil {
+	if silence != nil {
+		trigger.Status = repository.TriggerStatusSilenced
+		trigger.SilenceID = silence.ID
+	} else if !allowed() {
+		trigger.Status = repository.TriggerStatusRateLimited
+	} else if err := manager.Dispatch(trigger.Action).Handle(rule, trigger, grp); err != nil {
 		trigger.Status = repository.TriggerStatusFailed
 		trigger.FailedCount = trigger.FailedCount + 1
 		trigger.FailedReason = err.Error()
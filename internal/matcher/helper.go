@@ -6,9 +6,11 @@ import (
 	"encoding/base64"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mylxsw/adanos-alert/pkg/misc"
+	"github.com/mylxsw/asteria/log"
 )
 
 // Helpers 用于规则引擎的助手函数
@@ -24,8 +26,28 @@ func (Helpers) Upper(val string) string {
 	return strings.ToUpper(val)
 }
 
-// DailyTimeBetween 判断当前时间（格式 15:04）是否在 startTime 和 endTime 之间
+// DailyTimeBetween 判断当前时间（服务器本地时区）是否在 startTime 和 endTime 之间（格式 15:04）
+// 包含 startTime，不包含 endTime，endTime 早于 startTime 时表示跨天，如 DailyTimeBetween("22:00", "09:00")
 func (Helpers) DailyTimeBetween(startTime, endTime string) bool {
+	return dailyTimeBetween(time.Now(), startTime, endTime)
+}
+
+// DailyTimeBetweenTZ 判断 tz 时区（如 Asia/Shanghai）的当前时间是否在 startTime 和 endTime 之间，规则与 DailyTimeBetween 相同
+// 时区不存在时返回 false
+func (Helpers) DailyTimeBetweenTZ(startTime, endTime string, tz string) bool {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"tz": tz,
+		}).Errorf("DailyTimeBetweenTZ: invalid timezone: %v", err)
+		return false
+	}
+
+	return dailyTimeBetween(time.Now().In(loc), startTime, endTime)
+}
+
+// dailyTimeBetween 判断 now 的时刻（忽略日期）是否在 startTime 和 endTime 之间
+func dailyTimeBetween(now time.Time, startTime, endTime string) bool {
 	start, err := time.Parse("15:04", startTime)
 	if err != nil {
 		panic(fmt.Sprintf("invalid startTime, must be formatted as 15:04, error is %v", err))
@@ -36,12 +58,95 @@ func (Helpers) DailyTimeBetween(startTime, endTime string) bool {
 		panic(fmt.Sprintf("invalid endTime, must be formatted as 15:04, error is %v", err))
 	}
 
-	if start.After(end) {
-		end = end.Add(24 * time.Hour)
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	nowMinute := now.Hour()*60 + now.Minute()
+
+	if startMinute <= endMinute {
+		return nowMinute >= startMinute && nowMinute < endMinute
+	}
+
+	// 跨天的时间段
+	return nowMinute >= startMinute || nowMinute < endMinute
+}
+
+// holidayCalendar 节假日日历，日期格式为 2006-01-02
+var holidayCalendar = struct {
+	sync.RWMutex
+	days map[string]bool
+}{days: make(map[string]bool)}
+
+// SetHolidays 设置节假日日历（格式 2006-01-02），IsWorkday 会将这些日期视为非工作日
+// 格式错误的日期会被忽略，并返回错误
+func SetHolidays(days []string) error {
+	calendar := make(map[string]bool)
+	invalidDays := make([]string, 0)
+	for _, d := range days {
+		d = strings.TrimSpace(d)
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			invalidDays = append(invalidDays, d)
+			continue
+		}
+
+		calendar[d] = true
+	}
+
+	holidayCalendar.Lock()
+	holidayCalendar.days = calendar
+	holidayCalendar.Unlock()
+
+	if len(invalidDays) > 0 {
+		return fmt.Errorf("invalid holidays %v, must be formatted as 2006-01-02", invalidDays)
+	}
+
+	return nil
+}
+
+func isHoliday(t time.Time) bool {
+	holidayCalendar.RLock()
+	defer holidayCalendar.RUnlock()
+
+	return holidayCalendar.days[t.Format("2006-01-02")]
+}
+
+// isoWeekday 返回 ISO 格式的星期，周一为 1，周日为 7
+func isoWeekday(t time.Time) int {
+	weekday := int(t.Weekday())
+	if weekday == 0 {
+		return 7
+	}
+
+	return weekday
+}
+
+// IsWeekend 判断今天是否是周末（周六或者周日）
+func (Helpers) IsWeekend() bool {
+	return isoWeekday(time.Now()) >= 6
+}
+
+// IsWorkday 判断今天是否是工作日（非周末，并且不在节假日日历中）
+func (h Helpers) IsWorkday() bool {
+	return !h.IsWeekend() && !isHoliday(time.Now())
+}
+
+// WeekdayBetween 判断今天是否在 startDay 和 endDay 之间（包含边界），周一为 1，周日为 7
+// 当 startDay 大于 endDay 时表示跨周，例如 WeekdayBetween(6, 1) 表示周六到下周一
+// 星期超出 1 到 7 的范围时返回 false
+func (Helpers) WeekdayBetween(startDay, endDay int) bool {
+	if startDay < 1 || startDay > 7 || endDay < 1 || endDay > 7 {
+		log.WithFields(log.Fields{
+			"start_day": startDay,
+			"end_day":   endDay,
+		}).Errorf("WeekdayBetween: invalid weekday range, must between 1 and 7")
+		return false
+	}
+
+	today := isoWeekday(time.Now())
+	if startDay <= endDay {
+		return today >= startDay && today <= endDay
 	}
 
-	now, _ := time.Parse("15:04", time.Now().Format("15:04"))
-	return now.After(start) && now.Before(end)
+	return today >= startDay || today <= endDay
 }
 
 // Now return current time
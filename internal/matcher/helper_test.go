@@ -0,0 +1,64 @@
+package matcher_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/matcher"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHelpers_IsWorkday(t *testing.T) {
+	helpers := matcher.Helpers{}
+	weekday := time.Now().Weekday()
+	isWeekend := weekday == time.Saturday || weekday == time.Sunday
+
+	assert.NoError(t, matcher.SetHolidays([]string{}))
+	assert.Equal(t, isWeekend, helpers.IsWeekend())
+	assert.Equal(t, !isWeekend, helpers.IsWorkday())
+
+	assert.NoError(t, matcher.SetHolidays([]string{time.Now().Format("2006-01-02")}))
+	assert.False(t, helpers.IsWorkday())
+
+	assert.Error(t, matcher.SetHolidays([]string{"2020/01/01"}))
+	assert.NoError(t, matcher.SetHolidays([]string{}))
+}
+
+func TestHelpers_DailyTimeBetweenTZ(t *testing.T) {
+	helpers := matcher.Helpers{}
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+
+	// 以东京时间当前时刻为中心的两小时窗口，时刻接近零点时窗口跨天
+	now := time.Now().In(tokyo)
+	start, end := now.Add(-time.Hour).Format("15:04"), now.Add(time.Hour).Format("15:04")
+	assert.True(t, helpers.DailyTimeBetweenTZ(start, end, "Asia/Tokyo"))
+	assert.False(t, helpers.DailyTimeBetweenTZ(start, end, "UTC"))
+
+	// 跨天的窗口：当前时刻之后开始，当前时刻之前结束，不包含当前时刻
+	assert.False(t, helpers.DailyTimeBetweenTZ(now.Add(time.Hour).Format("15:04"), now.Add(-time.Hour).Format("15:04"), "Asia/Tokyo"))
+
+	// 时区不存在时返回 false，不会 panic
+	assert.False(t, helpers.DailyTimeBetweenTZ("00:00", "23:59", "Mars/Olympus"))
+
+	// 不指定时区时使用服务器本地时间
+	local := time.Now()
+	assert.True(t, helpers.DailyTimeBetween(local.Add(-time.Hour).Format("15:04"), local.Add(time.Hour).Format("15:04")))
+	assert.Panics(t, func() { helpers.DailyTimeBetween("9", "18:00") })
+}
+
+func TestHelpers_WeekdayBetween(t *testing.T) {
+	helpers := matcher.Helpers{}
+	today := int(time.Now().Weekday())
+	if today == 0 {
+		today = 7
+	}
+
+	assert.True(t, helpers.WeekdayBetween(1, 7))
+	assert.True(t, helpers.WeekdayBetween(today, today))
+	assert.False(t, helpers.WeekdayBetween(today%7+1, today%7+1))
+	assert.True(t, helpers.WeekdayBetween(today%7+1, today))
+	assert.False(t, helpers.WeekdayBetween(0, 8))
+	assert.False(t, helpers.WeekdayBetween(1, 8))
+}
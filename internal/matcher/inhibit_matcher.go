@@ -0,0 +1,83 @@
+package matcher
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+)
+
+// InhibitMatcher is a matcher for inhibit rule
+type InhibitMatcher struct {
+	source *EventMatcher
+	target *EventMatcher
+	rule   repository.InhibitRule
+}
+
+// NewInhibitMatcher create a new InhibitMatcher, source and target rules use the same syntax as EventMatcher
+func NewInhibitMatcher(rule repository.InhibitRule) (*InhibitMatcher, error) {
+	source, err := NewEventMatcher(repository.Rule{Rule: rule.SourceRule})
+	if err != nil {
+		return nil, fmt.Errorf("invalid source rule: %w", err)
+	}
+
+	target, err := NewEventMatcher(repository.Rule{Rule: rule.TargetRule})
+	if err != nil {
+		return nil, fmt.Errorf("invalid target rule: %w", err)
+	}
+
+	return &InhibitMatcher{source: source, target: target, rule: rule}, nil
+}
+
+// Rule return the inhibit rule
+func (m *InhibitMatcher) Rule() repository.InhibitRule {
+	return m.rule
+}
+
+// MatchTarget check whether the evt can be inhibited by this rule
+func (m *InhibitMatcher) MatchTarget(evt repository.Event) bool {
+	matched, _, err := m.target.Match(evt)
+	return err == nil && matched
+}
+
+// MatchSource check whether the evt matches the source rule
+func (m *InhibitMatcher) MatchSource(evt repository.Event) bool {
+	matched, _, err := m.source.Match(evt)
+	return err == nil && matched
+}
+
+// Inhibits check whether the source event is active at now and inhibits the target event
+func (m *InhibitMatcher) Inhibits(source, target repository.Event, now time.Time) bool {
+	if source.ID == target.ID && !source.ID.IsZero() {
+		return false
+	}
+
+	if source.CreatedAt.Before(now.Add(-m.rule.ActiveWindow())) {
+		return false
+	}
+
+	if !m.MatchSource(source) {
+		return false
+	}
+
+	if !m.MatchTarget(target) {
+		return false
+	}
+
+	for _, key := range m.rule.Equal {
+		if metaString(source.Meta, key) != metaString(target.Meta, key) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func metaString(meta repository.EventMeta, key string) string {
+	val, ok := meta[key]
+	if !ok || val == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%v", val)
+}
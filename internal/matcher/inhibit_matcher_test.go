@@ -0,0 +1,54 @@
+package matcher_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/matcher"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestInhibitMatcher_Inhibits(t *testing.T) {
+	m, err := matcher.NewInhibitMatcher(repository.InhibitRule{
+		SourceRule: `Meta["alertname"] == "ServiceDown"`,
+		TargetRule: `Meta["alertname"] == "HighLatency"`,
+		Equal:      []string{"service"},
+		Window:     300,
+	})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	target := repository.Event{
+		ID:        primitive.NewObjectID(),
+		Meta:      repository.EventMeta{"alertname": "HighLatency", "service": "order"},
+		CreatedAt: now,
+	}
+	source := repository.Event{
+		ID:        primitive.NewObjectID(),
+		Meta:      repository.EventMeta{"alertname": "ServiceDown", "service": "order"},
+		CreatedAt: now.Add(-time.Minute),
+	}
+
+	assert.True(t, m.MatchTarget(target))
+	// 源事件活跃时，目标事件被抑制
+	assert.True(t, m.Inhibits(source, target, now))
+
+	// 源事件超过有效时间之后，抑制解除
+	assert.False(t, m.Inhibits(source, target, now.Add(5*time.Minute)))
+
+	// Equal 中的 Meta 不同时不抑制
+	otherService := source
+	otherService.Meta = repository.EventMeta{"alertname": "ServiceDown", "service": "user"}
+	assert.False(t, m.Inhibits(otherService, target, now))
+
+	// 源事件不匹配源规则
+	assert.False(t, m.Inhibits(target, target, now))
+
+	// 目标事件不匹配目标规则
+	assert.False(t, m.Inhibits(source, source, now))
+
+	_, err = matcher.NewInhibitMatcher(repository.InhibitRule{SourceRule: `Meta[`, TargetRule: `true`})
+	assert.Error(t, err)
+}
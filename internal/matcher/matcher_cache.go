@@ -0,0 +1,81 @@
+package matcher
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventMatcherCache 缓存已经编译的规则匹配器，规则的 ID 与 UpdatedAt 都没有变化时，复用已经编译的匹配器
+type EventMatcherCache struct {
+	lock  sync.Mutex
+	items map[primitive.ObjectID]eventMatcherCacheItem
+}
+
+type eventMatcherCacheItem struct {
+	updatedAt time.Time
+	matcher   *EventMatcher
+}
+
+// NewEventMatcherCache create a new EventMatcherCache
+func NewEventMatcherCache() *EventMatcherCache {
+	return &EventMatcherCache{items: make(map[primitive.ObjectID]eventMatcherCacheItem)}
+}
+
+// Get 返回规则对应的匹配器，缓存中不存在或者规则已经变更时重新编译，编译失败的规则不会被缓存
+func (mc *EventMatcherCache) Get(rule repository.Rule) (*EventMatcher, error) {
+	mc.lock.Lock()
+	item, ok := mc.items[rule.ID]
+	mc.lock.Unlock()
+
+	if ok && item.updatedAt.Equal(rule.UpdatedAt) {
+		return item.matcher, nil
+	}
+
+	mat, err := NewEventMatcher(rule)
+	if err != nil {
+		mc.Remove(rule.ID)
+		return nil, err
+	}
+
+	mc.lock.Lock()
+	mc.items[rule.ID] = eventMatcherCacheItem{updatedAt: rule.UpdatedAt, matcher: mat}
+	mc.lock.Unlock()
+
+	return mat, nil
+}
+
+// Remove 移除规则对应的匹配器
+func (mc *EventMatcherCache) Remove(ruleID primitive.ObjectID) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	delete(mc.items, ruleID)
+}
+
+// Retain 只保留 rules 对应的匹配器，已经删除或者禁用的规则会被移除
+func (mc *EventMatcherCache) Retain(rules []repository.Rule) {
+	ids := make(map[primitive.ObjectID]bool, len(rules))
+	for _, rule := range rules {
+		ids[rule.ID] = true
+	}
+
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	for id := range mc.items {
+		if !ids[id] {
+			delete(mc.items, id)
+		}
+	}
+}
+
+// Len 返回缓存的匹配器数量
+func (mc *EventMatcherCache) Len() int {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	return len(mc.items)
+}
@@ -0,0 +1,103 @@
+package matcher_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/matcher"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEventMatcherCache(t *testing.T) {
+	mc := matcher.NewEventMatcherCache()
+
+	rule := repository.Rule{
+		ID:        primitive.NewObjectID(),
+		Rule:      `"php" in Tags`,
+		UpdatedAt: time.Now(),
+	}
+
+	m1, err := mc.Get(rule)
+	assert.NoError(t, err)
+
+	m2, err := mc.Get(rule)
+	assert.NoError(t, err)
+	assert.True(t, m1 == m2, "unchanged rule should reuse the compiled matcher")
+
+	// 规则变更后重新编译
+	rule.UpdatedAt = rule.UpdatedAt.Add(time.Second)
+	rule.Rule = `"java" in Tags`
+	m3, err := mc.Get(rule)
+	assert.NoError(t, err)
+	assert.False(t, m1 == m3)
+
+	matched, _, err := m3.Match(repository.Event{Tags: []string{"java"}})
+	assert.NoError(t, err)
+	assert.True(t, matched)
+
+	// 编译失败的规则不会被缓存
+	rule.UpdatedAt = rule.UpdatedAt.Add(time.Second)
+	rule.Rule = `Tags in`
+	_, err = mc.Get(rule)
+	assert.Error(t, err)
+	assert.Equal(t, 0, mc.Len())
+
+	other := repository.Rule{ID: primitive.NewObjectID(), Rule: "true"}
+	_, err = mc.Get(other)
+	assert.NoError(t, err)
+	rule.Rule = "true"
+	_, err = mc.Get(rule)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, mc.Len())
+
+	mc.Retain([]repository.Rule{other})
+	assert.Equal(t, 1, mc.Len())
+}
+
+func benchmarkRules(n int) []repository.Rule {
+	rules := make([]repository.Rule, n)
+	for i := range rules {
+		rules[i] = repository.Rule{
+			ID:         primitive.NewObjectID(),
+			Rule:       fmt.Sprintf(`"service-%d" in Tags and JsonGet("level", "") == "error" and Meta["env"] == "prod"`, i),
+			IgnoreRule: `Origin == "test"`,
+			UpdatedAt:  time.Now(),
+		}
+	}
+
+	return rules
+}
+
+// BenchmarkNewEventMatcher 每次聚合都重新编译所有规则
+func BenchmarkNewEventMatcher(b *testing.B) {
+	rules := benchmarkRules(50)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, rule := range rules {
+			if _, err := matcher.NewEventMatcher(rule); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkEventMatcherCache_Get 规则没有变化时，复用缓存中已经编译的匹配器
+func BenchmarkEventMatcherCache_Get(b *testing.B) {
+	rules := benchmarkRules(50)
+	mc := matcher.NewEventMatcherCache()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, rule := range rules {
+			if _, err := mc.Get(rule); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -3,7 +3,10 @@ package matcher
 import (
 	jsonEnc "encoding/json"
 	"errors"
+	"fmt"
+	"regexp"
 	"sync"
+	"time"
 
 	"github.com/antonmedv/expr"
 	"github.com/antonmedv/expr/vm"
@@ -22,6 +25,9 @@ type EventWrap struct {
 	Helpers
 	fullJSONOnce sync.Once
 	fullJSON     string
+
+	contentDocOnce sync.Once
+	contentDoc     *json.Document
 }
 
 func NewEventWrap(message repository.Event) *EventWrap {
@@ -38,8 +44,62 @@ func (msg *EventWrap) FullJSON() string {
 }
 
 // JsonGet parse message.Content as a json string and return the string value for key
+// 同一个 EventWrap 中多次调用时，message.Content 只会被解析一次
 func (msg *EventWrap) JsonGet(key string, defaultValue string) string {
-	return json.Gets(key, defaultValue, msg.Content)
+	return msg.contentDocument().Gets(key, defaultValue)
+}
+
+// contentDocument 将 message.Content 解析为 json 文档，同一个 EventWrap 只解析一次
+func (msg *EventWrap) contentDocument() *json.Document {
+	msg.contentDocOnce.Do(func() {
+		msg.contentDoc = json.Parse(msg.Content)
+	})
+
+	return msg.contentDoc
+}
+
+// JsonArrayLen parse message.Content as a json string and return the length of array for key
+// 如果 key 不存在或者不是数组，返回 0
+func (msg *EventWrap) JsonArrayLen(key string) int {
+	return msg.contentDocument().ArrayLen(key)
+}
+
+// JsonGetInt parse message.Content as a json string and return the int value for key
+func (msg *EventWrap) JsonGetInt(key string, defaultValue int) int {
+	return msg.contentDocument().GetInt(key, defaultValue)
+}
+
+// regexCache 缓存编译后的正则表达式，key 为正则表达式字符串，value 为 *regexp.Regexp，编译失败时为 nil
+var regexCache sync.Map
+
+func compileRegex(pattern string) *regexp.Regexp {
+	if re, ok := regexCache.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		re = nil
+	}
+
+	regexCache.Store(pattern, re)
+	return re
+}
+
+// RegexGroup match message.Content with regex pattern and return the capture group at groupIndex
+// groupIndex 为 0 时返回整个匹配内容，正则表达式无效、未匹配或者分组不存在时返回空字符串
+func (msg *EventWrap) RegexGroup(pattern string, groupIndex int) string {
+	re := compileRegex(pattern)
+	if re == nil {
+		return ""
+	}
+
+	matches := re.FindStringSubmatch(msg.Content)
+	if groupIndex < 0 || groupIndex >= len(matches) {
+		return ""
+	}
+
+	return matches[groupIndex]
 }
 
 // IsRecovery return whether the message is a recovery message
@@ -59,9 +119,10 @@ func (msg *EventWrap) IsPlain() bool {
 
 // EventMatcher is a matcher for repository.Event
 type EventMatcher struct {
-	matchProgram  *vm.Program
-	ignoreProgram *vm.Program
-	rule          repository.Rule
+	matchProgram   *vm.Program
+	ignoreProgram  *vm.Program
+	activeSchedule *repository.CompiledActiveSchedule
+	rule           repository.Rule
 }
 
 // NewEventMatcher create a new EventMatcher
@@ -86,11 +147,21 @@ func NewEventMatcher(rule repository.Rule) (*EventMatcher, error) {
 		return nil, err
 	}
 
-	return &EventMatcher{matchProgram: matchProgram, ignoreProgram: ignoreProgram, rule: rule}, nil
+	activeSchedule, err := rule.ActiveSchedule.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("invalid active schedule: %w", err)
+	}
+
+	return &EventMatcher{matchProgram: matchProgram, ignoreProgram: ignoreProgram, activeSchedule: activeSchedule, rule: rule}, nil
 }
 
 // Match check whether the msg is match with the rule
+// 事件的创建时间不在规则的生效时间内时，规则不匹配该事件
 func (m *EventMatcher) Match(evt repository.Event) (matched bool, ignored bool, err error) {
+	if !m.activeSchedule.ActiveAt(eventTime(evt)) {
+		return false, false, nil
+	}
+
 	wrapMsg := NewEventWrap(evt)
 	rs, err := expr.Run(m.matchProgram, wrapMsg)
 	if err != nil {
@@ -113,6 +184,15 @@ func (m *EventMatcher) Match(evt repository.Event) (matched bool, ignored bool,
 	return false, false, InvalidReturnVal
 }
 
+// eventTime 返回判断规则生效时间使用的事件时间，事件没有创建时间时使用当前时间
+func eventTime(evt repository.Event) time.Time {
+	if evt.CreatedAt.IsZero() {
+		return time.Now()
+	}
+
+	return evt.CreatedAt
+}
+
 // Rule return original rule object
 func (m *EventMatcher) Rule() repository.Rule {
 	return m.rule
@@ -1,6 +1,7 @@
 package matcher_test
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -21,7 +22,7 @@ func TestMessageMatcher_Match(t *testing.T) {
 
 	var msg = repository.Event{
 		ID:      primitive.NewObjectID(),
-		Content: `{"log_level": "debug", "message": "request", "context": {"user_id": 123}}`,
+		Content: `{"log_level": "debug", "message": "request", "context": {"user_id": 123, "retries": "3"}, "errors": [{"code": 500}, {"code": 502}]}`,
 		Meta: repository.EventMeta{
 			"environment": "dev",
 			"server":      "192.168.1.1",
@@ -44,6 +45,12 @@ func TestMessageMatcher_Match(t *testing.T) {
 		{Rule: `Content matches "\"request\""`, Matched: true},
 		{Rule: `JsonGet("context.user_id", "0") == "123"`, Matched: true},
 		{Rule: `JsonGet("context.enterprise_id", "0") == "0"`, Matched: true},
+		{Rule: `JsonArrayLen("errors") == 2`, Matched: true},
+		{Rule: `JsonArrayLen("context") == 0`, Matched: true},
+		{Rule: `JsonGetInt("context.user_id", 0) > 100`, Matched: true},
+		{Rule: `JsonGetInt("context.retries", 0) == 3`, Matched: true},
+		{Rule: `JsonGetInt("errors.[1].code", 0) == 502`, Matched: true},
+		{Rule: `JsonGetInt("context.not_exist", -1) == -1`, Matched: true},
 		{Rule: `Content startsWith "{"`, Matched: true},
 		{Rule: `Content endsWith "XX"`, Matched: false},
 		{Rule: `Upper(Meta["environment"]) == "DEV"`, Matched: true},
@@ -65,3 +72,60 @@ func TestMessageMatcher_Match(t *testing.T) {
 	_, err := matcher.NewEventMatcher(repository.Rule{Rule: `xxxxxxx`})
 	assert.Error(t, err)
 }
+
+func BenchmarkEventMatcher_Match(b *testing.B) {
+	var msg = repository.Event{
+		ID:      primitive.NewObjectID(),
+		Content: `{"log_level": "error", "message": "request failed", "context": {"user_id": 123, "retries": "3", "trace": "` + strings.Repeat("x", 4096) + `"}, "errors": [{"code": 500}, {"code": 502}]}`,
+	}
+
+	mt, err := matcher.NewEventMatcher(repository.Rule{
+		Rule: `JsonGet("log_level", "") == "error" and JsonGet("message", "") != "" and JsonGet("context.user_id", "0") == "123" and JsonGetInt("context.retries", 0) > 1 and JsonArrayLen("errors") == 2 and JsonGet("context.not_exist", "") == ""`,
+	})
+	assert.NoError(b, err)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := mt.Match(msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestEventMatcher_ActiveSchedule(t *testing.T) {
+	origin := time.Local
+	time.Local = time.FixedZone("CST", 8*3600)
+	defer func() { time.Local = origin }()
+
+	m, err := matcher.NewEventMatcher(repository.Rule{
+		Rule: `"php" in Tags`,
+		ActiveSchedule: repository.RuleActiveSchedule{
+			DailyRanges: []repository.DailyTimeRange{{StartTime: "22:00", EndTime: "06:00"}},
+		},
+	})
+	assert.NoError(t, err)
+
+	night := time.Date(2020, 7, 10, 23, 0, 0, 0, time.Local)
+	matched, _, err := m.Match(repository.Event{Tags: []string{"php"}, CreatedAt: night})
+	assert.NoError(t, err)
+	assert.True(t, matched)
+
+	// 不在生效时间内创建的事件，规则不匹配
+	matched, _, err = m.Match(repository.Event{Tags: []string{"php"}, CreatedAt: night.Add(-12 * time.Hour)})
+	assert.NoError(t, err)
+	assert.False(t, matched)
+
+	// 从 MongoDB 读取的事件创建时间为 UTC 时区，生效时间按照服务器本地时区判断
+	matched, _, err = m.Match(repository.Event{Tags: []string{"php"}, CreatedAt: night.UTC()})
+	assert.NoError(t, err)
+	assert.True(t, matched)
+
+	matched, _, err = m.Match(repository.Event{Tags: []string{"php"}, CreatedAt: night.Add(-12 * time.Hour).UTC()})
+	assert.NoError(t, err)
+	assert.False(t, matched)
+
+	_, err = matcher.NewEventMatcher(repository.Rule{
+		ActiveSchedule: repository.RuleActiveSchedule{Cron: "0 22 * * *"},
+	})
+	assert.Error(t, err)
+}
@@ -70,6 +70,24 @@ func TestMessageFinger(t *testing.T) {
 		assert.Equal(t, "", finger)
 	}
 
+	{
+		f, err := matcher.NewEventFinger(`RegexGroup('"log_level": "([a-z]+)"', 1) + ":" + RegexGroup('"user_id": ([0-9]+)', 1)`)
+		assert.NoError(t, err)
+
+		finger, err := f.Run(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, "debug:123", finger)
+	}
+
+	{
+		f, err := matcher.NewEventFinger(`RegexGroup("service=([a-z]+)", 1) + RegexGroup("log_level", 2) + RegexGroup("(invalid", 1)`)
+		assert.NoError(t, err)
+
+		finger, err := f.Run(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, "", finger)
+	}
+
 	{
 		f, err := matcher.NewEventFinger(`124`)
 		assert.NoError(t, err)
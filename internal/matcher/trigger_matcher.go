@@ -1,6 +1,10 @@
 package matcher
 
 import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -135,6 +139,209 @@ func (tc *TriggerContext) EventsWithMetaCount(key, value string) int64 {
 	return count
 }
 
+// MessagesCountByMeta get the count for events in group which has a meta[key] equals to value
+// This method is depressed
+func (tc *TriggerContext) MessagesCountByMeta(key, value string) int64 {
+	return tc.EventsCountByMeta(key, value)
+}
+
+// EventsCountByMeta get the count for events in group which has a meta[key] equals to value
+// 与 EventsWithMetaCount 不同的是，该方法直接对 Events() 返回的事件进行统计，不会查询数据库
+func (tc *TriggerContext) EventsCountByMeta(key, value string) int64 {
+	var count int64 = 0
+	for _, evt := range tc.Events() {
+		if v, ok := evt.Meta[key]; ok && fmt.Sprintf("%v", v) == value {
+			count++
+		}
+	}
+
+	return count
+}
+
+// DistinctMetaValues return all distinct values of meta[key] for events in group, in order of first appearance
+func (tc *TriggerContext) DistinctMetaValues(key string) []string {
+	values := make([]string, 0)
+	exists := make(map[string]bool)
+	for _, evt := range tc.Events() {
+		v, ok := evt.Meta[key]
+		if !ok {
+			continue
+		}
+
+		val := fmt.Sprintf("%v", v)
+		if !exists[val] {
+			exists[val] = true
+			values = append(values, val)
+		}
+	}
+
+	return values
+}
+
+// metaNumbers 返回事件组中所有事件 meta[key] 的数值，不存在或者无法解析为数字的值会被忽略
+func (tc *TriggerContext) metaNumbers(key string) []float64 {
+	numbers := make([]float64, 0)
+	for _, evt := range tc.Events() {
+		v, ok := evt.Meta[key]
+		if !ok {
+			continue
+		}
+
+		if num, ok := toFloat64(v); ok {
+			numbers = append(numbers, num)
+		}
+	}
+
+	return numbers
+}
+
+// toFloat64 将 meta 值转换为数字，支持数值类型以及数字格式的字符串
+func toFloat64(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case int32:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case string:
+		num, err := strconv.ParseFloat(strings.TrimSpace(val), 64)
+		if err != nil || math.IsNaN(num) || math.IsInf(num, 0) {
+			return 0, false
+		}
+
+		return num, true
+	}
+
+	return 0, false
+}
+
+// MetaNumericCount return the count of events in group which meta[key] is a number
+// 可以与 MetaMax/MetaMin/MetaAvg/MetaSum 一起使用，区分没有数据和统计值为 0 的情况，如 MetaNumericCount("latency") > 0 and MetaAvg("latency") > 500
+func (tc *TriggerContext) MetaNumericCount(key string) int64 {
+	return int64(len(tc.metaNumbers(key)))
+}
+
+// MetaSum return the sum of numeric meta[key] for events in group, 没有数值时返回 0
+func (tc *TriggerContext) MetaSum(key string) float64 {
+	var sum float64
+	for _, num := range tc.metaNumbers(key) {
+		sum += num
+	}
+
+	return sum
+}
+
+// MetaAvg return the average of numeric meta[key] for events in group, 没有数值时返回 0
+func (tc *TriggerContext) MetaAvg(key string) float64 {
+	numbers := tc.metaNumbers(key)
+	if len(numbers) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, num := range numbers {
+		sum += num
+	}
+
+	return sum / float64(len(numbers))
+}
+
+// MetaMax return the max value of numeric meta[key] for events in group, 没有数值时返回 0
+func (tc *TriggerContext) MetaMax(key string) float64 {
+	numbers := tc.metaNumbers(key)
+	if len(numbers) == 0 {
+		return 0
+	}
+
+	max := numbers[0]
+	for _, num := range numbers[1:] {
+		max = math.Max(max, num)
+	}
+
+	return max
+}
+
+// MetaMin return the min value of numeric meta[key] for events in group, 没有数值时返回 0
+func (tc *TriggerContext) MetaMin(key string) float64 {
+	numbers := tc.metaNumbers(key)
+	if len(numbers) == 0 {
+		return 0
+	}
+
+	min := numbers[0]
+	for _, num := range numbers[1:] {
+		min = math.Min(min, num)
+	}
+
+	return min
+}
+
+// GroupAge return the duration since the group was created, 事件组创建时间为空时返回 0
+// 在表达式中可以使用 GroupAge().Minutes() >= 10 的形式判断事件组持续收集的时长
+func (tc *TriggerContext) GroupAge() time.Duration {
+	if tc.Group.CreatedAt.IsZero() {
+		return 0
+	}
+
+	return time.Since(tc.Group.CreatedAt)
+}
+
+// FirstMessageAge return the duration since the earliest event in group was created
+// This method is depressed
+func (tc *TriggerContext) FirstMessageAge() time.Duration {
+	return tc.FirstEventAge()
+}
+
+// FirstEventAge return the duration since the earliest event in group was created, 事件组中没有事件时返回 0
+func (tc *TriggerContext) FirstEventAge() time.Duration {
+	first, _ := tc.eventsTimeRange()
+	if first.IsZero() {
+		return 0
+	}
+
+	return time.Since(first)
+}
+
+// LastMessageAge return the duration since the latest event in group was created
+// This method is depressed
+func (tc *TriggerContext) LastMessageAge() time.Duration {
+	return tc.LastEventAge()
+}
+
+// LastEventAge return the duration since the latest event in group was created, 事件组中没有事件时返回 0
+func (tc *TriggerContext) LastEventAge() time.Duration {
+	_, last := tc.eventsTimeRange()
+	if last.IsZero() {
+		return 0
+	}
+
+	return time.Since(last)
+}
+
+// eventsTimeRange 返回事件组中最早和最晚的事件创建时间，创建时间为空的事件会被忽略
+func (tc *TriggerContext) eventsTimeRange() (first time.Time, last time.Time) {
+	for _, evt := range tc.Events() {
+		if evt.CreatedAt.IsZero() {
+			continue
+		}
+
+		if first.IsZero() || evt.CreatedAt.Before(first) {
+			first = evt.CreatedAt
+		}
+
+		if last.IsZero() || evt.CreatedAt.After(last) {
+			last = evt.CreatedAt
+		}
+	}
+
+	return first, last
+}
+
 // TriggeredTimesInPeriod return triggered times in specified periods
 func (tc *TriggerContext) TriggeredTimesInPeriod(periodInMinutes int, triggerStatus string) int64 {
 	var triggeredTimes int64 = 0
@@ -162,6 +369,36 @@ func (tc *TriggerContext) TriggeredTimesInPeriod(periodInMinutes int, triggerSta
 	return triggeredTimes
 }
 
+// TriggeredTimesInPeriodByRule return triggered times in specified periods for current rule
+// 与 TriggeredTimesInPeriod 只统计当前 Trigger 不同，该方法统计的是当前规则下所有 Trigger 的触发次数（以事件组为单位），
+// 可以用于为整个规则设置统一的触发频率限制
+func (tc *TriggerContext) TriggeredTimesInPeriodByRule(periodInMinutes int, triggerStatus string) int64 {
+	var triggeredTimes int64 = 0
+	tc.cc.MustResolve(func(groupRepo repository.EventGroupRepo) {
+		filter := bson.M{
+			"rule._id":   tc.Group.Rule.ID,
+			"actions.0":  bson.M{"$exists": true},
+			"updated_at": bson.M{"$gt": time.Now().Add(-time.Duration(periodInMinutes) * time.Minute)},
+		}
+
+		if triggerStatus != "" {
+			filter["actions.trigger_status"] = triggerStatus
+		}
+
+		n, _ := groupRepo.Count(filter)
+
+		triggeredTimes = n
+	})
+
+	if log.DebugEnabled() {
+		log.WithFields(log.Fields{
+			"times": triggeredTimes,
+		}).Debugf("TriggeredTimesInPeriodByRule")
+	}
+
+	return triggeredTimes
+}
+
 // LastTriggeredGroup get last triggeredGroup
 func (tc *TriggerContext) LastTriggeredGroup(triggerStatus string) repository.EventGroup {
 	var lastTriggeredGroup repository.EventGroup
@@ -189,6 +426,53 @@ func (tc *TriggerContext) LastTriggeredGroup(triggerStatus string) repository.Ev
 	return lastTriggeredGroup
 }
 
+// InMaintenanceWindow return whether there is any enabled maintenance window open now
+// 这里不考虑维护窗口的 Matcher，只要有维护窗口处于开启状态就返回 true
+func (tc *TriggerContext) InMaintenanceWindow() bool {
+	inWindow := false
+	tc.cc.MustResolve(func(windowRepo repository.MaintenanceWindowRepo) {
+		windows, err := windowRepo.Find(bson.M{"enabled": true})
+		if err != nil {
+			log.Errorf("query maintenance windows failed: %v", err)
+			return
+		}
+
+		now := time.Now()
+		for _, w := range windows {
+			if open, err := w.OpenAt(now); err == nil && open {
+				inWindow = true
+				return
+			}
+		}
+	})
+
+	return inWindow
+}
+
+// OnCall return the id of user who is on call now for the schedule, return empty string if no one is on call
+func (tc *TriggerContext) OnCall(scheduleID string) string {
+	sid, err := primitive.ObjectIDFromHex(scheduleID)
+	if err != nil {
+		return ""
+	}
+
+	userID := primitive.NilObjectID
+	tc.cc.MustResolve(func(scheduleRepo repository.ScheduleRepo) {
+		userID, err = scheduleRepo.CurrentOnCall(sid, time.Now())
+		if err != nil && err != repository.ErrNoOnCall {
+			log.WithFields(log.Fields{
+				"schedule_id": scheduleID,
+			}).Errorf("query on-call user failed: %v", err)
+		}
+	})
+
+	if err != nil {
+		return ""
+	}
+
+	return userID.Hex()
+}
+
 // NewTriggerMatcher create a new TriggerMatcher
 // https://github.com/antonmedv/expr/blob/master/docs/Language-Definition.md
 func NewTriggerMatcher(trigger repository.Trigger) (*TriggerMatcher, error) {
@@ -72,3 +72,92 @@ func TestTriggerMatcher(t *testing.T) {
 	_, err := matcher.NewTriggerMatcher(repository.Trigger{PreCondition: "xxxxx"})
 	assert.Error(t, err)
 }
+
+func TestTriggerContext_MessagesCountByMeta(t *testing.T) {
+	triggerCtx := matcher.NewTriggerContext(container.New(), repository.Trigger{}, repository.EventGroup{}, func() []repository.Event {
+		return []repository.Event{
+			{Content: "a", Meta: repository.EventMeta{"log_level": "ERROR", "server": "192.168.1.2"}},
+			{Content: "b", Meta: repository.EventMeta{"log_level": "WARNING", "server": "192.168.1.3"}},
+			{Content: "c", Meta: repository.EventMeta{"log_level": "ERROR", "server": "192.168.1.3"}},
+			{Content: "d", Meta: repository.EventMeta{"code": 500}},
+		}
+	})
+
+	assert.EqualValues(t, 2, triggerCtx.MessagesCountByMeta("log_level", "ERROR"))
+	assert.EqualValues(t, 1, triggerCtx.EventsCountByMeta("code", "500"))
+	assert.EqualValues(t, 0, triggerCtx.EventsCountByMeta("log_level", "INFO"))
+	assert.Equal(t, []string{"192.168.1.2", "192.168.1.3"}, triggerCtx.DistinctMetaValues("server"))
+	assert.Empty(t, triggerCtx.DistinctMetaValues("not_exist"))
+
+	mt, err := matcher.NewTriggerMatcher(repository.Trigger{PreCondition: `MessagesCountByMeta("log_level", "ERROR") >= 2 and len(DistinctMetaValues("server")) == 2`})
+	assert.NoError(t, err)
+
+	matched, err := mt.Match(triggerCtx)
+	assert.NoError(t, err)
+	assert.True(t, matched)
+}
+
+func TestTriggerContext_MetaNumericAggregates(t *testing.T) {
+	triggerCtx := matcher.NewTriggerContext(container.New(), repository.Trigger{}, repository.EventGroup{}, func() []repository.Event {
+		return []repository.Event{
+			{Content: "a", Meta: repository.EventMeta{"latency": 120}},
+			{Content: "b", Meta: repository.EventMeta{"latency": "80.5"}},
+			{Content: "c", Meta: repository.EventMeta{"latency": 300.5}},
+			{Content: "d", Meta: repository.EventMeta{"latency": "timeout"}},
+			{Content: "e", Meta: repository.EventMeta{"server": "192.168.1.2"}},
+		}
+	})
+
+	assert.EqualValues(t, 3, triggerCtx.MetaNumericCount("latency"))
+	assert.Equal(t, 501.0, triggerCtx.MetaSum("latency"))
+	assert.Equal(t, 167.0, triggerCtx.MetaAvg("latency"))
+	assert.Equal(t, 300.5, triggerCtx.MetaMax("latency"))
+	assert.Equal(t, 80.5, triggerCtx.MetaMin("latency"))
+
+	// 没有数值时统计值均为 0
+	assert.EqualValues(t, 0, triggerCtx.MetaNumericCount("server"))
+	assert.Equal(t, 0.0, triggerCtx.MetaSum("server"))
+	assert.Equal(t, 0.0, triggerCtx.MetaAvg("not_exist"))
+	assert.Equal(t, 0.0, triggerCtx.MetaMax("not_exist"))
+	assert.Equal(t, 0.0, triggerCtx.MetaMin("not_exist"))
+
+	mt, err := matcher.NewTriggerMatcher(repository.Trigger{PreCondition: `MetaNumericCount("latency") > 0 and MetaAvg("latency") > 100`})
+	assert.NoError(t, err)
+
+	matched, err := mt.Match(triggerCtx)
+	assert.NoError(t, err)
+	assert.True(t, matched)
+}
+
+func TestTriggerContext_Ages(t *testing.T) {
+	now := time.Now()
+	grp := repository.EventGroup{CreatedAt: now.Add(-30 * time.Minute)}
+	triggerCtx := matcher.NewTriggerContext(container.New(), repository.Trigger{}, grp, func() []repository.Event {
+		return []repository.Event{
+			{Content: "a", CreatedAt: now.Add(-10 * time.Minute)},
+			{Content: "b", CreatedAt: now.Add(-25 * time.Minute)},
+			{Content: "c", CreatedAt: now.Add(-2 * time.Minute)},
+			{Content: "d"},
+		}
+	})
+
+	assert.InDelta(t, 30, triggerCtx.GroupAge().Minutes(), 0.1)
+	assert.InDelta(t, 25, triggerCtx.FirstMessageAge().Minutes(), 0.1)
+	assert.InDelta(t, 2, triggerCtx.LastMessageAge().Minutes(), 0.1)
+
+	mt, err := matcher.NewTriggerMatcher(repository.Trigger{PreCondition: `GroupAge().Minutes() >= 20 and FirstEventAge().Minutes() >= 20 and LastEventAge().Minutes() < 5`})
+	assert.NoError(t, err)
+
+	matched, err := mt.Match(triggerCtx)
+	assert.NoError(t, err)
+	assert.True(t, matched)
+
+	// 没有事件时均返回 0
+	emptyCtx := matcher.NewTriggerContext(container.New(), repository.Trigger{}, repository.EventGroup{}, func() []repository.Event {
+		return nil
+	})
+
+	assert.EqualValues(t, 0, emptyCtx.GroupAge())
+	assert.EqualValues(t, 0, emptyCtx.FirstEventAge())
+	assert.EqualValues(t, 0, emptyCtx.LastEventAge())
+}
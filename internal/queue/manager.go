@@ -22,11 +22,15 @@ type Manager interface {
 	Paused() bool
 	Info() Info
 	RegisterHandler(name string, handler Handler)
+	RegisterFailedHandler(name string, handler FailedHandler)
 }
 
 // Handler 队列消息处理器
 type Handler func(item repository.QueueJob) error
 
+// FailedHandler 队列消息超过最大重试次数仍然失败时的处理器
+type FailedHandler func(item repository.QueueJob)
+
 // Info 队列状态信息
 type Info struct {
 	StartAt        time.Time `json:"start_at"`
@@ -40,6 +44,8 @@ type queueManager struct {
 	cc       container.Container
 	repo     repository.QueueRepo
 	handlers map[string]Handler
+	// failedHandlers 超过最大重试次数之后的处理器，用于保存死信
+	failedHandlers map[string]FailedHandler
 
 	info Info
 
@@ -50,9 +56,10 @@ type queueManager struct {
 // NewManager create a QueueManager
 func NewManager(cc container.Container) Manager {
 	manager := queueManager{
-		cc:       cc,
-		paused:   true,
-		handlers: make(map[string]Handler),
+		cc:             cc,
+		paused:         true,
+		handlers:       make(map[string]Handler),
+		failedHandlers: make(map[string]FailedHandler),
 		info: Info{
 			StartAt:        time.Now(),
 			WorkerNum:      0,
@@ -82,6 +89,14 @@ func (manager *queueManager) RegisterHandler(name string, handler Handler) {
 	manager.handlers[name] = handler
 }
 
+// RegisterFailedHandler register a handler for job which is failed after max retry times
+func (manager *queueManager) RegisterFailedHandler(name string, handler FailedHandler) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	manager.failedHandlers[name] = handler
+}
+
 // Pause control whether the queue is working or paused
 func (manager *queueManager) Pause(pause bool) {
 	manager.lock.Lock()
@@ -213,6 +228,13 @@ func (manager *queueManager) handle(ctx context.Context, item repository.QueueJo
 				}).Errorf("can not update queue item: %v", err)
 			}
 
+			manager.lock.RLock()
+			failedHandler, ok := manager.failedHandlers[item.Name]
+			manager.lock.RUnlock()
+			if ok {
+				failedHandler(item)
+			}
+
 			return
 		}
 
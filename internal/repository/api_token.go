@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APITokenScope API Token 的权限范围
+type APITokenScope string
+
+const (
+	// APITokenScopeIngest 只允许推送事件
+	APITokenScopeIngest APITokenScope = "ingest"
+	// APITokenScopeAdmin 允许访问所有接口
+	APITokenScopeAdmin APITokenScope = "admin"
+)
+
+// APIToken 访问 API 的 Token，只保存 Token 的哈希值
+type APIToken struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name" json:"name"`
+	TokenHash string             `bson:"token_hash" json:"-"`
+	// TokenPrefix Token 的前几位，用于识别 Token
+	TokenPrefix string          `bson:"token_prefix" json:"token_prefix"`
+	Scopes      []APITokenScope `bson:"scopes" json:"scopes"`
+	// RateLimit 每分钟最多允许的请求数，为 0 时不限制
+	RateLimit int64 `bson:"rate_limit" json:"rate_limit"`
+	Enabled   bool  `bson:"enabled" json:"enabled"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// HasScope 判断 Token 是否拥有 scope 权限，admin 权限拥有所有权限
+func (t APIToken) HasScope(scope APITokenScope) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == APITokenScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HashAPIToken 计算 Token 的哈希值
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type APITokenRepo interface {
+	Add(token APIToken) (id primitive.ObjectID, err error)
+	Get(id primitive.ObjectID) (token APIToken, err error)
+	// GetByToken 根据 Token 明文查询 Token
+	GetByToken(token string) (APIToken, error)
+	Paginate(filter bson.M, offset, limit int64) (tokens []APIToken, next int64, err error)
+	DeleteID(id primitive.ObjectID) error
+	Update(id primitive.ObjectID, token APIToken) error
+	Count(filter bson.M) (int64, error)
+}
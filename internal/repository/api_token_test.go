@@ -0,0 +1,22 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIToken_HasScope(t *testing.T) {
+	ingest := repository.APIToken{Scopes: []repository.APITokenScope{repository.APITokenScopeIngest}}
+	assert.True(t, ingest.HasScope(repository.APITokenScopeIngest))
+	assert.False(t, ingest.HasScope(repository.APITokenScopeAdmin))
+
+	admin := repository.APIToken{Scopes: []repository.APITokenScope{repository.APITokenScopeAdmin}}
+	assert.True(t, admin.HasScope(repository.APITokenScopeIngest))
+	assert.True(t, admin.HasScope(repository.APITokenScopeAdmin))
+
+	assert.False(t, repository.APIToken{}.HasScope(repository.APITokenScopeIngest))
+	assert.Equal(t, repository.HashAPIToken("abc"), repository.HashAPIToken("abc"))
+	assert.NotEqual(t, repository.HashAPIToken("abc"), repository.HashAPIToken("abd"))
+}
@@ -17,6 +17,8 @@ const (
 	AuditLogTypeAction AuditLogType = "ACTION"
 	// AuditLogTypeSystem 系统类型
 	AuditLogTypeSystem AuditLogType = "SYSTEM"
+	// AuditLogTypeAPI 通过 API 修改数据的操作记录
+	AuditLogTypeAPI AuditLogType = "API"
 )
 
 // AuditLog 审计日志存储
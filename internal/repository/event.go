@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -47,6 +49,35 @@ type Event struct {
 	CreatedAt  time.Time            `bson:"created_at" json:"created_at"`
 }
 
+// EventStatusUpdate 批量更新事件时单个事件的状态、分组以及关联
+type EventStatusUpdate struct {
+	ID         primitive.ObjectID
+	Status     EventStatus
+	GroupID    []primitive.ObjectID
+	RelationID []primitive.ObjectID
+}
+
+// EventStatusUpdateFailure 批量更新中更新失败的事件
+type EventStatusUpdateFailure struct {
+	ID     primitive.ObjectID
+	Reason string
+}
+
+// EventBulkUpdateError 批量更新事件时部分事件更新失败，其它事件已经更新成功
+type EventBulkUpdateError struct {
+	Total  int
+	Failed []EventStatusUpdateFailure
+}
+
+func (e EventBulkUpdateError) Error() string {
+	ids := make([]string, 0, len(e.Failed))
+	for _, f := range e.Failed {
+		ids = append(ids, fmt.Sprintf("%s(%s)", f.ID.Hex(), f.Reason))
+	}
+
+	return fmt.Sprintf("bulk update events failed: %d of %d not applied: %s", len(e.Failed), e.Total, strings.Join(ids, ", "))
+}
+
 // EventByDatetimeCount 时间范围内的事件数量
 type EventByDatetimeCount struct {
 	Datetime time.Time `bson:"datetime" json:"datetime"`
@@ -61,10 +92,21 @@ type EventRepo interface {
 	Find(filter interface{}) (messages []Event, err error)
 	FindIDs(ctx context.Context, filter interface{}, limit int64) ([]primitive.ObjectID, error)
 	Paginate(filter interface{}, offset, limit int64) (messages []Event, next int64, err error)
+	// PaginateAfter 基于 _id 的游标分页，查询 afterID 之后（更早创建）的事件，next 为下一页的游标，没有更多数据时为空
+	PaginateAfter(filter interface{}, afterID primitive.ObjectID, limit int64) (messages []Event, next primitive.ObjectID, err error)
+	// Search 在事件内容中全文搜索，返回当前页的事件以及匹配的事件总数
+	Search(text string, filter bson.M, offset, limit int64) (messages []Event, total int64, err error)
 	Delete(filter interface{}) error
 	DeleteID(id primitive.ObjectID) error
 	Traverse(filter interface{}, cb func(msg Event) error) error
+	// TraverseCtx 遍历事件，ctx 被取消或者超时之后停止遍历并返回 ctx.Err()
+	TraverseCtx(ctx context.Context, filter interface{}, cb func(msg Event) error) error
 	UpdateID(id primitive.ObjectID, update Event) error
+	// BulkUpdateStatus 批量更新事件的状态、分组以及关联，部分事件更新失败时返回 EventBulkUpdateError
+	BulkUpdateStatus(updates []EventStatusUpdate) error
+	// ClaimPending 认领 pending 状态的事件，事件没有被其它 claimer 认领或者认领时间早于 expiredBefore 时认领成功，返回 true
+	// 多个聚合任务并发执行时，只有认领成功的任务才能为事件分组，事件状态更新之后认领信息被清除
+	ClaimPending(ctx context.Context, id primitive.ObjectID, claimer string, expiredBefore time.Time) (bool, error)
 	Count(filter interface{}) (int64, error)
 	CountByDatetime(ctx context.Context, filter bson.M, startTime, endTime time.Time, hour int64) ([]EventByDatetimeCount, error)
 }
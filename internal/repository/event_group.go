@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -18,6 +19,36 @@ const (
 	EventGroupStatusCanceled   EventGroupStatus = "canceled"
 )
 
+// eventGroupManualTransitions 允许人工变更的事件组状态，只能变更为 ok（已解决）或者 canceled（忽略）
+var eventGroupManualTransitions = map[EventGroupStatus][]EventGroupStatus{
+	EventGroupStatusCollecting: {EventGroupStatusCanceled},
+	EventGroupStatusPending:    {EventGroupStatusOK, EventGroupStatusCanceled},
+	EventGroupStatusFailed:     {EventGroupStatusOK, EventGroupStatusCanceled},
+}
+
+// ManualTransitionSources 返回允许人工变更为 target 的所有状态
+func ManualTransitionSources(target EventGroupStatus) []EventGroupStatus {
+	sources := make([]EventGroupStatus, 0)
+	for from := range eventGroupManualTransitions {
+		if from.CanTransitTo(target) {
+			sources = append(sources, from)
+		}
+	}
+
+	return sources
+}
+
+// CanTransitTo 判断事件组状态是否允许人工变更为 target
+func (s EventGroupStatus) CanTransitTo(target EventGroupStatus) bool {
+	for _, st := range eventGroupManualTransitions[s] {
+		if st == target {
+			return true
+		}
+	}
+
+	return false
+}
+
 type EventGroupRule struct {
 	ID   primitive.ObjectID `bson:"_id" json:"id"`
 	Name string             `bson:"name" json:"name"`
@@ -33,6 +64,8 @@ type EventGroupRule struct {
 	IgnoreRule      string `bson:"ignore_rule" json:"ignore_rule"`
 	Template        string `bson:"template" json:"template"`
 	SummaryTemplate string `bson:"summary_template" json:"summary_template"`
+	// RecoveryTemplate 恢复通知模板
+	RecoveryTemplate string `bson:"recovery_template" json:"recovery_template"`
 
 	// Report template
 	ReportTemplateID primitive.ObjectID `bson:"report_template_id" json:"report_template_id"`
@@ -49,12 +82,51 @@ type EventGroup struct {
 	MessageCount int64          `bson:"message_count" json:"message_count"`
 	Rule         EventGroupRule `bson:"rule" json:"rule"`
 	Actions      []Trigger      `bson:"actions" json:"actions"`
+	// History 人工操作记录
+	History []EventGroupHistory `bson:"history,omitempty" json:"history,omitempty"`
+	// Inhibition 事件组被抑制规则抑制时的抑制信息，抑制期间事件组保持 pending 状态，抑制解除后清空
+	Inhibition *EventGroupInhibition `bson:"inhibition,omitempty" json:"inhibition,omitempty"`
+	// MaintenanceWindowID 事件组的动作被维护窗口暂缓执行时，记录维护窗口 ID，窗口关闭动作执行后清空
+	MaintenanceWindowID primitive.ObjectID `bson:"maintenance_window_id,omitempty" json:"maintenance_window_id,omitempty"`
+	// AckedAt 事件组被确认的时间，确认后不再执行升级步骤
+	AckedAt *time.Time `bson:"acked_at,omitempty" json:"acked_at,omitempty"`
+	// AckedBy 确认事件组的用户 ID
+	AckedBy primitive.ObjectID `bson:"acked_by,omitempty" json:"acked_by,omitempty"`
+	// Assignee 事件组负责人（用户 ID）
+	Assignee primitive.ObjectID `bson:"assignee,omitempty" json:"assignee,omitempty"`
 
 	Status    EventGroupStatus `bson:"status" json:"status"`
 	CreatedAt time.Time        `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time        `bson:"updated_at" json:"updated_at"`
 }
 
+// EventGroupHistoryType 事件组人工操作类型
+type EventGroupHistoryType string
+
+const (
+	// EventGroupHistoryTypeRetrigger 重新触发事件组的动作
+	EventGroupHistoryTypeRetrigger EventGroupHistoryType = "retrigger"
+	// EventGroupHistoryTypeAck 确认事件组
+	EventGroupHistoryTypeAck EventGroupHistoryType = "ack"
+	// EventGroupHistoryTypeAssign 指派事件组负责人
+	EventGroupHistoryTypeAssign EventGroupHistoryType = "assign"
+)
+
+// EventGroupHistory 事件组人工操作记录
+type EventGroupHistory struct {
+	Type      EventGroupHistoryType `bson:"type" json:"type"`
+	Operator  string                `bson:"operator" json:"operator"`
+	CreatedAt time.Time             `bson:"created_at" json:"created_at"`
+}
+
+// EventGroupInhibition 事件组的抑制信息
+type EventGroupInhibition struct {
+	RuleID        primitive.ObjectID `bson:"rule_id" json:"rule_id"`
+	RuleName      string             `bson:"rule_name" json:"rule_name"`
+	SourceEventID primitive.ObjectID `bson:"source_event_id" json:"source_event_id"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+}
+
 // Ready return whether the message group has reached close conditions
 func (grp *EventGroup) Ready() bool {
 	return grp.Rule.ExpectReadyAt.Before(time.Now())
@@ -80,16 +152,46 @@ type EventGroupByDatetimeCount struct {
 	TotalMessages int64     `bson:"total_messages" json:"total_messages"`
 }
 
+// EventGroupBucket 时间桶内的事件组数量，Bucket 为时间桶的开始时间（本地时区），格式取决于统计周期
+type EventGroupBucket struct {
+	Bucket        string `bson:"bucket" json:"bucket"`
+	Total         int64  `bson:"total" json:"total"`
+	TotalMessages int64  `bson:"total_messages" json:"total_messages"`
+}
+
+// BucketIntervalFormats 支持的时间桶统计周期，以及对应的 $dateToString 格式
+var BucketIntervalFormats = map[string]string{
+	"minute": "%Y-%m-%d %H:%M",
+	"hour":   "%Y-%m-%d %H:00",
+	"day":    "%Y-%m-%d",
+}
+
+// ErrAlreadyAcked 事件组已经被确认
+var ErrAlreadyAcked = errors.New("event group is already acked")
+
 type EventGroupRepo interface {
 	Add(grp EventGroup) (id primitive.ObjectID, err error)
 	Get(id primitive.ObjectID) (grp EventGroup, err error)
 	Find(filter bson.M) (grps []EventGroup, err error)
 	Paginate(filter bson.M, offset, limit int64) (grps []EventGroup, next int64, err error)
+	// PaginateAfter 基于 _id 的游标分页，next 为下一页的游标，没有更多数据时为空
+	PaginateAfter(filter bson.M, afterID primitive.ObjectID, limit int64) (grps []EventGroup, next primitive.ObjectID, err error)
 	Delete(filter bson.M) error
 	DeleteID(id primitive.ObjectID) error
 	Traverse(filter bson.M, cb func(grp EventGroup) error) error
+	// TraverseCtx 遍历事件组，ctx 被取消或者超时之后停止遍历并返回 ctx.Err()
+	TraverseCtx(ctx context.Context, filter bson.M, cb func(grp EventGroup) error) error
 	UpdateID(id primitive.ObjectID, grp EventGroup) error
 	Count(filter bson.M) (int64, error)
+	// UpdateStatusMany 批量更新分组状态，只更新当前状态属于 from 的分组，返回实际更新的分组数量
+	UpdateStatusMany(ids []primitive.ObjectID, from []EventGroupStatus, status string) (int64, error)
+	// CountByStatus 按照状态统计分组数量，返回 状态 => 数量
+	CountByStatus(filter bson.M) (map[string]int64, error)
+	// Ack 确认事件组，事件组不存在时返回 ErrNotFound，已经被确认时返回 ErrAlreadyAcked
+	Ack(id primitive.ObjectID, userID primitive.ObjectID) error
+	// MarkEscalated 将事件组第 actionIndex 个动作已执行的升级步骤数量加 1
+	// 只有事件组未被确认，并且该动作已执行的升级步骤数量仍然为 escalatedSteps 时才会更新，返回是否更新成功
+	MarkEscalated(id primitive.ObjectID, actionIndex int, escalatedSteps int) (bool, error)
 
 	// LastGroup get last group which match the filter in messageGroups
 	LastGroup(filter bson.M) (grp EventGroup, err error)
@@ -100,4 +202,6 @@ type EventGroupRepo interface {
 	StatByRuleCount(ctx context.Context, startTime, endTime time.Time) ([]EventGroupByRuleCount, error)
 	StatByUserCount(ctx context.Context, startTime, endTime time.Time) ([]EventGroupByUserCount, error)
 	StatByDatetimeCount(ctx context.Context, filter bson.M, startTime, endTime time.Time, hour int64) ([]EventGroupByDatetimeCount, error)
+	// StatByTimeBucket 按照 created_at 以 interval（minute/hour/day）为周期统计事件组数量
+	StatByTimeBucket(ctx context.Context, filter bson.M, interval string) ([]EventGroupBucket, error)
 }
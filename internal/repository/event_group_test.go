@@ -0,0 +1,64 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventGroupStatus_CanTransitTo(t *testing.T) {
+	assert.True(t, repository.EventGroupStatusPending.CanTransitTo(repository.EventGroupStatusOK))
+	assert.True(t, repository.EventGroupStatusFailed.CanTransitTo(repository.EventGroupStatusCanceled))
+	assert.True(t, repository.EventGroupStatusCollecting.CanTransitTo(repository.EventGroupStatusCanceled))
+
+	assert.False(t, repository.EventGroupStatusCollecting.CanTransitTo(repository.EventGroupStatusOK))
+	assert.False(t, repository.EventGroupStatusOK.CanTransitTo(repository.EventGroupStatusCollecting))
+	assert.False(t, repository.EventGroupStatusFailed.CanTransitTo(repository.EventGroupStatusPending))
+	assert.False(t, repository.EventGroupStatusCanceled.CanTransitTo(repository.EventGroupStatusOK))
+}
+
+func TestManualTransitionSources(t *testing.T) {
+	assert.ElementsMatch(t, []repository.EventGroupStatus{
+		repository.EventGroupStatusPending,
+		repository.EventGroupStatusFailed,
+	}, repository.ManualTransitionSources(repository.EventGroupStatusOK))
+
+	assert.ElementsMatch(t, []repository.EventGroupStatus{
+		repository.EventGroupStatusCollecting,
+		repository.EventGroupStatusPending,
+		repository.EventGroupStatusFailed,
+	}, repository.ManualTransitionSources(repository.EventGroupStatusCanceled))
+
+	assert.Empty(t, repository.ManualTransitionSources(repository.EventGroupStatusPending))
+}
+
+func TestTrigger_DueEscalation(t *testing.T) {
+	tr := repository.Trigger{
+		Escalations: []repository.EscalationStep{
+			{Delay: 600, Action: "dingding"},
+			{Delay: 1800, Action: "sms_aliyun"},
+		},
+	}
+
+	step, due := tr.DueEscalation(5 * time.Minute)
+	assert.False(t, due)
+	assert.Equal(t, "dingding", step.Action)
+
+	step, due = tr.DueEscalation(10 * time.Minute)
+	assert.True(t, due)
+	assert.Equal(t, "dingding", step.Action)
+
+	tr.EscalatedSteps = 1
+	_, due = tr.DueEscalation(20 * time.Minute)
+	assert.False(t, due)
+
+	step, due = tr.DueEscalation(30 * time.Minute)
+	assert.True(t, due)
+	assert.Equal(t, "sms_aliyun", step.Action)
+
+	tr.EscalatedSteps = 2
+	_, due = tr.DueEscalation(time.Hour)
+	assert.False(t, due)
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// EventInhibit 事件去重记录，以 EventControl.ID 为主键
+// 抑制周期内第一个事件正常保存，之后相同 ID 的事件被丢弃，丢弃的事件数量记录在 Dropped 中
+type EventInhibit struct {
+	ID            string    `bson:"_id" json:"id"`
+	Dropped       int64     `bson:"dropped" json:"dropped"`
+	LastDroppedAt time.Time `bson:"last_dropped_at,omitempty" json:"last_dropped_at,omitempty"`
+	ExpiredAt     time.Time `bson:"expired_at" json:"expired_at"`
+	CreatedAt     time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Active 抑制周期是否还有效
+func (inhibit EventInhibit) Active(now time.Time) bool {
+	return inhibit.ExpiredAt.After(now)
+}
+
+type InhibitRepo interface {
+	// Inhibit 检查 id 是否处于抑制周期内，是则丢弃计数加一并返回 true，否则开始一个长度为 interval 的新抑制周期并返回 false
+	Inhibit(ctx context.Context, id string, interval time.Duration) (inhibited bool, err error)
+	// Get 查询 id 对应的去重记录，不存在或者已经过期时返回 ErrNotFound
+	Get(ctx context.Context, id string) (inhibit EventInhibit, err error)
+}
@@ -19,7 +19,10 @@ type EventRelation struct {
 
 // EventRelationRepo 事件关联仓库接口
 type EventRelationRepo interface {
-	AddOrUpdateEventRelation(ctx context.Context, summary string, matchedRuleID primitive.ObjectID) (EventRelation, error)
+	// EnsureEventRelation 返回 summary 与 matchedRuleID 对应的事件关联，不存在时创建，不修改事件数量，可以重复执行
+	EnsureEventRelation(ctx context.Context, summary string, matchedRuleID primitive.ObjectID) (EventRelation, error)
+	// IncrEventCount 增加事件关联的事件数量，counts 为事件关联 ID 与新增的事件数量，需要在事件的关联写入之后执行
+	IncrEventCount(ctx context.Context, counts map[primitive.ObjectID]int64) error
 	Get(ctx context.Context, id primitive.ObjectID) (eventRel EventRelation, err error)
 	Paginate(ctx context.Context, filter interface{}, offset, limit int64) (eventRels []EventRelation, next int64, err error)
 	Count(ctx context.Context, filter interface{}) (int64, error)
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type FailedActionStatus string
+
+const (
+	// FailedActionStatusDead 超过最大重试次数，等待人工处理
+	FailedActionStatusDead FailedActionStatus = "dead"
+	// FailedActionStatusRetried 已经重新加入队列
+	FailedActionStatusRetried FailedActionStatus = "retried"
+)
+
+// FailedAction 超过最大重试次数仍然执行失败的动作（死信），保存完整的 Payload 用于重新执行
+type FailedAction struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	// QueueJobID 失败的队列任务 ID
+	QueueJobID primitive.ObjectID `bson:"queue_job_id" json:"queue_job_id"`
+	Action     string             `bson:"action" json:"action"`
+	RuleID     primitive.ObjectID `bson:"rule_id" json:"rule_id"`
+	GroupID    primitive.ObjectID `bson:"group_id" json:"group_id"`
+	TriggerID  primitive.ObjectID `bson:"trigger_id" json:"trigger_id"`
+	Payload    string             `bson:"payload" json:"payload"`
+	// Attempts 执行次数
+	Attempts  int                `bson:"attempts" json:"attempts"`
+	LastError string             `bson:"last_error" json:"last_error"`
+	Status    FailedActionStatus `bson:"status" json:"status"`
+	// RetryJobID 重新执行时创建的队列任务 ID
+	RetryJobID primitive.ObjectID `bson:"retry_job_id,omitempty" json:"retry_job_id,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+type FailedActionRepo interface {
+	Add(fa FailedAction) (id primitive.ObjectID, err error)
+	Get(id primitive.ObjectID) (fa FailedAction, err error)
+	Paginate(filter bson.M, offset, limit int64) (fas []FailedAction, next int64, err error)
+	Update(id primitive.ObjectID, fa FailedAction) error
+	DeleteID(id primitive.ObjectID) error
+	Count(filter bson.M) (int64, error)
+}
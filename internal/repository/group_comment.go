@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GroupComment 事件组评论，用于记录事件处理过程
+type GroupComment struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID   primitive.ObjectID `bson:"group_id" json:"group_id"`
+	Author    string             `bson:"author" json:"author"`
+	Body      string             `bson:"body" json:"body"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type GroupCommentRepo interface {
+	Add(comment GroupComment) (id primitive.ObjectID, err error)
+	Get(id primitive.ObjectID) (comment GroupComment, err error)
+	// Paginate 分页查询评论，按照创建时间倒序排列
+	Paginate(filter bson.M, offset, limit int64) (comments []GroupComment, next int64, err error)
+	Delete(filter bson.M) error
+	Count(filter bson.M) (int64, error)
+}
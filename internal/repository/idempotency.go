@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// IdempotencyRecord 推送事件接口的幂等记录，以 Idempotency-Key 的哈希值为主键
+// 请求处理完成之前 Completed 为 false，完成之后保存原始的响应，有效期内相同 Key 的请求直接返回该响应
+type IdempotencyRecord struct {
+	ID         string    `bson:"_id" json:"id"`
+	Completed  bool      `bson:"completed" json:"completed"`
+	StatusCode int       `bson:"status_code" json:"status_code"`
+	Response   []byte    `bson:"response" json:"response"`
+	ExpiredAt  time.Time `bson:"expired_at" json:"expired_at"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+}
+
+// HashIdempotencyKey 计算 Idempotency-Key 的哈希值，scope 用于区分不同的接口
+func HashIdempotencyKey(scope, key string) string {
+	sum := sha256.Sum256([]byte(scope + ":" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+type IdempotencyRepo interface {
+	// Reserve 占用 id，id 不存在或者已经过期时创建一个有效期为 ttl 的未完成记录并返回 true
+	// 否则返回已有的记录以及 false
+	Reserve(ctx context.Context, id string, ttl time.Duration) (record IdempotencyRecord, reserved bool, err error)
+	// Complete 保存请求的响应，标记记录为已完成
+	Complete(ctx context.Context, id string, statusCode int, response []byte) error
+	// Release 删除未完成的记录，请求处理失败时调用，允许客户端使用相同的 Key 重试
+	Release(ctx context.Context, id string) error
+}
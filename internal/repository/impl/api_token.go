@@ -0,0 +1,102 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type APITokenRepo struct {
+	col *mongo.Collection
+}
+
+func NewAPITokenRepo(db *mongo.Database) repository.APITokenRepo {
+	return &APITokenRepo{col: db.Collection("api_token")}
+}
+
+// EnsureIndexes 创建 api_token 集合的索引：token_hash（唯一）
+func (r APITokenRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	if err != nil {
+		return fmt.Errorf("create indexes for api_token failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r APITokenRepo) Add(token repository.APIToken) (id primitive.ObjectID, err error) {
+	token.CreatedAt = time.Now()
+	token.UpdatedAt = token.CreatedAt
+
+	rs, err := r.col.InsertOne(context.TODO(), token)
+	if err != nil {
+		return
+	}
+
+	return rs.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r APITokenRepo) Get(id primitive.ObjectID) (token repository.APIToken, err error) {
+	err = r.col.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&token)
+	if err == mongo.ErrNoDocuments {
+		err = repository.ErrNotFound
+	}
+
+	return
+}
+
+func (r APITokenRepo) GetByToken(token string) (t repository.APIToken, err error) {
+	err = r.col.FindOne(context.TODO(), bson.M{"token_hash": repository.HashAPIToken(token)}).Decode(&t)
+	if err == mongo.ErrNoDocuments {
+		err = repository.ErrNotFound
+	}
+
+	return
+}
+
+func (r APITokenRepo) Paginate(filter bson.M, offset, limit int64) (tokens []repository.APIToken, next int64, err error) {
+	tokens = make([]repository.APIToken, 0)
+	cur, err := r.col.Find(context.TODO(), filter, options.Find().SetSkip(offset).SetLimit(limit).SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var token repository.APIToken
+		if err = cur.Decode(&token); err != nil {
+			return
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	if int64(len(tokens)) == limit {
+		next = offset + limit
+	}
+
+	return
+}
+
+func (r APITokenRepo) DeleteID(id primitive.ObjectID) error {
+	_, err := r.col.DeleteOne(context.TODO(), bson.M{"_id": id})
+	return err
+}
+
+func (r APITokenRepo) Update(id primitive.ObjectID, token repository.APIToken) error {
+	token.UpdatedAt = time.Now()
+	_, err := r.col.ReplaceOne(context.TODO(), bson.M{"_id": id}, token)
+	return err
+}
+
+func (r APITokenRepo) Count(filter bson.M) (int64, error) {
+	return r.col.CountDocuments(context.TODO(), filter)
+}
@@ -2,6 +2,9 @@ package impl
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/mylxsw/adanos-alert/internal/repository"
@@ -20,28 +23,23 @@ type EventRepo struct {
 func NewEventRepo(db *mongo.Database, seqRepo repository.SequenceRepo) repository.EventRepo {
 	col := db.Collection("message")
 
-	if _, err := col.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
-		Keys:    bson.M{"created_at": 1},
-		Options: options.Index().SetUnique(false),
-	}); err != nil {
-		log.Errorf("can not create index for message.created_at: %v", err)
-	}
-
-	if _, err := col.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
-		Keys:    bson.M{"group_ids": 1},
-		Options: options.Index().SetUnique(false),
-	}); err != nil {
-		log.Errorf("can not create index for message.group_ids: %v", err)
-	}
+	return &EventRepo{col: col, seqRepo: seqRepo}
+}
 
-	if _, err := col.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
-		Keys:    bson.M{"relation_ids": 1},
-		Options: options.Index().SetUnique(false),
-	}); err != nil {
-		log.Errorf("can not create index for message.relation_ids: %v", err)
+// EnsureIndexes 创建 message 集合的索引：created_at、status、group_ids、relation_ids，以及 content 上的全文索引
+func (m EventRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := m.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"created_at": 1}},
+		{Keys: bson.M{"status": 1}},
+		{Keys: bson.M{"group_ids": 1}},
+		{Keys: bson.M{"relation_ids": 1}},
+		{Keys: bson.M{"content": "text"}},
+	})
+	if err != nil {
+		return fmt.Errorf("create indexes for message failed: %w", err)
 	}
 
-	return &EventRepo{col: col, seqRepo: seqRepo}
+	return nil
 }
 
 func (m EventRepo) AddWithContext(ctx context.Context, msg repository.Event) (id primitive.ObjectID, err error) {
@@ -144,6 +142,74 @@ func (m EventRepo) Paginate(filter interface{}, offset, limit int64) (messages [
 	return messages, next, err
 }
 
+func (m EventRepo) PaginateAfter(filter interface{}, afterID primitive.ObjectID, limit int64) (messages []repository.Event, next primitive.ObjectID, err error) {
+	messages = make([]repository.Event, 0)
+	cur, err := m.col.Find(context.TODO(), repository.AfterIDFilter(filter, afterID), options.Find().SetLimit(limit).SetSort(bson.M{"_id": -1}))
+	if err != nil {
+		return
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var msg repository.Event
+		if err = cur.Decode(&msg); err != nil {
+			return
+		}
+
+		messages = append(messages, msg)
+	}
+
+	if int64(len(messages)) == limit && limit > 0 {
+		next = messages[len(messages)-1].ID
+	}
+
+	return messages, next, err
+}
+
+// traverseBatchSize 遍历时每批从 MongoDB 获取的文档数量
+const traverseBatchSize int32 = 500
+
+// mongoErrIndexNotFound MongoDB 中没有可用的全文索引时，$text 查询返回的错误码
+const mongoErrIndexNotFound = 27
+
+func (m EventRepo) Search(text string, filter bson.M, offset, limit int64) (messages []repository.Event, total int64, err error) {
+	textFilter := bson.M{"$text": bson.M{"$search": text}}
+	for k, v := range filter {
+		textFilter[k] = v
+	}
+
+	messages, total, err = m.search(textFilter, offset, limit)
+
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == mongoErrIndexNotFound {
+		// 全文索引不可用时，使用正则表达式匹配
+		log.Warningf("text index for message.content is not available, fallback to regex: %v", err)
+
+		regexFilter := bson.M{"content": bson.M{"$regex": regexp.QuoteMeta(text), "$options": "i"}}
+		for k, v := range filter {
+			regexFilter[k] = v
+		}
+
+		return m.search(regexFilter, offset, limit)
+	}
+
+	return messages, total, err
+}
+
+func (m EventRepo) search(filter bson.M, offset, limit int64) ([]repository.Event, int64, error) {
+	total, err := m.col.CountDocuments(context.TODO(), filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	messages, _, err := m.Paginate(filter, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return messages, total, nil
+}
+
 func (m EventRepo) Delete(filter interface{}) error {
 	_, err := m.col.DeleteMany(context.TODO(), filter)
 	return err
@@ -154,13 +220,21 @@ func (m EventRepo) DeleteID(id primitive.ObjectID) error {
 }
 
 func (m EventRepo) Traverse(filter interface{}, cb func(msg repository.Event) error) error {
-	cur, err := m.col.Find(context.TODO(), filter)
+	return m.TraverseCtx(context.TODO(), filter, cb)
+}
+
+func (m EventRepo) TraverseCtx(ctx context.Context, filter interface{}, cb func(msg repository.Event) error) error {
+	cur, err := m.col.Find(ctx, filter, options.Find().SetBatchSize(traverseBatchSize))
 	if err != nil {
 		return err
 	}
 	defer cur.Close(context.TODO())
 
-	for cur.Next(context.TODO()) {
+	for cur.Next(ctx) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var msg repository.Event
 		if err = cur.Decode(&msg); err != nil {
 			return err
@@ -171,7 +245,7 @@ func (m EventRepo) Traverse(filter interface{}, cb func(msg repository.Event) er
 		}
 	}
 
-	return nil
+	return cur.Err()
 }
 
 func (m EventRepo) UpdateID(id primitive.ObjectID, update repository.Event) error {
@@ -179,6 +253,101 @@ func (m EventRepo) UpdateID(id primitive.ObjectID, update repository.Event) erro
 	return err
 }
 
+func (m EventRepo) BulkUpdateStatus(updates []repository.EventStatusUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, len(updates))
+	for i, u := range updates {
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": u.ID}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"status":       u.Status,
+					"group_ids":    u.GroupID,
+					"relation_ids": u.RelationID,
+				},
+				"$unset": bson.M{"claimed_by": "", "claimed_at": ""},
+			})
+	}
+
+	// 使用无序写入，单个事件更新失败不影响其它事件
+	res, err := m.col.BulkWrite(context.TODO(), models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		bulkErr, ok := err.(mongo.BulkWriteException)
+		if !ok {
+			return fmt.Errorf("bulk update %d events failed: %w", len(updates), err)
+		}
+
+		failed := make([]repository.EventStatusUpdateFailure, 0, len(bulkErr.WriteErrors))
+		for _, we := range bulkErr.WriteErrors {
+			if we.Index >= 0 && we.Index < len(updates) {
+				failed = append(failed, repository.EventStatusUpdateFailure{ID: updates[we.Index].ID, Reason: we.Message})
+			}
+		}
+
+		if bulkErr.WriteConcernError != nil && len(failed) == 0 {
+			return fmt.Errorf("bulk update %d events failed: %w", len(updates), err)
+		}
+
+		return repository.EventBulkUpdateError{Total: len(updates), Failed: failed}
+	}
+
+	if res.MatchedCount < int64(len(updates)) {
+		return m.missingEventsError(updates)
+	}
+
+	return nil
+}
+
+func (m EventRepo) ClaimPending(ctx context.Context, id primitive.ObjectID, claimer string, expiredBefore time.Time) (bool, error) {
+	res, err := m.col.UpdateOne(ctx, bson.M{
+		"_id":    id,
+		"status": repository.EventStatusPending,
+		"$or": []bson.M{
+			{"claimed_by": bson.M{"$in": []interface{}{nil, claimer}}},
+			{"claimed_at": bson.M{"$lt": expiredBefore}},
+		},
+	}, bson.M{"$set": bson.M{"claimed_by": claimer, "claimed_at": time.Now()}})
+	if err != nil {
+		return false, err
+	}
+
+	return res.MatchedCount > 0, nil
+}
+
+// missingEventsError 批量更新时部分事件没有匹配（已经被删除），查询出这些事件的 ID
+func (m EventRepo) missingEventsError(updates []repository.EventStatusUpdate) error {
+	ids := make([]primitive.ObjectID, len(updates))
+	for i, u := range updates {
+		ids[i] = u.ID
+	}
+
+	existIDs, err := m.FindIDs(context.TODO(), bson.M{"_id": bson.M{"$in": ids}}, 0)
+	if err != nil {
+		return fmt.Errorf("query events not applied failed: %w", err)
+	}
+
+	exists := make(map[primitive.ObjectID]bool, len(existIDs))
+	for _, id := range existIDs {
+		exists[id] = true
+	}
+
+	failed := make([]repository.EventStatusUpdateFailure, 0)
+	for _, id := range ids {
+		if !exists[id] {
+			failed = append(failed, repository.EventStatusUpdateFailure{ID: id, Reason: "not found"})
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	return repository.EventBulkUpdateError{Total: len(updates), Failed: failed}
+}
+
 func (m EventRepo) Count(filter interface{}) (int64, error) {
 	return m.col.CountDocuments(context.TODO(), filter)
 }
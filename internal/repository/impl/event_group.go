@@ -2,10 +2,10 @@ package impl
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/mylxsw/adanos-alert/internal/repository"
-	"github.com/mylxsw/asteria/log"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -18,16 +18,21 @@ type EventGroupRepo struct {
 }
 
 func NewEventGroupRepo(db *mongo.Database, seqRepo repository.SequenceRepo) repository.EventGroupRepo {
-	grp := db.Collection("message_group")
-	_, err := grp.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
-		Keys:    bson.M{"created_at": 1},
-		Options: options.Index().SetUnique(false),
+	return &EventGroupRepo{col: db.Collection("message_group"), seqRepo: seqRepo}
+}
+
+// EnsureIndexes 创建 message_group 集合的索引：created_at、status、rule._id
+func (m EventGroupRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := m.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"created_at": 1}},
+		{Keys: bson.M{"status": 1}},
+		{Keys: bson.M{"rule._id": 1}},
 	})
 	if err != nil {
-		log.Errorf("can not create index for message_group.created_at: %v", err)
+		return fmt.Errorf("create indexes for message_group failed: %w", err)
 	}
 
-	return &EventGroupRepo{col: grp, seqRepo: seqRepo}
+	return nil
 }
 
 func (m EventGroupRepo) Add(grp repository.EventGroup) (id primitive.ObjectID, err error) {
@@ -105,14 +110,52 @@ func (m EventGroupRepo) Paginate(filter bson.M, offset, limit int64) (grps []rep
 	return
 }
 
+func (m EventGroupRepo) PaginateAfter(filter bson.M, afterID primitive.ObjectID, limit int64) (grps []repository.EventGroup, next primitive.ObjectID, err error) {
+	grps = make([]repository.EventGroup, 0)
+	cur, err := m.col.Find(
+		context.TODO(),
+		repository.AfterIDFilter(filter, afterID),
+		options.Find().
+			SetLimit(limit).
+			SetSort(bson.M{"_id": -1}),
+	)
+	if err != nil {
+		return
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var grp repository.EventGroup
+		if err = cur.Decode(&grp); err != nil {
+			return
+		}
+
+		grps = append(grps, grp)
+	}
+
+	if int64(len(grps)) == limit && limit > 0 {
+		next = grps[len(grps)-1].ID
+	}
+
+	return
+}
+
 func (m EventGroupRepo) Traverse(filter bson.M, cb func(grp repository.EventGroup) error) error {
-	cur, err := m.col.Find(context.TODO(), filter)
+	return m.TraverseCtx(context.TODO(), filter, cb)
+}
+
+func (m EventGroupRepo) TraverseCtx(ctx context.Context, filter bson.M, cb func(grp repository.EventGroup) error) error {
+	cur, err := m.col.Find(ctx, filter, options.Find().SetBatchSize(traverseBatchSize))
 	if err != nil {
 		return err
 	}
 	defer cur.Close(context.TODO())
 
-	for cur.Next(context.TODO()) {
+	for cur.Next(ctx) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		var grp repository.EventGroup
 		if err = cur.Decode(&grp); err != nil {
 			return err
@@ -123,7 +166,7 @@ func (m EventGroupRepo) Traverse(filter bson.M, cb func(grp repository.EventGrou
 		}
 	}
 
-	return nil
+	return cur.Err()
 }
 
 func (m EventGroupRepo) UpdateID(id primitive.ObjectID, grp repository.EventGroup) error {
@@ -132,6 +175,66 @@ func (m EventGroupRepo) UpdateID(id primitive.ObjectID, grp repository.EventGrou
 	return err
 }
 
+func (m EventGroupRepo) Ack(id primitive.ObjectID, userID primitive.ObjectID) error {
+	now := time.Now()
+	rs, err := m.col.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": id, "acked_at": nil},
+		bson.M{"$set": bson.M{"acked_at": now, "acked_by": userID, "updated_at": now}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if rs.MatchedCount > 0 {
+		return nil
+	}
+
+	if _, err := m.Get(id); err != nil {
+		return err
+	}
+
+	return repository.ErrAlreadyAcked
+}
+
+func (m EventGroupRepo) MarkEscalated(id primitive.ObjectID, actionIndex int, escalatedSteps int) (bool, error) {
+	field := fmt.Sprintf("actions.%d.escalated_steps", actionIndex)
+
+	// escalated_steps 为 0 时字段不存在（omitempty）
+	var stepsFilter interface{} = escalatedSteps
+	if escalatedSteps == 0 {
+		stepsFilter = bson.M{"$in": bson.A{0, nil}}
+	}
+
+	rs, err := m.col.UpdateOne(
+		context.TODO(),
+		bson.M{"_id": id, "acked_at": nil, field: stepsFilter},
+		bson.M{"$inc": bson.M{field: 1}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return rs.ModifiedCount > 0, nil
+}
+
+func (m EventGroupRepo) UpdateStatusMany(ids []primitive.ObjectID, from []repository.EventGroupStatus, status string) (int64, error) {
+	if len(ids) == 0 || len(from) == 0 {
+		return 0, nil
+	}
+
+	rs, err := m.col.UpdateMany(
+		context.TODO(),
+		bson.M{"_id": bson.M{"$in": ids}, "status": bson.M{"$in": from}},
+		bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return rs.ModifiedCount, nil
+}
+
 func (m EventGroupRepo) Delete(filter bson.M) error {
 	_, err := m.col.DeleteMany(context.TODO(), filter)
 	return err
@@ -145,6 +248,39 @@ func (m EventGroupRepo) Count(filter bson.M) (int64, error) {
 	return m.col.CountDocuments(context.TODO(), filter)
 }
 
+func (m EventGroupRepo) CountByStatus(filter bson.M) (map[string]int64, error) {
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	aggregate, err := m.col.Aggregate(context.TODO(), mongo.Pipeline{
+		bson.D{{"$match", filter}},
+		bson.D{{"$group", bson.M{
+			"_id":   "$status",
+			"count": bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer aggregate.Close(context.TODO())
+
+	results := make(map[string]int64)
+	for aggregate.Next(context.TODO()) {
+		var res struct {
+			Status string `bson:"_id"`
+			Count  int64  `bson:"count"`
+		}
+		if err := aggregate.Decode(&res); err != nil {
+			return nil, err
+		}
+
+		results[res.Status] = res.Count
+	}
+
+	return results, nil
+}
+
 func (m EventGroupRepo) CollectingGroup(rule repository.EventGroupRule) (group repository.EventGroup, err error) {
 	err = m.col.FindOneAndUpdate(
 		context.TODO(),
@@ -322,3 +458,50 @@ func (m EventGroupRepo) StatByDatetimeCount(ctx context.Context, filter bson.M,
 
 	return results, nil
 }
+
+func (m EventGroupRepo) StatByTimeBucket(ctx context.Context, filter bson.M, interval string) ([]repository.EventGroupBucket, error) {
+	format, ok := repository.BucketIntervalFormats[interval]
+	if !ok {
+		return nil, fmt.Errorf("invalid interval: %s", interval)
+	}
+
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	aggregate, err := m.col.Aggregate(ctx, mongo.Pipeline{
+		bson.D{{"$match", filter}},
+		bson.D{{"$group", bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"format":   format,
+				"date":     "$created_at",
+				"timezone": time.Now().Format("-07:00"),
+			}},
+			"count":         bson.M{"$sum": 1},
+			"message_count": bson.M{"$sum": "$message_count"},
+		}}},
+		bson.D{{"$project", bson.M{
+			"bucket":         "$_id",
+			"total":          "$count",
+			"total_messages": "$message_count",
+			"_id":            0,
+		}}},
+		bson.D{{"$sort", bson.M{"bucket": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer aggregate.Close(ctx)
+
+	results := make([]repository.EventGroupBucket, 0)
+	for aggregate.Next(ctx) {
+		var res repository.EventGroupBucket
+		if err := aggregate.Decode(&res); err != nil {
+			return nil, err
+		}
+
+		results = append(results, res)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,115 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InhibitRepo struct {
+	col *mongo.Collection
+}
+
+func NewInhibitRepo(db *mongo.Database) repository.InhibitRepo {
+	return &InhibitRepo{col: db.Collection("event_inhibit")}
+}
+
+// EnsureIndexes 创建 event_inhibit 集合的索引，expired_at 上的 TTL 索引由 MongoDB 自动清理过期的去重记录
+func (r InhibitRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"expired_at": 1}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return fmt.Errorf("create indexes for event_inhibit failed: %w", err)
+	}
+
+	return nil
+}
+
+// Inhibit 检查 id 是否处于抑制周期内
+// 先尝试对有效的去重记录增加丢弃计数，记录不存在或者已经过期时，通过 upsert 开始新的抑制周期，
+// 并发请求同时开始新周期时，只有一个能够成功，其它请求会得到 DuplicateKey 错误，此时按照被抑制处理
+func (r InhibitRepo) Inhibit(ctx context.Context, id string, interval time.Duration) (bool, error) {
+	now := time.Now()
+	var inhibit repository.EventInhibit
+	err := r.col.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id, "expired_at": bson.M{"$gt": now}},
+		bson.M{
+			"$inc": bson.M{"dropped": 1},
+			"$set": bson.M{"last_dropped_at": now},
+		},
+	).Decode(&inhibit)
+	if err == nil {
+		return true, nil
+	}
+
+	if err != mongo.ErrNoDocuments {
+		return false, err
+	}
+
+	_, err = r.col.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "expired_at": bson.M{"$lte": now}},
+		bson.M{
+			"$set": bson.M{
+				"dropped":    0,
+				"expired_at": now.Add(interval),
+				"created_at": now,
+			},
+			"$unset": bson.M{"last_dropped_at": ""},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			_, err = r.col.UpdateOne(
+				ctx,
+				bson.M{"_id": id},
+				bson.M{
+					"$inc": bson.M{"dropped": 1},
+					"$set": bson.M{"last_dropped_at": now},
+				},
+			)
+			return true, err
+		}
+
+		return false, err
+	}
+
+	return false, nil
+}
+
+func (r InhibitRepo) Get(ctx context.Context, id string) (inhibit repository.EventInhibit, err error) {
+	err = r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&inhibit)
+	if err == mongo.ErrNoDocuments {
+		return inhibit, repository.ErrNotFound
+	}
+
+	if err == nil && !inhibit.Active(time.Now()) {
+		return inhibit, repository.ErrNotFound
+	}
+
+	return
+}
+
+// isDuplicateKeyError 判断是否是唯一键冲突错误
+func isDuplicateKeyError(err error) bool {
+	switch e := err.(type) {
+	case mongo.CommandError:
+		return e.Name == "DuplicateKey" || e.Code == 11000
+	case mongo.WriteException:
+		for _, we := range e.WriteErrors {
+			if we.Code == 11000 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package impl_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/internal/repository/impl"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestInhibitRepo(t *testing.T) {
+	db, err := Database()
+	assert.NoError(t, err)
+
+	_, _ = db.Collection("event_inhibit").DeleteMany(context.TODO(), bson.M{})
+
+	repo := impl.NewInhibitRepo(db)
+	ctx := context.TODO()
+
+	_, err = repo.Get(ctx, "disk-full")
+	assert.Equal(t, repository.ErrNotFound, err)
+
+	// 第一个事件开始抑制周期，不被抑制
+	inhibited, err := repo.Inhibit(ctx, "disk-full", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, inhibited)
+
+	// 抑制周期内的相同 ID 事件被丢弃并计数
+	for i := 0; i < 3; i++ {
+		inhibited, err = repo.Inhibit(ctx, "disk-full", time.Minute)
+		assert.NoError(t, err)
+		assert.True(t, inhibited)
+	}
+
+	inhibit, err := repo.Get(ctx, "disk-full")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, inhibit.Dropped)
+
+	// 抑制周期结束后，开始新的抑制周期，丢弃计数重置
+	inhibited, err = repo.Inhibit(ctx, "cpu-high", time.Millisecond)
+	assert.NoError(t, err)
+	assert.False(t, inhibited)
+
+	time.Sleep(5 * time.Millisecond)
+	inhibited, err = repo.Inhibit(ctx, "cpu-high", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, inhibited)
+
+	inhibit, err = repo.Get(ctx, "cpu-high")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, inhibit.Dropped)
+}
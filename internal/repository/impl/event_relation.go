@@ -28,23 +28,33 @@ func NewEventRelationRepo(db *mongo.Database) repository.EventRelationRepo {
 	return &EventRelationRepo{col: col}
 }
 
-func (m *EventRelationRepo) AddOrUpdateEventRelation(ctx context.Context, summary string, matchedRuleID primitive.ObjectID) (eventRel repository.EventRelation, err error) {
+func (m *EventRelationRepo) EnsureEventRelation(ctx context.Context, summary string, matchedRuleID primitive.ObjectID) (eventRel repository.EventRelation, err error) {
+	now := time.Now()
 	err = m.col.FindOneAndUpdate(
 		ctx,
 		bson.M{"matched_rule_id": matchedRuleID, "summary": summary},
-		bson.M{"$inc": bson.M{"event_count": 1}, "$set": bson.M{"updated_at": time.Now()}},
+		bson.M{"$setOnInsert": bson.M{"event_count": 0, "created_at": now, "updated_at": now}},
 		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
 	).Decode(&eventRel)
 
-	if err == nil && eventRel.CreatedAt.IsZero() {
-		eventRel.CreatedAt = time.Now()
-		eventRel.UpdatedAt = eventRel.CreatedAt
-		eventRel.EventCount = 1
+	return
+}
 
-		_, err = m.col.ReplaceOne(ctx, bson.M{"_id": eventRel.ID}, eventRel)
+func (m *EventRelationRepo) IncrEventCount(ctx context.Context, counts map[primitive.ObjectID]int64) error {
+	if len(counts) == 0 {
+		return nil
 	}
 
-	return
+	now := time.Now()
+	models := make([]mongo.WriteModel, 0, len(counts))
+	for id, count := range counts {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$inc": bson.M{"event_count": count}, "$set": bson.M{"updated_at": now}}))
+	}
+
+	_, err := m.col.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
 }
 
 func (m *EventRelationRepo) Get(ctx context.Context, id primitive.ObjectID) (eventRel repository.EventRelation, err error) {
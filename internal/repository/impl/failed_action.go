@@ -0,0 +1,83 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type FailedActionRepo struct {
+	col *mongo.Collection
+}
+
+func NewFailedActionRepo(db *mongo.Database) repository.FailedActionRepo {
+	return &FailedActionRepo{col: db.Collection("failed_action")}
+}
+
+func (r FailedActionRepo) Add(fa repository.FailedAction) (id primitive.ObjectID, err error) {
+	fa.CreatedAt = time.Now()
+	fa.UpdatedAt = fa.CreatedAt
+	if fa.Status == "" {
+		fa.Status = repository.FailedActionStatusDead
+	}
+
+	rs, err := r.col.InsertOne(context.TODO(), fa)
+	if err != nil {
+		return
+	}
+
+	return rs.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r FailedActionRepo) Get(id primitive.ObjectID) (fa repository.FailedAction, err error) {
+	err = r.col.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&fa)
+	if err == mongo.ErrNoDocuments {
+		err = repository.ErrNotFound
+	}
+
+	return
+}
+
+func (r FailedActionRepo) Paginate(filter bson.M, offset, limit int64) (fas []repository.FailedAction, next int64, err error) {
+	fas = make([]repository.FailedAction, 0)
+	cur, err := r.col.Find(context.TODO(), filter, options.Find().SetSkip(offset).SetLimit(limit).SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var fa repository.FailedAction
+		if err = cur.Decode(&fa); err != nil {
+			return
+		}
+
+		fas = append(fas, fa)
+	}
+
+	if int64(len(fas)) == limit {
+		next = offset + limit
+	}
+
+	return
+}
+
+func (r FailedActionRepo) Update(id primitive.ObjectID, fa repository.FailedAction) error {
+	fa.UpdatedAt = time.Now()
+	_, err := r.col.ReplaceOne(context.TODO(), bson.M{"_id": id}, fa)
+	return err
+}
+
+func (r FailedActionRepo) DeleteID(id primitive.ObjectID) error {
+	_, err := r.col.DeleteOne(context.TODO(), bson.M{"_id": id})
+	return err
+}
+
+func (r FailedActionRepo) Count(filter bson.M) (int64, error) {
+	return r.col.CountDocuments(context.TODO(), filter)
+}
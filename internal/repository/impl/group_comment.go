@@ -0,0 +1,86 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type GroupCommentRepo struct {
+	col *mongo.Collection
+}
+
+func NewGroupCommentRepo(db *mongo.Database) repository.GroupCommentRepo {
+	return &GroupCommentRepo{col: db.Collection("group_comment")}
+}
+
+// EnsureIndexes 创建 group_comment 集合的索引：group_id + created_at
+func (r GroupCommentRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "group_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("create indexes for group_comment failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r GroupCommentRepo) Add(comment repository.GroupComment) (id primitive.ObjectID, err error) {
+	comment.CreatedAt = time.Now()
+
+	rs, err := r.col.InsertOne(context.TODO(), comment)
+	if err != nil {
+		return
+	}
+
+	return rs.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r GroupCommentRepo) Get(id primitive.ObjectID) (comment repository.GroupComment, err error) {
+	err = r.col.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&comment)
+	if err == mongo.ErrNoDocuments {
+		err = repository.ErrNotFound
+	}
+
+	return
+}
+
+func (r GroupCommentRepo) Paginate(filter bson.M, offset, limit int64) (comments []repository.GroupComment, next int64, err error) {
+	comments = make([]repository.GroupComment, 0)
+	cur, err := r.col.Find(context.TODO(), filter, options.Find().SetSkip(offset).SetLimit(limit).SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var comment repository.GroupComment
+		if err = cur.Decode(&comment); err != nil {
+			return
+		}
+
+		comments = append(comments, comment)
+	}
+
+	if int64(len(comments)) == limit {
+		next = offset + limit
+	}
+
+	return
+}
+
+func (r GroupCommentRepo) Delete(filter bson.M) error {
+	_, err := r.col.DeleteMany(context.TODO(), filter)
+	return err
+}
+
+func (r GroupCommentRepo) Count(filter bson.M) (int64, error) {
+	return r.col.CountDocuments(context.TODO(), filter)
+}
@@ -0,0 +1,86 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IdempotencyRepo struct {
+	col *mongo.Collection
+}
+
+func NewIdempotencyRepo(db *mongo.Database) repository.IdempotencyRepo {
+	return &IdempotencyRepo{col: db.Collection("idempotency")}
+}
+
+// EnsureIndexes 创建 idempotency 集合的索引，expired_at 上的 TTL 索引由 MongoDB 自动清理过期的幂等记录
+func (r IdempotencyRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"expired_at": 1}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return fmt.Errorf("create indexes for idempotency failed: %w", err)
+	}
+
+	return nil
+}
+
+// Reserve 占用 id，通过 upsert 替换已经过期的记录（TTL 索引删除过期记录存在延迟）
+// 并发请求同时占用时，只有一个能够成功，其它请求会得到 DuplicateKey 错误，此时返回已有的记录
+func (r IdempotencyRepo) Reserve(ctx context.Context, id string, ttl time.Duration) (repository.IdempotencyRecord, bool, error) {
+	now := time.Now()
+	_, err := r.col.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "expired_at": bson.M{"$lte": now}},
+		bson.M{
+			"$set": bson.M{
+				"completed":   false,
+				"status_code": 0,
+				"response":    []byte{},
+				"expired_at":  now.Add(ttl),
+				"created_at":  now,
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err == nil {
+		return repository.IdempotencyRecord{}, true, nil
+	}
+
+	if !isDuplicateKeyError(err) {
+		return repository.IdempotencyRecord{}, false, err
+	}
+
+	var record repository.IdempotencyRecord
+	if err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&record); err != nil {
+		if err == mongo.ErrNoDocuments {
+			err = repository.ErrNotFound
+		}
+
+		return record, false, err
+	}
+
+	return record, false, nil
+}
+
+// Complete 保存请求的响应
+func (r IdempotencyRepo) Complete(ctx context.Context, id string, statusCode int, response []byte) error {
+	_, err := r.col.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"completed": true, "status_code": statusCode, "response": response}},
+	)
+	return err
+}
+
+// Release 删除未完成的记录
+func (r IdempotencyRepo) Release(ctx context.Context, id string) error {
+	_, err := r.col.DeleteOne(ctx, bson.M{"_id": id, "completed": false})
+	return err
+}
@@ -0,0 +1,63 @@
+package impl_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/internal/repository/impl"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestIdempotencyRepo(t *testing.T) {
+	db, err := Database()
+	assert.NoError(t, err)
+
+	_, _ = db.Collection("idempotency").DeleteMany(context.TODO(), bson.M{})
+
+	repo := impl.NewIdempotencyRepo(db)
+	ctx := context.TODO()
+	id := repository.HashIdempotencyKey("events:add:common", "retry-1")
+
+	_, reserved, err := repo.Reserve(ctx, id, time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	// 处理中的请求
+	record, reserved, err := repo.Reserve(ctx, id, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.False(t, record.Completed)
+
+	// 处理完成之后返回原始响应
+	assert.NoError(t, repo.Complete(ctx, id, 200, []byte(`{"id":"abc"}`)))
+	record, reserved, err = repo.Reserve(ctx, id, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.True(t, record.Completed)
+	assert.Equal(t, 200, record.StatusCode)
+	assert.Equal(t, `{"id":"abc"}`, string(record.Response))
+
+	// 已完成的记录不会被释放
+	assert.NoError(t, repo.Release(ctx, id))
+	_, reserved, _ = repo.Reserve(ctx, id, time.Minute)
+	assert.False(t, reserved)
+
+	// 处理失败释放之后可以重新占用
+	failedID := repository.HashIdempotencyKey("events:add:common", "retry-2")
+	_, reserved, _ = repo.Reserve(ctx, failedID, time.Minute)
+	assert.True(t, reserved)
+	assert.NoError(t, repo.Release(ctx, failedID))
+	_, reserved, _ = repo.Reserve(ctx, failedID, time.Minute)
+	assert.True(t, reserved)
+
+	// 过期之后可以重新占用
+	expiredID := repository.HashIdempotencyKey("events:add:common", "retry-3")
+	_, reserved, _ = repo.Reserve(ctx, expiredID, time.Millisecond)
+	assert.True(t, reserved)
+	time.Sleep(5 * time.Millisecond)
+	_, reserved, _ = repo.Reserve(ctx, expiredID, time.Minute)
+	assert.True(t, reserved)
+}
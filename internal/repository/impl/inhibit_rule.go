@@ -0,0 +1,93 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type InhibitRuleRepo struct {
+	col *mongo.Collection
+}
+
+func NewInhibitRuleRepo(db *mongo.Database) repository.InhibitRuleRepo {
+	return &InhibitRuleRepo{col: db.Collection("inhibit_rule")}
+}
+
+func (r InhibitRuleRepo) Add(rule repository.InhibitRule) (id primitive.ObjectID, err error) {
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = rule.CreatedAt
+
+	rs, err := r.col.InsertOne(context.TODO(), rule)
+	if err != nil {
+		return
+	}
+
+	return rs.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r InhibitRuleRepo) Get(id primitive.ObjectID) (rule repository.InhibitRule, err error) {
+	err = r.col.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&rule)
+	if err == mongo.ErrNoDocuments {
+		err = repository.ErrNotFound
+	}
+
+	return
+}
+
+func (r InhibitRuleRepo) Find(filter bson.M) (rules []repository.InhibitRule, err error) {
+	return r.find(filter, options.Find().SetSort(bson.M{"created_at": -1}))
+}
+
+func (r InhibitRuleRepo) Paginate(filter bson.M, offset, limit int64) (rules []repository.InhibitRule, next int64, err error) {
+	rules, err = r.find(filter, options.Find().SetSkip(offset).SetLimit(limit).SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return
+	}
+
+	if int64(len(rules)) == limit {
+		next = offset + limit
+	}
+
+	return
+}
+
+func (r InhibitRuleRepo) find(filter bson.M, opts *options.FindOptions) (rules []repository.InhibitRule, err error) {
+	rules = make([]repository.InhibitRule, 0)
+	cur, err := r.col.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var rule repository.InhibitRule
+		if err = cur.Decode(&rule); err != nil {
+			return
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return
+}
+
+func (r InhibitRuleRepo) DeleteID(id primitive.ObjectID) error {
+	_, err := r.col.DeleteOne(context.TODO(), bson.M{"_id": id})
+	return err
+}
+
+func (r InhibitRuleRepo) Update(id primitive.ObjectID, rule repository.InhibitRule) error {
+	rule.UpdatedAt = time.Now()
+	_, err := r.col.ReplaceOne(context.TODO(), bson.M{"_id": id}, rule)
+	return err
+}
+
+func (r InhibitRuleRepo) Count(filter bson.M) (int64, error) {
+	return r.col.CountDocuments(context.TODO(), filter)
+}
@@ -2,11 +2,13 @@ package impl
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/mylxsw/adanos-alert/internal/repository"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type KVRepo struct {
@@ -17,6 +19,25 @@ func NewKVRepo(db *mongo.Database) repository.KVRepo {
 	return &KVRepo{col: db.Collection("kv")}
 }
 
+// EnsureIndexes 创建 kv 集合的索引
+// key 索引用于加速查询，expired_at 上的 TTL 索引只作用于设置了 TTL 的记录，由 MongoDB 自动清理过期的记录
+func (repo KVRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := repo.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"key": 1}},
+		{
+			Keys: bson.M{"expired_at": 1},
+			Options: options.Index().
+				SetExpireAfterSeconds(0).
+				SetPartialFilterExpression(bson.M{"with_ttl": true}),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("create indexes for kv failed: %w", err)
+	}
+
+	return nil
+}
+
 func (repo KVRepo) Set(key string, value interface{}) error {
 	return repo.SetWithTTL(key, value, 0)
 }
@@ -127,6 +127,23 @@ func (l *LockRepo) Remove(resource string) error {
 	return err
 }
 
+func (l *LockRepo) CurrentOwner(resource string) (owner string, expiresAt time.Time, err error) {
+	var lock repository.Lock
+	if err := l.col.FindOne(context.TODO(), bson.M{"resource": resource}).Decode(&lock); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return "", time.Time{}, nil
+		}
+
+		return "", time.Time{}, err
+	}
+
+	if !lock.Acquired || !lock.ExpiredAt.After(time.Now()) {
+		return "", time.Time{}, nil
+	}
+
+	return lock.Owner, lock.ExpiredAt, nil
+}
+
 func NewLockRepo(db *mongo.Database) repository.LockRepo {
 	col := db.Collection("lock")
 	name, err := col.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
@@ -0,0 +1,93 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MaintenanceWindowRepo struct {
+	col *mongo.Collection
+}
+
+func NewMaintenanceWindowRepo(db *mongo.Database) repository.MaintenanceWindowRepo {
+	return &MaintenanceWindowRepo{col: db.Collection("maintenance_window")}
+}
+
+func (r MaintenanceWindowRepo) Add(window repository.MaintenanceWindow) (id primitive.ObjectID, err error) {
+	window.CreatedAt = time.Now()
+	window.UpdatedAt = window.CreatedAt
+
+	rs, err := r.col.InsertOne(context.TODO(), window)
+	if err != nil {
+		return
+	}
+
+	return rs.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r MaintenanceWindowRepo) Get(id primitive.ObjectID) (window repository.MaintenanceWindow, err error) {
+	err = r.col.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&window)
+	if err == mongo.ErrNoDocuments {
+		err = repository.ErrNotFound
+	}
+
+	return
+}
+
+func (r MaintenanceWindowRepo) Find(filter bson.M) (windows []repository.MaintenanceWindow, err error) {
+	return r.find(filter, options.Find().SetSort(bson.M{"created_at": -1}))
+}
+
+func (r MaintenanceWindowRepo) Paginate(filter bson.M, offset, limit int64) (windows []repository.MaintenanceWindow, next int64, err error) {
+	windows, err = r.find(filter, options.Find().SetSkip(offset).SetLimit(limit).SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return
+	}
+
+	if int64(len(windows)) == limit {
+		next = offset + limit
+	}
+
+	return
+}
+
+func (r MaintenanceWindowRepo) find(filter bson.M, opts *options.FindOptions) (windows []repository.MaintenanceWindow, err error) {
+	windows = make([]repository.MaintenanceWindow, 0)
+	cur, err := r.col.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var window repository.MaintenanceWindow
+		if err = cur.Decode(&window); err != nil {
+			return
+		}
+
+		windows = append(windows, window)
+	}
+
+	return
+}
+
+func (r MaintenanceWindowRepo) DeleteID(id primitive.ObjectID) error {
+	_, err := r.col.DeleteOne(context.TODO(), bson.M{"_id": id})
+	return err
+}
+
+func (r MaintenanceWindowRepo) Update(id primitive.ObjectID, window repository.MaintenanceWindow) error {
+	window.UpdatedAt = time.Now()
+	_, err := r.col.ReplaceOne(context.TODO(), bson.M{"_id": id}, window)
+	return err
+}
+
+func (r MaintenanceWindowRepo) Count(filter bson.M) (int64, error) {
+	return r.col.CountDocuments(context.TODO(), filter)
+}
@@ -1,17 +1,16 @@
 package impl
 
 import (
+	"context"
 	"time"
 
 	"github.com/mylxsw/adanos-alert/configs"
 	"github.com/mylxsw/adanos-alert/internal/repository"
 	"github.com/mylxsw/asteria/log"
-	"github.com/mylxsw/coll"
 	"github.com/mylxsw/container"
 	"github.com/mylxsw/glacier/cron"
 	"github.com/mylxsw/glacier/infra"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type ServiceProvider struct{}
@@ -32,14 +31,26 @@ func (s ServiceProvider) Register(app container.Container) {
 	app.MustSingleton(NewAgentRepo)
 	app.MustSingleton(NewAuditLogRepo)
 	app.MustSingleton(NewRecoveryRepo)
+	app.MustSingleton(NewInhibitRuleRepo)
+	app.MustSingleton(NewSilenceRepo)
+	app.MustSingleton(NewMaintenanceWindowRepo)
+	app.MustSingleton(NewScheduleRepo)
+	app.MustSingleton(NewRateLimitRepo)
+	app.MustSingleton(NewGroupCommentRepo)
+	app.MustSingleton(NewFailedActionRepo)
+	app.MustSingleton(NewAPITokenRepo)
+	app.MustSingleton(NewInhibitRepo)
+	app.MustSingleton(NewIdempotencyRepo)
 }
 
 func (s ServiceProvider) Boot(app infra.Glacier) {
+	app.MustResolve(func(eventRepo repository.EventRepo, groupRepo repository.EventGroupRepo, kvRepo repository.KVRepo, recoveryRepo repository.RecoveryRepo, commentRepo repository.GroupCommentRepo, tokenRepo repository.APITokenRepo, inhibitRepo repository.InhibitRepo, idempotencyRepo repository.IdempotencyRepo) {
+		ensureIndexes(eventRepo, groupRepo, kvRepo, recoveryRepo, commentRepo, tokenRepo, inhibitRepo, idempotencyRepo)
+	})
+
 	app.Cron(func(cr cron.Manager, cc container.Container) error {
 		return cc.Resolve(func(
 			kvRepo repository.KVRepo,
-			groupRepo repository.EventGroupRepo,
-			eventRepo repository.EventRepo,
 			auditRepo repository.AuditLogRepo,
 			conf *configs.Config,
 		) {
@@ -59,56 +70,20 @@ func (s ServiceProvider) Boot(app infra.Glacier) {
 					}
 				})
 			}
-
-			if conf.KeepPeriod > 0 {
-				_ = cr.Add("remove_expired_events", "@midnight", func() {
-					expiredEventsGC(conf, eventRepo, groupRepo)
-				})
-
-				// 每次重启服务时，自动触发一次GC
-				expiredEventsGC(conf, eventRepo, groupRepo)
-			}
 		})
 	})
 }
 
-// expiredEventsGC 清理过期的 event/event_group
-func expiredEventsGC(conf *configs.Config, msgRepo repository.EventRepo, groupRepo repository.EventGroupRepo) {
-	deadLineDate := time.Now().AddDate(0, 0, -conf.KeepPeriod)
-	log.Infof("clear expired/canceled events and groups before %v", deadLineDate)
+// ensureIndexes 为实现了 repository.IndexEnsurer 接口的 Repo 创建索引，创建失败只记录日志，不影响服务启动
+func ensureIndexes(repos ...interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-	// 删除过期或者取消发送的 messages
-	if err := msgRepo.Delete(bson.M{
-		"status": bson.M{"$in": []repository.EventStatus{
-			repository.EventStatusCanceled,
-			repository.EventStatusExpired,
-			repository.EventStatusIgnored,
-		}},
-		"created_at": bson.M{"$lt": deadLineDate},
-	}); err != nil {
-		log.Errorf("remove expired/canceled events before %v failed: %v", deadLineDate, err)
-	}
-
-	// 删除过期的 groups
-	// 1. 查询过期的 groups
-	// 2. 删除过期分组关联的所有 messages
-	// 3. 删除过期分组
-	groups, err := groupRepo.Find(bson.M{"created_at": bson.M{"$lt": deadLineDate}})
-	if err != nil {
-		log.Errorf("query expired event groups before %v failed: %v", deadLineDate, err)
-		return
-	}
-
-	groupIds := coll.MustNew(groups).Map(func(grp repository.EventGroup) primitive.ObjectID {
-		return grp.ID
-	}).Items()
-	if err := msgRepo.Delete(bson.M{"group_ids": bson.M{"$in": groupIds}}); err != nil {
-		log.Errorf("remove events in group_ids before %v failed: %v", deadLineDate, err)
-		return
-	}
-
-	if err := groupRepo.Delete(bson.M{"_id": bson.M{"$in": groupIds}}); err != nil {
-		log.Errorf("remove events in group_ids before %v failed: %v", deadLineDate, err)
-		return
+	for _, repo := range repos {
+		if ie, ok := repo.(repository.IndexEnsurer); ok {
+			if err := ie.EnsureIndexes(ctx); err != nil {
+				log.Errorf("ensure indexes failed: %v", err)
+			}
+		}
 	}
 }
@@ -0,0 +1,60 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RateLimitRepo struct {
+	col *mongo.Collection
+}
+
+func NewRateLimitRepo(db *mongo.Database) repository.RateLimitRepo {
+	return &RateLimitRepo{col: db.Collection("rate_limit")}
+}
+
+func (r RateLimitRepo) Get(ruleID primitive.ObjectID) (bucket repository.RateLimitBucket, err error) {
+	err = r.col.FindOne(context.TODO(), bson.M{"_id": ruleID}).Decode(&bucket)
+	if err == mongo.ErrNoDocuments {
+		err = repository.ErrNotFound
+	}
+
+	return
+}
+
+func (r RateLimitRepo) Find(filter bson.M) (buckets []repository.RateLimitBucket, err error) {
+	buckets = make([]repository.RateLimitBucket, 0)
+	cur, err := r.col.Find(context.TODO(), filter)
+	if err != nil {
+		return
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var bucket repository.RateLimitBucket
+		if err = cur.Decode(&bucket); err != nil {
+			return
+		}
+
+		buckets = append(buckets, bucket)
+	}
+
+	return
+}
+
+func (r RateLimitRepo) Save(bucket repository.RateLimitBucket) error {
+	bucket.UpdatedAt = time.Now()
+	_, err := r.col.ReplaceOne(context.TODO(), bson.M{"_id": bucket.RuleID}, bucket, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r RateLimitRepo) DeleteID(ruleID primitive.ObjectID) error {
+	_, err := r.col.DeleteOne(context.TODO(), bson.M{"_id": ruleID})
+	return err
+}
@@ -2,6 +2,7 @@ package impl
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/mylxsw/adanos-alert/internal/repository"
@@ -9,6 +10,7 @@ import (
 	"github.com/mylxsw/asteria/log"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -20,6 +22,19 @@ func NewRecoveryRepo(db *mongo.Database) repository.RecoveryRepo {
 	return &RecoveryRepo{col: db.Collection("recovery")}
 }
 
+// EnsureIndexes 创建 recovery 集合的索引：recovery_id、recovery_at
+func (r RecoveryRepo) EnsureIndexes(ctx context.Context) error {
+	_, err := r.col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"recovery_id": 1}},
+		{Keys: bson.M{"recovery_at": 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("create indexes for recovery failed: %w", err)
+	}
+
+	return nil
+}
+
 func (r RecoveryRepo) Register(ctx context.Context, recoveryAt time.Time, recoveryID string, refID primitive.ObjectID) error {
 	rec, err := r.get(ctx, recoveryID)
 	if err != nil {
@@ -62,8 +77,16 @@ func (r RecoveryRepo) get(ctx context.Context, recoveryID string) (rec repositor
 }
 
 func (r RecoveryRepo) RecoverableEvents(ctx context.Context, deadline time.Time) ([]repository.Recovery, error) {
+	return r.find(ctx, bson.M{"recovery_at": bson.M{"$lt": deadline}})
+}
+
+func (r RecoveryRepo) FindByIdentifier(ctx context.Context, recoveryID string) ([]repository.Recovery, error) {
+	return r.find(ctx, bson.M{"recovery_id": recoveryID})
+}
+
+func (r RecoveryRepo) find(ctx context.Context, filter bson.M) ([]repository.Recovery, error) {
 	results := make([]repository.Recovery, 0)
-	cursor, err := r.col.Find(ctx, bson.M{"recovery_at": bson.M{"$lt": deadline}})
+	cursor, err := r.col.Find(ctx, filter)
 	if err != nil {
 		return results, err
 	}
@@ -83,6 +106,38 @@ func (r RecoveryRepo) RecoverableEvents(ctx context.Context, deadline time.Time)
 }
 
 func (r RecoveryRepo) Delete(ctx context.Context, recoveryID string) error {
-	_, err := r.col.DeleteOne(ctx, bson.M{"recovery_id": recoveryID})
+	_, err := r.col.DeleteMany(ctx, bson.M{"recovery_id": recoveryID})
 	return err
 }
+
+// DeleteBefore 删除最多 limit 条预计恢复时间早于 before 的恢复记录
+func (r RecoveryRepo) DeleteBefore(ctx context.Context, before time.Time, limit int64) (int64, error) {
+	cursor, err := r.col.Find(ctx, bson.M{"recovery_at": bson.M{"$lt": before}}, options.Find().SetLimit(limit).SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	ids := make([]primitive.ObjectID, 0)
+	for cursor.Next(ctx) {
+		var rec struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&rec); err != nil {
+			return 0, err
+		}
+
+		ids = append(ids, rec.ID)
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	rs, err := r.col.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return 0, err
+	}
+
+	return rs.DeletedCount, nil
+}
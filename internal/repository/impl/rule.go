@@ -147,6 +147,29 @@ func (r RuleRepo) UpdateID(id primitive.ObjectID, rule repository.Rule) error {
 	return err
 }
 
+func (r RuleRepo) SetStatus(id primitive.ObjectID, status repository.RuleStatus, operator string, reason string) error {
+	now := time.Now()
+	rs, err := r.col.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"status":     status,
+		"updated_at": now,
+		"last_status_change": repository.RuleStatusChange{
+			Status:    status,
+			Operator:  operator,
+			Reason:    reason,
+			CreatedAt: now,
+		},
+	}})
+	if err != nil {
+		return err
+	}
+
+	if rs.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
 func (r RuleRepo) Count(filter bson.M) (int64, error) {
 	return r.col.CountDocuments(context.TODO(), filter)
 }
@@ -0,0 +1,107 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ScheduleRepo struct {
+	col *mongo.Collection
+}
+
+func NewScheduleRepo(db *mongo.Database) repository.ScheduleRepo {
+	return &ScheduleRepo{col: db.Collection("oncall_schedule")}
+}
+
+func (r ScheduleRepo) Add(schedule repository.Schedule) (id primitive.ObjectID, err error) {
+	schedule.CreatedAt = time.Now()
+	schedule.UpdatedAt = schedule.CreatedAt
+
+	rs, err := r.col.InsertOne(context.TODO(), schedule)
+	if err != nil {
+		return
+	}
+
+	return rs.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r ScheduleRepo) Get(id primitive.ObjectID) (schedule repository.Schedule, err error) {
+	err = r.col.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&schedule)
+	if err == mongo.ErrNoDocuments {
+		err = repository.ErrNotFound
+	}
+
+	return
+}
+
+func (r ScheduleRepo) Find(filter bson.M) (schedules []repository.Schedule, err error) {
+	return r.find(filter, options.Find().SetSort(bson.M{"created_at": -1}))
+}
+
+func (r ScheduleRepo) Paginate(filter bson.M, offset, limit int64) (schedules []repository.Schedule, next int64, err error) {
+	schedules, err = r.find(filter, options.Find().SetSkip(offset).SetLimit(limit).SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return
+	}
+
+	if int64(len(schedules)) == limit {
+		next = offset + limit
+	}
+
+	return
+}
+
+func (r ScheduleRepo) find(filter bson.M, opts *options.FindOptions) (schedules []repository.Schedule, err error) {
+	schedules = make([]repository.Schedule, 0)
+	cur, err := r.col.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var schedule repository.Schedule
+		if err = cur.Decode(&schedule); err != nil {
+			return
+		}
+
+		schedules = append(schedules, schedule)
+	}
+
+	return
+}
+
+func (r ScheduleRepo) DeleteID(id primitive.ObjectID) error {
+	_, err := r.col.DeleteOne(context.TODO(), bson.M{"_id": id})
+	return err
+}
+
+func (r ScheduleRepo) Update(id primitive.ObjectID, schedule repository.Schedule) error {
+	schedule.UpdatedAt = time.Now()
+	_, err := r.col.ReplaceOne(context.TODO(), bson.M{"_id": id}, schedule)
+	return err
+}
+
+func (r ScheduleRepo) Count(filter bson.M) (int64, error) {
+	return r.col.CountDocuments(context.TODO(), filter)
+}
+
+func (r ScheduleRepo) CurrentOnCall(scheduleID primitive.ObjectID, at time.Time) (primitive.ObjectID, error) {
+	schedule, err := r.Get(scheduleID)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	userID := schedule.OnCallAt(at)
+	if userID.IsZero() {
+		return userID, repository.ErrNoOnCall
+	}
+
+	return userID, nil
+}
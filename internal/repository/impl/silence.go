@@ -0,0 +1,93 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SilenceRepo struct {
+	col *mongo.Collection
+}
+
+func NewSilenceRepo(db *mongo.Database) repository.SilenceRepo {
+	return &SilenceRepo{col: db.Collection("silence")}
+}
+
+func (r SilenceRepo) Add(silence repository.Silence) (id primitive.ObjectID, err error) {
+	silence.CreatedAt = time.Now()
+	silence.UpdatedAt = silence.CreatedAt
+
+	rs, err := r.col.InsertOne(context.TODO(), silence)
+	if err != nil {
+		return
+	}
+
+	return rs.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r SilenceRepo) Get(id primitive.ObjectID) (silence repository.Silence, err error) {
+	err = r.col.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&silence)
+	if err == mongo.ErrNoDocuments {
+		err = repository.ErrNotFound
+	}
+
+	return
+}
+
+func (r SilenceRepo) Find(filter bson.M) (silences []repository.Silence, err error) {
+	return r.find(filter, options.Find().SetSort(bson.M{"starts_at": -1}))
+}
+
+func (r SilenceRepo) Paginate(filter bson.M, offset, limit int64) (silences []repository.Silence, next int64, err error) {
+	silences, err = r.find(filter, options.Find().SetSkip(offset).SetLimit(limit).SetSort(bson.M{"starts_at": -1}))
+	if err != nil {
+		return
+	}
+
+	if int64(len(silences)) == limit {
+		next = offset + limit
+	}
+
+	return
+}
+
+func (r SilenceRepo) find(filter bson.M, opts *options.FindOptions) (silences []repository.Silence, err error) {
+	silences = make([]repository.Silence, 0)
+	cur, err := r.col.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var silence repository.Silence
+		if err = cur.Decode(&silence); err != nil {
+			return
+		}
+
+		silences = append(silences, silence)
+	}
+
+	return
+}
+
+func (r SilenceRepo) DeleteID(id primitive.ObjectID) error {
+	_, err := r.col.DeleteOne(context.TODO(), bson.M{"_id": id})
+	return err
+}
+
+func (r SilenceRepo) Update(id primitive.ObjectID, silence repository.Silence) error {
+	silence.UpdatedAt = time.Now()
+	_, err := r.col.ReplaceOne(context.TODO(), bson.M{"_id": id}, silence)
+	return err
+}
+
+func (r SilenceRepo) Count(filter bson.M) (int64, error) {
+	return r.col.CountDocuments(context.TODO(), filter)
+}
@@ -2,15 +2,18 @@ package impl
 
 import (
 	"context"
+	"crypto/subtle"
+	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/mylxsw/adanos-alert/internal/repository"
-	"github.com/mylxsw/coll"
 	"github.com/mylxsw/go-utils/str"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type UserRepo struct {
@@ -22,6 +25,10 @@ func NewUserRepo(db *mongo.Database) repository.UserRepo {
 }
 
 func (u UserRepo) Add(user repository.User) (id primitive.ObjectID, err error) {
+	if user.Password, err = hashPassword(user.Password); err != nil {
+		return
+	}
+
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = user.CreatedAt
 	if user.Status == "" {
@@ -46,7 +53,7 @@ func (u UserRepo) Get(id primitive.ObjectID) (user repository.User, err error) {
 }
 
 func (u UserRepo) GetByEmail(email string) (user repository.User, err error) {
-	err = u.col.FindOne(context.TODO(), bson.M{"email": email}).Decode(&user)
+	err = u.col.FindOne(context.TODO(), excludeDeleted(bson.M{"email": email})).Decode(&user)
 	if err == mongo.ErrNoDocuments {
 		err = repository.ErrNotFound
 	}
@@ -54,9 +61,77 @@ func (u UserRepo) GetByEmail(email string) (user repository.User, err error) {
 	return
 }
 
+// excludeDeleted 查询条件中没有指定 status 时，排除已删除的用户
+func excludeDeleted(filter bson.M) bson.M {
+	if _, ok := filter["status"]; ok {
+		return filter
+	}
+
+	newFilter := bson.M{"status": bson.M{"$ne": repository.UserStatusDeleted}}
+	for k, v := range filter {
+		newFilter[k] = v
+	}
+
+	return newFilter
+}
+
+func (u UserRepo) VerifyPassword(email, plaintext string) (user repository.User, err error) {
+	user, err = u.GetByEmail(email)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			err = repository.ErrInvalidPassword
+		}
+		return
+	}
+
+	if user.Password == "" || user.Status != repository.UserStatusEnabled {
+		return user, repository.ErrInvalidPassword
+	}
+
+	if repository.IsPasswordHashed(user.Password) {
+		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(plaintext)) != nil {
+			return user, repository.ErrInvalidPassword
+		}
+
+		return user, nil
+	}
+
+	// 早期版本明文存储的密码，校验成功后迁移为哈希之后的密码
+	if subtle.ConstantTimeCompare([]byte(user.Password), []byte(plaintext)) != 1 {
+		return user, repository.ErrInvalidPassword
+	}
+
+	hashed, err := hashPassword(plaintext)
+	if err != nil {
+		return user, err
+	}
+
+	if _, err = u.col.UpdateOne(context.TODO(), bson.M{"_id": user.ID, "password": user.Password}, bson.M{"$set": bson.M{"password": hashed}}); err != nil {
+		return user, err
+	}
+
+	user.Password = hashed
+	return user, nil
+}
+
+// hashPassword 使用 bcrypt 对用户输入的明文密码进行哈希，密码为空时直接返回
+// 即使输入的内容看起来已经是 bcrypt 哈希，也会作为明文处理，已经哈希的密码使用 SetPasswordHash 保存
+func hashPassword(password string) (string, error) {
+	if password == "" {
+		return password, nil
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("hash password failed: %w", err)
+	}
+
+	return string(hashed), nil
+}
+
 func (u UserRepo) Find(filter bson.M) (users []repository.User, err error) {
 	users = make([]repository.User, 0)
-	cur, err := u.col.Find(context.TODO(), filter)
+	cur, err := u.col.Find(context.TODO(), excludeDeleted(filter))
 	if err != nil {
 		return
 	}
@@ -76,7 +151,7 @@ func (u UserRepo) Find(filter bson.M) (users []repository.User, err error) {
 
 func (u UserRepo) Paginate(filter bson.M, offset, limit int64) (users []repository.User, next int64, err error) {
 	users = make([]repository.User, 0)
-	cur, err := u.col.Find(context.TODO(), filter, options.Find().SetSkip(offset).SetLimit(limit).SetSort(bson.M{"created_at": -1}))
+	cur, err := u.col.Find(context.TODO(), excludeDeleted(filter), options.Find().SetSkip(offset).SetLimit(limit).SetSort(bson.M{"created_at": -1}))
 	if err != nil {
 		return
 	}
@@ -98,11 +173,49 @@ func (u UserRepo) Paginate(filter bson.M, offset, limit int64) (users []reposito
 	return
 }
 
+func (u UserRepo) PaginateAfter(filter bson.M, afterID primitive.ObjectID, limit int64) (users []repository.User, next primitive.ObjectID, err error) {
+	users = make([]repository.User, 0)
+	cur, err := u.col.Find(context.TODO(), repository.AfterIDFilter(excludeDeleted(filter), afterID), options.Find().SetLimit(limit).SetSort(bson.M{"_id": -1}))
+	if err != nil {
+		return
+	}
+	defer cur.Close(context.TODO())
+
+	for cur.Next(context.TODO()) {
+		var user repository.User
+		if err = cur.Decode(&user); err != nil {
+			return
+		}
+
+		users = append(users, user)
+	}
+
+	if int64(len(users)) == limit && limit > 0 {
+		next = users[len(users)-1].ID
+	}
+
+	return
+}
+
 func (u UserRepo) DeleteID(id primitive.ObjectID) error {
 	return u.Delete(bson.M{"_id": id})
 }
 
 func (u UserRepo) Delete(filter bson.M) error {
+	now := time.Now()
+	_, err := u.col.UpdateMany(context.TODO(), filter, bson.M{"$set": bson.M{
+		"status":     repository.UserStatusDeleted,
+		"deleted_at": now,
+		"updated_at": now,
+	}})
+	return err
+}
+
+func (u UserRepo) PurgeID(id primitive.ObjectID) error {
+	return u.Purge(bson.M{"_id": id})
+}
+
+func (u UserRepo) Purge(filter bson.M) error {
 	_, err := u.col.DeleteMany(context.TODO(), filter)
 	return err
 }
@@ -114,54 +227,105 @@ func (u UserRepo) Update(id primitive.ObjectID, user repository.User) error {
 	}
 	user.CreatedAt = old.CreatedAt
 	user.UpdatedAt = time.Now()
-	if user.Password == "" {
+	// 密码为空或者与已保存的值相同时保持不变，否则作为明文密码哈希
+	if user.Password == "" || user.Password == old.Password {
 		user.Password = old.Password
+	} else {
+		if user.Password, err = hashPassword(user.Password); err != nil {
+			return err
+		}
 	}
 
 	_, err = u.col.ReplaceOne(context.TODO(), bson.M{"_id": id}, user)
 	return err
 }
 
+func (u UserRepo) SetPasswordHash(id primitive.ObjectID, hashed string) error {
+	if !repository.IsPasswordHashed(hashed) {
+		return fmt.Errorf("invalid password hash: %w", repository.ErrInvalidPassword)
+	}
+
+	_, err := u.col.UpdateOne(context.TODO(), bson.M{"_id": id}, bson.M{"$set": bson.M{"password": hashed, "updated_at": time.Now()}})
+	return err
+}
+
 func (u UserRepo) Count(filter bson.M) (int64, error) {
-	return u.col.CountDocuments(context.TODO(), filter)
+	return u.col.CountDocuments(context.TODO(), excludeDeleted(filter))
 }
 
+// userBuiltinFields 用户的内置字段，其它字段从用户的 Metas 中查询
+var userBuiltinFields = []string{"name", "phone", "email", "role", "status"}
+
 func (u UserRepo) GetUserMetas(queryK, queryV, field string) ([]string, error) {
+	return u.QueryUserMetas(repository.UserMetaQuery{QueryK: queryK, QueryV: queryV, Field: field})
+}
+
+func (u UserRepo) QueryUserMetas(query repository.UserMetaQuery) ([]string, error) {
+	var value interface{} = query.QueryV
+	if query.Fuzzy {
+		value = primitive.Regex{Pattern: regexp.QuoteMeta(query.QueryV), Options: "i"}
+	}
+
 	filter := bson.M{}
-	if str.In(queryK, []string{"name", "phone", "email", "role", "status"}) {
-		filter[queryK] = queryV
+	if query.QueryK != "status" {
+		filter["status"] = bson.M{"$ne": repository.UserStatusDeleted}
+	}
+	if query.QueryK != "" {
+		if str.In(query.QueryK, userBuiltinFields) {
+			filter[query.QueryK] = value
+		} else {
+			filter["metas"] = bson.M{"$elemMatch": bson.M{"key": query.QueryK, "value": value}}
+		}
+	}
+
+	// 使用聚合查询对字段值去重，避免加载完整的用户文档
+	pipeline := mongo.Pipeline{bson.D{{Key: "$match", Value: filter}}}
+	if str.In(query.Field, userBuiltinFields) {
+		pipeline = append(pipeline, bson.D{{Key: "$group", Value: bson.M{"_id": "$" + query.Field}}})
 	} else {
-		filter["metas.key"] = queryK
-		filter["metas.value"] = queryV
+		pipeline = append(
+			pipeline,
+			bson.D{{Key: "$unwind", Value: "$metas"}},
+			bson.D{{Key: "$match", Value: bson.M{"metas.key": query.Field}}},
+			bson.D{{Key: "$group", Value: bson.M{"_id": "$metas.value"}}},
+		)
 	}
 
-	users, err := u.Find(filter)
+	pipeline = append(
+		pipeline,
+		bson.D{{Key: "$match", Value: bson.M{"_id": bson.M{"$nin": bson.A{"", nil}}}}},
+		bson.D{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	)
+	if query.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: query.Limit}})
+	}
+
+	cur, err := u.col.Aggregate(context.TODO(), pipeline)
 	if err != nil {
 		return nil, err
 	}
+	defer cur.Close(context.TODO())
 
-	var res []string
-	_ = coll.MustNew(users).Map(func(u repository.User) string {
-		switch field {
-		case "name":
-			return u.Name
-		case "phone":
-			return u.Phone
-		case "email":
-			return u.Email
-		case "role":
-			return u.Role
-		case "status":
-			return string(u.Status)
-		default:
-			for _, m := range u.Metas {
-				if m.Key == field {
-					return m.Value
-				}
-			}
-
-			return ""
+	res := make([]string, 0)
+	for cur.Next(context.TODO()) {
+		var item struct {
+			Value string `bson:"_id"`
+		}
+		if err := cur.Decode(&item); err != nil {
+			return nil, err
 		}
-	}).Filter(func(v string) bool { return v != "" }).All(&res)
-	return res, nil
+
+		res = append(res, item.Value)
+	}
+
+	return res, cur.Err()
+}
+
+func (u UserRepo) ResolveChannels(userID primitive.ObjectID, severity string) ([]repository.NotifyChannel, error) {
+	user, err := u.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return user.NotifyChannels.Resolve(severity), nil
 }
@@ -17,7 +17,7 @@ type UserRepoTestSuite struct {
 }
 
 func (u *UserRepoTestSuite) TearDownTest() {
-	u.NoError(u.repo.Delete(bson.M{}))
+	u.NoError(u.repo.Purge(bson.M{}))
 }
 
 func (u *UserRepoTestSuite) SetupTest() {
@@ -90,6 +90,17 @@ func (u *UserRepoTestSuite) TestUserRepo() {
 	u.EqualValues(0, next)
 	u.EqualValues(6, len(users))
 
+	// PaginateAfter
+	users, cursor, err := u.repo.PaginateAfter(bson.M{}, primitive.NilObjectID, 5)
+	u.NoError(err)
+	u.EqualValues(5, len(users))
+	u.Equal(users[4].ID, cursor)
+
+	users, cursor, err = u.repo.PaginateAfter(bson.M{}, cursor, 1000)
+	u.NoError(err)
+	u.EqualValues(6, len(users))
+	u.True(cursor.IsZero())
+
 	// UpdateID
 	user.Name = "Saturday"
 	u.NoError(u.repo.Update(id, user))
@@ -103,6 +114,91 @@ func (u *UserRepoTestSuite) TestUserRepo() {
 	userCount, err = u.repo.Count(bson.M{})
 	u.NoError(err)
 	u.EqualValues(10, userCount)
+
+	// 软删除的用户仍然可以查询
+	deleted, err := u.repo.Get(id)
+	u.NoError(err)
+	u.Equal(repository.UserStatusDeleted, deleted.Status)
+	u.NotNil(deleted.DeletedAt)
+
+	users, err = u.repo.Find(repository.WithDeletedUsers(bson.M{"_id": id}))
+	u.NoError(err)
+	u.Len(users, 1)
+
+	// Purge
+	u.NoError(u.repo.PurgeID(id))
+	_, err = u.repo.Get(id)
+	u.Equal(repository.ErrNotFound, err)
+}
+
+func (u *UserRepoTestSuite) TestVerifyPassword() {
+	id, err := u.repo.Add(repository.User{Name: "Friday", Email: "friday@example.com", Password: "secret"})
+	u.NoError(err)
+
+	user, err := u.repo.Get(id)
+	u.NoError(err)
+	u.True(repository.IsPasswordHashed(user.Password))
+
+	_, err = u.repo.VerifyPassword("friday@example.com", "secret")
+	u.NoError(err)
+
+	_, err = u.repo.VerifyPassword("friday@example.com", "wrong")
+	u.Equal(repository.ErrInvalidPassword, err)
+
+	_, err = u.repo.VerifyPassword("nobody@example.com", "secret")
+	u.Equal(repository.ErrInvalidPassword, err)
+
+	// 密码没有变化时不重新哈希
+	u.NoError(u.repo.Update(id, user))
+	user2, err := u.repo.Get(id)
+	u.NoError(err)
+	u.Equal(user.Password, user2.Password)
+
+	// 用户输入的内容即使与 bcrypt 哈希格式相同，也作为明文密码哈希
+	hashLike := "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
+	id2, err := u.repo.Add(repository.User{Name: "Saturday", Email: "saturday@example.com", Password: hashLike})
+	u.NoError(err)
+
+	user3, err := u.repo.Get(id2)
+	u.NoError(err)
+	u.NotEqual(hashLike, user3.Password)
+
+	_, err = u.repo.VerifyPassword("saturday@example.com", hashLike)
+	u.NoError(err)
+
+	// 已经哈希的密码通过 SetPasswordHash 保存
+	u.NoError(u.repo.SetPasswordHash(id2, user.Password))
+	_, err = u.repo.VerifyPassword("saturday@example.com", "secret")
+	u.NoError(err)
+
+	u.Error(u.repo.SetPasswordHash(id2, "secret"))
+}
+
+func (u *UserRepoTestSuite) TestQueryUserMetas() {
+	for i := 0; i < 6; i++ {
+		_, err := u.repo.Add(repository.User{
+			Name:  fmt.Sprintf("User %d", i),
+			Role:  []string{"ops", "dev"}[i%2],
+			Metas: []repository.UserMeta{{Key: "team", Value: fmt.Sprintf("Team-%d", i%3)}},
+		})
+		u.NoError(err)
+	}
+
+	metas, err := u.repo.GetUserMetas("role", "ops", "team")
+	u.NoError(err)
+	u.Equal([]string{"Team-0", "Team-1", "Team-2"}, metas)
+
+	metas, err = u.repo.GetUserMetas("team", "Team-1", "name")
+	u.NoError(err)
+	u.Equal([]string{"User 1", "User 4"}, metas)
+
+	metas, err = u.repo.QueryUserMetas(repository.UserMetaQuery{QueryK: "team", QueryV: "team-", Field: "role", Fuzzy: true})
+	u.NoError(err)
+	u.Equal([]string{"dev", "ops"}, metas)
+
+	metas, err = u.repo.QueryUserMetas(repository.UserMetaQuery{Field: "team", Limit: 2})
+	u.NoError(err)
+	u.Equal([]string{"Team-0", "Team-1"}, metas)
 }
 
 func TestUserRepo(t *testing.T) {
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultInhibitWindow 抑制规则源事件默认的有效时间（秒）
+const DefaultInhibitWindow int64 = 600
+
+// InhibitRule 抑制规则，参考 Alertmanager 的 inhibit_rules
+// 存在与 SourceRule 匹配的活跃事件时，与 TargetRule 匹配，并且 Equal 中指定的 Meta 值都相同的事件组不再发送通知
+type InhibitRule struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+
+	// SourceRule 源事件匹配规则，语法与规则的匹配规则相同
+	SourceRule string `bson:"source_rule" json:"source_rule"`
+	// TargetRule 被抑制的事件匹配规则
+	TargetRule string `bson:"target_rule" json:"target_rule"`
+	// Equal 源事件与目标事件中必须相同的 Meta 字段，两者都不存在该字段时也视为相同
+	Equal []string `bson:"equal" json:"equal"`
+	// Window 源事件的有效时间（秒），源事件在该时间内产生时视为活跃
+	Window int64 `bson:"window" json:"window"`
+
+	Enabled bool `bson:"enabled" json:"enabled"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// ActiveWindow 返回源事件的有效时间
+func (rule InhibitRule) ActiveWindow() time.Duration {
+	if rule.Window <= 0 {
+		return time.Duration(DefaultInhibitWindow) * time.Second
+	}
+
+	return time.Duration(rule.Window) * time.Second
+}
+
+type InhibitRuleRepo interface {
+	Add(rule InhibitRule) (id primitive.ObjectID, err error)
+	Get(id primitive.ObjectID) (rule InhibitRule, err error)
+	Find(filter bson.M) (rules []InhibitRule, err error)
+	Paginate(filter bson.M, offset, limit int64) (rules []InhibitRule, next int64, err error)
+	DeleteID(id primitive.ObjectID) error
+	Update(id primitive.ObjectID, rule InhibitRule) error
+	Count(filter bson.M) (int64, error)
+}
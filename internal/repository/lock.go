@@ -32,4 +32,6 @@ type LockRepo interface {
 	Renew(lockID primitive.ObjectID, ttl uint) (*Lock, error)
 	UnLock(lockID primitive.ObjectID) error
 	Remove(resource string) error
+	// CurrentOwner 返回资源当前的锁持有者以及锁的过期时间，没有节点持有锁时 owner 为空
+	CurrentOwner(resource string) (owner string, expiresAt time.Time, err error)
 }
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaintenanceWindow 周期性维护窗口，窗口开启期间，与 Matcher 匹配的事件组的动作会被暂缓执行，窗口关闭后再执行
+type MaintenanceWindow struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	// Schedule 窗口开启时间，Cron 表达式，如 `0 2 * * 6` 表示每周六 02:00
+	Schedule string `bson:"schedule" json:"schedule"`
+	// Duration 窗口持续时间，单位为秒
+	Duration int64 `bson:"duration" json:"duration"`
+	// Matcher 匹配规则，语法与动作的触发条件（PreCondition）相同，为空时匹配所有事件组
+	Matcher string `bson:"matcher" json:"matcher"`
+	Enabled bool   `bson:"enabled" json:"enabled"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// ParseSchedule 解析窗口的 Cron 表达式
+func (w MaintenanceWindow) ParseSchedule() (cron.Schedule, error) {
+	return cron.ParseStandard(w.Schedule)
+}
+
+// OpenAt 判断维护窗口在 now 时是否处于开启状态
+func (w MaintenanceWindow) OpenAt(now time.Time) (bool, error) {
+	schedule, err := w.ParseSchedule()
+	if err != nil {
+		return false, err
+	}
+
+	// 从 now - Duration 之后第一次开启的时间不晚于 now 时，窗口处于开启状态
+	startAt := schedule.Next(now.Add(-time.Duration(w.Duration) * time.Second))
+	return !startAt.IsZero() && !startAt.After(now), nil
+}
+
+type MaintenanceWindowRepo interface {
+	Add(window MaintenanceWindow) (id primitive.ObjectID, err error)
+	Get(id primitive.ObjectID) (window MaintenanceWindow, err error)
+	Find(filter bson.M) (windows []MaintenanceWindow, err error)
+	Paginate(filter bson.M, offset, limit int64) (windows []MaintenanceWindow, next int64, err error)
+	DeleteID(id primitive.ObjectID) error
+	Update(id primitive.ObjectID, window MaintenanceWindow) error
+	Count(filter bson.M) (int64, error)
+}
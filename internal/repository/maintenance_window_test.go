@@ -0,0 +1,33 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceWindow_OpenAt(t *testing.T) {
+	// 每天 02:00 开启，持续 1 小时
+	window := repository.MaintenanceWindow{Schedule: "0 2 * * *", Duration: 3600}
+
+	day := time.Date(2020, 12, 1, 0, 0, 0, 0, time.Local)
+	for _, c := range []struct {
+		at   time.Time
+		open bool
+	}{
+		{at: day.Add(time.Hour + 59*time.Minute), open: false},
+		{at: day.Add(2 * time.Hour), open: true},
+		{at: day.Add(2*time.Hour + 30*time.Minute), open: true},
+		{at: day.Add(3 * time.Hour), open: false},
+		{at: day.Add(12 * time.Hour), open: false},
+	} {
+		open, err := window.OpenAt(c.at)
+		assert.NoError(t, err)
+		assert.Equal(t, c.open, open, c.at.String())
+	}
+
+	_, err := repository.MaintenanceWindow{Schedule: "invalid", Duration: 3600}.OpenAt(day)
+	assert.Error(t, err)
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RateLimitBucket 规则的通知频率限制令牌桶状态，持久化在数据库中，服务重启或者切换实例后依然有效
+type RateLimitBucket struct {
+	// RuleID 规则 ID，每个规则一个令牌桶
+	RuleID     primitive.ObjectID `bson:"_id" json:"rule_id"`
+	Tokens     float64            `bson:"tokens" json:"tokens"`
+	RefilledAt time.Time          `bson:"refilled_at" json:"refilled_at"`
+	// Suppressed 因为超出频率限制而没有发送的通知数量
+	Suppressed int64 `bson:"suppressed" json:"suppressed"`
+	// LastSuppressedGroupID 最后一个没有发送通知的事件组 ID
+	LastSuppressedGroupID primitive.ObjectID `bson:"last_suppressed_group_id,omitempty" json:"last_suppressed_group_id,omitempty"`
+
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// NewRateLimitBucket 创建一个装满令牌的令牌桶
+func NewRateLimitBucket(ruleID primitive.ObjectID, limit RuleRateLimit, now time.Time) RateLimitBucket {
+	return RateLimitBucket{RuleID: ruleID, Tokens: float64(limit.Capacity), RefilledAt: now}
+}
+
+// Refill 按照距离上次填充的时间补充令牌，令牌数量不超过桶容量
+func (b *RateLimitBucket) Refill(limit RuleRateLimit, now time.Time) {
+	if !limit.Enabled() {
+		return
+	}
+
+	elapsed := now.Sub(b.RefilledAt).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	b.Tokens += elapsed / float64(limit.RefillInterval)
+	if b.Tokens > float64(limit.Capacity) {
+		b.Tokens = float64(limit.Capacity)
+	}
+
+	b.RefilledAt = now
+}
+
+// Take 从令牌桶中取出一个令牌，没有可用令牌时返回 false
+func (b *RateLimitBucket) Take(limit RuleRateLimit, now time.Time) bool {
+	if !limit.Enabled() {
+		return true
+	}
+
+	b.Refill(limit, now)
+	if b.Tokens < 1 {
+		return false
+	}
+
+	b.Tokens--
+	return true
+}
+
+type RateLimitRepo interface {
+	// Get 查询规则的令牌桶，不存在时返回 ErrNotFound
+	Get(ruleID primitive.ObjectID) (bucket RateLimitBucket, err error)
+	Find(filter bson.M) (buckets []RateLimitBucket, err error)
+	// Save 保存令牌桶，不存在时创建
+	Save(bucket RateLimitBucket) error
+	DeleteID(ruleID primitive.ObjectID) error
+}
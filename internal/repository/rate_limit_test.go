@@ -0,0 +1,35 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRateLimitBucket_Take(t *testing.T) {
+	// 容量为 2，每 60 秒生成一个令牌
+	limit := repository.RuleRateLimit{Capacity: 2, RefillInterval: 60}
+
+	now := time.Now()
+	bucket := repository.NewRateLimitBucket(primitive.NewObjectID(), limit, now)
+
+	assert.True(t, bucket.Take(limit, now))
+	assert.True(t, bucket.Take(limit, now))
+	assert.False(t, bucket.Take(limit, now))
+	assert.False(t, bucket.Take(limit, now.Add(30*time.Second)))
+
+	// 60 秒后恢复一个令牌
+	assert.True(t, bucket.Take(limit, now.Add(60*time.Second)))
+	assert.False(t, bucket.Take(limit, now.Add(60*time.Second)))
+
+	// 令牌数量不超过桶容量
+	bucket.Refill(limit, now.Add(time.Hour))
+	assert.Equal(t, float64(2), bucket.Tokens)
+
+	// 没有启用频率限制时不限制
+	disabled := repository.RuleRateLimit{}
+	assert.True(t, bucket.Take(disabled, now))
+}
@@ -18,5 +18,10 @@ type Recovery struct {
 type RecoveryRepo interface {
 	Register(ctx context.Context, recoveryAt time.Time, recoveryID string, refID primitive.ObjectID) (err error)
 	RecoverableEvents(ctx context.Context, deadline time.Time) ([]Recovery, error)
+	// FindByIdentifier 查询恢复标识（EventControl.ID）对应的所有恢复记录
+	FindByIdentifier(ctx context.Context, recoveryID string) ([]Recovery, error)
+	// Delete 删除恢复标识对应的所有恢复记录
 	Delete(ctx context.Context, recoveryID string) error
+	// DeleteBefore 删除最多 limit 条预计恢复时间早于 before 的恢复记录，返回删除的数量
+	DeleteBefore(ctx context.Context, before time.Time, limit int64) (int64, error)
 }
@@ -1,9 +1,33 @@
 package repository
 
 import (
+	"context"
 	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var ErrNotFound = errors.New("not found")
 
-type ID string
\ No newline at end of file
+type ID string
+
+// IndexEnsurer 需要创建索引的 Repo，服务启动时会调用 EnsureIndexes 创建索引
+type IndexEnsurer interface {
+	EnsureIndexes(ctx context.Context) error
+}
+
+// AfterIDFilter 游标分页查询条件，在 filter 的基础上只查询 _id 小于 afterID 的记录
+// afterID 为空时表示查询第一页，直接返回 filter
+func AfterIDFilter(filter interface{}, afterID primitive.ObjectID) interface{} {
+	if afterID.IsZero() {
+		return filter
+	}
+
+	idFilter := bson.M{"_id": bson.M{"$lt": afterID}}
+	if filter == nil {
+		return idFilter
+	}
+
+	return bson.M{"$and": bson.A{filter, idFilter}}
+}
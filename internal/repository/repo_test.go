@@ -0,0 +1,22 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAfterIDFilter(t *testing.T) {
+	filter := bson.M{"status": "ok"}
+	assert.Equal(t, filter, repository.AfterIDFilter(filter, primitive.NilObjectID))
+
+	afterID := primitive.NewObjectID()
+	assert.Equal(t, bson.M{"_id": bson.M{"$lt": afterID}}, repository.AfterIDFilter(nil, afterID))
+
+	// 原有的查询条件中包含 _id 时也不会被覆盖
+	filter = bson.M{"_id": bson.M{"$in": []primitive.ObjectID{afterID}}}
+	assert.Equal(t, bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$lt": afterID}}}}, repository.AfterIDFilter(filter, afterID))
+}
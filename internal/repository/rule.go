@@ -16,6 +16,8 @@ type RuleStatus string
 const (
 	RuleStatusEnabled  RuleStatus = "enabled"
 	RuleStatusDisabled RuleStatus = "disabled"
+	// RuleStatusInvalid 规则表达式编译失败，聚合时被自动标记，修正规则后需要重新启用
+	RuleStatusInvalid RuleStatus = "invalid"
 )
 
 const (
@@ -47,6 +49,8 @@ type Rule struct {
 	AggregateRule string `bson:"aggregate_rule" json:"aggregate_rule"`
 	// RelationRule 关联规则，匹配的事件会被创建关联关系
 	RelationRule string `bson:"relation_rule" json:"relation_rule"`
+	// Priority 规则优先级，值越大优先级越高，first 匹配模式下事件只会分配到优先级最高的匹配规则
+	Priority int64 `bson:"priority" json:"priority"`
 
 	// ReadType 就绪类型，支持 interval/daily_time
 	ReadyType  string      `bson:"ready_type" json:"ready_type"`
@@ -56,21 +60,50 @@ type Rule struct {
 
 	// Rule 用于分组匹配的规则
 	Rule string `bson:"rule" json:"rule"`
+	// ActiveSchedule 规则的生效时间，不在生效时间内时规则不匹配任何事件（影响分组，而不只是通知）
+	ActiveSchedule RuleActiveSchedule `bson:"active_schedule" json:"active_schedule"`
 	// IgnoreRule 分组匹配后，检查 message 是否应该被忽略
-	IgnoreRule      string    `bson:"ignore_rule" json:"ignore_rule"`
-	Template        string    `bson:"template" json:"template"`
-	SummaryTemplate string    `bson:"summary_template" json:"summary_template"`
-	Triggers        []Trigger `bson:"triggers" json:"triggers"`
+	IgnoreRule      string `bson:"ignore_rule" json:"ignore_rule"`
+	Template        string `bson:"template" json:"template"`
+	SummaryTemplate string `bson:"summary_template" json:"summary_template"`
+	// RecoveryTemplate 恢复通知模板，事件组为恢复事件组时代替 Template 使用，为空时使用 Template
+	RecoveryTemplate string    `bson:"recovery_template" json:"recovery_template"`
+	Triggers         []Trigger `bson:"triggers" json:"triggers"`
 
 	// ReportTemplateID 报表模板 ID
 	ReportTemplateID primitive.ObjectID `bson:"report_template_id" json:"report_template_id"`
+	// RateLimit 通知频率限制
+	RateLimit RuleRateLimit `bson:"rate_limit" json:"rate_limit"`
 
 	Status RuleStatus `bson:"status" json:"status"`
+	// LastStatusChange 最近一次通过启用/禁用接口变更状态的记录
+	LastStatusChange *RuleStatusChange `bson:"last_status_change,omitempty" json:"last_status_change,omitempty"`
 
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 }
 
+// RuleRateLimit 规则的通知频率限制（令牌桶），每个事件组的通知消耗一个令牌
+type RuleRateLimit struct {
+	// Capacity 令牌桶容量，为 0 时不限制
+	Capacity int64 `bson:"capacity" json:"capacity"`
+	// RefillInterval 每生成一个令牌需要的时间，单位为秒
+	RefillInterval int64 `bson:"refill_interval" json:"refill_interval"`
+}
+
+// Enabled 是否启用了频率限制
+func (l RuleRateLimit) Enabled() bool {
+	return l.Capacity > 0 && l.RefillInterval > 0
+}
+
+// RuleStatusChange 规则状态变更记录
+type RuleStatusChange struct {
+	Status    RuleStatus `bson:"status" json:"status"`
+	Operator  string     `bson:"operator" json:"operator"`
+	Reason    string     `bson:"reason" json:"reason"`
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+}
+
 // ToGroupRule convert Rule to EventGroupRule
 func (rule Rule) ToGroupRule(aggregateKey string, msgType EventType) EventGroupRule {
 	groupRule := EventGroupRule{
@@ -80,6 +113,7 @@ func (rule Rule) ToGroupRule(aggregateKey string, msgType EventType) EventGroupR
 		IgnoreRule:       rule.IgnoreRule,
 		Template:         rule.Template,
 		SummaryTemplate:  rule.SummaryTemplate,
+		RecoveryTemplate: rule.RecoveryTemplate,
 		ReportTemplateID: rule.ReportTemplateID,
 		AggregateKey:     aggregateKey,
 		Type:             msgType,
@@ -110,6 +144,8 @@ type RuleRepo interface {
 	Find(filter bson.M) (rules []Rule, err error)
 	Traverse(filter bson.M, cb func(rule Rule) error) error
 	UpdateID(id primitive.ObjectID, rule Rule) error
+	// SetStatus 变更规则状态，同时记录变更人以及变更原因
+	SetStatus(id primitive.ObjectID, status RuleStatus, operator string, reason string) error
 	Count(filter bson.M) (int64, error)
 	Delete(filter bson.M) error
 	DeleteID(id primitive.ObjectID) error
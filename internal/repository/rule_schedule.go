@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// RuleActiveSchedule 规则的生效时间，不在生效时间内时规则不匹配任何事件，没有配置时规则始终生效
+// DailyRanges 与 Cron 窗口同时配置时，处于任意一个时间段内即生效
+type RuleActiveSchedule struct {
+	// DailyRanges 每天的生效时间段
+	DailyRanges []DailyTimeRange `bson:"daily_ranges" json:"daily_ranges"`
+	// Cron 生效窗口的开启时间，Cron 表达式，如 `0 22 * * 1-5` 表示工作日 22:00
+	Cron string `bson:"cron" json:"cron"`
+	// Duration 生效窗口持续时间，单位为秒，与 Cron 一起使用
+	Duration int64 `bson:"duration" json:"duration"`
+}
+
+// DailyTimeRange 每天的时间段，格式为 15:04，包含开始时间，不包含截止时间，截止时间早于开始时间时表示跨天
+type DailyTimeRange struct {
+	StartTime string `bson:"start_time" json:"start_time"`
+	EndTime   string `bson:"end_time" json:"end_time"`
+}
+
+// Empty 是否没有配置生效时间
+func (s RuleActiveSchedule) Empty() bool {
+	return len(s.DailyRanges) == 0 && s.Cron == ""
+}
+
+// Compile 解析生效时间配置，配置不合法时返回错误
+func (s RuleActiveSchedule) Compile() (*CompiledActiveSchedule, error) {
+	compiled := &CompiledActiveSchedule{empty: s.Empty()}
+
+	for _, r := range s.DailyRanges {
+		start, err := parseMinuteOfDay(r.StartTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start_time %s in daily range: %w", r.StartTime, err)
+		}
+
+		end, err := parseMinuteOfDay(r.EndTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end_time %s in daily range: %w", r.EndTime, err)
+		}
+
+		if start == end {
+			return nil, fmt.Errorf("invalid daily range %s-%s: start_time must not equal to end_time", r.StartTime, r.EndTime)
+		}
+
+		compiled.ranges = append(compiled.ranges, [2]int{start, end})
+	}
+
+	if s.Cron != "" {
+		schedule, err := cron.ParseStandard(s.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron %s: %w", s.Cron, err)
+		}
+
+		if s.Duration <= 0 {
+			return nil, errors.New("duration must be greater than 0 when cron is set")
+		}
+
+		compiled.cron = schedule
+		compiled.duration = time.Duration(s.Duration) * time.Second
+	}
+
+	return compiled, nil
+}
+
+// parseMinuteOfDay 将 15:04 格式的时间转换为当天的分钟数
+func parseMinuteOfDay(val string) (int, error) {
+	t, err := time.Parse("15:04", val)
+	if err != nil {
+		return 0, err
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// CompiledActiveSchedule 解析之后的规则生效时间
+type CompiledActiveSchedule struct {
+	empty    bool
+	ranges   [][2]int
+	cron     cron.Schedule
+	duration time.Duration
+}
+
+// ActiveAt 判断规则在 t 时是否生效，生效时间使用服务器本地时区，与 DailyTimeBetween 一致
+func (s *CompiledActiveSchedule) ActiveAt(t time.Time) bool {
+	if s == nil || s.empty {
+		return true
+	}
+
+	// 从 MongoDB 读取的时间为 UTC 时区，需要转换为本地时区之后再判断
+	t = t.In(time.Local)
+
+	minute := t.Hour()*60 + t.Minute()
+	for _, r := range s.ranges {
+		start, end := r[0], r[1]
+		if start < end && minute >= start && minute < end {
+			return true
+		}
+
+		// 跨天的时间段
+		if start > end && (minute >= start || minute < end) {
+			return true
+		}
+	}
+
+	if s.cron != nil {
+		// 从 t - duration 之后第一次开启的时间不晚于 t 时，窗口处于开启状态
+		startAt := s.cron.Next(t.Add(-s.duration))
+		if !startAt.IsZero() && !startAt.After(t) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,75 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleActiveSchedule_Compile(t *testing.T) {
+	invalids := []repository.RuleActiveSchedule{
+		{DailyRanges: []repository.DailyTimeRange{{StartTime: "25:00", EndTime: "09:00"}}},
+		{DailyRanges: []repository.DailyTimeRange{{StartTime: "09:00", EndTime: "9"}}},
+		{DailyRanges: []repository.DailyTimeRange{{StartTime: "09:00", EndTime: "09:00"}}},
+		{Cron: "invalid", Duration: 3600},
+		{Cron: "0 22 * * *"},
+	}
+
+	for _, s := range invalids {
+		_, err := s.Compile()
+		assert.Error(t, err, "%+v", s)
+	}
+
+	compiled, err := repository.RuleActiveSchedule{}.Compile()
+	assert.NoError(t, err)
+	assert.True(t, compiled.ActiveAt(time.Now()))
+}
+
+// withLocalZone 测试期间将本地时区设置为 loc
+func withLocalZone(loc *time.Location) func() {
+	origin := time.Local
+	time.Local = loc
+
+	return func() { time.Local = origin }
+}
+
+func TestRuleActiveSchedule_ActiveAt(t *testing.T) {
+	defer withLocalZone(time.FixedZone("CST", 8*3600))()
+
+	// 每天 22:00 到次日 06:00，以及每周六 12:00 开始的 2 小时
+	compiled, err := repository.RuleActiveSchedule{
+		DailyRanges: []repository.DailyTimeRange{{StartTime: "22:00", EndTime: "06:00"}},
+		Cron:        "0 12 * * 6",
+		Duration:    7200,
+	}.Compile()
+	assert.NoError(t, err)
+
+	testcases := map[string]bool{
+		"2020-07-10T21:59:00+08:00": false,
+		"2020-07-10T22:00:00+08:00": true,
+		"2020-07-11T05:59:00+08:00": true,
+		"2020-07-11T06:00:00+08:00": false,
+		"2020-07-11T12:30:00+08:00": true,
+		"2020-07-11T14:00:00+08:00": false,
+		"2020-07-10T12:30:00+08:00": false,
+	}
+
+	for ts, expected := range testcases {
+		assert.Equal(t, expected, compiled.ActiveAt(parseTime(ts)), ts)
+	}
+
+	// 从 MongoDB 读取的时间为 UTC 时区，生效时间仍然按照本地时区判断
+	utcTestcases := map[string]bool{
+		"2020-07-10T14:00:00Z": true,  // 本地时间 22:00
+		"2020-07-10T21:59:00Z": true,  // 本地时间 05:59
+		"2020-07-10T22:00:00Z": false, // 本地时间 06:00
+		"2020-07-10T06:00:00Z": false, // 本地时间 14:00
+		"2020-07-11T04:30:00Z": true,  // 本地时间周六 12:30
+	}
+
+	for ts, expected := range utcTestcases {
+		assert.Equal(t, expected, compiled.ActiveAt(parseTime(ts).UTC()), ts)
+	}
+}
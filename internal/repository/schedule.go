@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrNoOnCall 值班表在指定时间没有值班人员
+var ErrNoOnCall = errors.New("no one is on call")
+
+// Schedule 值班表，Users 按顺序轮流值班，从 StartAt 开始每个人值班 ShiftDuration 秒
+type Schedule struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+
+	// Users 轮值用户，按照值班顺序排列
+	Users   []primitive.ObjectID `bson:"users" json:"users"`
+	StartAt time.Time            `bson:"start_at" json:"start_at"`
+	// ShiftDuration 每个班次的持续时间，单位为秒
+	ShiftDuration int64 `bson:"shift_duration" json:"shift_duration"`
+	// Overrides 临时调班，优先级高于轮值，多个调班时间重叠时后添加的优先
+	Overrides []ScheduleOverride `bson:"overrides" json:"overrides"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// ScheduleOverride 临时调班，StartAt ~ EndAt 期间由 UserID 值班
+type ScheduleOverride struct {
+	UserID  primitive.ObjectID `bson:"user_id" json:"user_id"`
+	StartAt time.Time          `bson:"start_at" json:"start_at"`
+	EndAt   time.Time          `bson:"end_at" json:"end_at"`
+	Comment string             `bson:"comment" json:"comment"`
+}
+
+// OnCallAt 返回 at 时刻的值班人员，没有值班人员时返回 primitive.NilObjectID
+func (s Schedule) OnCallAt(at time.Time) primitive.ObjectID {
+	for i := len(s.Overrides) - 1; i >= 0; i-- {
+		o := s.Overrides[i]
+		if !at.Before(o.StartAt) && at.Before(o.EndAt) {
+			return o.UserID
+		}
+	}
+
+	if len(s.Users) == 0 || s.ShiftDuration <= 0 || at.Before(s.StartAt) {
+		return primitive.NilObjectID
+	}
+
+	shift := int64(at.Sub(s.StartAt) / (time.Duration(s.ShiftDuration) * time.Second))
+	return s.Users[shift%int64(len(s.Users))]
+}
+
+type ScheduleRepo interface {
+	Add(schedule Schedule) (id primitive.ObjectID, err error)
+	Get(id primitive.ObjectID) (schedule Schedule, err error)
+	Find(filter bson.M) (schedules []Schedule, err error)
+	Paginate(filter bson.M, offset, limit int64) (schedules []Schedule, next int64, err error)
+	DeleteID(id primitive.ObjectID) error
+	Update(id primitive.ObjectID, schedule Schedule) error
+	Count(filter bson.M) (int64, error)
+	// CurrentOnCall 返回值班表在 at 时刻的值班人员，没有值班人员时返回 ErrNoOnCall
+	CurrentOnCall(scheduleID primitive.ObjectID, at time.Time) (primitive.ObjectID, error)
+}
@@ -0,0 +1,40 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSchedule_OnCallAt(t *testing.T) {
+	alice, bob, carol := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	startAt := time.Date(2020, 6, 1, 9, 0, 0, 0, time.Local)
+
+	schedule := repository.Schedule{
+		Users:         []primitive.ObjectID{alice, bob},
+		StartAt:       startAt,
+		ShiftDuration: 3600 * 24,
+	}
+
+	// 轮值开始之前没有值班人员
+	assert.True(t, schedule.OnCallAt(startAt.Add(-time.Minute)).IsZero())
+
+	assert.Equal(t, alice, schedule.OnCallAt(startAt))
+	assert.Equal(t, alice, schedule.OnCallAt(startAt.Add(23*time.Hour)))
+	assert.Equal(t, bob, schedule.OnCallAt(startAt.Add(24*time.Hour)))
+	assert.Equal(t, alice, schedule.OnCallAt(startAt.Add(48*time.Hour)))
+
+	// 临时调班优先于轮值，重叠时后添加的优先
+	schedule.Overrides = []repository.ScheduleOverride{
+		{UserID: carol, StartAt: startAt.Add(24 * time.Hour), EndAt: startAt.Add(36 * time.Hour)},
+		{UserID: alice, StartAt: startAt.Add(30 * time.Hour), EndAt: startAt.Add(32 * time.Hour)},
+	}
+	assert.Equal(t, carol, schedule.OnCallAt(startAt.Add(25*time.Hour)))
+	assert.Equal(t, alice, schedule.OnCallAt(startAt.Add(31*time.Hour)))
+	assert.Equal(t, bob, schedule.OnCallAt(startAt.Add(36*time.Hour)))
+
+	assert.True(t, repository.Schedule{StartAt: startAt}.OnCallAt(startAt).IsZero())
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Silence 静默规则，在 [StartsAt, EndsAt) 时间范围内，与 Matcher 匹配的事件组不执行动作
+type Silence struct {
+	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	// Matcher 匹配规则，语法与动作的触发条件（PreCondition）相同
+	Matcher  string    `bson:"matcher" json:"matcher"`
+	StartsAt time.Time `bson:"starts_at" json:"starts_at"`
+	EndsAt   time.Time `bson:"ends_at" json:"ends_at"`
+	Creator  string    `bson:"creator" json:"creator"`
+	Comment  string    `bson:"comment" json:"comment"`
+
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Active 判断静默规则在 now 时是否生效
+func (s Silence) Active(now time.Time) bool {
+	return !now.Before(s.StartsAt) && now.Before(s.EndsAt)
+}
+
+// ActiveSilenceFilter 返回查询在 now 时生效的静默规则的查询条件
+func ActiveSilenceFilter(now time.Time) bson.M {
+	return bson.M{
+		"starts_at": bson.M{"$lte": now},
+		"ends_at":   bson.M{"$gt": now},
+	}
+}
+
+type SilenceRepo interface {
+	Add(silence Silence) (id primitive.ObjectID, err error)
+	Get(id primitive.ObjectID) (silence Silence, err error)
+	Find(filter bson.M) (silences []Silence, err error)
+	Paginate(filter bson.M, offset, limit int64) (silences []Silence, next int64, err error)
+	DeleteID(id primitive.ObjectID) error
+	Update(id primitive.ObjectID, silence Silence) error
+	Count(filter bson.M) (int64, error)
+}
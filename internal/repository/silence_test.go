@@ -0,0 +1,23 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSilence_Active(t *testing.T) {
+	now := time.Now()
+	silence := repository.Silence{
+		StartsAt: now.Add(-time.Hour),
+		EndsAt:   now.Add(time.Hour),
+	}
+
+	assert.True(t, silence.Active(now))
+	assert.True(t, silence.Active(silence.StartsAt))
+	assert.False(t, silence.Active(silence.EndsAt))
+	assert.False(t, silence.Active(now.Add(-2*time.Hour)))
+	assert.False(t, silence.Active(now.Add(2*time.Hour)))
+}
@@ -15,6 +15,8 @@ const (
 	TemplateTypeTriggerRule      TemplateType = "trigger_rule"
 	TemplateTypeDingdingTemplate TemplateType = "template_dingding"
 	TemplateTypeReport           TemplateType = "template_report"
+	// TemplateTypeRecovery 恢复通知模板，事件组中的事件为恢复事件时使用
+	TemplateTypeRecovery TemplateType = "template_recovery"
 )
 
 func AllTemplateTypes() []string {
@@ -24,6 +26,7 @@ func AllTemplateTypes() []string {
 		string(TemplateTypeTemplate),
 		string(TemplateTypeDingdingTemplate),
 		string(TemplateTypeReport),
+		string(TemplateTypeRecovery),
 	}
 }
 
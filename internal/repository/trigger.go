@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -9,6 +11,10 @@ type TriggerStatus string
 const (
 	TriggerStatusOK     TriggerStatus = "ok"
 	TriggerStatusFailed TriggerStatus = "failed"
+	// TriggerStatusSilenced 事件组与生效中的静默规则匹配，动作没有执行
+	TriggerStatusSilenced TriggerStatus = "silenced"
+	// TriggerStatusRateLimited 规则的通知超出频率限制，动作没有执行，令牌恢复后会发送一条汇总通知
+	TriggerStatusRateLimited TriggerStatus = "rate_limited"
 )
 
 // Trigger is a action trigger for matched rules
@@ -22,8 +28,38 @@ type Trigger struct {
 	Action        string               `bson:"action" json:"action"`
 	Meta          string               `bson:"meta" json:"meta"`
 	UserRefs      []primitive.ObjectID `bson:"user_refs" json:"user_refs"`
+	// ScheduleID 值班表 ID，动作执行时通知该值班表当前的值班人员
+	ScheduleID primitive.ObjectID `bson:"schedule_id,omitempty" json:"schedule_id,omitempty"`
 	// for group actions
 	Status       TriggerStatus `bson:"trigger_status,omitempty" json:"trigger_status,omitempty"`
 	FailedCount  int           `bson:"failed_count" json:"failed_count"`
 	FailedReason string        `bson:"failed_reason" json:"failed_reason"`
+	// SilenceID 动作被静默时，匹配的静默规则 ID
+	SilenceID primitive.ObjectID `bson:"silence_id,omitempty" json:"silence_id,omitempty"`
+
+	// Escalations 升级链，动作执行成功后，事件组一直没有被确认时，按顺序执行升级步骤
+	Escalations []EscalationStep `bson:"escalations,omitempty" json:"escalations,omitempty"`
+	// EscalatedSteps 已经执行的升级步骤数量
+	EscalatedSteps int `bson:"escalated_steps,omitempty" json:"escalated_steps,omitempty"`
+}
+
+// EscalationMaxDelay 升级步骤允许配置的最大延迟时间
+const EscalationMaxDelay = 24 * time.Hour
+
+// EscalationStep 升级步骤，事件组就绪超过 Delay 秒仍未被确认时，执行该步骤的动作
+type EscalationStep struct {
+	Delay    int64                `bson:"delay" json:"delay"`
+	Action   string               `bson:"action" json:"action"`
+	Meta     string               `bson:"meta" json:"meta"`
+	UserRefs []primitive.ObjectID `bson:"user_refs" json:"user_refs"`
+}
+
+// DueEscalation 返回下一个升级步骤，age 为事件组就绪后经过的时间，第二个返回值表示该步骤是否已经到期
+func (tr Trigger) DueEscalation(age time.Duration) (EscalationStep, bool) {
+	if tr.EscalatedSteps >= len(tr.Escalations) {
+		return EscalationStep{}, false
+	}
+
+	step := tr.Escalations[tr.EscalatedSteps]
+	return step, age >= time.Duration(step.Delay)*time.Second
 }
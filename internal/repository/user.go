@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"errors"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,8 +14,28 @@ type UserStatus string
 const (
 	UserStatusEnabled  UserStatus = "enabled"
 	UserStatusDisabled UserStatus = "disabled"
+	// UserStatusDeleted 用户已删除（软删除），保留用户记录，用于展示历史记录中引用的用户
+	UserStatusDeleted UserStatus = "deleted"
 )
 
+// WithDeletedUsers 查询条件中包含已删除的用户
+// 默认的 Find/Paginate/Count 查询不包含已删除的用户，除非查询条件中明确指定了 status
+func WithDeletedUsers(filter bson.M) bson.M {
+	if _, ok := filter["status"]; !ok {
+		filter["status"] = bson.M{"$exists": true}
+	}
+
+	return filter
+}
+
+// ErrInvalidPassword 用户不存在或者密码错误
+var ErrInvalidPassword = errors.New("invalid email or password")
+
+// IsPasswordHashed 判断密码是否已经使用 bcrypt 哈希
+func IsPasswordHashed(password string) bool {
+	return len(password) == 60 && (strings.HasPrefix(password, "$2a$") || strings.HasPrefix(password, "$2b$") || strings.HasPrefix(password, "$2y$"))
+}
+
 type UserMeta struct {
 	Key   string `bson:"key" json:"key" schema:"key"`
 	Value string `bson:"value" json:"value" schema:"value"`
@@ -31,6 +53,45 @@ func (ums UserMetas) Get(key string) string {
 	return ""
 }
 
+// NotifyChannel 用户的通知渠道，Type 为动作名称（dingding/email/sms_aliyun 等）
+// 钉钉渠道的 Address 为机器人 ID，邮件、短信、语音渠道的 Address 为接收地址（邮箱、手机号），Address 为空时使用用户自身的联系方式
+type NotifyChannel struct {
+	Type    string `bson:"type" json:"type"`
+	Address string `bson:"address" json:"address"`
+	// Severities 该渠道接收的告警级别，为空时接收所有级别的告警
+	Severities []string `bson:"severities" json:"severities"`
+}
+
+// Accept 渠道是否接收 severity 级别的告警，severity 为空时总是接收
+func (nc NotifyChannel) Accept(severity string) bool {
+	if severity == "" || len(nc.Severities) == 0 {
+		return true
+	}
+
+	for _, s := range nc.Severities {
+		if strings.EqualFold(s, severity) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NotifyChannels 用户的通知渠道，按照优先级排序
+type NotifyChannels []NotifyChannel
+
+// Resolve 按照优先级顺序返回接收 severity 级别告警的渠道
+func (ncs NotifyChannels) Resolve(severity string) []NotifyChannel {
+	channels := make([]NotifyChannel, 0)
+	for _, nc := range ncs {
+		if nc.Accept(severity) {
+			channels = append(channels, nc)
+		}
+	}
+
+	return channels
+}
+
 type User struct {
 	ID primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 
@@ -38,27 +99,60 @@ type User struct {
 	Email string `bson:"email" json:"email"`
 	Phone string `bson:"phone" json:"phone"`
 
+	// Password 使用 bcrypt 哈希之后的密码，早期版本中可能为明文，在用户第一次登录成功后迁移
 	Password string `bson:"password" json:"password"`
 	Role     string `bson:"role" json:"role"`
 
 	Metas UserMetas `bson:"metas" json:"metas"`
 
+	NotifyChannels NotifyChannels `bson:"notify_channels" json:"notify_channels"`
+
 	Status UserStatus `bson:"status" json:"status"`
+	// DeletedAt 用户被删除的时间
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 }
 
+// UserMetaQuery 用户元信息查询条件，查询 QueryK = QueryV 的用户的 Field 字段值
+// QueryK/Field 为 name/phone/email/role/status 时查询用户的内置字段，否则查询用户的 Metas
+type UserMetaQuery struct {
+	QueryK string
+	QueryV string
+	Field  string
+	// Fuzzy 为 true 时 QueryV 不区分大小写模糊匹配，QueryK 为空时不过滤用户
+	Fuzzy bool
+	// Limit 最多返回的数量，为 0 时不限制
+	Limit int64
+}
+
 type UserRepo interface {
 	Add(user User) (id primitive.ObjectID, err error)
 	Get(id primitive.ObjectID) (user User, err error)
 	GetByEmail(email string) (user User, err error)
+	// VerifyPassword 校验用户的邮箱和密码，校验失败时返回 ErrInvalidPassword
+	// 密码为明文存储时，校验成功后将其替换为哈希之后的密码
+	VerifyPassword(email, plaintext string) (user User, err error)
 	Find(filter bson.M) (users []User, err error)
 	Paginate(filter bson.M, offset, limit int64) (users []User, next int64, err error)
+	// PaginateAfter 基于 _id 的游标分页，next 为下一页的游标，没有更多数据时为空
+	PaginateAfter(filter bson.M, afterID primitive.ObjectID, limit int64) (users []User, next primitive.ObjectID, err error)
+	// DeleteID/Delete 软删除用户，用户状态修改为 UserStatusDeleted，用户记录仍然保留
 	DeleteID(id primitive.ObjectID) error
 	Delete(filter bson.M) error
+	// PurgeID/Purge 彻底删除用户
+	PurgeID(id primitive.ObjectID) error
+	Purge(filter bson.M) error
+	// Update 更新用户，Password 为空或者与已保存的值相同时保持不变，否则作为明文密码进行哈希
 	Update(id primitive.ObjectID, user User) error
+	// SetPasswordHash 直接保存已经使用 bcrypt 哈希的密码（如从其它系统迁移的用户），不会再次哈希
+	SetPasswordHash(id primitive.ObjectID, hashed string) error
 	Count(filter bson.M) (int64, error)
 
 	GetUserMetas(queryK, queryV, field string) ([]string, error)
+	// QueryUserMetas 查询用户元信息，返回去重之后的值
+	QueryUserMetas(query UserMetaQuery) ([]string, error)
+	// ResolveChannels 根据告警级别返回用户的通知渠道，按照用户设置的优先级排序
+	ResolveChannels(userID primitive.ObjectID, severity string) ([]NotifyChannel, error)
 }
@@ -0,0 +1,35 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotifyChannels_Resolve(t *testing.T) {
+	channels := repository.NotifyChannels{
+		{Type: "phone_call_aliyun", Severities: []string{"critical"}},
+		{Type: "dingding", Address: "5f3d4c2b1a0e9d8c7b6a5f4e"},
+		{Type: "email", Severities: []string{"warning", "Info"}},
+	}
+
+	resolveTypes := func(severity string) []string {
+		types := make([]string, 0)
+		for _, c := range channels.Resolve(severity) {
+			types = append(types, c.Type)
+		}
+		return types
+	}
+
+	assert.Equal(t, []string{"phone_call_aliyun", "dingding"}, resolveTypes("critical"))
+	assert.Equal(t, []string{"dingding", "email"}, resolveTypes("info"))
+	assert.Equal(t, []string{"phone_call_aliyun", "dingding", "email"}, resolveTypes(""))
+	assert.Empty(t, repository.NotifyChannels{}.Resolve("critical"))
+}
+
+func TestIsPasswordHashed(t *testing.T) {
+	assert.True(t, repository.IsPasswordHashed("$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"))
+	assert.False(t, repository.IsPasswordHashed("secret"))
+	assert.False(t, repository.IsPasswordHashed(""))
+}
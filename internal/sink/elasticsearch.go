@@ -0,0 +1,291 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/configs"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/asteria/log"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// maxBulkAttempts 单个文档最多尝试写入的次数，超过之后丢弃
+const maxBulkAttempts = 3
+
+// GroupDocument 归档到 Elasticsearch 的事件组文档，包含事件组及其部分事件样本
+type GroupDocument struct {
+	repository.EventGroup
+	Events     []repository.Event `json:"events"`
+	ArchivedAt time.Time          `json:"archived_at"`
+}
+
+// bulkItem 等待写入的文档
+type bulkItem struct {
+	index    string
+	id       string
+	doc      []byte
+	attempts int
+}
+
+// bulkResponse Elasticsearch bulk API 的响应，只解析需要的字段
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		ID     string `json:"_id"`
+		Status int    `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// ElasticsearchSink 将触发的事件组及其事件样本通过 bulk API 归档到 Elasticsearch/OpenSearch
+// 事件组通过 Enqueue 放入有界队列，由 Run 在后台批量写入，Elasticsearch 变慢或者不可用时丢弃新的事件组，不阻塞调用方
+type ElasticsearchSink struct {
+	conf      configs.Elasticsearch
+	eventRepo repository.EventRepo
+	client    *http.Client
+	queue     chan repository.EventGroup
+}
+
+// NewElasticsearchSink create a new ElasticsearchSink
+func NewElasticsearchSink(conf *configs.Config, eventRepo repository.EventRepo) *ElasticsearchSink {
+	esConf := conf.Elasticsearch
+	if esConf.BatchSize <= 0 {
+		esConf.BatchSize = 100
+	}
+
+	if esConf.QueueSize <= 0 {
+		esConf.QueueSize = 1000
+	}
+
+	if esConf.FlushInterval <= 0 {
+		esConf.FlushInterval = 5 * time.Second
+	}
+
+	if esConf.Index == "" {
+		esConf.Index = "adanos-groups"
+	}
+
+	return &ElasticsearchSink{
+		conf:      esConf,
+		eventRepo: eventRepo,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		queue:     make(chan repository.EventGroup, esConf.QueueSize),
+	}
+}
+
+// Enqueue 将事件组放入归档队列，队列已满时丢弃并返回 false
+func (s *ElasticsearchSink) Enqueue(grp repository.EventGroup) bool {
+	select {
+	case s.queue <- grp:
+		return true
+	default:
+		documentsTotal.WithLabelValues("dropped").Inc()
+		log.WithFields(log.Fields{
+			"group_id": grp.ID.Hex(),
+		}).Warningf("elasticsearch sink queue is full, group dropped")
+		return false
+	}
+}
+
+// Run 从队列中读取事件组，达到 BatchSize 或者每隔 FlushInterval 批量写入 Elasticsearch，ctx 结束时写入剩余的文档后返回
+func (s *ElasticsearchSink) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.conf.FlushInterval)
+	defer ticker.Stop()
+
+	pending := make([]bulkItem, 0, s.conf.BatchSize)
+	for {
+		select {
+		case <-ctx.Done():
+			if len(pending) > 0 {
+				flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				s.Flush(flushCtx, pending)
+				cancel()
+			}
+			return
+		case grp := <-s.queue:
+			item, err := s.document(grp)
+			if err != nil {
+				documentsTotal.WithLabelValues("failed").Inc()
+				log.WithFields(log.Fields{
+					"group_id": grp.ID.Hex(),
+				}).Errorf("build elasticsearch document failed: %v", err)
+				continue
+			}
+
+			pending = append(pending, item)
+			if len(pending) >= s.conf.BatchSize {
+				pending = s.Flush(ctx, pending)
+			}
+		case <-ticker.C:
+			if len(pending) > 0 {
+				pending = s.Flush(ctx, pending)
+			}
+		}
+	}
+}
+
+// document 查询事件组的事件样本，创建待写入的文档
+func (s *ElasticsearchSink) document(grp repository.EventGroup) (bulkItem, error) {
+	events := make([]repository.Event, 0)
+	if s.conf.SampleSize > 0 {
+		samples, _, err := s.eventRepo.Paginate(bson.M{"group_ids": grp.ID}, 0, s.conf.SampleSize)
+		if err != nil {
+			return bulkItem{}, fmt.Errorf("query group events failed: %w", err)
+		}
+
+		events = samples
+	}
+
+	doc, err := json.Marshal(GroupDocument{EventGroup: grp, Events: events, ArchivedAt: time.Now()})
+	if err != nil {
+		return bulkItem{}, err
+	}
+
+	createdAt := grp.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	return bulkItem{index: IndexName(s.conf.Index, createdAt), id: grp.ID.Hex(), doc: doc}, nil
+}
+
+// Flush 通过 bulk API 写入文档，返回需要重试的文档
+// 请求失败、429 以及 5xx 错误的文档会在下一次写入时重试，其它错误的文档直接丢弃
+func (s *ElasticsearchSink) Flush(ctx context.Context, items []bulkItem) []bulkItem {
+	resp, err := s.bulk(ctx, items)
+	if err != nil {
+		log.Errorf("write to elasticsearch failed: %v", err)
+		return s.retry(items)
+	}
+
+	if !resp.Errors {
+		documentsTotal.WithLabelValues("indexed").Add(float64(len(items)))
+		return nil
+	}
+
+	retries := make([]bulkItem, 0)
+	for i, item := range items {
+		if i >= len(resp.Items) {
+			retries = append(retries, item)
+			continue
+		}
+
+		for _, result := range resp.Items[i] {
+			switch {
+			case result.Status >= 200 && result.Status < 300:
+				documentsTotal.WithLabelValues("indexed").Inc()
+			case retryableStatus(result.Status):
+				retries = append(retries, item)
+			default:
+				documentsTotal.WithLabelValues("failed").Inc()
+				reason := ""
+				if result.Error != nil {
+					reason = result.Error.Type + ": " + result.Error.Reason
+				}
+
+				log.WithFields(log.Fields{
+					"index":  item.index,
+					"id":     item.id,
+					"status": result.Status,
+				}).Errorf("index document to elasticsearch failed: %s", reason)
+			}
+		}
+	}
+
+	return s.retry(retries)
+}
+
+// retry 增加文档的尝试次数，超过最大尝试次数的文档被丢弃
+func (s *ElasticsearchSink) retry(items []bulkItem) []bulkItem {
+	retries := make([]bulkItem, 0, len(items))
+	for _, item := range items {
+		item.attempts++
+		if item.attempts >= maxBulkAttempts {
+			documentsTotal.WithLabelValues("failed").Inc()
+			log.WithFields(log.Fields{
+				"index": item.index,
+				"id":    item.id,
+			}).Errorf("index document to elasticsearch failed after %d attempts, dropped", item.attempts)
+			continue
+		}
+
+		retries = append(retries, item)
+	}
+
+	return retries
+}
+
+// bulk 发送 bulk 请求，整个请求失败（网络错误、429、5xx 等）时返回错误
+func (s *ElasticsearchSink) bulk(ctx context.Context, items []bulkItem) (*bulkResponse, error) {
+	var body bytes.Buffer
+	for _, item := range items {
+		action, _ := json.Marshal(map[string]interface{}{
+			"index": map[string]string{"_index": item.index, "_id": item.id},
+		})
+
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(item.doc)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(s.conf.Endpoint, "/")+"/_bulk", &body)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.conf.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+s.conf.APIKey)
+	} else if s.conf.Username != "" {
+		req.SetBasicAuth(s.conf.Username, s.conf.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("elasticsearch responds %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var bulkResp bulkResponse
+	if err := json.Unmarshal(respBody, &bulkResp); err != nil {
+		return nil, fmt.Errorf("invalid bulk response: %w", err)
+	}
+
+	return &bulkResp, nil
+}
+
+// retryableStatus 写入单个文档失败时，是否可以重试
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+var indexDatePattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+// IndexName 根据索引名称模板生成索引名，模板中 {2006.01.02} 形式的部分替换为 t 按照该格式格式化之后的日期
+func IndexName(pattern string, t time.Time) string {
+	return indexDatePattern.ReplaceAllStringFunc(pattern, func(s string) string {
+		return t.Format(s[1 : len(s)-1])
+	})
+}
@@ -0,0 +1,116 @@
+package sink_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/configs"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/internal/sink"
+	mockRepo "github.com/mylxsw/adanos-alert/test/mock/repository"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestIndexName(t *testing.T) {
+	at := time.Date(2020, 11, 9, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "adanos-groups-2020.11", sink.IndexName("adanos-groups-{2006.01}", at))
+	assert.Equal(t, "adanos-2020-11-09", sink.IndexName("adanos-{2006-01-02}", at))
+	assert.Equal(t, "adanos-groups", sink.IndexName("adanos-groups", at))
+}
+
+func TestElasticsearchSink_PartialFailure(t *testing.T) {
+	var lock sync.Mutex
+	requests := make([][]string, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/_bulk", r.URL.Path)
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "elastic", user)
+		assert.Equal(t, "secret", pass)
+
+		ids := make([]string, 0)
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for i := 0; scanner.Scan(); i++ {
+			if i%2 != 0 {
+				continue
+			}
+
+			var action map[string]map[string]string
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &action))
+			ids = append(ids, action["index"]["_id"])
+		}
+
+		lock.Lock()
+		requests = append(requests, ids)
+		first := len(requests) == 1
+		lock.Unlock()
+
+		items := make([]map[string]interface{}, 0)
+		for i, id := range ids {
+			status := 201
+			// 第一次请求中，第一个文档被限流（可重试），第二个文档格式错误（不可重试）
+			if first && i == 0 {
+				status = 429
+			} else if first && i == 1 {
+				status = 400
+			}
+
+			items = append(items, map[string]interface{}{"index": map[string]interface{}{"_id": id, "status": status}})
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": first, "items": items})
+	}))
+	defer server.Close()
+
+	eventRepo := mockRepo.NewMessageRepo()
+	groups := []repository.EventGroup{
+		{ID: primitive.NewObjectID(), CreatedAt: time.Now()},
+		{ID: primitive.NewObjectID(), CreatedAt: time.Now()},
+	}
+	_, _ = eventRepo.Add(repository.Event{Content: "sample", GroupID: []primitive.ObjectID{groups[0].ID}})
+
+	es := sink.NewElasticsearchSink(&configs.Config{Elasticsearch: configs.Elasticsearch{
+		Endpoint:      server.URL,
+		Index:         "adanos-groups-{2006.01}",
+		Username:      "elastic",
+		Password:      "secret",
+		SampleSize:    5,
+		BatchSize:     10,
+		QueueSize:     10,
+		FlushInterval: 20 * time.Millisecond,
+	}}, eventRepo)
+
+	for _, grp := range groups {
+		assert.True(t, es.Enqueue(grp))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	es.Run(ctx)
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	assert.Len(t, requests, 2)
+	assert.Equal(t, []string{groups[0].ID.Hex(), groups[1].ID.Hex()}, requests[0])
+	// 只重试被限流的文档
+	assert.Equal(t, []string{groups[0].ID.Hex()}, requests[1])
+}
+
+func TestElasticsearchSink_QueueFull(t *testing.T) {
+	es := sink.NewElasticsearchSink(&configs.Config{Elasticsearch: configs.Elasticsearch{
+		Endpoint:  "http://127.0.0.1:9200",
+		QueueSize: 1,
+	}}, mockRepo.NewMessageRepo())
+
+	assert.True(t, es.Enqueue(repository.EventGroup{ID: primitive.NewObjectID()}))
+	assert.False(t, es.Enqueue(repository.EventGroup{ID: primitive.NewObjectID()}))
+}
@@ -0,0 +1,165 @@
+package sink
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/configs"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/kafka"
+	"github.com/mylxsw/asteria/log"
+)
+
+// kafkaBatchSize 每次发送到 Kafka 的最大消息数量
+const kafkaBatchSize = 100
+
+// FiredGroup 发送到 Kafka 的事件组
+type FiredGroup struct {
+	repository.EventGroup
+	FiredAt time.Time `json:"fired_at"`
+}
+
+// KafkaProducer 发送消息到 Kafka，返回每条消息的投递结果
+type KafkaProducer interface {
+	Produce(ctx context.Context, topic string, messages []kafka.Message) []kafka.DeliveryReport
+}
+
+// KafkaSink 将触发的事件组以 JSON 格式发送到 Kafka，消息 Key 为规则 ID，保证同一个规则的事件组发送到同一个分区
+// 事件组先放入有界的内存缓冲区，由 Run 在后台批量发送，缓冲区满时按照 OverflowPolicy 丢弃最早的消息或者阻塞调用方
+type KafkaSink struct {
+	conf   configs.Kafka
+	buffer chan kafka.Message
+	// lock 保证 drop-oldest 策略下丢弃与写入的原子性
+	lock sync.Mutex
+}
+
+// NewKafkaSink create a new KafkaSink
+func NewKafkaSink(conf *configs.Config) *KafkaSink {
+	kafkaConf := conf.Kafka
+	if kafkaConf.BufferSize <= 0 {
+		kafkaConf.BufferSize = 1000
+	}
+
+	return &KafkaSink{conf: kafkaConf, buffer: make(chan kafka.Message, kafkaConf.BufferSize)}
+}
+
+// Enqueue 将事件组放入发送缓冲区
+func (s *KafkaSink) Enqueue(grp repository.EventGroup) {
+	value, err := json.Marshal(FiredGroup{EventGroup: grp, FiredAt: time.Now()})
+	if err != nil {
+		log.WithFields(log.Fields{
+			"group_id": grp.ID.Hex(),
+		}).Errorf("encode group for kafka failed: %v", err)
+		return
+	}
+
+	msg := kafka.Message{Key: []byte(grp.Rule.ID.Hex()), Value: value}
+	if s.conf.OverflowPolicy == configs.KafkaOverflowBlock {
+		s.buffer <- msg
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for {
+		select {
+		case s.buffer <- msg:
+			return
+		default:
+		}
+
+		// 缓冲区已满，丢弃最早的消息
+		select {
+		case <-s.buffer:
+			kafkaMessagesTotal.WithLabelValues("dropped").Inc()
+			log.Warningf("kafka sink buffer is full, the oldest message dropped")
+		default:
+		}
+	}
+}
+
+// Run 从缓冲区读取消息批量发送到 Kafka，直到 ctx 结束
+func (s *KafkaSink) Run(ctx context.Context) {
+	producerConf := kafka.Config{
+		Brokers:      s.conf.Brokers,
+		RequiredAcks: -1,
+		Timeout:      10 * time.Second,
+	}
+	if s.conf.TLS {
+		producerConf.TLS = &tls.Config{InsecureSkipVerify: s.conf.TLSSkipVerify}
+	}
+	if s.conf.SASLUsername != "" {
+		producerConf.SASL = &kafka.SASL{Mechanism: s.conf.SASLMechanism, Username: s.conf.SASLUsername, Password: s.conf.SASLPassword}
+	}
+
+	// 创建生产者时不会连接 broker，失败说明配置错误，重试也无法恢复
+	producer, err := kafka.NewProducer(producerConf)
+	if err != nil {
+		log.Errorf("create kafka producer failed, messages for kafka will be dropped: %v", err)
+		s.drain(ctx)
+		return
+	}
+	defer producer.Close()
+
+	s.RunWithProducer(ctx, producer)
+}
+
+// drain 丢弃缓冲区中的消息，直到 ctx 结束，避免 block 策略下 Enqueue 永久阻塞
+func (s *KafkaSink) drain(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.buffer:
+			kafkaMessagesTotal.WithLabelValues("dropped").Inc()
+		}
+	}
+}
+
+// RunWithProducer 使用指定的 producer 发送缓冲区中的消息，直到 ctx 结束
+func (s *KafkaSink) RunWithProducer(ctx context.Context, producer KafkaProducer) {
+	for {
+		var first kafka.Message
+		select {
+		case <-ctx.Done():
+			return
+		case first = <-s.buffer:
+		}
+
+		messages := []kafka.Message{first}
+	drain:
+		for len(messages) < kafkaBatchSize {
+			select {
+			case msg := <-s.buffer:
+				messages = append(messages, msg)
+			default:
+				break drain
+			}
+		}
+
+		s.send(ctx, producer, messages)
+	}
+}
+
+// send 发送消息并记录投递结果，投递失败的消息只记录日志
+func (s *KafkaSink) send(ctx context.Context, producer KafkaProducer, messages []kafka.Message) {
+	sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	for _, report := range producer.Produce(sendCtx, s.conf.Topic, messages) {
+		if report.Err != nil {
+			kafkaMessagesTotal.WithLabelValues("failed").Inc()
+			log.WithFields(log.Fields{
+				"topic": s.conf.Topic,
+				"key":   string(report.Message.Key),
+			}).Errorf("deliver message to kafka failed: %v", report.Err)
+			continue
+		}
+
+		kafkaMessagesTotal.WithLabelValues("delivered").Inc()
+	}
+}
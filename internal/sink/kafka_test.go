@@ -0,0 +1,73 @@
+package sink_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/configs"
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/internal/sink"
+	"github.com/mylxsw/adanos-alert/pkg/kafka"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeProducer struct {
+	lock     sync.Mutex
+	topic    string
+	messages []kafka.Message
+}
+
+func (p *fakeProducer) Produce(ctx context.Context, topic string, messages []kafka.Message) []kafka.DeliveryReport {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.topic = topic
+	reports := make([]kafka.DeliveryReport, 0, len(messages))
+	for _, msg := range messages {
+		p.messages = append(p.messages, msg)
+		reports = append(reports, kafka.DeliveryReport{Message: msg})
+	}
+
+	return reports
+}
+
+func TestKafkaSink_DropOldest(t *testing.T) {
+	ks := sink.NewKafkaSink(&configs.Config{Kafka: configs.Kafka{
+		Brokers:        []string{"127.0.0.1:9092"},
+		Topic:          "alerts",
+		BufferSize:     2,
+		OverflowPolicy: configs.KafkaOverflowDropOldest,
+	}})
+
+	rule := primitive.NewObjectID()
+	groups := make([]repository.EventGroup, 0)
+	for i := 0; i < 3; i++ {
+		grp := repository.EventGroup{ID: primitive.NewObjectID(), Rule: repository.EventGroupRule{ID: rule}}
+		groups = append(groups, grp)
+		// 缓冲区满时丢弃最早的消息，不阻塞
+		ks.Enqueue(grp)
+	}
+
+	producer := &fakeProducer{}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	ks.RunWithProducer(ctx, producer)
+
+	producer.lock.Lock()
+	defer producer.lock.Unlock()
+
+	assert.Equal(t, "alerts", producer.topic)
+	assert.Len(t, producer.messages, 2)
+	for i, msg := range producer.messages {
+		assert.Equal(t, rule.Hex(), string(msg.Key))
+
+		var fired sink.FiredGroup
+		assert.NoError(t, json.Unmarshal(msg.Value, &fired))
+		assert.Equal(t, groups[i+1].ID, fired.ID)
+		assert.False(t, fired.FiredAt.IsZero())
+	}
+}
@@ -0,0 +1,20 @@
+package sink
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// documentsTotal 归档到 Elasticsearch 的事件组文档数量，按照结果（indexed/failed/dropped）区分
+	documentsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "adanos",
+		Subsystem: "elasticsearch_sink",
+		Name:      "documents_total",
+		Help:      "Total number of event group documents archived to elasticsearch, partitioned by the result",
+	}, []string{"result"})
+	// kafkaMessagesTotal 发送到 Kafka 的事件组消息数量，按照结果（delivered/failed/dropped）区分
+	kafkaMessagesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "adanos",
+		Subsystem: "kafka_sink",
+		Name:      "messages_total",
+		Help:      "Total number of fired event group messages sent to kafka, partitioned by the result",
+	}, []string{"result"})
+)
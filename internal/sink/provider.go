@@ -0,0 +1,64 @@
+package sink
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mylxsw/adanos-alert/configs"
+	"github.com/mylxsw/adanos-alert/pubsub"
+	"github.com/mylxsw/container"
+	"github.com/mylxsw/glacier/event"
+	"github.com/mylxsw/glacier/infra"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type ServiceProvider struct{}
+
+func (s ServiceProvider) Register(app container.Container) {
+	app.MustSingleton(NewElasticsearchSink)
+	app.MustSingleton(NewKafkaSink)
+}
+
+func (s ServiceProvider) Boot(app infra.Glacier) {
+	app.MustResolve(func(conf *configs.Config, em event.Manager, es *ElasticsearchSink, ks *KafkaSink) {
+		if conf.Elasticsearch.Enabled() {
+			prometheus.MustRegister(documentsTotal)
+
+			// 事件组转换为 pending 状态（即将触发通知）时归档，只放入队列，不阻塞聚合任务
+			em.Listen(func(ev pubsub.MessageGroupPendingEvent) {
+				es.Enqueue(ev.Group)
+			})
+		}
+
+		if conf.Kafka.Enabled() {
+			prometheus.MustRegister(kafkaMessagesTotal)
+
+			em.Listen(func(ev pubsub.MessageGroupPendingEvent) {
+				ks.Enqueue(ev.Group)
+			})
+		}
+	})
+}
+
+func (s ServiceProvider) Daemon(ctx context.Context, app infra.Glacier) {
+	app.MustResolve(func(conf *configs.Config, es *ElasticsearchSink, ks *KafkaSink) {
+		var wg sync.WaitGroup
+		if conf.Elasticsearch.Enabled() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				es.Run(ctx)
+			}()
+		}
+
+		if conf.Kafka.Enabled() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ks.Run(ctx)
+			}()
+		}
+
+		wg.Wait()
+	})
+}
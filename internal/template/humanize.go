@@ -0,0 +1,174 @@
+package template
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var byteUnits = []string{"B", "KB", "MB", "GB", "TB", "PB", "EB"}
+
+// HumanizeBytes 将字节数格式化为易读的形式，如 1258291 -> 1.2 MB，无法识别为数字时原样返回
+func HumanizeBytes(size interface{}) string {
+	val, ok := numberValue(size)
+	if !ok {
+		return fmt.Sprintf("%v", size)
+	}
+
+	sign := ""
+	if val < 0 {
+		sign = "-"
+		val = -val
+	}
+
+	if val < 1024 {
+		return fmt.Sprintf("%s%d B", sign, int64(math.Round(val)))
+	}
+
+	unit := 0
+	for val >= 1024 && unit < len(byteUnits)-1 {
+		val /= 1024
+		unit++
+	}
+
+	// 四舍五入之后达到 1024 时进位，避免出现 1024.0 KB
+	if math.Round(val*10)/10 >= 1024 && unit < len(byteUnits)-1 {
+		val /= 1024
+		unit++
+	}
+
+	return fmt.Sprintf("%s%.1f %s", sign, val, byteUnits[unit])
+}
+
+// HumanizeDuration 将时长格式化为易读的形式，如 3725s -> 1h 2m，最多展示两个单位
+// 支持 time.Duration、Go 时长格式的字符串（如 1m30s），数字类型被看作秒数
+func HumanizeDuration(duration interface{}) string {
+	d, ok := durationValue(duration)
+	if !ok {
+		return fmt.Sprintf("%v", duration)
+	}
+
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+
+	if d < time.Millisecond {
+		return fmt.Sprintf("%s%dµs", sign, d.Round(time.Microsecond)/time.Microsecond)
+	}
+
+	if d < time.Second {
+		if ms := d.Round(time.Millisecond) / time.Millisecond; ms < 1000 {
+			return fmt.Sprintf("%s%dms", sign, ms)
+		}
+	}
+
+	seconds := int64(d.Round(time.Second) / time.Second)
+	units := []struct {
+		name    string
+		seconds int64
+	}{
+		{"d", 86400},
+		{"h", 3600},
+		{"m", 60},
+		{"s", 1},
+	}
+
+	parts := make([]string, 0, 2)
+	for i, u := range units {
+		if seconds < u.seconds {
+			continue
+		}
+
+		parts = append(parts, fmt.Sprintf("%d%s", seconds/u.seconds, u.name))
+		if i+1 < len(units) {
+			next := units[i+1]
+			if rest := seconds % u.seconds / next.seconds; rest > 0 {
+				parts = append(parts, fmt.Sprintf("%d%s", rest, next.name))
+			}
+		}
+
+		break
+	}
+
+	return sign + strings.Join(parts, " ")
+}
+
+// CommaNumber 为数字添加千分位分隔符，如 1234567.5 -> 1,234,567.5，无法识别为数字时原样返回
+func CommaNumber(number interface{}) string {
+	val, ok := numberValue(number)
+	if !ok {
+		return fmt.Sprintf("%v", number)
+	}
+
+	str := strconv.FormatFloat(val, 'f', -1, 64)
+	sign := ""
+	if strings.HasPrefix(str, "-") {
+		sign = "-"
+		str = str[1:]
+	}
+
+	intPart, fracPart := str, ""
+	if idx := strings.Index(str, "."); idx >= 0 {
+		intPart, fracPart = str[:idx], str[idx:]
+	}
+
+	var sb strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(c)
+	}
+
+	return sign + sb.String() + fracPart
+}
+
+// numberValue 将数字或者数字字符串转换为 float64
+func numberValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+// durationValue 将 time.Duration、时长字符串或者秒数转换为 time.Duration
+func durationValue(v interface{}) (time.Duration, bool) {
+	switch d := v.(type) {
+	case time.Duration:
+		return d, true
+	case string:
+		if parsed, err := time.ParseDuration(strings.TrimSpace(d)); err == nil {
+			return parsed, true
+		}
+	}
+
+	seconds, ok := numberValue(v)
+	if !ok {
+		return 0, false
+	}
+
+	return time.Duration(seconds * float64(time.Second)), true
+}
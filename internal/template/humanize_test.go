@@ -0,0 +1,73 @@
+package template
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHumanizeBytes(t *testing.T) {
+	testCases := []struct {
+		input    interface{}
+		expected string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{1258291, "1.2 MB"},
+		{1048575, "1.0 MB"},
+		{int64(1) << 40, "1.0 TB"},
+		{-2048, "-2.0 KB"},
+		{"1258291", "1.2 MB"},
+		{1023.6, "1024 B"},
+		{"abc", "abc"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, HumanizeBytes(tc.input), "input: %v", tc.input)
+	}
+}
+
+func TestHumanizeDuration(t *testing.T) {
+	testCases := []struct {
+		input    interface{}
+		expected string
+	}{
+		{500 * time.Microsecond, "500µs"},
+		{350 * time.Millisecond, "350ms"},
+		{999600 * time.Microsecond, "1s"},
+		{"1m30s", "1m 30s"},
+		{59.6, "1m"},
+		{3600, "1h"},
+		{3725, "1h 2m"},
+		{"90061", "1d 1h"},
+		{-90 * time.Second, "-1m 30s"},
+		{"abc", "abc"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, HumanizeDuration(tc.input), "input: %v", tc.input)
+	}
+}
+
+func TestCommaNumber(t *testing.T) {
+	testCases := []struct {
+		input    interface{}
+		expected string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1,000"},
+		{1234567, "1,234,567"},
+		{int64(-1234567), "-1,234,567"},
+		{1234.5, "1,234.5"},
+		{"1000000", "1,000,000"},
+		{"abc", "abc"},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, CommaNumber(tc.input), "input: %v", tc.input)
+	}
+}
@@ -80,6 +80,10 @@ func CreateParser(cc SimpleContainer, templateStr string) (*template.Template, e
 		"sql_finger":     misc.SQLFinger,
 		"open_falcon_im": ParseOpenFalconImMessage,
 
+		"commaNumber":      CommaNumber,
+		"humanizeBytes":    HumanizeBytes,
+		"humanizeDuration": HumanizeDuration,
+
 		"json":         jsonFormatter,
 		"json_get":     pkgJSON.Get,
 		"json_gets":    pkgJSON.Gets,
@@ -89,6 +93,9 @@ func CreateParser(cc SimpleContainer, templateStr string) (*template.Template, e
 
 		"serialize":            Serialize,
 		"sort_map_human":       SortMapByKeyHuman,
+		"table":                MarkdownTable,
+		"meta_rows":            EventsMetaRows,
+		"groupBy":              GroupEventsByMeta,
 		"error_notice":         errorNotice,
 		"success_notice":       successNotice,
 		"error_success_notice": errorOrSuccessNotice,
@@ -104,6 +111,8 @@ func CreateParser(cc SimpleContainer, templateStr string) (*template.Template, e
 		"meta_filter_exclude":        MetaFilterExclude,
 		"meta_prefix_filter":         MetaFilterPrefix,
 		"meta_prefix_filter_exclude": MetaFilterPrefixExclude,
+		"meta_suffix_filter":         MetaFilterSuffix,
+		"meta_regex_filter":          MetaFilterRegex,
 
 		"prefix_all_str":      prefixStrArray,
 		"suffix_all_str":      suffixStrArray,
@@ -129,6 +138,7 @@ func CreateParser(cc SimpleContainer, templateStr string) (*template.Template, e
 
 		"html2md":           HTML2Markdown,
 		"md2html":           Markdown2html,
+		"markdown2html":     Markdown2html,
 		"md2confluence":     Markdown2Confluence,
 		"dom_filter_html":   DOMFilterHTML,
 		"dom_filter_html_n": DOMFilterHTMLIndex,
@@ -413,6 +423,39 @@ func MetaFilterPrefixExclude(meta map[string]interface{}, disableKeyPrefixes ...
 	return res
 }
 
+// MetaFilterSuffix 过滤 Meta，只保留以 allowKeySuffix 结尾的项
+func MetaFilterSuffix(meta map[string]interface{}, allowKeySuffix ...string) map[string]interface{} {
+	res := make(map[string]interface{})
+	for k, v := range meta {
+		for _, suffix := range allowKeySuffix {
+			if strings.HasSuffix(k, suffix) {
+				res[k] = v
+				break
+			}
+		}
+	}
+
+	return res
+}
+
+// MetaFilterRegex 过滤 Meta，只保留 Key 与正则表达式匹配的项，正则表达式不合法时返回空
+func MetaFilterRegex(meta map[string]interface{}, keyRegex string) map[string]interface{} {
+	res := make(map[string]interface{})
+
+	re, err := regexp.Compile(keyRegex)
+	if err != nil {
+		return res
+	}
+
+	for k, v := range meta {
+		if re.MatchString(k) {
+			res[k] = v
+		}
+	}
+
+	return res
+}
+
 // Serialize 对象序列化为字符串，用于展示
 func Serialize(data interface{}) string {
 	serialized, err := json.Marshal(data)
@@ -487,6 +530,164 @@ func Implode(elems interface{}, sep string) string {
 	return fmt.Sprintf("%v", elems)
 }
 
+// MarkdownTable 将表头和多行数据渲染为 Markdown 表格，headers 和 rows 中的每一行都可以是任意类型的数组
+// 单元格中的 | 会被转义，换行会被替换为空格，避免破坏表格结构
+func MarkdownTable(headers interface{}, rows interface{}) string {
+	headerCells := toStringSlice(headers)
+	if len(headerCells) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	writeMarkdownTableRow(&sb, headerCells, len(headerCells))
+
+	sb.WriteString("|")
+	for range headerCells {
+		sb.WriteString(" --- |")
+	}
+	sb.WriteString("\n")
+
+	rowsVal := reflect.ValueOf(rows)
+	if rowsVal.Kind() == reflect.Array || rowsVal.Kind() == reflect.Slice {
+		for i := 0; i < rowsVal.Len(); i++ {
+			writeMarkdownTableRow(&sb, toStringSlice(rowsVal.Index(i).Interface()), len(headerCells))
+		}
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// writeMarkdownTableRow 写入表格的一行，列数不足时补齐空单元格，超出时截断
+func writeMarkdownTableRow(sb *strings.Builder, cells []string, columns int) {
+	sb.WriteString("|")
+	for i := 0; i < columns; i++ {
+		cell := ""
+		if i < len(cells) {
+			cell = escapeMarkdownTableCell(cells[i])
+		}
+
+		sb.WriteString(" " + cell + " |")
+	}
+	sb.WriteString("\n")
+}
+
+var markdownTableCellReplacer = strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ")
+
+func escapeMarkdownTableCell(cell string) string {
+	return markdownTableCellReplacer.Replace(cell)
+}
+
+// toStringSlice 将数组转换为字符串数组，非数组类型的值作为只有一个元素的数组
+func toStringSlice(elems interface{}) []string {
+	if elems == nil {
+		return []string{}
+	}
+
+	if strs, ok := elems.([]string); ok {
+		return strs
+	}
+
+	elemsVal := reflect.ValueOf(elems)
+	if elemsVal.Kind() != reflect.Array && elemsVal.Kind() != reflect.Slice {
+		return []string{fmt.Sprintf("%v", elems)}
+	}
+
+	strs := make([]string, 0, elemsVal.Len())
+	for i := 0; i < elemsVal.Len(); i++ {
+		strs = append(strs, fmt.Sprintf("%v", elemsVal.Index(i).Interface()))
+	}
+
+	return strs
+}
+
+// EventsMetaRows 从事件列表中提取指定的 meta 字段作为表格数据，配合 table 函数使用
+// 如 {{ table (explode "host,level" ",") (meta_rows (.Events 10) "host" "level") }}
+func EventsMetaRows(events []repository.Event, keys ...string) [][]string {
+	rows := make([][]string, 0, len(events))
+	for _, evt := range events {
+		row := make([]string, 0, len(keys))
+		for _, k := range keys {
+			if v, ok := evt.Meta[k]; ok && v != nil {
+				row = append(row, fmt.Sprintf("%v", v))
+			} else {
+				row = append(row, "")
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// EventsBucket 按照 meta 字段分组后的事件
+type EventsBucket struct {
+	Key    string
+	Count  int
+	Events []repository.Event
+}
+
+// GroupEventsByMeta 按照 meta 字段的值对事件分组，分组按照事件数量倒序排列，数量相同时按照 Key 排序
+// metaKey 优先作为完整的 meta 字段名查找，找不到时按照 . 分隔逐级查找嵌套的字段，如 log.file.path
+func GroupEventsByMeta(events []repository.Event, metaKey string) []EventsBucket {
+	buckets := make([]EventsBucket, 0)
+	indexes := make(map[string]int)
+	for _, evt := range events {
+		key := ""
+		if v, ok := lookupMeta(evt.Meta, metaKey); ok && v != nil {
+			key = fmt.Sprintf("%v", v)
+		}
+
+		idx, ok := indexes[key]
+		if !ok {
+			idx = len(buckets)
+			indexes[key] = idx
+			buckets = append(buckets, EventsBucket{Key: key})
+		}
+
+		buckets[idx].Count++
+		buckets[idx].Events = append(buckets[idx].Events, evt)
+	}
+
+	sort.SliceStable(buckets, func(i, j int) bool {
+		if buckets[i].Count != buckets[j].Count {
+			return buckets[i].Count > buckets[j].Count
+		}
+
+		return buckets[i].Key < buckets[j].Key
+	})
+
+	return buckets
+}
+
+// lookupMeta 查找 meta 字段，支持使用 . 分隔的嵌套字段
+func lookupMeta(meta map[string]interface{}, key string) (interface{}, bool) {
+	if v, ok := meta[key]; ok {
+		return v, true
+	}
+
+	var current interface{} = meta
+	var ok bool
+	for _, seg := range strings.Split(key, ".") {
+		switch m := current.(type) {
+		case map[string]interface{}:
+			current, ok = m[seg]
+		case repository.EventMeta:
+			current, ok = m[seg]
+		case bson.M:
+			current, ok = m[seg]
+		default:
+			return nil, false
+		}
+
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
 // NumberBeauty 字符串数字格式化
 func NumberBeauty(number interface{}) string {
 	str, ok := number.(string)
@@ -697,7 +898,8 @@ func HTML2Markdown(ht string) string {
 	return mdStr
 }
 
-// Markdown2html 将 Markdown 转换为 HTML
+// Markdown2html 将 Markdown 转换为 HTML，输出的 HTML 经过过滤，移除了脚本等不安全的内容
+// 用于邮件等需要 HTML 格式的通知渠道，同一个模板在钉钉等渠道中可以直接使用 Markdown 内容
 func Markdown2html(mc string) string {
 	unsafe := blackfriday.Run([]byte(mc))
 	return string(bluemonday.UGCPolicy().SanitizeBytes(unsafe))
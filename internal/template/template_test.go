@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/mylxsw/adanos-alert/internal/repository"
 	pkgJSON "github.com/mylxsw/adanos-alert/pkg/json"
 	"github.com/mylxsw/container"
 	"github.com/stretchr/testify/assert"
@@ -232,6 +233,20 @@ func TestMetaFilter(t *testing.T) {
 	assert.Equal(t, "[message.k1: v1][message.k2: v2][message.k3: v3][message.k4: v4][version: 1.0]", res)
 }
 
+func TestMetaFilterSuffixAndRegex(t *testing.T) {
+	meta := map[string]interface{}{
+		"request.error":    "timeout",
+		"request.duration": "3s",
+		"db.error":         "deadlock",
+		"version":          "1.0",
+	}
+
+	assert.Equal(t, map[string]interface{}{"request.error": "timeout", "db.error": "deadlock"}, MetaFilterSuffix(meta, ".error"))
+	assert.Equal(t, map[string]interface{}{"request.error": "timeout", "request.duration": "3s", "db.error": "deadlock"}, MetaFilterSuffix(meta, ".error", ".duration"))
+	assert.Equal(t, map[string]interface{}{"request.error": "timeout", "request.duration": "3s"}, MetaFilterRegex(meta, `^request\.`))
+	assert.Empty(t, MetaFilterRegex(meta, `(`))
+}
+
 func TestSortMapByKeyHuman(t *testing.T) {
 	data := map[string]interface{}{
 		"@timestamp":      "123456",
@@ -271,6 +286,50 @@ func TestImplode(t *testing.T) {
 	assert.Equal(t, "{zhangsan 11},{lisi 22}", Implode(users, ","))
 }
 
+func TestMarkdownTable(t *testing.T) {
+	rows := [][]interface{}{
+		{"web-01", 12},
+		{"a|b", "line1\nline2"},
+		{"short"},
+	}
+
+	expected := `| host | count |
+| --- | --- |
+| web-01 | 12 |
+| a\|b | line1 line2 |
+| short |  |`
+	assert.Equal(t, expected, MarkdownTable([]string{"host", "count"}, rows))
+	assert.Equal(t, "", MarkdownTable([]string{}, rows))
+	assert.Equal(t, "| host |\n| --- |", MarkdownTable([]string{"host"}, nil))
+
+	events := []repository.Event{
+		{Meta: repository.EventMeta{"host": "web-01", "level": "error"}},
+		{Meta: repository.EventMeta{"host": "web-02"}},
+	}
+	assert.Equal(t, [][]string{{"web-01", "error"}, {"web-02", ""}}, EventsMetaRows(events, "host", "level"))
+}
+
+func TestGroupEventsByMeta(t *testing.T) {
+	events := []repository.Event{
+		{Content: "1", Meta: repository.EventMeta{"log.file.path": "/var/log/a.log"}},
+		{Content: "2", Meta: repository.EventMeta{"log": map[string]interface{}{"file": map[string]interface{}{"path": "/var/log/b.log"}}}},
+		{Content: "3", Meta: repository.EventMeta{"log.file.path": "/var/log/b.log"}},
+		{Content: "4", Meta: repository.EventMeta{}},
+		{Content: "5", Meta: repository.EventMeta{"log.file.path": "/var/log/c.log"}},
+	}
+
+	buckets := GroupEventsByMeta(events, "log.file.path")
+	assert.Equal(t, 4, len(buckets))
+	assert.Equal(t, "/var/log/b.log", buckets[0].Key)
+	assert.Equal(t, 2, buckets[0].Count)
+	assert.Equal(t, "2", buckets[0].Events[0].Content)
+	assert.Equal(t, "", buckets[1].Key)
+	assert.Equal(t, "/var/log/a.log", buckets[2].Key)
+	assert.Equal(t, "/var/log/c.log", buckets[3].Key)
+
+	assert.Empty(t, GroupEventsByMeta(nil, "host"))
+}
+
 func TestNumberBeauty(t *testing.T) {
 	parsed, _ := Parse(container.New(), `{{ index .Data "number" | format "%.0f" | number_beauty }} | {{ index .Data "number" | number_beauty }}`, struct {
 		Data map[string]interface{}
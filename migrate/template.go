@@ -114,6 +114,20 @@ var predefinedTemplates = []repository.Template{
 		Content:     `{{ .RuleTemplateParsed }}`,
 		Type:        repository.TemplateTypeTemplate,
 	},
+	{
+		Name:        "报警恢复通知",
+		Description: "报警恢复时发送的通知",
+		Content: `## ✅ {{ .Rule.Name }} 已恢复
+
+{{ range $i, $evt := .Events 4 }}- 来源：**{{ $evt.Origin }}**，标签：{{ $evt.Tags }}
+{{ cutoff 400 $evt.Content | ident "    > " }}
+{{ end }}
+
+---
+
+[共 {{ .Group.MessageCount }} 条，查看详细]({{ .PreviewURL }})`,
+		Type: repository.TemplateTypeRecovery,
+	},
 }
 
 func initPredefinedTemplates(conf *configs.Config, repo repository.TemplateRepo) {
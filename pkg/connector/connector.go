@@ -2,12 +2,17 @@ package connector
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/rand"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/mylxsw/adanos-alert/internal/extension"
 	"github.com/mylxsw/asteria/log"
@@ -18,16 +23,186 @@ import (
 type Connector struct {
 	servers []string
 	token   string
+	client  *http.Client
+
+	maxAttempts int
+	baseDelay   time.Duration
+
+	compression          bool
+	compressionThreshold int
 }
 
+// DefaultCompressionThreshold 默认的压缩阈值，请求体小于该值时不进行压缩
+const DefaultCompressionThreshold = 1024
+
 // NewConnector create a new connector
+// 所有未指定 TLS 配置的连接器共享同一个 http.Client，连接池在连接器之间复用
 func NewConnector(token string, servers ...string) *Connector {
-	return &Connector{servers: servers, token: token}
+	return &Connector{servers: servers, token: token, maxAttempts: 1, client: defaultHTTPClient}
+}
+
+// NewConnectorWithTLS create a new connector with tls config, used for mTLS
+// 指定了 TLS 配置时会创建独立的连接池，调用方应该复用返回的连接器
+func NewConnectorWithTLS(token string, tlsConfig *tls.Config, servers ...string) *Connector {
+	conn := NewConnector(token, servers...)
+	if tlsConfig != nil {
+		conn.client = newHTTPClient(tlsConfig)
+	}
+
+	return conn
+}
+
+// WithHTTPClient 使用自定义的 http.Client 发送请求
+func (conn *Connector) WithHTTPClient(client *http.Client) *Connector {
+	if client != nil {
+		conn.client = client
+	}
+
+	return conn
+}
+
+// DefaultRequestTimeout 默认的单次请求超时时间
+const DefaultRequestTimeout = 30 * time.Second
+
+// defaultHTTPClient 默认的 http.Client，Transport 只创建一次，包级别的 Send 函数以及所有连接器共享该连接池
+var defaultHTTPClient = newHTTPClient(nil)
+
+// newHTTPClient 创建一个启用了连接池的 http.Client，连接会在多次请求之间复用
+func newHTTPClient(tlsConfig *tls.Config) *http.Client {
+	return &http.Client{
+		Timeout: DefaultRequestTimeout,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   10 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSClientConfig:       tlsConfig,
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   10,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+	}
+}
+
+// WithRetry 设置每个服务器的最大尝试次数，失败后按照 baseDelay 指数退避（附带随机抖动）重试，
+// 所有重试均失败后才会切换到下一个服务器
+func (conn *Connector) WithRetry(maxAttempts int, baseDelay time.Duration) *Connector {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	conn.maxAttempts = maxAttempts
+	conn.baseDelay = baseDelay
+	return conn
+}
+
+// WithCompression 启用请求体 gzip 压缩，只有请求体大小超过压缩阈值时才会压缩
+func (conn *Connector) WithCompression() *Connector {
+	conn.compression = true
+	if conn.compressionThreshold <= 0 {
+		conn.compressionThreshold = DefaultCompressionThreshold
+	}
+
+	return conn
+}
+
+// WithCompressionThreshold 设置压缩阈值（字节），请求体小于该值时不压缩
+func (conn *Connector) WithCompressionThreshold(threshold int) *Connector {
+	conn.compressionThreshold = threshold
+	return conn
+}
+
+// Send send a message to adanos server, return the event id created by server
+func (conn *Connector) Send(ctx context.Context, evt *Event) (string, error) {
+	return conn.send(ctx, evt.toCommonEvent())
+}
+
+// ServerError 单个服务器发送失败的错误信息
+type ServerError struct {
+	Server string
+	Err    error
+}
+
+// SendError 发送到所有服务器均失败时返回的错误，包含每个服务器的失败原因
+type SendError struct {
+	Errors []ServerError
+}
+
+func (e *SendError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, se := range e.Errors {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", se.Server, se.Err))
+	}
+
+	return fmt.Sprintf("send to all servers failed: [%s]", strings.Join(msgs, "; "))
 }
 
-// Send send a message to adanos server
-func (conn *Connector) Send(ctx context.Context, evt *Event) error {
-	return Send(ctx, conn.servers, conn.token, evt.meta, evt.tags, evt.origin, evt.ctl.toExtensionEventControl(), evt.content)
+// statusError 服务端返回了非 2xx 的响应码
+type statusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("unexpected response [%d] %s", e.StatusCode, e.Body)
+}
+
+// BatchFailure 批量发送时单个事件的失败信息
+type BatchFailure struct {
+	Index int
+	Err   error
+}
+
+// BatchError 批量发送时部分事件失败返回的错误
+type BatchError struct {
+	Failures []BatchFailure
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		msgs = append(msgs, fmt.Sprintf("#%d: %v", f.Index, f.Err))
+	}
+
+	return fmt.Sprintf("%d events send failed: [%s]", len(e.Failures), strings.Join(msgs, "; "))
+}
+
+// FailedIndexes 返回发送失败的事件索引
+func (e *BatchError) FailedIndexes() []int {
+	indexes := make([]int, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		indexes = append(indexes, f.Index)
+	}
+
+	return indexes
+}
+
+// isNotFound 判断是否所有服务器都响应了 404
+func isNotFound(err error) bool {
+	sendErr, ok := err.(*SendError)
+	if !ok {
+		return false
+	}
+
+	for _, se := range sendErr.Errors {
+		if st, ok := errors.Cause(se.Err).(statusError); !ok || st.StatusCode != http.StatusNotFound {
+			return false
+		}
+	}
+
+	return len(sendErr.Errors) > 0
+}
+
+// retryable 判断请求失败后是否需要重试，4xx 类错误重试也不会成功
+func retryable(err error) bool {
+	if se, ok := errors.Cause(err).(statusError); ok {
+		return se.StatusCode >= 500
+	}
+
+	return true
 }
 
 // Event is a adanos alert message
@@ -58,6 +233,16 @@ func NewEvent(content string) *Event {
 	return &Event{content: content, tags: make([]string, 0), meta: make(map[string]interface{})}
 }
 
+func (m *Event) toCommonEvent() extension.CommonEvent {
+	return extension.CommonEvent{
+		Content: m.content,
+		Meta:    m.meta,
+		Tags:    m.tags,
+		Origin:  m.origin,
+		Control: m.ctl.toExtensionEventControl(),
+	}
+}
+
 func (m *Event) WithTags(tags ...string) *Event {
 	m.tags = append(m.tags, tags...)
 	return m
@@ -85,61 +270,234 @@ func (m *Event) WithMeta(key string, value interface{}) *Event {
 	return m
 }
 
-// Send send a message to adanos servers
-func Send(ctx context.Context, servers []string, token string, meta map[string]interface{}, tags []string, origin string, ctl extension.EventControl, message string) error {
-	evt := extension.CommonEvent{
+// Send send a message to adanos servers, return the event id created by server
+// 如果事件被服务端抑制，返回的 id 为空字符串
+func Send(ctx context.Context, servers []string, token string, meta map[string]interface{}, tags []string, origin string, ctl extension.EventControl, message string) (string, error) {
+	return NewConnector(token, servers...).send(ctx, extension.CommonEvent{
 		Content: message,
 		Meta:    meta,
 		Tags:    tags,
 		Origin:  origin,
 		Control: ctl,
-	}
+	})
+}
+
+func (conn *Connector) send(ctx context.Context, evt extension.CommonEvent) (string, error) {
 	data, _ := json.Marshal(evt)
 
+	respBody, err := conn.request(ctx, "/api/events/", evt, data)
+	if err != nil {
+		return "", err
+	}
+
+	var idResp idResponse
+	if err := json.Unmarshal(respBody, &idResp); err != nil {
+		return "", errors.Wrap(err, "parse response body failed")
+	}
+
+	return idResp.ID, nil
+}
+
+// SendBatch 批量发送事件到 Adanos 服务器，所有事件在一次请求中发送，服务端按照顺序处理
+// 如果服务端不支持批量接口（响应 404），则自动降级为逐条发送
+// 部分事件发送失败时，返回 *BatchError，其中包含了失败事件的索引
+func (conn *Connector) SendBatch(ctx context.Context, events []*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	commonEvents := make([]extension.CommonEvent, len(events))
+	for i, evt := range events {
+		commonEvents[i] = evt.toCommonEvent()
+	}
+
+	data, _ := json.Marshal(commonEvents)
+	respBody, err := conn.request(ctx, "/api/events/batch/", commonEvents, data)
+	if err != nil {
+		if isNotFound(err) {
+			return conn.sendOneByOne(ctx, commonEvents)
+		}
+
+		return err
+	}
+
+	var batchResp batchResponse
+	if err := json.Unmarshal(respBody, &batchResp); err != nil {
+		return errors.Wrap(err, "parse response body failed")
+	}
+
+	if len(batchResp.Failures) == 0 {
+		return nil
+	}
+
+	batchErr := &BatchError{Failures: make([]BatchFailure, 0, len(batchResp.Failures))}
+	for _, f := range batchResp.Failures {
+		batchErr.Failures = append(batchErr.Failures, BatchFailure{Index: f.Index, Err: errors.New(f.Error)})
+	}
+
+	return batchErr
+}
+
+// sendOneByOne 逐条发送事件，用于服务端不支持批量接口时的降级
+func (conn *Connector) sendOneByOne(ctx context.Context, events []extension.CommonEvent) error {
+	batchErr := &BatchError{Failures: make([]BatchFailure, 0)}
+	for i, evt := range events {
+		if _, err := conn.send(ctx, evt); err != nil {
+			batchErr.Failures = append(batchErr.Failures, BatchFailure{Index: i, Err: err})
+		}
+	}
+
+	if len(batchErr.Failures) > 0 {
+		return batchErr
+	}
+
+	return nil
+}
+
+// request 依次向所有服务器发送请求，直到有一个服务器请求成功为止，返回成功的响应体
+func (conn *Connector) request(ctx context.Context, path string, payload interface{}, data []byte) ([]byte, error) {
+	sendErr := &SendError{Errors: make([]ServerError, 0)}
+	for _, s := range conn.servers {
+		respBody, err := conn.requestWithRetry(ctx, path, payload, data, s)
+		if err == nil {
+			return respBody, nil
+		}
+
+		log.Warningf("send to server %s failed: %v", s, err)
+		sendErr.Errors = append(sendErr.Errors, ServerError{Server: s, Err: err})
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(sendErr.Errors) == 0 {
+		return nil, errors.New("no adanos server available")
+	}
+
+	return nil, sendErr
+}
+
+func (conn *Connector) requestWithRetry(ctx context.Context, path string, payload interface{}, data []byte, server string) ([]byte, error) {
 	var err error
-	for _, s := range servers {
-		if err = sendEventToServer(ctx, evt, data, s, token); err == nil {
+	for attempt := 1; attempt <= conn.maxAttempts; attempt++ {
+		var respBody []byte
+		if respBody, err = conn.sendToServer(ctx, path, payload, data, server); err == nil || !retryable(err) {
+			return respBody, err
+		}
+
+		if attempt == conn.maxAttempts {
 			break
 		}
 
-		log.Warningf("send to server %s failed: %v", s, err)
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(err, "retry canceled: %v", ctx.Err())
+		case <-time.After(backoff(conn.baseDelay, attempt)):
+		}
+	}
+
+	if conn.maxAttempts > 1 {
+		return nil, errors.Wrapf(err, "failed after %d attempts", conn.maxAttempts)
+	}
+
+	return nil, err
+}
+
+// backoff 计算第 attempt 次失败后的等待时间：baseDelay * 2^(attempt-1)，并附加最多 50% 的随机抖动
+func backoff(baseDelay time.Duration, attempt int) time.Duration {
+	if baseDelay <= 0 {
+		return 0
+	}
+
+	delay := baseDelay << uint(attempt-1)
+	if delay <= 0 {
+		delay = baseDelay
+	}
+
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+// idResponse 服务端创建事件后的响应
+type idResponse struct {
+	ID string `json:"id"`
+}
+
+// batchResponse 服务端批量创建事件后的响应
+type batchResponse struct {
+	IDs      []string `json:"ids"`
+	Failures []struct {
+		Index int    `json:"index"`
+		Error string `json:"error"`
+	} `json:"failures"`
+}
+
+// compress 使用 gzip 压缩请求体，返回压缩后的数据以及是否进行了压缩
+func (conn *Connector) compress(data []byte) ([]byte, bool, error) {
+	if !conn.compression || len(data) < conn.compressionThreshold {
+		return data, false, nil
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, false, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, false, err
 	}
 
-	return err
+	return buf.Bytes(), true, nil
 }
 
-func sendEventToServer(ctx context.Context, evt extension.CommonEvent, data []byte, adanosServer, adanosToken string) error {
-	reqURL := fmt.Sprintf("%s/api/events/", strings.TrimRight(adanosServer, "/"))
+func (conn *Connector) sendToServer(ctx context.Context, path string, payload interface{}, data []byte, adanosServer string) ([]byte, error) {
+	reqURL := fmt.Sprintf("%s%s", strings.TrimRight(adanosServer, "/"), path)
 
 	if log.DebugEnabled() {
 		log.WithFields(log.Fields{
-			"event": evt,
+			"payload": payload,
 		}).Debugf("request: %v", reqURL)
 	}
 
-	client := &http.Client{}
-	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewReader(data))
+	body, compressed, err := conn.compress(data)
 	if err != nil {
-		return errors.Wrap(err, "create request failed")
+		return nil, errors.Wrap(err, "compress request body failed")
 	}
 
-	if adanosToken != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", adanosToken))
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "create request failed")
+	}
+
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Type", "application/json")
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	if conn.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", conn.token))
 	}
 
-	resp, err := client.Do(req)
+	resp, err := conn.client.Do(req)
 	if err != nil {
-		return errors.Wrap(err, "request failed")
+		return nil, errors.Wrap(err, "request failed")
 	}
 	defer resp.Body.Close()
 
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return errors.Wrap(err, "read response body failed")
+		return nil, errors.Wrap(err, "read response body failed")
 	}
 
 	if log.DebugEnabled() {
 		log.Debugf("response: %v", string(respBody))
 	}
-	return nil
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, statusError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	return respBody, nil
 }
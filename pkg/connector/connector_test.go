@@ -1,22 +1,224 @@
 package connector_test
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/mylxsw/adanos-alert/internal/extension"
 	"github.com/mylxsw/adanos-alert/pkg/connector"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestSend(t *testing.T) {
 	ctx, _ := context.WithTimeout(context.TODO(), 1*time.Second)
-	assert.NoError(t, connector.NewConnector("", "http://localhost:19999").Send(
+	_, err := connector.NewConnector("", "http://localhost:19999").Send(
 		ctx,
 		connector.NewEvent("Hello, world").
 			WithMeta("occur_at", time.Now()).
 			WithMeta("user", "adanos").
 			WithTags("hello", "connector").
 			WithOrigin("connector"),
-	))
+	)
+	assert.NoError(t, err)
+}
+
+func TestSendWithRetry(t *testing.T) {
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requestCount, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"id":"5f0b3b4b2c5b1e2b3c4d5e6f"}`))
+	}))
+	defer server.Close()
+
+	conn := connector.NewConnector("", server.URL).WithRetry(3, 10*time.Millisecond)
+	id, err := conn.Send(context.TODO(), connector.NewEvent("Hello, world"))
+	assert.NoError(t, err)
+	assert.Equal(t, "5f0b3b4b2c5b1e2b3c4d5e6f", id)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&requestCount))
+}
+
+func TestSendWithRetryFailed(t *testing.T) {
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requestCount, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, err := connector.NewConnector("", server.URL, server.URL).
+		WithRetry(2, time.Millisecond).
+		Send(context.TODO(), connector.NewEvent("Hello, world"))
+	assert.Error(t, err)
+
+	sendErr, ok := err.(*connector.SendError)
+	assert.True(t, ok)
+	assert.Len(t, sendErr.Errors, 2)
+	assert.EqualValues(t, 4, atomic.LoadInt32(&requestCount))
+}
+
+func TestSendWithRetryCanceled(t *testing.T) {
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requestCount, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := connector.NewConnector("", server.URL, server.URL).
+		WithRetry(10, time.Second).
+		Send(ctx, connector.NewEvent("Hello, world"))
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&requestCount))
+}
+
+func TestSendBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/events/batch/", r.URL.Path)
+
+		var events []map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&events))
+		assert.Len(t, events, 3)
+		assert.Equal(t, "event 1", events[1]["content"])
+
+		_, _ = w.Write([]byte(`{"ids":["a","","c"],"failures":[{"index":1,"error":"save failed"}]}`))
+	}))
+	defer server.Close()
+
+	err := connector.NewConnector("", server.URL).SendBatch(context.TODO(), []*connector.Event{
+		connector.NewEvent("event 0"),
+		connector.NewEvent("event 1"),
+		connector.NewEvent("event 2"),
+	})
+	assert.Error(t, err)
+
+	batchErr, ok := err.(*connector.BatchError)
+	assert.True(t, ok)
+	assert.EqualValues(t, []int{1}, batchErr.FailedIndexes())
+}
+
+func TestSendBatchFallback(t *testing.T) {
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/events/batch/" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var evt map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&evt))
+		received = append(received, evt["content"].(string))
+
+		_, _ = w.Write([]byte(`{"id":"a"}`))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, connector.NewConnector("", server.URL).SendBatch(context.TODO(), []*connector.Event{
+		connector.NewEvent("event 0"),
+		connector.NewEvent("event 1"),
+	}))
+	assert.EqualValues(t, []string{"event 0", "event 1"}, received)
+}
+
+func TestSendWithCompression(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.EqualValues(t, len(body), r.ContentLength)
+
+		var reader io.Reader = bytes.NewReader(body)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gr, err := gzip.NewReader(reader)
+			assert.NoError(t, err)
+			reader = gr
+		}
+
+		var evt map[string]interface{}
+		assert.NoError(t, json.NewDecoder(reader).Decode(&evt))
+
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"id":"%s"}`, r.Header.Get("Content-Encoding"))))
+	}))
+	defer server.Close()
+
+	conn := connector.NewConnector("", server.URL).WithCompression().WithCompressionThreshold(512)
+
+	id, err := conn.Send(context.TODO(), connector.NewEvent("small"))
+	assert.NoError(t, err)
+	assert.Equal(t, "", id)
+
+	id, err = conn.Send(context.TODO(), connector.NewEvent(strings.Repeat("large event content ", 100)))
+	assert.NoError(t, err)
+	assert.Equal(t, "gzip", id)
+}
+
+func TestNewConnectorWithTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"tls"}`))
+	}))
+	defer server.Close()
+
+	_, err := connector.NewConnector("", server.URL).Send(context.TODO(), connector.NewEvent("Hello, world"))
+	assert.Error(t, err)
+
+	certPool := x509.NewCertPool()
+	certPool.AddCert(server.Certificate())
+
+	id, err := connector.NewConnectorWithTLS("", &tls.Config{RootCAs: certPool}, server.URL).
+		Send(context.TODO(), connector.NewEvent("Hello, world"))
+	assert.NoError(t, err)
+	assert.Equal(t, "tls", id)
+}
+
+func TestSendReuseConnections(t *testing.T) {
+	var newConns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"a"}`))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&newConns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	// 包级别的 Send 函数每次调用都会创建新的连接器，连接器之间应该共享连接池
+	for i := 0; i < 3; i++ {
+		_, err := connector.Send(context.TODO(), []string{server.URL}, "", nil, nil, "", extension.EventControl{}, "Hello, world")
+		assert.NoError(t, err)
+	}
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&newConns))
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"id":"a"}`))
+	}))
+	defer server.Close()
+
+	_, err := connector.NewConnector("", server.URL).
+		WithHTTPClient(&http.Client{Timeout: 10 * time.Millisecond}).
+		Send(context.TODO(), connector.NewEvent("Hello, world"))
+	assert.Error(t, err)
 }
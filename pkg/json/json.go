@@ -35,9 +35,13 @@ func GetArray(key string, body string) []string {
 
 // Get 从json中提取单个值
 func Get(key string, defaultValue string, body string) string {
+	return get(key, defaultValue, []byte(body))
+}
+
+func get(key string, defaultValue string, data []byte) string {
 	keys := strings.Split(key, ".")
 
-	value, dataType, _, err := jsonparser.Get([]byte(body), keys...)
+	value, dataType, _, err := jsonparser.Get(data, keys...)
 	if err != nil {
 		return defaultValue
 	}
@@ -76,3 +80,88 @@ func Get(key string, defaultValue string, body string) string {
 
 	return defaultValue
 }
+
+// Document 是一个 json 文档，用于对同一个 json 多次查询时，避免每次查询都重新转换和扫描整个文档
+// Document 不是并发安全的
+type Document struct {
+	data  []byte
+	cache map[string]string
+}
+
+// Parse 创建一个 json 文档
+func Parse(body string) *Document {
+	return &Document{data: []byte(body), cache: make(map[string]string)}
+}
+
+// Gets 从文档中提取单个值，可以使用逗号分割多个key作为备选，与 Gets 函数的行为一致
+func (doc *Document) Gets(key string, defaultValue string) string {
+	for _, k := range strings.Split(key, ",") {
+		if res := doc.Get(k, ""); res != "" {
+			return res
+		}
+	}
+
+	return defaultValue
+}
+
+// Get 从文档中提取单个值，与 Get 函数的行为一致，查询结果会被缓存
+func (doc *Document) Get(key string, defaultValue string) string {
+	res, ok := doc.cache[key]
+	if !ok {
+		res = get(key, "", doc.data)
+		doc.cache[key] = res
+	}
+
+	if res == "" {
+		return defaultValue
+	}
+
+	return res
+}
+
+// ArrayLen 返回 key 对应的数组长度，key 不存在或者不是数组时返回 0
+func (doc *Document) ArrayLen(key string) int {
+	value, dataType, _, err := jsonparser.Get(doc.data, splitKey(key)...)
+	if err != nil || dataType != jsonparser.Array {
+		return 0
+	}
+
+	count := 0
+	_, _ = jsonparser.ArrayEach(value, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		count++
+	})
+
+	return count
+}
+
+// GetInt 返回 key 对应的整数值，key 不存在或者无法转换为整数时返回 defaultValue
+func (doc *Document) GetInt(key string, defaultValue int) int {
+	value, dataType, _, err := jsonparser.Get(doc.data, splitKey(key)...)
+	if err != nil {
+		return defaultValue
+	}
+
+	switch dataType {
+	case jsonparser.Number:
+		if res, err := jsonparser.ParseInt(value); err == nil {
+			return int(res)
+		}
+		if res, err := jsonparser.ParseFloat(value); err == nil {
+			return int(res)
+		}
+	case jsonparser.String:
+		if res, err := strconv.Atoi(strings.TrimSpace(string(value))); err == nil {
+			return res
+		}
+	}
+
+	return defaultValue
+}
+
+func splitKey(key string) []string {
+	if key == "" {
+		return []string{}
+	}
+
+	return strings.Split(key, ".")
+}
@@ -0,0 +1,54 @@
+package json_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/pkg/json"
+	"github.com/stretchr/testify/assert"
+)
+
+var testContent = `{"log_level": "error", "message": "request failed", "context": {"user_id": 123, "ratio": 0.5, "ok": false, "ext": null}, "tags": ["a", "b"]}`
+
+func TestDocument_Gets(t *testing.T) {
+	doc := json.Parse(testContent)
+	for _, key := range []string{"log_level", "message", "context.user_id", "context.ratio", "context.ok", "context.ext", "not_exist", "not_exist,log_level", "tags.[1]"} {
+		assert.Equal(t, json.Gets(key, "default", testContent), doc.Gets(key, "default"), key)
+	}
+
+	assert.Equal(t, `["a", "b"]`, doc.Get("tags", ""))
+	assert.Equal(t, "default", json.Parse("not a json").Gets("message", "default"))
+}
+
+func buildLargeContent() string {
+	items := make([]string, 0)
+	for i := 0; i < 100; i++ {
+		items = append(items, fmt.Sprintf(`{"id": %d, "message": "item %d"}`, i, i))
+	}
+
+	return fmt.Sprintf(`{"log_level": "error", "context": {"user_id": 123}, "items": [%s]}`, strings.Join(items, ","))
+}
+
+var jsonGetKeys = []string{"log_level", "context.user_id", "context.not_exist", "log_level", "context.user_id", "context.not_exist", "log_level", "context.user_id", "context.not_exist", "log_level"}
+
+func BenchmarkGets(b *testing.B) {
+	content := buildLargeContent()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, key := range jsonGetKeys {
+			json.Gets(key, "", content)
+		}
+	}
+}
+
+func BenchmarkDocument_Gets(b *testing.B) {
+	content := buildLargeContent()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		doc := json.Parse(content)
+		for _, key := range jsonGetKeys {
+			doc.Gets(key, "")
+		}
+	}
+}
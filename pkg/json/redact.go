@@ -0,0 +1,62 @@
+package json
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// RedactedValue 敏感字段脱敏之后的值
+const RedactedValue = "******"
+
+// sensitiveKeys 字段名中包含这些关键字时（不区分大小写）视为敏感字段
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "api_key", "apikey", "authorization", "credential"}
+
+// IsSensitiveKey 判断字段名是否是敏感字段
+func IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range sensitiveKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redact 将 JSON 中所有敏感字段（包括嵌套对象及数组中的对象）的值替换为 RedactedValue
+// body 不是合法的 JSON 时返回 false
+func Redact(body []byte) ([]byte, bool) {
+	var data interface{}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, false
+	}
+
+	redacted, err := json.Marshal(redactValue(data))
+	if err != nil {
+		return nil, false
+	}
+
+	return redacted, true
+}
+
+func redactValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if IsSensitiveKey(key) {
+				v[key] = RedactedValue
+				continue
+			}
+
+			v[key] = redactValue(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,20 @@
+package json_test
+
+import (
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/pkg/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	redacted, ok := json.Redact([]byte(`{"name":"guest","password":"123456","meta":{"API_KEY":"abc","users":[{"token":"xyz","id":1}]}}`))
+	assert.True(t, ok)
+	assert.JSONEq(t, `{"name":"guest","password":"******","meta":{"API_KEY":"******","users":[{"token":"******","id":1}]}}`, string(redacted))
+
+	_, ok = json.Redact([]byte(`password=123456`))
+	assert.False(t, ok)
+
+	assert.True(t, json.IsSensitiveKey("jira_password"))
+	assert.False(t, json.IsSensitiveKey("name"))
+}
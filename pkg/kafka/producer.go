@@ -0,0 +1,149 @@
+// Package kafka 基于 segmentio/kafka-go 的 Kafka 生产者封装
+//
+// 与 broker 协商协议版本，支持 Kafka 0.10.1 及以上版本（包括 4.x），
+// SASL 支持 PLAIN、SCRAM-SHA-256 和 SCRAM-SHA-512 机制，按照 Kafka 默认分区器（murmur2）根据消息 Key 选择分区。
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// SASL 认证机制
+const (
+	SASLMechanismPlain       = "PLAIN"
+	SASLMechanismScramSHA256 = "SCRAM-SHA-256"
+	SASLMechanismScramSHA512 = "SCRAM-SHA-512"
+)
+
+// SASL SASL 认证配置，Mechanism 为空时使用 PLAIN
+type SASL struct {
+	Mechanism string
+	Username  string
+	Password  string
+}
+
+// Config 生产者配置
+type Config struct {
+	// Brokers 启动时连接的 broker 地址（host:port），用于获取集群元数据
+	Brokers  []string
+	ClientID string
+	// TLS 不为空时使用 TLS 连接
+	TLS  *tls.Config
+	SASL *SASL
+	// RequiredAcks 0：不等待确认，1：等待 leader 确认，-1：等待所有 ISR 确认
+	RequiredAcks int16
+	Timeout      time.Duration
+}
+
+// Message 发送到 Kafka 的消息
+type Message struct {
+	Key   []byte
+	Value []byte
+}
+
+// DeliveryReport 单条消息的投递结果，Err 为空时表示投递成功
+type DeliveryReport struct {
+	Message Message
+	Err     error
+}
+
+// Producer Kafka 生产者，每次 Produce 同步发送，适合在单个后台任务中批量发送消息的场景
+type Producer struct {
+	writer *kafkago.Writer
+}
+
+// NewProducer create a new Producer，只校验配置，不会连接 broker
+func NewProducer(conf Config) (*Producer, error) {
+	if len(conf.Brokers) == 0 {
+		return nil, errors.New("kafka: brokers required")
+	}
+
+	if conf.ClientID == "" {
+		conf.ClientID = "adanos-alert"
+	}
+
+	if conf.Timeout <= 0 {
+		conf.Timeout = 10 * time.Second
+	}
+
+	transport := &kafkago.Transport{ClientID: conf.ClientID, TLS: conf.TLS}
+	if conf.SASL != nil {
+		mechanism, err := saslMechanism(*conf.SASL)
+		if err != nil {
+			return nil, err
+		}
+
+		transport.SASL = mechanism
+	}
+
+	return &Producer{writer: &kafkago.Writer{
+		Addr:         kafkago.TCP(conf.Brokers...),
+		Balancer:     &kafkago.Murmur2Balancer{},
+		RequiredAcks: kafkago.RequiredAcks(conf.RequiredAcks),
+		WriteTimeout: conf.Timeout,
+		ReadTimeout:  conf.Timeout,
+		// 每次 Produce 同步发送一批消息，不需要等待更多的消息
+		BatchTimeout: 10 * time.Millisecond,
+		Transport:    transport,
+	}}, nil
+}
+
+// saslMechanism 根据配置创建 SASL 认证机制
+func saslMechanism(conf SASL) (sasl.Mechanism, error) {
+	switch strings.ToUpper(conf.Mechanism) {
+	case "", SASLMechanismPlain:
+		return plain.Mechanism{Username: conf.Username, Password: conf.Password}, nil
+	case SASLMechanismScramSHA256:
+		return scram.Mechanism(scram.SHA256, conf.Username, conf.Password)
+	case SASLMechanismScramSHA512:
+		return scram.Mechanism(scram.SHA512, conf.Username, conf.Password)
+	default:
+		return nil, fmt.Errorf("kafka: unsupported sasl mechanism %s", conf.Mechanism)
+	}
+}
+
+// Produce 发送消息到 topic，返回每条消息的投递结果
+// 分区 leader 变化等可重试的错误由 kafka-go 自动重试
+func (p *Producer) Produce(ctx context.Context, topic string, messages []Message) []DeliveryReport {
+	reports := make([]DeliveryReport, len(messages))
+	msgs := make([]kafkago.Message, len(messages))
+	for i, msg := range messages {
+		reports[i].Message = msg
+		msgs[i] = kafkago.Message{Topic: topic, Key: msg.Key, Value: msg.Value}
+	}
+
+	err := p.writer.WriteMessages(ctx, msgs...)
+	if err == nil {
+		return reports
+	}
+
+	var writeErrs kafkago.WriteErrors
+	if errors.As(err, &writeErrs) && len(writeErrs) == len(reports) {
+		for i, e := range writeErrs {
+			reports[i].Err = e
+		}
+
+		return reports
+	}
+
+	for i := range reports {
+		reports[i].Err = err
+	}
+
+	return reports
+}
+
+// Close 关闭生产者
+func (p *Producer) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,29 @@
+package kafka_test
+
+import (
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/pkg/kafka"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProducer(t *testing.T) {
+	_, err := kafka.NewProducer(kafka.Config{})
+	assert.Error(t, err)
+
+	for _, mechanism := range []string{"", kafka.SASLMechanismPlain, kafka.SASLMechanismScramSHA256, "scram-sha-512"} {
+		producer, err := kafka.NewProducer(kafka.Config{
+			Brokers: []string{"127.0.0.1:9092"},
+			SASL:    &kafka.SASL{Mechanism: mechanism, Username: "adanos", Password: "secret"},
+		})
+		if assert.NoError(t, err, mechanism) {
+			assert.NoError(t, producer.Close())
+		}
+	}
+
+	_, err = kafka.NewProducer(kafka.Config{
+		Brokers: []string{"127.0.0.1:9092"},
+		SASL:    &kafka.SASL{Mechanism: "GSSAPI"},
+	})
+	assert.Error(t, err)
+}
@@ -2,6 +2,7 @@ package dingding
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
@@ -108,14 +109,45 @@ type Message interface {
 	Encode() ([]byte, error)
 }
 
+// CodeRateLimited 钉钉机器人发送频率超过限制时返回的错误码
+const CodeRateLimited = 130101
+
+// Error 钉钉接口返回的错误
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("[%d] %s", e.Code, e.Message)
+}
+
+// RateLimited 是否因为发送频率超过限制导致的失败，此时可以稍后重试
+func (e Error) RateLimited() bool {
+	return e.Code == CodeRateLimited
+}
+
 // dingResponse 钉钉响应
 type dingResponse struct {
 	ErrorCode    int    `json:"errcode"`
 	ErrorMessage string `json:"errmsg"`
 }
 
+// Send send a message to dingding robot
 func (ding *Dingding) Send(msg Message) error {
+	return ding.SendWithContext(context.Background(), msg)
+}
+
+// SendMarkdown 发送一条 markdown 消息，atMobiles 为需要 @ 的手机号，atAll 为 true 时 @ 所有人
+func (ding *Dingding) SendMarkdown(ctx context.Context, title, text string, atMobiles []string, atAll bool) error {
+	msg := NewMarkdownMessage(title, text, atMobiles)
+	msg.At.ToAll = atAll
+
+	return ding.SendWithContext(ctx, msg)
+}
 
+// SendWithContext send a message to dingding robot with context
+func (ding *Dingding) SendWithContext(ctx context.Context, msg Message) error {
 	v := url.Values{}
 	v.Add("access_token", ding.Token)
 
@@ -136,7 +168,7 @@ func (ding *Dingding) Send(msg Message) error {
 	}
 
 	reader := bytes.NewReader(msgEncoded)
-	request, err := http.NewRequest("POST", endpointURL, reader)
+	request, err := http.NewRequestWithContext(ctx, "POST", endpointURL, reader)
 	if err != nil {
 		return fmt.Errorf("dingding create request failed: %w", err)
 	}
@@ -147,6 +179,7 @@ func (ding *Dingding) Send(msg Message) error {
 	if err != nil {
 		return fmt.Errorf("dingding send msg failed: %w", err)
 	}
+	defer resp.Body.Close()
 
 	respBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
@@ -159,7 +192,7 @@ func (ding *Dingding) Send(msg Message) error {
 	}
 
 	if dresp.ErrorCode > 0 {
-		return fmt.Errorf("[%d] %s", dresp.ErrorCode, dresp.ErrorMessage)
+		return Error{Code: dresp.ErrorCode, Message: dresp.ErrorMessage}
 	}
 
 	return nil
@@ -1,7 +1,12 @@
 package dingding_test
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
@@ -71,3 +76,29 @@ func TestExtractAtSomeones(t *testing.T) {
 	lines := "Hello\n@18888888888\nok,@19999999949\t@19433333334"
 	assert.Equal(t, 3, len(dingding.ExtractAtSomeones(lines)))
 }
+
+func TestDingding_SendMarkdown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token", r.URL.Query().Get("access_token"))
+		assert.NotEmpty(t, r.URL.Query().Get("sign"))
+		assert.NotEmpty(t, r.URL.Query().Get("timestamp"))
+
+		var msg dingding.MarkdownMessage
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		assert.True(t, msg.At.ToAll)
+		assert.Equal(t, []string{"18888888888"}, msg.At.Mobiles)
+
+		_, _ = w.Write([]byte(`{"errcode":130101,"errmsg":"send too fast"}`))
+	}))
+	defer server.Close()
+
+	ding := dingding.NewDingding("token", "secret")
+	ding.Endpoint = server.URL
+
+	err := ding.SendMarkdown(context.TODO(), "Hello", "world", []string{"18888888888"}, true)
+	assert.Error(t, err)
+
+	var dingErr dingding.Error
+	assert.True(t, errors.As(err, &dingErr))
+	assert.True(t, dingErr.RateLimited())
+}
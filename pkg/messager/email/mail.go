@@ -1,26 +1,130 @@
 package email
 
-import "gopkg.in/gomail.v2"
+import (
+	"context"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"gopkg.in/gomail.v2"
+)
 
 // Client is a email sender client
+// 端口为 465 时使用隐式 TLS（SMTPS）连接，其它端口在服务器支持的情况下使用 STARTTLS
 type Client struct {
 	dailer *gomail.Dialer
 	from   string
 }
 
-// NewClient create a new mail client
+// NewClient create a new mail client, the sender address is same as username
 func NewClient(host string, port int, username string, password string) *Client {
+	return NewClientWithFrom(host, port, username, password, username)
+}
+
+// NewClientWithFrom create a new mail client with specified sender address
+func NewClientWithFrom(host string, port int, username string, password string, from string) *Client {
 	dailer := gomail.NewDialer(host, port, username, password)
-	return &Client{dailer: dailer, from: username}
+	dailer.SSL = port == 465
+
+	return &Client{dailer: dailer, from: from}
+}
+
+// Attachment 邮件附件
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// Message 邮件内容
+type Message struct {
+	To       []string
+	CC       []string
+	BCC      []string
+	Subject  string
+	HTMLBody string
+	// TextBody 纯文本内容，用于不支持 HTML 的邮件客户端，为空时自动从 HTMLBody 中提取
+	TextBody    string
+	Attachments []Attachment
 }
 
 // Send send email to users
-func (m Client) Send(subject, body string, users ...string) error {
+func (m Client) Send(ctx context.Context, to []string, subject, htmlBody string, attachments []Attachment) error {
+	return m.SendMessage(ctx, Message{
+		To:          to,
+		Subject:     subject,
+		HTMLBody:    htmlBody,
+		Attachments: attachments,
+	})
+}
+
+// SendMessage 发送邮件，支持抄送、密送以及附件
+func (m Client) SendMessage(ctx context.Context, message Message) error {
+	msg := m.buildMessage(message)
+
+	// gomail 不支持 context，这里在 context 取消时直接返回，发送过程在后台继续直到结束
+	errCh := make(chan error, 1)
+	go func() { errCh <- m.dailer.DialAndSend(msg) }()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-errCh:
+		return err
+	}
+}
+
+func (m Client) buildMessage(message Message) *gomail.Message {
 	msg := gomail.NewMessage()
 	msg.SetHeader("From", m.from)
-	msg.SetHeader("To", users...)
-	msg.SetHeader("Subject", subject)
-	msg.SetBody("text/html", body)
+	msg.SetHeader("To", message.To...)
+	if len(message.CC) > 0 {
+		msg.SetHeader("Cc", message.CC...)
+	}
+	if len(message.BCC) > 0 {
+		msg.SetHeader("Bcc", message.BCC...)
+	}
+	msg.SetHeader("Subject", message.Subject)
+
+	textBody := message.TextBody
+	if textBody == "" {
+		textBody = htmlToText(message.HTMLBody)
+	}
+
+	msg.SetBody("text/plain", textBody)
+	msg.AddAlternative("text/html", message.HTMLBody)
+
+	for _, att := range message.Attachments {
+		content := att.Content
+		settings := []gomail.FileSetting{
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(content)
+				return err
+			}),
+		}
+
+		if att.ContentType != "" {
+			settings = append(settings, gomail.SetHeader(map[string][]string{"Content-Type": {att.ContentType}}))
+		}
+
+		msg.Attach(att.Filename, settings...)
+	}
+
+	return msg
+}
+
+var (
+	htmlLineBreakRegexp = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>|</h\d>|</li>|</tr>`)
+	htmlTagRegexp       = regexp.MustCompile(`<[^>]*>`)
+	blankLinesRegexp    = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText 移除 HTML 标签，生成纯文本内容
+func htmlToText(body string) string {
+	text := htmlLineBreakRegexp.ReplaceAllString(body, "\n")
+	text = htmlTagRegexp.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
 
-	return m.dailer.DialAndSend(msg)
+	return strings.TrimSpace(blankLinesRegexp.ReplaceAllString(text, "\n\n"))
 }
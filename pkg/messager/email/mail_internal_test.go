@@ -0,0 +1,141 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_buildMessage(t *testing.T) {
+	client := NewClientWithFrom("smtp.example.com", 465, "user", "password", "adanos@example.com")
+	msg := client.buildMessage(Message{
+		To:       []string{"a@example.com", "b@example.com"},
+		CC:       []string{"c@example.com"},
+		BCC:      []string{"d@example.com"},
+		Subject:  "Hello, world",
+		HTMLBody: "<h1>Alert</h1><p>CPU &gt; 90%</p><br/>host-1",
+		Attachments: []Attachment{
+			{Filename: "digest.txt", ContentType: "text/plain", Content: []byte("full alert digest")},
+		},
+	})
+
+	// 密送地址只用于投递，不能出现在邮件头中
+	assert.Equal(t, []string{"d@example.com"}, msg.GetHeader("Bcc"))
+
+	var buf bytes.Buffer
+	_, err := msg.WriteTo(&buf)
+	assert.NoError(t, err)
+
+	parsed, err := mail.ReadMessage(&buf)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "adanos@example.com", parsed.Header.Get("From"))
+	assert.Equal(t, "a@example.com, b@example.com", parsed.Header.Get("To"))
+	assert.Equal(t, "c@example.com", parsed.Header.Get("Cc"))
+	assert.Empty(t, parsed.Header.Get("Bcc"))
+	assert.Equal(t, "Hello, world", parsed.Header.Get("Subject"))
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	mixed := multipart.NewReader(parsed.Body, params["boundary"])
+
+	// 第一部分为 text/plain 与 text/html 组成的 multipart/alternative
+	part, err := mixed.NextPart()
+	assert.NoError(t, err)
+
+	mediaType, params, err = mime.ParseMediaType(part.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	alternative := multipart.NewReader(part, params["boundary"])
+	bodies := make(map[string]string)
+	for {
+		p, err := alternative.NextPart()
+		if err != nil {
+			break
+		}
+
+		contentType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		content, err := ioutil.ReadAll(p)
+		assert.NoError(t, err)
+
+		// 邮件正文传输时使用 CRLF 换行
+		bodies[contentType] = strings.ReplaceAll(string(content), "\r\n", "\n")
+	}
+
+	assert.Equal(t, "Alert\nCPU > 90%\n\nhost-1", bodies["text/plain"])
+	assert.Equal(t, "<h1>Alert</h1><p>CPU &gt; 90%</p><br/>host-1", bodies["text/html"])
+
+	// 第二部分为附件
+	part, err = mixed.NextPart()
+	assert.NoError(t, err)
+	assert.Equal(t, "digest.txt", part.FileName())
+
+	contentType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain", contentType)
+	assert.Equal(t, "base64", part.Header.Get("Content-Transfer-Encoding"))
+
+	content, err := ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+	assert.NoError(t, err)
+	assert.Equal(t, "full alert digest", string(content))
+
+	_, err = mixed.NextPart()
+	assert.Error(t, err)
+}
+
+func TestClient_buildMessageWithTextBody(t *testing.T) {
+	msg := NewClient("smtp.example.com", 587, "adanos@example.com", "password").buildMessage(Message{
+		To:       []string{"a@example.com"},
+		Subject:  "Hello, world",
+		HTMLBody: "<p>html body</p>",
+		TextBody: "custom text body",
+	})
+
+	var buf bytes.Buffer
+	_, err := msg.WriteTo(&buf)
+	assert.NoError(t, err)
+
+	parsed, err := mail.ReadMessage(&buf)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "adanos@example.com", parsed.Header.Get("From"))
+	assert.Empty(t, parsed.Header.Get("Cc"))
+
+	// 没有附件时直接使用 multipart/alternative
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	part, err := multipart.NewReader(parsed.Body, params["boundary"]).NextPart()
+	assert.NoError(t, err)
+
+	content, err := ioutil.ReadAll(part)
+	assert.NoError(t, err)
+	assert.Equal(t, "custom text body", string(content))
+}
+
+func TestHtmlToText(t *testing.T) {
+	testcases := map[string]string{
+		"":                                    "",
+		"plain text":                          "plain text",
+		"<p>line 1</p><p>line 2</p>":          "line 1\nline 2",
+		"a<br>b<BR/>c":                        "a\nb\nc",
+		"<ul><li>x</li><li>y</li></ul>":       "x\ny",
+		"<p>a</p>\n\n\n\n<p>b</p>":            "a\n\nb",
+		"<b>Tom &amp; Jerry</b> &lt;3 &quot;": "Tom & Jerry <3 \"",
+	}
+
+	for input, expected := range testcases {
+		assert.Equal(t, expected, htmlToText(input), input)
+	}
+}
@@ -1,6 +1,7 @@
 package email_test
 
 import (
+	"context"
 	"os"
 	"strconv"
 	"testing"
@@ -23,5 +24,11 @@ func TestEmailSend(t *testing.T) {
 	assert.NoError(t, err)
 
 	client := email.NewClient(host, portI, user, password)
-	assert.NoError(t, client.Send("Hello, world", "This is message body", "mylxsw@aicode.cc", "mylxsw@126.com"))
+	assert.NoError(t, client.Send(
+		context.TODO(),
+		[]string{"mylxsw@aicode.cc", "mylxsw@126.com"},
+		"Hello, world",
+		"<p>This is message body</p>",
+		[]email.Attachment{{Filename: "digest.txt", ContentType: "text/plain", Content: []byte("full alert digest")}},
+	))
 }
@@ -0,0 +1,132 @@
+package feishu
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CodeRateLimited 飞书机器人发送频率超过限制时返回的错误码
+const CodeRateLimited = 9499
+
+// Error 飞书接口返回的错误
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("feishu: [%d] %s", e.Code, e.Message)
+}
+
+// RateLimited 是否因为发送频率超过限制导致的失败，此时可以稍后重试
+func (e Error) RateLimited() bool {
+	return e.Code == CodeRateLimited
+}
+
+// Client 飞书自定义机器人客户端
+type Client struct {
+	webhook string
+	secret  string
+	client  *http.Client
+}
+
+// NewClient create a new feishu robot client
+// secret 为机器人安全设置中的签名密钥，为空时不签名
+func NewClient(webhook, secret string) *Client {
+	return &Client{webhook: webhook, secret: secret, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Send 发送一条卡片消息，卡片内容为 markdown 格式
+func (client Client) Send(ctx context.Context, title, markdown string) error {
+	return client.send(ctx, map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"config": map[string]interface{}{"wide_screen_mode": true},
+			"header": map[string]interface{}{
+				"title": map[string]interface{}{"tag": "plain_text", "content": title},
+			},
+			"elements": []interface{}{
+				map[string]interface{}{"tag": "markdown", "content": markdown},
+			},
+		},
+	})
+}
+
+// SendText 发送一条纯文本消息
+func (client Client) SendText(ctx context.Context, text string) error {
+	return client.send(ctx, map[string]interface{}{
+		"msg_type": "text",
+		"content":  map[string]interface{}{"text": text},
+	})
+}
+
+// feishuResponse 飞书响应
+type feishuResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"msg"`
+}
+
+func (client Client) send(ctx context.Context, msg map[string]interface{}) error {
+	if client.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		sign, err := client.sign(timestamp)
+		if err != nil {
+			return fmt.Errorf("feishu sign failed: %w", err)
+		}
+
+		msg["timestamp"] = timestamp
+		msg["sign"] = sign
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("feishu message encode failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", client.webhook, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("feishu create request failed: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json;charset=UTF-8")
+	resp, err := client.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("feishu send msg failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("feishu read response failed: %w", err)
+	}
+
+	var fresp feishuResponse
+	if err := json.Unmarshal(respBytes, &fresp); err != nil {
+		return fmt.Errorf("send finished, response: [%d] %s", resp.StatusCode, string(respBytes))
+	}
+
+	if fresp.Code != 0 {
+		return Error{Code: fresp.Code, Message: fresp.Message}
+	}
+
+	return nil
+}
+
+// sign 计算签名，飞书的签名算法是以 timestamp + "\n" + secret 作为密钥，对空字符串进行 HmacSHA256 计算
+func (client Client) sign(timestamp string) (string, error) {
+	hash := hmac.New(sha256.New, []byte(timestamp+"\n"+client.secret))
+	if _, err := hash.Write([]byte{}); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,42 @@
+package feishu_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/pkg/messager/feishu"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		assert.Equal(t, "interactive", msg["msg_type"])
+		assert.NotEmpty(t, msg["sign"])
+		assert.NotEmpty(t, msg["timestamp"])
+
+		_, _ = w.Write([]byte(`{"code":0,"msg":"success"}`))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, feishu.NewClient(server.URL, "secret").Send(context.TODO(), "Hello", "**world**"))
+}
+
+func TestClient_SendRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":9499,"msg":"too many request"}`))
+	}))
+	defer server.Close()
+
+	err := feishu.NewClient(server.URL, "").SendText(context.TODO(), "Hello")
+	assert.Error(t, err)
+
+	var ferr feishu.Error
+	assert.True(t, errors.As(err, &ferr))
+	assert.True(t, ferr.RateLimited())
+}
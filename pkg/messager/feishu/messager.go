@@ -0,0 +1,58 @@
+package feishu
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/messager"
+)
+
+func init() {
+	messager.Register(Messager{})
+}
+
+// Meta 飞书 消息通道的动作元数据
+type Meta struct {
+	Webhook string `json:"webhook"`
+	// Secret 机器人的签名密钥，为空时不签名
+	Secret string `json:"secret"`
+}
+
+// Messager 将 飞书 适配为 messager.Messager，动作名称为 feishu
+type Messager struct{}
+
+// Name 返回通道名称
+func (Messager) Name() string {
+	return "feishu"
+}
+
+// Validate 校验触发动作的元数据
+func (Messager) Validate(meta string) error {
+	_, err := parseMeta(meta)
+	return err
+}
+
+// Send 以规则名称为标题，将渲染之后的内容作为卡片消息发送
+func (Messager) Send(ctx context.Context, meta string, group repository.EventGroup, rendered string) error {
+	m, err := parseMeta(meta)
+	if err != nil {
+		return err
+	}
+
+	return NewClient(m.Webhook, m.Secret).Send(ctx, group.Rule.Name, rendered)
+}
+
+func parseMeta(meta string) (m Meta, err error) {
+	if err := json.Unmarshal([]byte(meta), &m); err != nil {
+		return m, fmt.Errorf("feishu: invalid meta: %w", err)
+	}
+
+	if m.Webhook == "" {
+		return m, errors.New("feishu: webhook required")
+	}
+
+	return m, nil
+}
@@ -3,9 +3,11 @@ package jira
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/andygrunwald/go-jira"
 )
@@ -47,6 +49,8 @@ func NewClient(baseURL string, username, password string) (*Client, error) {
 
 // IssueResp 查询到的 Issue，附加状态
 type IssueResp struct {
+	ID     string `json:"id"`
+	Key    string `json:"key"`
 	Issue  Issue  `json:"issue"`
 	Status string `json:"status"`
 }
@@ -58,18 +62,67 @@ func (client Client) GetIssue(ctx context.Context, issueID string) (IssueResp, e
 		return IssueResp{}, fmt.Errorf("%w: %s", err, client.extractResponse(resp))
 	}
 
-	return IssueResp{
-		Issue: Issue{
-			CustomFields: issue.Fields.Unknowns,
-			ProjectKey:   issue.Fields.Project.Key,
-			Summary:      issue.Fields.Summary,
-			Description:  issue.Fields.Description,
-			IssueType:    issue.Fields.Type.ID,
-			Priority:     issue.Fields.Priority.ID,
-			Assignee:     issue.Fields.Assignee.Name,
-		},
-		Status: issue.Fields.Status.Name,
-	}, nil
+	return buildIssueResp(issue), nil
+}
+
+// searchPageSize 搜索 Issue 时每页查询的数量
+const searchPageSize = 50
+
+// SearchIssues 使用 JQL 搜索 Issue，最多返回 maxResults 条，maxResults <= 0 时返回所有结果
+func (client Client) SearchIssues(ctx context.Context, jql string, maxResults int) ([]IssueResp, error) {
+	results := make([]IssueResp, 0)
+	for {
+		pageSize := searchPageSize
+		if maxResults > 0 && maxResults-len(results) < pageSize {
+			pageSize = maxResults - len(results)
+		}
+
+		issues, resp, err := client.client.Issue.SearchWithContext(ctx, jql, &jira.SearchOptions{
+			StartAt:    len(results),
+			MaxResults: pageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, client.extractResponse(resp))
+		}
+
+		for i := range issues {
+			results = append(results, buildIssueResp(&issues[i]))
+		}
+
+		if len(issues) < pageSize || (maxResults > 0 && len(results) >= maxResults) || (resp != nil && len(results) >= resp.Total) {
+			break
+		}
+	}
+
+	return results, nil
+}
+
+// buildIssueResp 将 jira.Issue 转换为 IssueResp
+func buildIssueResp(issue *jira.Issue) IssueResp {
+	res := IssueResp{ID: issue.ID, Key: issue.Key}
+	if issue.Fields == nil {
+		return res
+	}
+
+	res.Issue = Issue{
+		CustomFields: issue.Fields.Unknowns,
+		ProjectKey:   issue.Fields.Project.Key,
+		Summary:      issue.Fields.Summary,
+		Description:  issue.Fields.Description,
+		IssueType:    issue.Fields.Type.ID,
+	}
+
+	if issue.Fields.Priority != nil {
+		res.Issue.Priority = issue.Fields.Priority.ID
+	}
+	if issue.Fields.Assignee != nil {
+		res.Issue.Assignee = issue.Fields.Assignee.Name
+	}
+	if issue.Fields.Status != nil {
+		res.Status = issue.Fields.Status.Name
+	}
+
+	return res
 }
 
 // CreateIssue create a jira issue
@@ -119,6 +172,73 @@ func (client Client) CreateComment(ctx context.Context, issueID string, comment
 	return nil
 }
 
+// AddAttachment 为 Issue 添加附件，返回附件 ID
+// go-jira 会使用 multipart 上传，并且设置 Jira 要求的 X-Atlassian-Token: no-check 请求头
+func (client Client) AddAttachment(ctx context.Context, issueID, filename string, content io.Reader) (string, error) {
+	attachments, resp, err := client.client.Issue.PostAttachmentWithContext(ctx, issueID, content, filename)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, client.extractResponse(resp))
+	}
+
+	if attachments == nil || len(*attachments) == 0 {
+		return "", fmt.Errorf("no attachment returned for issue %s", issueID)
+	}
+
+	return (*attachments)[0].ID, nil
+}
+
+// Transition 是 Issue 可以执行的一个工作流转换
+type Transition struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	ToStatus string `json:"to_status"`
+}
+
+// GetTransitions 获取 Issue 当前可以执行的工作流转换
+func (client Client) GetTransitions(ctx context.Context, issueID string) ([]Transition, error) {
+	transitions, resp, err := client.client.Issue.GetTransitionsWithContext(ctx, issueID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, client.extractResponse(resp))
+	}
+
+	results := make([]Transition, 0)
+	for _, t := range transitions {
+		results = append(results, Transition{
+			ID:       t.ID,
+			Name:     t.Name,
+			ToStatus: t.To.Name,
+		})
+	}
+
+	return results, nil
+}
+
+// DoTransition 对 Issue 执行工作流转换
+func (client Client) DoTransition(ctx context.Context, issueID, transitionID string) error {
+	resp, err := client.client.Issue.DoTransitionWithContext(ctx, issueID, transitionID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, client.extractResponse(resp))
+	}
+
+	return nil
+}
+
+// TransitionTo 将 Issue 转换到指定的状态（如 Done），status 匹配转换名称或者目标状态名称，不区分大小写
+func (client Client) TransitionTo(ctx context.Context, issueID, status string) error {
+	transitions, err := client.GetTransitions(ctx, issueID)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range transitions {
+		if strings.EqualFold(t.ToStatus, status) || strings.EqualFold(t.Name, status) {
+			return client.DoTransition(ctx, issueID, t.ID)
+		}
+	}
+
+	return fmt.Errorf("no transition to status %s for issue %s", status, issueID)
+}
+
 // IssueType is a jira issue type object
 type IssueType struct {
 	ID   string `json:"id"`
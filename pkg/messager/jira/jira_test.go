@@ -0,0 +1,46 @@
+package jira_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/pkg/messager/jira"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_SearchIssues(t *testing.T) {
+	const total = 120
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/api/2/search", r.URL.Path)
+		assert.Equal(t, "cf[10001] = abc", r.URL.Query().Get("jql"))
+
+		startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+		maxResults, _ := strconv.Atoi(r.URL.Query().Get("maxResults"))
+
+		issues := make([]string, 0)
+		for i := startAt; i < startAt+maxResults && i < total; i++ {
+			issues = append(issues, fmt.Sprintf(`{"id":"%d","key":"ADA-%d","fields":{"summary":"issue %d","status":{"name":"Open"}}}`, i, i, i))
+		}
+
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"startAt":%d,"maxResults":%d,"total":%d,"issues":[%s]}`, startAt, maxResults, total, strings.Join(issues, ","))))
+	}))
+	defer server.Close()
+
+	client, err := jira.NewClient(server.URL, "", "")
+	assert.NoError(t, err)
+
+	issues, err := client.SearchIssues(context.TODO(), "cf[10001] = abc", 0)
+	assert.NoError(t, err)
+	assert.Len(t, issues, total)
+	assert.Equal(t, "ADA-119", issues[119].Key)
+	assert.Equal(t, "Open", issues[0].Status)
+
+	issues, err = client.SearchIssues(context.TODO(), "cf[10001] = abc", 60)
+	assert.NoError(t, err)
+	assert.Len(t, issues, 60)
+}
@@ -0,0 +1,83 @@
+package messager
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+)
+
+// Messager 消息发送通道接口，rendered 为事件组使用规则模板渲染之后的内容
+// 消息通道实现在包的 init 函数中通过 Register 注册到默认注册表
+type Messager interface {
+	// Name 返回通道名称，触发动作通过该名称查找通道
+	Name() string
+	// Send 发送事件组通知，meta 为触发动作的元数据（JSON），包含通道的地址、密钥等配置
+	Send(ctx context.Context, meta string, group repository.EventGroup, rendered string) error
+}
+
+// Validator 消息通道可以实现该接口，在保存规则时校验触发动作的元数据
+type Validator interface {
+	Validate(meta string) error
+}
+
+// Registry 消息通道注册表
+type Registry struct {
+	lock      sync.RWMutex
+	messagers map[string]Messager
+}
+
+// NewRegistry create a new Registry
+func NewRegistry() *Registry {
+	return &Registry{messagers: make(map[string]Messager)}
+}
+
+// Register 注册消息通道，同名的通道会被覆盖
+func (r *Registry) Register(m Messager) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.messagers[m.Name()] = m
+}
+
+// Get 根据名称查找消息通道
+func (r *Registry) Get(name string) (Messager, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	m, ok := r.messagers[name]
+	return m, ok
+}
+
+// Names 返回所有已注册的通道名称（已排序）
+func (r *Registry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	names := make([]string, 0, len(r.messagers))
+	for name := range r.messagers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// defaultRegistry 默认注册表，消息通道实现通过 Register 将自己注册到这里
+var defaultRegistry = NewRegistry()
+
+// Register 注册消息通道到默认注册表
+func Register(m Messager) {
+	defaultRegistry.Register(m)
+}
+
+// Get 从默认注册表中查找消息通道
+func Get(name string) (Messager, bool) {
+	return defaultRegistry.Get(name)
+}
+
+// Names 返回默认注册表中所有的通道名称
+func Names() []string {
+	return defaultRegistry.Names()
+}
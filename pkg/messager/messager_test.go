@@ -0,0 +1,57 @@
+package messager_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/messager"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMessager struct {
+	name string
+	sent []string
+}
+
+func (f *fakeMessager) Name() string {
+	return f.name
+}
+
+func (f *fakeMessager) Send(ctx context.Context, meta string, group repository.EventGroup, rendered string) error {
+	f.sent = append(f.sent, rendered)
+	return nil
+}
+
+func TestRegistry(t *testing.T) {
+	registry := messager.NewRegistry()
+
+	_, ok := registry.Get("slack")
+	assert.False(t, ok)
+
+	slack := &fakeMessager{name: "slack"}
+	registry.Register(slack)
+	registry.Register(&fakeMessager{name: "teams"})
+
+	m, ok := registry.Get("slack")
+	assert.True(t, ok)
+	assert.NoError(t, m.Send(context.TODO(), "", repository.EventGroup{}, "hello"))
+	assert.Equal(t, []string{"hello"}, slack.sent)
+
+	assert.Equal(t, []string{"slack", "teams"}, registry.Names())
+
+	// 同名通道覆盖之前的注册
+	replaced := &fakeMessager{name: "slack"}
+	registry.Register(replaced)
+	m, _ = registry.Get("slack")
+	assert.Equal(t, replaced, m)
+	assert.Len(t, registry.Names(), 2)
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	messager.Register(&fakeMessager{name: "test-default"})
+
+	_, ok := messager.Get("test-default")
+	assert.True(t, ok)
+	assert.Contains(t, messager.Names(), "test-default")
+}
@@ -0,0 +1,60 @@
+package opsgenie
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/messager"
+)
+
+func init() {
+	messager.Register(Messager{})
+}
+
+// Meta OpsGenie 消息通道的动作元数据
+type Meta struct {
+	APIKey string `json:"api_key"`
+	// Region 为 eu 时使用欧洲区域的 API 地址
+	Region string `json:"region"`
+	// Priority 告警优先级，取值为 P1-P5
+	Priority string `json:"priority"`
+}
+
+// Messager 将 OpsGenie 适配为 messager.Messager，动作名称为 opsgenie
+type Messager struct{}
+
+// Name 返回通道名称
+func (Messager) Name() string {
+	return "opsgenie"
+}
+
+// Validate 校验触发动作的元数据
+func (Messager) Validate(meta string) error {
+	_, err := parseMeta(meta)
+	return err
+}
+
+// Send 使用事件组 ID 作为 alias 创建告警，渲染之后的内容作为告警消息
+func (Messager) Send(ctx context.Context, meta string, group repository.EventGroup, rendered string) error {
+	m, err := parseMeta(meta)
+	if err != nil {
+		return err
+	}
+
+	return NewClient(m.APIKey, m.Region).CreateAlert(ctx, group.ID.Hex(), rendered, m.Priority, nil)
+}
+
+func parseMeta(meta string) (m Meta, err error) {
+	if err := json.Unmarshal([]byte(meta), &m); err != nil {
+		return m, fmt.Errorf("opsgenie: invalid meta: %w", err)
+	}
+
+	if m.APIKey == "" {
+		return m, errors.New("opsgenie: api_key required")
+	}
+
+	return m, nil
+}
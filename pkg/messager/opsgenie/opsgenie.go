@@ -0,0 +1,137 @@
+package opsgenie
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OpsGenie 不同区域的 API 地址
+const (
+	EndpointUS = "https://api.opsgenie.com"
+	EndpointEU = "https://api.eu.opsgenie.com"
+)
+
+// maxMessageLength OpsGenie 告警 message 的最大长度
+const maxMessageLength = 130
+
+// Error OpsGenie 接口返回的错误
+type Error struct {
+	StatusCode int
+	Message    string
+	RequestID  string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("opsgenie: [%d] %s (request_id=%s)", e.StatusCode, e.Message, e.RequestID)
+}
+
+// RateLimited 是否因为请求频率超过限制导致的失败，此时可以稍后重试
+func (e Error) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// Client OpsGenie Alert API 客户端
+type Client struct {
+	apiKey   string
+	region   string
+	endpoint string
+	client   *http.Client
+}
+
+// NewClient create a new opsgenie client, region 为 eu 时使用欧洲区域的 API 地址，其它情况使用美国区域
+func NewClient(apiKey, region string) *Client {
+	endpoint := EndpointUS
+	if strings.EqualFold(region, "eu") {
+		endpoint = EndpointEU
+	}
+
+	return &Client{apiKey: apiKey, region: region, endpoint: endpoint, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// WithEndpoint 设置 API 地址
+func (client *Client) WithEndpoint(endpoint string) *Client {
+	client.endpoint = strings.TrimRight(endpoint, "/")
+	return client
+}
+
+// opsgenieResponse OpsGenie 响应
+type opsgenieResponse struct {
+	Result    string  `json:"result"`
+	Message   string  `json:"message"`
+	Took      float64 `json:"took"`
+	RequestID string  `json:"requestId"`
+}
+
+// CreateAlert 创建一个告警，相同 alias 的告警在 OpsGenie 中会被去重，只更新同一个告警
+// priority 取值为 P1-P5，为空时使用 OpsGenie 的默认值 P3
+func (client Client) CreateAlert(ctx context.Context, alias, message string, priority string, details map[string]string) error {
+	if len([]rune(message)) > maxMessageLength {
+		message = string([]rune(message)[:maxMessageLength])
+	}
+
+	alert := map[string]interface{}{
+		"message": message,
+		"alias":   alias,
+		"source":  "adanos-alert",
+	}
+	if priority != "" {
+		alert["priority"] = strings.ToUpper(priority)
+	}
+	if len(details) > 0 {
+		alert["details"] = details
+	}
+
+	return client.send(ctx, "/v2/alerts", alert)
+}
+
+// CloseAlert 关闭 alias 对应的告警
+func (client Client) CloseAlert(ctx context.Context, alias string, note string) error {
+	path := fmt.Sprintf("/v2/alerts/%s/close?identifierType=alias", url.PathEscape(alias))
+	return client.send(ctx, path, map[string]interface{}{
+		"source": "adanos-alert",
+		"note":   note,
+	})
+}
+
+func (client Client) send(ctx context.Context, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("opsgenie request encode failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", client.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("opsgenie create request failed: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+client.apiKey)
+	resp, err := client.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("opsgenie send request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("opsgenie read response failed: %w", err)
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	var oresp opsgenieResponse
+	if err := json.Unmarshal(respBytes, &oresp); err != nil || oresp.Message == "" {
+		oresp.Message = strings.TrimSpace(string(respBytes))
+	}
+
+	return Error{StatusCode: resp.StatusCode, Message: oresp.Message, RequestID: oresp.RequestID}
+}
@@ -0,0 +1,54 @@
+package opsgenie_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/pkg/messager/opsgenie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_CreateAndCloseAlert(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GenieKey api-key", r.Header.Get("Authorization"))
+
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		switch r.URL.Path {
+		case "/v2/alerts":
+			assert.Equal(t, "group-1", body["alias"])
+			assert.Equal(t, "P1", body["priority"])
+		case "/v2/alerts/group-1/close":
+			assert.Equal(t, "alias", r.URL.Query().Get("identifierType"))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"result":"Request will be processed","took":0.302,"requestId":"43a29c5c"}`))
+	}))
+	defer server.Close()
+
+	client := opsgenie.NewClient("api-key", "us").WithEndpoint(server.URL)
+	assert.NoError(t, client.CreateAlert(context.TODO(), "group-1", "disk full", "p1", map[string]string{"host": "127.0.0.1"}))
+	assert.NoError(t, client.CloseAlert(context.TODO(), "group-1", "recovered"))
+}
+
+func TestClient_CreateAlertFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"message":"Request body is not processable","took":0.01,"requestId":"b1d5"}`))
+	}))
+	defer server.Close()
+
+	err := opsgenie.NewClient("api-key", "eu").WithEndpoint(server.URL).CreateAlert(context.TODO(), "a", "b", "", nil)
+	assert.Error(t, err)
+
+	oerr := err.(opsgenie.Error)
+	assert.Equal(t, "Request body is not processable", oerr.Message)
+	assert.Equal(t, "b1d5", oerr.RequestID)
+}
@@ -0,0 +1,59 @@
+package pagerduty
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/messager"
+)
+
+func init() {
+	messager.Register(Messager{})
+}
+
+// Meta PagerDuty 消息通道的动作元数据
+type Meta struct {
+	RoutingKey string `json:"routing_key"`
+	// Severity 告警级别，取值为 critical/error/warning/info
+	Severity string `json:"severity"`
+}
+
+// Messager 将 PagerDuty 适配为 messager.Messager，动作名称为 pagerduty
+type Messager struct{}
+
+// Name 返回通道名称
+func (Messager) Name() string {
+	return "pagerduty"
+}
+
+// Validate 校验触发动作的元数据
+func (Messager) Validate(meta string) error {
+	_, err := parseMeta(meta)
+	return err
+}
+
+// Send 使用事件组 ID 作为 dedup_key 触发告警，渲染之后的内容作为告警摘要
+func (Messager) Send(ctx context.Context, meta string, group repository.EventGroup, rendered string) error {
+	m, err := parseMeta(meta)
+	if err != nil {
+		return err
+	}
+
+	_, err = NewClient(m.RoutingKey).Trigger(ctx, DedupKey(group.ID.Hex()), rendered, m.Severity, nil)
+	return err
+}
+
+func parseMeta(meta string) (m Meta, err error) {
+	if err := json.Unmarshal([]byte(meta), &m); err != nil {
+		return m, fmt.Errorf("pagerduty: invalid meta: %w", err)
+	}
+
+	if m.RoutingKey == "" {
+		return m, errors.New("pagerduty: routing_key required")
+	}
+
+	return m, nil
+}
@@ -0,0 +1,169 @@
+package pagerduty
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint PagerDuty Events API v2 地址
+const DefaultEndpoint = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty 支持的告警级别
+const (
+	SeverityCritical = "critical"
+	SeverityError    = "error"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// DedupKey 根据 adanos 事件组 ID 生成 dedup_key，同一个事件组的多次触发在 PagerDuty 中会合并为一个 incident
+func DedupKey(groupID string) string {
+	return "adanos-alert:" + groupID
+}
+
+// Error PagerDuty 接口返回的错误
+type Error struct {
+	StatusCode int
+	Status     string
+	Message    string
+	Errors     []string
+}
+
+func (e Error) Error() string {
+	if len(e.Errors) > 0 {
+		return fmt.Sprintf("pagerduty: [%d] %s: %s", e.StatusCode, e.Message, strings.Join(e.Errors, "; "))
+	}
+
+	return fmt.Sprintf("pagerduty: [%d] %s", e.StatusCode, e.Message)
+}
+
+// RateLimited 是否因为请求频率超过限制导致的失败，此时可以稍后重试
+func (e Error) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// Client PagerDuty Events API v2 客户端
+type Client struct {
+	routingKey string
+	endpoint   string
+	client     *http.Client
+}
+
+// NewClient create a new pagerduty client, routingKey 为 PagerDuty 服务的 Integration Key
+func NewClient(routingKey string) *Client {
+	return &Client{routingKey: routingKey, endpoint: DefaultEndpoint, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// WithEndpoint 设置 Events API 地址
+func (client *Client) WithEndpoint(endpoint string) *Client {
+	client.endpoint = endpoint
+	return client
+}
+
+type eventPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+type event struct {
+	RoutingKey  string        `json:"routing_key"`
+	EventAction string        `json:"event_action"`
+	DedupKey    string        `json:"dedup_key,omitempty"`
+	Payload     *eventPayload `json:"payload,omitempty"`
+}
+
+// pagerdutyResponse PagerDuty 响应
+type pagerdutyResponse struct {
+	Status   string   `json:"status"`
+	Message  string   `json:"message"`
+	DedupKey string   `json:"dedup_key"`
+	Errors   []string `json:"errors"`
+}
+
+// Trigger 触发一个告警，返回 PagerDuty 使用的 dedup_key
+// severity 取值为 critical/error/warning/info，其它值会被当做 error 处理
+func (client Client) Trigger(ctx context.Context, dedupKey, summary, severity string, customDetails map[string]interface{}) (string, error) {
+	resp, err := client.send(ctx, event{
+		RoutingKey:  client.routingKey,
+		EventAction: "trigger",
+		DedupKey:    dedupKey,
+		Payload: &eventPayload{
+			Summary:       summary,
+			Source:        "adanos-alert",
+			Severity:      normalizeSeverity(severity),
+			CustomDetails: customDetails,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resp.DedupKey, nil
+}
+
+// Resolve 恢复 dedupKey 对应的告警
+func (client Client) Resolve(ctx context.Context, dedupKey string) error {
+	_, err := client.send(ctx, event{
+		RoutingKey:  client.routingKey,
+		EventAction: "resolve",
+		DedupKey:    dedupKey,
+	})
+
+	return err
+}
+
+func (client Client) send(ctx context.Context, evt event) (*pagerdutyResponse, error) {
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return nil, fmt.Errorf("pagerduty event encode failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", client.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("pagerduty create request failed: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("pagerduty send event failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("pagerduty read response failed: %w", err)
+	}
+
+	var presp pagerdutyResponse
+	if err := json.Unmarshal(respBytes, &presp); err != nil && resp.StatusCode < 300 {
+		return nil, fmt.Errorf("send finished, response: [%d] %s", resp.StatusCode, string(respBytes))
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if presp.Message == "" {
+			presp.Message = strings.TrimSpace(string(respBytes))
+		}
+
+		return nil, Error{StatusCode: resp.StatusCode, Status: presp.Status, Message: presp.Message, Errors: presp.Errors}
+	}
+
+	return &presp, nil
+}
+
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case SeverityCritical, SeverityError, SeverityWarning, SeverityInfo:
+		return strings.ToLower(severity)
+	default:
+		return SeverityError
+	}
+}
@@ -0,0 +1,48 @@
+package pagerduty_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/pkg/messager/pagerduty"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_TriggerAndResolve(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var evt map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&evt))
+		assert.Equal(t, "routing-key", evt["routing_key"])
+
+		if evt["event_action"] == "trigger" {
+			payload := evt["payload"].(map[string]interface{})
+			assert.Equal(t, "warning", payload["severity"])
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"status":"success","message":"Event processed","dedup_key":"` + evt["dedup_key"].(string) + `"}`))
+	}))
+	defer server.Close()
+
+	client := pagerduty.NewClient("routing-key").WithEndpoint(server.URL)
+
+	dedupKey, err := client.Trigger(context.TODO(), pagerduty.DedupKey("5f0b3b4b2c5b1e2b3c4d5e6f"), "disk full", "Warning", map[string]interface{}{"host": "127.0.0.1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "adanos-alert:5f0b3b4b2c5b1e2b3c4d5e6f", dedupKey)
+
+	assert.NoError(t, client.Resolve(context.TODO(), dedupKey))
+}
+
+func TestClient_TriggerRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := pagerduty.NewClient("routing-key").WithEndpoint(server.URL).Trigger(context.TODO(), "key", "summary", "critical", nil)
+	assert.Error(t, err)
+	assert.True(t, err.(pagerduty.Error).RateLimited())
+}
@@ -0,0 +1,56 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/messager"
+)
+
+func init() {
+	messager.Register(Messager{})
+}
+
+// Meta Slack 消息通道的动作元数据
+type Meta struct {
+	WebhookURL string `json:"webhook_url"`
+}
+
+// Messager 将 Slack 适配为 messager.Messager，动作名称为 slack
+type Messager struct{}
+
+// Name 返回通道名称
+func (Messager) Name() string {
+	return "slack"
+}
+
+// Validate 校验触发动作的元数据
+func (Messager) Validate(meta string) error {
+	_, err := parseMeta(meta)
+	return err
+}
+
+// Send 将渲染之后的内容作为文本消息发送到 Slack incoming webhook
+func (Messager) Send(ctx context.Context, meta string, group repository.EventGroup, rendered string) error {
+	m, err := parseMeta(meta)
+	if err != nil {
+		return err
+	}
+
+	return NewClient(m.WebhookURL).SendText(ctx, rendered)
+}
+
+func parseMeta(meta string) (m Meta, err error) {
+	if err := json.Unmarshal([]byte(meta), &m); err != nil {
+		return m, fmt.Errorf("slack: invalid meta: %w", err)
+	}
+
+	if m.WebhookURL == "" {
+		return m, errors.New("slack: webhook_url required")
+	}
+
+	return m, nil
+}
@@ -0,0 +1,156 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRateLimitRetries 被限流（429）时最多重试的次数
+const maxRateLimitRetries = 3
+
+// Error Slack webhook 返回的错误，Slack 的 webhook 响应为纯文本，例如 invalid_payload
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("slack: [%d] %s", e.StatusCode, e.Message)
+}
+
+// RateLimitError 发送频率超过限制，RetryAfter 为 Slack 建议的重试等待时间
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e RateLimitError) Error() string {
+	return fmt.Sprintf("slack: rate limited, retry after %s", e.RetryAfter)
+}
+
+// TextObject 文本对象，Type 为 plain_text 或者 mrkdwn
+type TextObject struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Block 是 Block Kit 中的一个布局块
+type Block struct {
+	Type     string       `json:"type"`
+	Text     *TextObject  `json:"text,omitempty"`
+	Fields   []TextObject `json:"fields,omitempty"`
+	Elements []TextObject `json:"elements,omitempty"`
+}
+
+// HeaderBlock 创建一个标题块
+func HeaderBlock(text string) Block {
+	return Block{Type: "header", Text: &TextObject{Type: "plain_text", Text: text}}
+}
+
+// SectionBlock 创建一个 markdown 格式的内容块
+func SectionBlock(markdown string, fields ...string) Block {
+	block := Block{Type: "section", Text: &TextObject{Type: "mrkdwn", Text: markdown}}
+	for _, f := range fields {
+		block.Fields = append(block.Fields, TextObject{Type: "mrkdwn", Text: f})
+	}
+
+	return block
+}
+
+// ContextBlock 创建一个上下文块，用于展示次要信息
+func ContextBlock(markdowns ...string) Block {
+	block := Block{Type: "context"}
+	for _, m := range markdowns {
+		block.Elements = append(block.Elements, TextObject{Type: "mrkdwn", Text: m})
+	}
+
+	return block
+}
+
+// DividerBlock 创建一个分割线
+func DividerBlock() Block {
+	return Block{Type: "divider"}
+}
+
+// Client Slack incoming webhook 客户端
+type Client struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewClient create a new slack incoming webhook client
+func NewClient(webhookURL string) *Client {
+	return &Client{webhookURL: webhookURL, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// SendText 发送一条文本消息，文本支持 Slack 的 mrkdwn 格式
+func (client Client) SendText(ctx context.Context, text string) error {
+	return client.send(ctx, map[string]interface{}{"text": text})
+}
+
+// SendBlocks 发送 Block Kit 消息
+func (client Client) SendBlocks(ctx context.Context, blocks []Block) error {
+	return client.send(ctx, map[string]interface{}{"blocks": blocks})
+}
+
+func (client Client) send(ctx context.Context, msg map[string]interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("slack message encode failed: %w", err)
+	}
+
+	for i := 0; ; i++ {
+		err := client.post(ctx, body)
+		rateLimitErr, ok := err.(RateLimitError)
+		if !ok || i >= maxRateLimitRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %v", rateLimitErr, ctx.Err())
+		case <-time.After(rateLimitErr.RetryAfter):
+		}
+	}
+}
+
+func (client Client) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", client.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("slack create request failed: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack send msg failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("slack read response failed: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+		if err != nil || retryAfter < 0 {
+			retryAfter = 1
+		}
+
+		return RateLimitError{RetryAfter: time.Duration(retryAfter) * time.Second}
+	}
+
+	respText := strings.TrimSpace(string(respBytes))
+	if resp.StatusCode != http.StatusOK || respText != "ok" {
+		return Error{StatusCode: resp.StatusCode, Message: respText}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package slack_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/pkg/messager/slack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_SendBlocks(t *testing.T) {
+	var requestCount int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requestCount, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		var msg map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		assert.Len(t, msg["blocks"], 3)
+
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, slack.NewClient(server.URL).SendBlocks(context.TODO(), []slack.Block{
+		slack.HeaderBlock("Hello"),
+		slack.SectionBlock("*world*", "*level*\nerror"),
+		slack.DividerBlock(),
+	}))
+	assert.EqualValues(t, 2, atomic.LoadInt32(&requestCount))
+}
+
+func TestClient_SendTextFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte("invalid_payload"))
+	}))
+	defer server.Close()
+
+	err := slack.NewClient(server.URL).SendText(context.TODO(), "Hello")
+	assert.Error(t, err)
+	assert.Equal(t, "invalid_payload", err.(slack.Error).Message)
+}
@@ -0,0 +1,142 @@
+package twilio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint Twilio API 地址
+const DefaultEndpoint = "https://api.twilio.com"
+
+// 单条短信的最大长度，短信内容全部为 GSM-7 字符时为 160，包含其它字符（如中文）时使用 UCS-2 编码，为 70
+const (
+	gsm7SegmentLength = 160
+	ucs2SegmentLength = 70
+)
+
+// Error Twilio 接口返回的错误
+type Error struct {
+	StatusCode int
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+	MoreInfo   string `json:"more_info"`
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("twilio: [%d] %s (%s)", e.Code, e.Message, e.MoreInfo)
+}
+
+// RateLimited 是否因为请求频率超过限制导致的失败，此时可以稍后重试
+func (e Error) RateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// Client Twilio 短信客户端
+type Client struct {
+	accountSID   string
+	authToken    string
+	from         string
+	multiSegment bool
+	endpoint     string
+	client       *http.Client
+}
+
+// NewClient create a new twilio sms client
+func NewClient(accountSID, authToken, from string) *Client {
+	return &Client{
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+		endpoint:   DefaultEndpoint,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// WithMultiSegment 允许发送多段短信，默认情况下短信内容会被截断为一条短信的长度
+func (client *Client) WithMultiSegment() *Client {
+	client.multiSegment = true
+	return client
+}
+
+// WithEndpoint 设置 API 地址
+func (client *Client) WithEndpoint(endpoint string) *Client {
+	client.endpoint = strings.TrimRight(endpoint, "/")
+	return client
+}
+
+// Send 发送短信，返回 Twilio 的消息 SID
+func (client Client) Send(ctx context.Context, to, body string) (string, error) {
+	if !client.multiSegment {
+		body = truncateToSegment(body)
+	}
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", client.from)
+	form.Set("Body", body)
+
+	reqURL := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", client.endpoint, client.accountSID)
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("twilio create request failed: %w", err)
+	}
+
+	req.SetBasicAuth(client.accountSID, client.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("twilio send sms failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("twilio read response failed: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		twilioErr := Error{StatusCode: resp.StatusCode}
+		if err := json.Unmarshal(respBytes, &twilioErr); err != nil || twilioErr.Message == "" {
+			twilioErr.Message = strings.TrimSpace(string(respBytes))
+		}
+
+		return "", twilioErr
+	}
+
+	var msg struct {
+		SID string `json:"sid"`
+	}
+	if err := json.Unmarshal(respBytes, &msg); err != nil {
+		return "", fmt.Errorf("send finished, response: %s", string(respBytes))
+	}
+
+	return msg.SID, nil
+}
+
+// gsm7Chars GSM-7 基本字符集
+const gsm7Chars = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// truncateToSegment 将短信内容截断为一条短信的长度
+func truncateToSegment(body string) string {
+	limit := gsm7SegmentLength
+	for _, r := range body {
+		if !strings.ContainsRune(gsm7Chars, r) {
+			limit = ucs2SegmentLength
+			break
+		}
+	}
+
+	runes := []rune(body)
+	if len(runes) <= limit {
+		return body
+	}
+
+	return string(runes[:limit])
+}
@@ -0,0 +1,62 @@
+package twilio_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/pkg/messager/sms/twilio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Send(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "AC123", user)
+		assert.Equal(t, "token", pass)
+
+		assert.NoError(t, r.ParseForm())
+		bodies = append(bodies, r.PostForm.Get("Body"))
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"sid":"SM123","status":"queued"}`))
+	}))
+	defer server.Close()
+
+	client := twilio.NewClient("AC123", "token", "+15005550006").WithEndpoint(server.URL)
+
+	sid, err := client.Send(context.TODO(), "+8618888888888", strings.Repeat("a", 200))
+	assert.NoError(t, err)
+	assert.Equal(t, "SM123", sid)
+
+	_, err = client.Send(context.TODO(), "+8618888888888", strings.Repeat("报警", 50))
+	assert.NoError(t, err)
+
+	_, err = client.WithMultiSegment().Send(context.TODO(), "+8618888888888", strings.Repeat("a", 200))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 160, len([]rune(bodies[0])))
+	assert.Equal(t, 70, len([]rune(bodies[1])))
+	assert.Equal(t, 200, len([]rune(bodies[2])))
+}
+
+func TestClient_SendFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"code":20429,"message":"Too Many Requests","more_info":"https://www.twilio.com/docs/errors/20429","status":429}`))
+	}))
+	defer server.Close()
+
+	_, err := twilio.NewClient("AC123", "token", "+15005550006").WithEndpoint(server.URL).Send(context.TODO(), "+8618888888888", "hello")
+	assert.Error(t, err)
+
+	terr := err.(twilio.Error)
+	assert.True(t, terr.RateLimited())
+	assert.Equal(t, 20429, terr.Code)
+}
@@ -0,0 +1,56 @@
+package teams
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/messager"
+)
+
+func init() {
+	messager.Register(Messager{})
+}
+
+// Meta Microsoft Teams 消息通道的动作元数据
+type Meta struct {
+	WebhookURL string `json:"webhook_url"`
+}
+
+// Messager 将 Microsoft Teams 适配为 messager.Messager，动作名称为 teams
+type Messager struct{}
+
+// Name 返回通道名称
+func (Messager) Name() string {
+	return "teams"
+}
+
+// Validate 校验触发动作的元数据
+func (Messager) Validate(meta string) error {
+	_, err := parseMeta(meta)
+	return err
+}
+
+// Send 以规则名称为标题，将渲染之后的内容作为 MessageCard 发送
+func (Messager) Send(ctx context.Context, meta string, group repository.EventGroup, rendered string) error {
+	m, err := parseMeta(meta)
+	if err != nil {
+		return err
+	}
+
+	return NewClient(m.WebhookURL).SendCard(ctx, group.Rule.Name, rendered, nil)
+}
+
+func parseMeta(meta string) (m Meta, err error) {
+	if err := json.Unmarshal([]byte(meta), &m); err != nil {
+		return m, fmt.Errorf("teams: invalid meta: %w", err)
+	}
+
+	if m.WebhookURL == "" {
+		return m, errors.New("teams: webhook_url required")
+	}
+
+	return m, nil
+}
@@ -0,0 +1,149 @@
+package teams
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 常用的主题颜色
+const (
+	ColorCritical = "E81123"
+	ColorWarning  = "FF8C00"
+	ColorInfo     = "0078D7"
+	ColorOK       = "107C10"
+)
+
+// SeverityColor 根据告警级别返回对应的主题颜色
+func SeverityColor(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "fatal", "error", "high", "p1":
+		return ColorCritical
+	case "warning", "warn", "medium", "p2":
+		return ColorWarning
+	case "ok", "resolved", "recovery", "success":
+		return ColorOK
+	default:
+		return ColorInfo
+	}
+}
+
+// Error Teams webhook 返回的错误，Teams 成功时响应纯文本 1，失败时响应错误信息
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("teams: [%d] %s", e.StatusCode, e.Message)
+}
+
+// Card 是一个 MessageCard 消息
+type Card struct {
+	Title      string
+	Text       string
+	ThemeColor string
+	Facts      map[string]string
+}
+
+type messageCardFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type messageCardSection struct {
+	Facts    []messageCardFact `json:"facts,omitempty"`
+	Markdown bool              `json:"markdown"`
+}
+
+type messageCard struct {
+	Type       string               `json:"@type"`
+	Context    string               `json:"@context"`
+	ThemeColor string               `json:"themeColor,omitempty"`
+	Summary    string               `json:"summary"`
+	Title      string               `json:"title"`
+	Text       string               `json:"text"`
+	Sections   []messageCardSection `json:"sections,omitempty"`
+}
+
+func (card Card) toMessageCard() messageCard {
+	msg := messageCard{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		ThemeColor: card.ThemeColor,
+		Summary:    card.Title,
+		Title:      card.Title,
+		Text:       card.Text,
+	}
+
+	if len(card.Facts) > 0 {
+		keys := make([]string, 0, len(card.Facts))
+		for k := range card.Facts {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		facts := make([]messageCardFact, 0, len(keys))
+		for _, k := range keys {
+			facts = append(facts, messageCardFact{Name: k, Value: card.Facts[k]})
+		}
+
+		msg.Sections = []messageCardSection{{Facts: facts, Markdown: true}}
+	}
+
+	return msg
+}
+
+// Client Microsoft Teams incoming webhook 客户端
+type Client struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewClient create a new teams incoming webhook client
+func NewClient(webhookURL string) *Client {
+	return &Client{webhookURL: webhookURL, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// SendCard 发送一条 MessageCard 消息，facts 会按照 key 排序后展示
+func (client Client) SendCard(ctx context.Context, title, text string, facts map[string]string) error {
+	return client.Send(ctx, Card{Title: title, Text: text, Facts: facts, ThemeColor: ColorInfo})
+}
+
+// Send 发送一条 MessageCard 消息
+func (client Client) Send(ctx context.Context, card Card) error {
+	body, err := json.Marshal(card.toMessageCard())
+	if err != nil {
+		return fmt.Errorf("teams message encode failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", client.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("teams create request failed: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("teams send msg failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("teams read response failed: %w", err)
+	}
+
+	respText := strings.TrimSpace(string(respBytes))
+	if resp.StatusCode != http.StatusOK || respText != "1" {
+		return Error{StatusCode: resp.StatusCode, Message: respText}
+	}
+
+	return nil
+}
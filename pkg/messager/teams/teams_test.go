@@ -0,0 +1,42 @@
+package teams_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/pkg/messager/teams"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		assert.Equal(t, "MessageCard", msg["@type"])
+		assert.Equal(t, teams.ColorCritical, msg["themeColor"])
+
+		_, _ = w.Write([]byte("1"))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, teams.NewClient(server.URL).Send(context.TODO(), teams.Card{
+		Title:      "Hello",
+		Text:       "world",
+		ThemeColor: teams.SeverityColor("error"),
+		Facts:      map[string]string{"host": "127.0.0.1"},
+	}))
+}
+
+func TestClient_SendCardFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("Summary or Text is required."))
+	}))
+	defer server.Close()
+
+	err := teams.NewClient(server.URL).SendCard(context.TODO(), "", "", nil)
+	assert.Error(t, err)
+	assert.Equal(t, "Summary or Text is required.", err.(teams.Error).Message)
+}
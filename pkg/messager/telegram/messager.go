@@ -0,0 +1,57 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/messager"
+)
+
+func init() {
+	messager.Register(Messager{})
+}
+
+// Meta Telegram 消息通道的动作元数据
+type Meta struct {
+	Token  string `json:"token"`
+	ChatID string `json:"chat_id"`
+}
+
+// Messager 将 Telegram 适配为 messager.Messager，动作名称为 telegram
+type Messager struct{}
+
+// Name 返回通道名称
+func (Messager) Name() string {
+	return "telegram"
+}
+
+// Validate 校验触发动作的元数据
+func (Messager) Validate(meta string) error {
+	_, err := parseMeta(meta)
+	return err
+}
+
+// Send 将渲染之后的内容作为纯文本消息发送到 chat_id 对应的会话
+func (Messager) Send(ctx context.Context, meta string, group repository.EventGroup, rendered string) error {
+	m, err := parseMeta(meta)
+	if err != nil {
+		return err
+	}
+
+	return NewClient(m.Token, m.ChatID).Send(ctx, rendered)
+}
+
+func parseMeta(meta string) (m Meta, err error) {
+	if err := json.Unmarshal([]byte(meta), &m); err != nil {
+		return m, fmt.Errorf("telegram: invalid meta: %w", err)
+	}
+
+	if m.Token == "" || m.ChatID == "" {
+		return m, errors.New("telegram: token and chat_id required")
+	}
+
+	return m, nil
+}
@@ -0,0 +1,193 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// MaxMessageLength Telegram 单条消息的最大长度
+const MaxMessageLength = 4096
+
+// DefaultEndpoint Telegram Bot API 地址
+const DefaultEndpoint = "https://api.telegram.org"
+
+// markdownV2Reserved MarkdownV2 中需要转义的保留字符
+const markdownV2Reserved = "_*[]()~`>#+-=|{}.!\\"
+
+// EscapeMarkdownV2 转义 MarkdownV2 中的保留字符，转义后的文本会按照原样展示
+func EscapeMarkdownV2(text string) string {
+	var builder strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(markdownV2Reserved, r) {
+			builder.WriteRune('\\')
+		}
+		builder.WriteRune(r)
+	}
+
+	return builder.String()
+}
+
+// Error Telegram 接口返回的错误（ok 为 false）
+type Error struct {
+	Code        int
+	Description string
+	// RetryAfter 被限流（429）时，Telegram 建议的重试等待时间
+	RetryAfter time.Duration
+}
+
+func (e Error) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("telegram: [%d] %s (retry after %s)", e.Code, e.Description, e.RetryAfter)
+	}
+
+	return fmt.Sprintf("telegram: [%d] %s", e.Code, e.Description)
+}
+
+// RateLimited 是否因为发送频率超过限制导致的失败
+func (e Error) RateLimited() bool {
+	return e.Code == http.StatusTooManyRequests
+}
+
+// Client Telegram 机器人客户端
+type Client struct {
+	token    string
+	chatID   string
+	endpoint string
+	client   *http.Client
+}
+
+// NewClient create a new telegram bot client
+func NewClient(token, chatID string) *Client {
+	return &Client{token: token, chatID: chatID, endpoint: DefaultEndpoint, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// WithEndpoint 设置 Bot API 地址，用于使用代理或者自建的 Bot API 服务
+func (client *Client) WithEndpoint(endpoint string) *Client {
+	client.endpoint = strings.TrimRight(endpoint, "/")
+	return client
+}
+
+// Send 发送一条文本消息，文本中的 MarkdownV2 保留字符会被转义，超过长度限制时自动拆分为多条消息
+func (client Client) Send(ctx context.Context, text string) error {
+	for _, chunk := range splitMessage(text, MaxMessageLength, escapedLen) {
+		if err := client.sendMessage(ctx, EscapeMarkdownV2(chunk)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SendMarkdown 发送一条 MarkdownV2 格式的消息，调用方需要自行处理保留字符的转义
+func (client Client) SendMarkdown(ctx context.Context, markdown string) error {
+	for _, chunk := range splitMessage(markdown, MaxMessageLength, func(r rune) int { return 1 }) {
+		if err := client.sendMessage(ctx, chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// telegramResponse Telegram 响应
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+func (client Client) sendMessage(ctx context.Context, text string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id":    client.chatID,
+		"text":       text,
+		"parse_mode": "MarkdownV2",
+	})
+	if err != nil {
+		return fmt.Errorf("telegram message encode failed: %w", err)
+	}
+
+	reqURL := fmt.Sprintf("%s/bot%s/sendMessage", client.endpoint, client.token)
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("telegram create request failed: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegram send msg failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("telegram read response failed: %w", err)
+	}
+
+	var tresp telegramResponse
+	if err := json.Unmarshal(respBytes, &tresp); err != nil {
+		return fmt.Errorf("send finished, response: [%d] %s", resp.StatusCode, string(respBytes))
+	}
+
+	if !tresp.OK {
+		return Error{
+			Code:        tresp.ErrorCode,
+			Description: tresp.Description,
+			RetryAfter:  time.Duration(tresp.Parameters.RetryAfter) * time.Second,
+		}
+	}
+
+	return nil
+}
+
+// escapedLen 返回字符转义后的长度
+func escapedLen(r rune) int {
+	if strings.ContainsRune(markdownV2Reserved, r) {
+		return 2
+	}
+
+	return 1
+}
+
+// splitMessage 将消息拆分为多段，每段长度（由 cost 计算）不超过 limit，优先在换行处拆分
+func splitMessage(text string, limit int, cost func(r rune) int) []string {
+	runes := []rune(text)
+	chunks := make([]string, 0)
+
+	start, size, lastNewline := 0, 0, -1
+	for i, r := range runes {
+		c := cost(r)
+		if size+c > limit && i > start {
+			end := i
+			if lastNewline >= start {
+				end = lastNewline + 1
+			}
+
+			chunks = append(chunks, string(runes[start:end]))
+			start, size, lastNewline = end, 0, -1
+			for _, rr := range runes[start:i] {
+				size += cost(rr)
+			}
+		}
+
+		size += c
+		if r == '\n' {
+			lastNewline = i
+		}
+	}
+
+	if start < len(runes) {
+		chunks = append(chunks, string(runes[start:]))
+	}
+
+	return chunks
+}
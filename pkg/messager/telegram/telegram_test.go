@@ -0,0 +1,73 @@
+package telegram_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/pkg/messager/telegram"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeMarkdownV2(t *testing.T) {
+	assert.Equal(t, `hello\_world\! 1\.0 \(test\)`, telegram.EscapeMarkdownV2("hello_world! 1.0 (test)"))
+}
+
+func TestClient_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bottoken/sendMessage", r.URL.Path)
+
+		var msg map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		assert.Equal(t, "MarkdownV2", msg["parse_mode"])
+		assert.Equal(t, `hello\.world`, msg["text"])
+
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, telegram.NewClient("token", "123").WithEndpoint(server.URL).Send(context.TODO(), "hello.world"))
+}
+
+func TestClient_SendRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 5","parameters":{"retry_after":5}}`))
+	}))
+	defer server.Close()
+
+	err := telegram.NewClient("token", "123").WithEndpoint(server.URL).Send(context.TODO(), "hello")
+	assert.Error(t, err)
+
+	terr := err.(telegram.Error)
+	assert.True(t, terr.RateLimited())
+	assert.Equal(t, 5*time.Second, terr.RetryAfter)
+}
+
+func TestClient_SendLongMessage(t *testing.T) {
+	var chunks []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		chunks = append(chunks, msg["text"].(string))
+
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	text := strings.Repeat("a.b\n", 3000)
+	assert.NoError(t, telegram.NewClient("token", "123").WithEndpoint(server.URL).Send(context.TODO(), text))
+	assert.True(t, len(chunks) > 1)
+
+	var total int
+	for _, c := range chunks {
+		assert.True(t, len([]rune(c)) <= telegram.MaxMessageLength)
+		assert.True(t, strings.HasSuffix(c, "\n"))
+		total += len(c)
+	}
+	assert.Equal(t, len(telegram.EscapeMarkdownV2(text)), total)
+}
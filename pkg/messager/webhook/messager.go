@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/messager"
+)
+
+// defaultBodyTemplate 没有指定请求体模板时，将渲染之后的内容作为 content 字段发送
+const defaultBodyTemplate = `{"group_id": {{ json .Group.ID }}, "content": {{ json .Content }}}`
+
+func init() {
+	messager.Register(Messager{})
+}
+
+// Meta webhook 消息通道的动作元数据
+type Meta struct {
+	URL string `json:"url"`
+	// BodyTemplate 请求体模板，可以使用 .Group（事件组）和 .Content（渲染之后的内容），为空时使用 defaultBodyTemplate
+	BodyTemplate string            `json:"body_template"`
+	Headers      map[string]string `json:"headers"`
+}
+
+// Payload 渲染请求体模板使用的数据
+type Payload struct {
+	Group   repository.EventGroup
+	Content string
+}
+
+// Messager 将 webhook 适配为 messager.Messager，动作名称为 webhook
+type Messager struct{}
+
+// Name 返回通道名称
+func (Messager) Name() string {
+	return "webhook"
+}
+
+// Validate 校验触发动作的元数据
+func (Messager) Validate(meta string) error {
+	_, err := newClientFromMeta(meta)
+	return err
+}
+
+// Send 使用事件组和渲染之后的内容渲染请求体模板，然后发送到 url
+func (Messager) Send(ctx context.Context, meta string, group repository.EventGroup, rendered string) error {
+	client, err := newClientFromMeta(meta)
+	if err != nil {
+		return err
+	}
+
+	return client.Send(ctx, Payload{Group: group, Content: rendered})
+}
+
+func newClientFromMeta(meta string) (*Client, error) {
+	var m Meta
+	if err := json.Unmarshal([]byte(meta), &m); err != nil {
+		return nil, fmt.Errorf("webhook: invalid meta: %w", err)
+	}
+
+	if m.URL == "" {
+		return nil, errors.New("webhook: url required")
+	}
+
+	if m.BodyTemplate == "" {
+		m.BodyTemplate = defaultBodyTemplate
+	}
+
+	return NewClient(m.URL, m.BodyTemplate, m.Headers)
+}
@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Error 服务端返回了非预期的响应码
+type Error struct {
+	StatusCode int
+	Body       string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("webhook: unexpected response [%d] %s", e.StatusCode, e.Body)
+}
+
+// Client 通用的 webhook 客户端，使用模板渲染请求体后发送到指定的 URL
+type Client struct {
+	url            string
+	bodyTemplate   *template.Template
+	headers        map[string]string
+	expectedStatus []int
+	client         *http.Client
+}
+
+// NewClient create a new webhook client
+// bodyTemplate 为 Go text/template 格式的请求体模板，headers 为附加的请求头
+func NewClient(url string, bodyTemplate string, headers map[string]string) (*Client, error) {
+	tmpl, err := template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(bodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("webhook parse body template failed: %w", err)
+	}
+
+	return &Client{
+		url:          url,
+		bodyTemplate: tmpl,
+		headers:      headers,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// WithExpectedStatus 设置预期的响应码，响应码不在列表中时返回错误，为空时所有 2xx 响应码都视为成功
+func (client *Client) WithExpectedStatus(codes ...int) *Client {
+	client.expectedStatus = codes
+	return client
+}
+
+// Send 使用 payload 渲染请求体模板，然后发送 POST 请求
+func (client Client) Send(ctx context.Context, payload interface{}) error {
+	var body bytes.Buffer
+	if err := client.bodyTemplate.Execute(&body, payload); err != nil {
+		return fmt.Errorf("webhook render body failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", client.url, &body)
+	if err != nil {
+		return fmt.Errorf("webhook create request failed: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range client.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook send request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("webhook read response failed: %w", err)
+	}
+
+	if !client.statusExpected(resp.StatusCode) {
+		return Error{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(respBytes))}
+	}
+
+	return nil
+}
+
+func (client Client) statusExpected(statusCode int) bool {
+	if len(client.expectedStatus) == 0 {
+		return statusCode >= 200 && statusCode < 300
+	}
+
+	for _, code := range client.expectedStatus {
+		if code == statusCode {
+			return true
+		}
+	}
+
+	return false
+}
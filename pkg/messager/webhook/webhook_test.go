@@ -0,0 +1,61 @@
+package webhook_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/messager"
+	"github.com/mylxsw/adanos-alert/pkg/messager/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, `{"title":"disk full","tags":["a","b"]}`, string(body))
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	client, err := webhook.NewClient(server.URL, `{"title":{{ json .Title }},"tags":{{ json .Tags }}}`, map[string]string{"X-Token": "secret"})
+	assert.NoError(t, err)
+
+	payload := struct {
+		Title string
+		Tags  []string
+	}{Title: "disk full", Tags: []string{"a", "b"}}
+
+	assert.NoError(t, client.Send(context.TODO(), payload))
+
+	err = client.WithExpectedStatus(http.StatusOK).Send(context.TODO(), payload)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusAccepted, err.(webhook.Error).StatusCode)
+}
+
+func TestNewClientWithInvalidTemplate(t *testing.T) {
+	_, err := webhook.NewClient("http://localhost", `{{ .Title `, nil)
+	assert.Error(t, err)
+}
+
+func TestMessager_Send(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, `{"group_id": "000000000000000000000000", "content": "disk full"}`, string(body))
+	}))
+	defer server.Close()
+
+	m, ok := messager.Get("webhook")
+	assert.True(t, ok)
+	assert.NoError(t, m.Send(context.TODO(), fmt.Sprintf(`{"url":%q}`, server.URL), repository.EventGroup{}, "disk full"))
+
+	assert.Error(t, m.(messager.Validator).Validate(`{}`))
+	assert.Error(t, m.(messager.Validator).Validate(`{"url":"http://localhost","body_template":"{{ .Content "}`))
+}
@@ -0,0 +1,56 @@
+package wecom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"github.com/mylxsw/adanos-alert/pkg/messager"
+)
+
+func init() {
+	messager.Register(Messager{})
+}
+
+// Meta 企业微信 消息通道的动作元数据
+type Meta struct {
+	WebhookKey string `json:"webhook_key"`
+}
+
+// Messager 将 企业微信 适配为 messager.Messager，动作名称为 wecom
+type Messager struct{}
+
+// Name 返回通道名称
+func (Messager) Name() string {
+	return "wecom"
+}
+
+// Validate 校验触发动作的元数据
+func (Messager) Validate(meta string) error {
+	_, err := parseMeta(meta)
+	return err
+}
+
+// Send 将渲染之后的内容作为 markdown 消息发送到企业微信群机器人
+func (Messager) Send(ctx context.Context, meta string, group repository.EventGroup, rendered string) error {
+	m, err := parseMeta(meta)
+	if err != nil {
+		return err
+	}
+
+	return NewClient(m.WebhookKey).SendMarkdown(ctx, rendered)
+}
+
+func parseMeta(meta string) (m Meta, err error) {
+	if err := json.Unmarshal([]byte(meta), &m); err != nil {
+		return m, fmt.Errorf("wecom: invalid meta: %w", err)
+	}
+
+	if m.WebhookKey == "" {
+		return m, errors.New("wecom: webhook_key required")
+	}
+
+	return m, nil
+}
@@ -0,0 +1,147 @@
+package wecom
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint 企业微信群机器人 webhook 地址
+const DefaultEndpoint = "https://qyapi.weixin.qq.com/cgi-bin/webhook/send"
+
+// CodeRateLimited 接口调用超过限制时返回的错误码
+const CodeRateLimited = 45009
+
+// Error 企业微信接口返回的错误
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("wecom: [%d] %s", e.Code, e.Message)
+}
+
+// Retryable 是否可以稍后重试，目前只有频率限制导致的失败可以重试
+func (e Error) Retryable() bool {
+	return e.Code == CodeRateLimited
+}
+
+// Mention 消息中需要提醒的人，UserIDs 中包含 @all 时提醒所有人
+type Mention struct {
+	UserIDs []string
+	Mobiles []string
+}
+
+// MentionMarkdown 生成 markdown 消息中提醒指定用户的文本，markdown 消息只支持通过 userid 提醒
+func MentionMarkdown(userIDs ...string) string {
+	mentions := make([]string, 0, len(userIDs))
+	for _, u := range userIDs {
+		mentions = append(mentions, fmt.Sprintf("<@%s>", u))
+	}
+
+	return strings.Join(mentions, "")
+}
+
+// Article 图文消息中的一篇文章
+type Article struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+	PicURL      string `json:"picurl,omitempty"`
+}
+
+// Client 企业微信群机器人客户端
+type Client struct {
+	webhookKey string
+	endpoint   string
+	client     *http.Client
+}
+
+// NewClient create a new wecom group robot client
+func NewClient(webhookKey string) *Client {
+	return &Client{webhookKey: webhookKey, endpoint: DefaultEndpoint, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// WithEndpoint 设置 webhook 地址
+func (client *Client) WithEndpoint(endpoint string) *Client {
+	client.endpoint = endpoint
+	return client
+}
+
+// SendText 发送文本消息
+func (client Client) SendText(ctx context.Context, content string, mention Mention) error {
+	text := map[string]interface{}{"content": content}
+	if len(mention.UserIDs) > 0 {
+		text["mentioned_list"] = mention.UserIDs
+	}
+	if len(mention.Mobiles) > 0 {
+		text["mentioned_mobile_list"] = mention.Mobiles
+	}
+
+	return client.send(ctx, map[string]interface{}{"msgtype": "text", "text": text})
+}
+
+// SendMarkdown 发送 markdown 消息
+func (client Client) SendMarkdown(ctx context.Context, content string) error {
+	return client.send(ctx, map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]interface{}{"content": content},
+	})
+}
+
+// SendNews 发送图文消息，最多支持 8 篇文章
+func (client Client) SendNews(ctx context.Context, articles []Article) error {
+	return client.send(ctx, map[string]interface{}{
+		"msgtype": "news",
+		"news":    map[string]interface{}{"articles": articles},
+	})
+}
+
+// wecomResponse 企业微信响应
+type wecomResponse struct {
+	ErrorCode    int    `json:"errcode"`
+	ErrorMessage string `json:"errmsg"`
+}
+
+func (client Client) send(ctx context.Context, msg map[string]interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("wecom message encode failed: %w", err)
+	}
+
+	reqURL := client.endpoint + "?" + url.Values{"key": {client.webhookKey}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("wecom create request failed: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("wecom send msg failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("wecom read response failed: %w", err)
+	}
+
+	var wresp wecomResponse
+	if err := json.Unmarshal(respBytes, &wresp); err != nil {
+		return fmt.Errorf("send finished, response: [%d] %s", resp.StatusCode, string(respBytes))
+	}
+
+	if wresp.ErrorCode != 0 {
+		return Error{Code: wresp.ErrorCode, Message: wresp.ErrorMessage}
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package wecom_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mylxsw/adanos-alert/pkg/messager/wecom"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_SendText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "robot-key", r.URL.Query().Get("key"))
+
+		var msg struct {
+			MsgType string `json:"msgtype"`
+			Text    struct {
+				Content             string   `json:"content"`
+				MentionedList       []string `json:"mentioned_list"`
+				MentionedMobileList []string `json:"mentioned_mobile_list"`
+			} `json:"text"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		assert.Equal(t, "text", msg.MsgType)
+		assert.Equal(t, []string{"@all"}, msg.Text.MentionedList)
+		assert.Equal(t, []string{"18888888888"}, msg.Text.MentionedMobileList)
+
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer server.Close()
+
+	client := wecom.NewClient("robot-key").WithEndpoint(server.URL)
+	assert.NoError(t, client.SendText(context.TODO(), "hello", wecom.Mention{UserIDs: []string{"@all"}, Mobiles: []string{"18888888888"}}))
+}
+
+func TestClient_SendMarkdownRateLimited(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errcode":45009,"errmsg":"api freq out of limit"}`))
+	}))
+	defer server.Close()
+
+	err := wecom.NewClient("robot-key").WithEndpoint(server.URL).SendMarkdown(context.TODO(), "# hello "+wecom.MentionMarkdown("zhangsan"))
+	assert.Error(t, err)
+	assert.True(t, err.(wecom.Error).Retryable())
+}
@@ -0,0 +1,69 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// ExpiresParam 签名链接中过期时间（Unix 时间戳）的参数名
+	ExpiresParam = "expires"
+	// TokenParam 签名链接中签名的参数名
+	TokenParam = "token"
+)
+
+var (
+	// ErrInvalidSignature 签名缺失或者与资源不匹配
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrExpired 签名链接已过期
+	ErrExpired = errors.New("signature expired")
+)
+
+// Sign 使用 HMAC-SHA256 对资源 ID 和过期时间签名
+func Sign(secret, resourceID string, expiredAt int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "%s:%d", resourceID, expiredAt)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignURL 为链接追加过期时间和签名参数，链接在 now+ttl 之后失效
+func SignURL(rawURL, secret, resourceID string, ttl time.Duration, now time.Time) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+
+	expiredAt := now.Add(ttl).Unix()
+
+	query := u.Query()
+	query.Set(ExpiresParam, strconv.FormatInt(expiredAt, 10))
+	query.Set(TokenParam, Sign(secret, resourceID, expiredAt))
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// Verify 校验资源 ID 的签名，签名不匹配时返回 ErrInvalidSignature，过期时返回 ErrExpired
+func Verify(secret, resourceID, expires, token string, now time.Time) error {
+	expiredAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || token == "" {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(Sign(secret, resourceID, expiredAt)), []byte(token)) {
+		return ErrInvalidSignature
+	}
+
+	if now.Unix() > expiredAt {
+		return ErrExpired
+	}
+
+	return nil
+}
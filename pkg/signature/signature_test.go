@@ -0,0 +1,32 @@
+package signature_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/pkg/signature"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignURL(t *testing.T) {
+	now := time.Now()
+	signed, err := signature.SignURL("http://localhost:19999/ui/groups/abc.html?offset=10", "secret", "abc", time.Hour, now)
+	assert.NoError(t, err)
+
+	u, err := url.Parse(signed)
+	assert.NoError(t, err)
+	assert.Equal(t, "10", u.Query().Get("offset"))
+
+	expires, token := u.Query().Get(signature.ExpiresParam), u.Query().Get(signature.TokenParam)
+	assert.NoError(t, signature.Verify("secret", "abc", expires, token, now))
+
+	// 链接过期
+	assert.Equal(t, signature.ErrExpired, signature.Verify("secret", "abc", expires, token, now.Add(2*time.Hour)))
+
+	// 签名与资源、密钥或者过期时间不匹配
+	assert.Equal(t, signature.ErrInvalidSignature, signature.Verify("secret", "other", expires, token, now))
+	assert.Equal(t, signature.ErrInvalidSignature, signature.Verify("other", "abc", expires, token, now))
+	assert.Equal(t, signature.ErrInvalidSignature, signature.Verify("secret", "abc", "9999999999", token, now))
+	assert.Equal(t, signature.ErrInvalidSignature, signature.Verify("secret", "abc", "", "", now))
+}
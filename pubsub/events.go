@@ -62,9 +62,28 @@ type MessageGroupTriggeredEvent struct {
 }
 
 // EventGroupReduceEvent 事件组缩减事件
+// 按照数量缩减时 KeepCount 为保留的事件数，按照时间缩减时 Before 为删除事件的截止时间
 type EventGroupReduceEvent struct {
 	GroupID     primitive.ObjectID
 	KeepCount   int64
+	Before      time.Time
 	DeleteCount int64
 	CreatedAt   time.Time
 }
+
+// EventGroupAssignedEvent 事件组指派负责人事件
+type EventGroupAssignedEvent struct {
+	Group            repository.EventGroup
+	Assignee         repository.User
+	PreviousAssignee primitive.ObjectID
+	Operator         string
+	CreatedAt        time.Time
+}
+
+// RecoveryForcedEvent 手动立即恢复事件
+type RecoveryForcedEvent struct {
+	RecoveryID      string
+	RecoveryEventID primitive.ObjectID
+	Operator        string
+	CreatedAt       time.Time
+}
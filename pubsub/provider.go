@@ -56,8 +56,32 @@ func (s ServiceProvider) Boot(app infra.Glacier) {
 			})
 		})
 
+		// 事件组指派负责人事件监听
+		em.Listen(func(ev EventGroupAssignedEvent) {
+			auditRepo.Add(repository.AuditLog{
+				Type: repository.AuditLogTypeAction,
+				Body: fmt.Sprintf("[%s] EventGroup (%s) is assigned to %s(%s) by %s", ev.CreatedAt.Format(time.RFC3339), ev.Group.ID.Hex(), ev.Assignee.Name, ev.Assignee.ID.Hex(), ev.Operator),
+			})
+		})
+
+		// 手动立即恢复事件监听
+		em.Listen(func(ev RecoveryForcedEvent) {
+			auditRepo.Add(repository.AuditLog{
+				Type: repository.AuditLogTypeAction,
+				Body: fmt.Sprintf("[%s] Recovery (%s) is forced by %s, recovery event=%s", ev.CreatedAt.Format(time.RFC3339), ev.RecoveryID, ev.Operator, ev.RecoveryEventID.Hex()),
+			})
+		})
+
 		// 事件组事件清理
 		em.Listen(func(ev EventGroupReduceEvent) {
+			if !ev.Before.IsZero() {
+				auditRepo.Add(repository.AuditLog{
+					Type: repository.AuditLogTypeAction,
+					Body: fmt.Sprintf("[%s] EventGroup's (%s) events created before %s are removed, deleted count=%d", ev.CreatedAt.Format(time.RFC3339), ev.GroupID.Hex(), ev.Before.Format(time.RFC3339), ev.DeleteCount),
+				})
+				return
+			}
+
 			auditRepo.Add(repository.AuditLog{
 				Type: repository.AuditLogTypeAction,
 				Body: fmt.Sprintf("[%s] EventGroup's (%s) event count reduced to %d, deleted count=%d", ev.CreatedAt.Format(time.RFC3339), ev.GroupID.Hex(), ev.KeepCount, ev.DeleteCount),
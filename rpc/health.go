@@ -0,0 +1,30 @@
+package rpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthService grpc.health.v1.Health 服务实现，用于 Kubernetes、负载均衡器等进行 gRPC 健康检查
+type HealthService struct {
+	*health.Server
+}
+
+// NewHealthService create a new health service, all services are serving by default
+func NewHealthService() *HealthService {
+	return &HealthService{Server: health.NewServer()}
+}
+
+// AuthFuncOverride 健康检查不需要校验 Token
+func (hs *HealthService) AuthFuncOverride(ctx context.Context, fullMethodName string) (context.Context, error) {
+	return ctx, nil
+}
+
+// serving 设置指定服务的状态为 SERVING
+func (hs *HealthService) serving(services ...string) {
+	for _, s := range services {
+		hs.SetServingStatus(s, grpc_health_v1.HealthCheckResponse_SERVING)
+	}
+}
@@ -3,10 +3,12 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"io"
 
 	"github.com/mylxsw/adanos-alert/internal/extension"
 	"github.com/mylxsw/adanos-alert/rpc/protocol"
 	"github.com/mylxsw/adanos-alert/service"
+	"github.com/mylxsw/asteria/log"
 	"github.com/mylxsw/container"
 )
 
@@ -37,3 +39,73 @@ func (ms *EventService) Push(ctx context.Context, request *protocol.MessageReque
 
 	return &protocol.IDResponse{Id: id.Hex()}, nil
 }
+
+// PushBatch 按顺序批量保存事件，无法解析的事件会被拒绝并跳过
+// 某个事件保存失败时停止处理剩余的事件，响应中只包含已经处理的事件（len(Ids) + Rejected），
+// agent 只确认这部分事件，剩余的事件在之后重新发送，避免已经保存的事件被重复保存
+// 第一个事件就保存失败时直接返回错误
+func (ms *EventService) PushBatch(ctx context.Context, request *protocol.MessageBatchRequest) (*protocol.BatchResponse, error) {
+	resp := &protocol.BatchResponse{Ids: make([]string, 0, len(request.Messages))}
+	for _, msg := range request.Messages {
+		var commonMessage extension.CommonEvent
+		if err := json.Unmarshal([]byte(msg.Data), &commonMessage); err != nil {
+			log.WithFields(log.Fields{
+				"data": msg.Data,
+			}).Warningf("reject invalid message: %v", err)
+			resp.Rejected++
+			continue
+		}
+
+		id, err := ms.msgService.Add(ctx, commonMessage)
+		if err != nil {
+			if len(resp.Ids) == 0 && resp.Rejected == 0 {
+				return nil, err
+			}
+
+			log.WithFields(log.Fields{
+				"data":      msg.Data,
+				"processed": len(resp.Ids) + int(resp.Rejected),
+				"total":     len(request.Messages),
+			}).Errorf("save message failed, the rest of the batch is left for retry: %v", err)
+			break
+		}
+
+		resp.Ids = append(resp.Ids, id.Hex())
+	}
+
+	return resp, nil
+}
+
+// PushStream receive a stream of messages and return the count of accepted and rejected messages when the stream closed
+func (ms *EventService) PushStream(stream protocol.Message_PushStreamServer) error {
+	resp := &protocol.StreamResponse{}
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(resp)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		var commonMessage extension.CommonEvent
+		if err := json.Unmarshal([]byte(msg.Data), &commonMessage); err != nil {
+			log.WithFields(log.Fields{
+				"data": msg.Data,
+			}).Warningf("reject invalid message: %v", err)
+			resp.Rejected++
+			continue
+		}
+
+		if _, err := ms.msgService.Add(stream.Context(), commonMessage); err != nil {
+			log.WithFields(log.Fields{
+				"data": msg.Data,
+			}).Errorf("save message failed: %v", err)
+			resp.Rejected++
+			continue
+		}
+
+		resp.Accepted++
+	}
+}
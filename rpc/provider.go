@@ -15,6 +15,7 @@ import (
 	"github.com/mylxsw/glacier/infra"
 	"github.com/mylxsw/graceful"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type ServiceProvider struct{}
@@ -33,24 +34,31 @@ func (p ServiceProvider) Register(app container.Container) {
 			),
 		)
 	})
-
+	app.MustSingleton(NewHealthService)
 }
 
 func (p ServiceProvider) Boot(app infra.Glacier) {
-	app.MustResolve(func(serv *grpc.Server) {
+	app.MustResolve(func(serv *grpc.Server, hs *HealthService) {
 		protocol.RegisterMessageServer(serv, NewEventService(app.Container()))
 		protocol.RegisterHeartbeatServer(serv, NewHeartbeatService(app.Container()))
+		grpc_health_v1.RegisterHealthServer(serv, hs)
+
+		for name := range serv.GetServiceInfo() {
+			hs.serving(name)
+		}
 	})
 }
 
 func (p ServiceProvider) Daemon(_ context.Context, app infra.Glacier) {
-	app.MustResolve(func(serv *grpc.Server, conf *configs.Config, gf graceful.Graceful) {
+	app.MustResolve(func(serv *grpc.Server, hs *HealthService, conf *configs.Config, gf graceful.Graceful) {
 		listener, err := net.Listen("tcp", conf.GRPCListen)
 		if err != nil {
 			panic(fmt.Sprintf("can not create listener for grpc: %v", err))
 		}
 
 		gf.AddShutdownHandler(func() {
+			// 停止之前先将健康检查状态设置为 NOT_SERVING，让负载均衡器不再转发新的请求
+			hs.Shutdown()
 			serv.GracefulStop()
 			if log.DebugEnabled() {
 				log.Debug("grpc server has been stopped")
@@ -2,9 +2,9 @@ package service
 
 import (
 	"context"
-	"fmt"
 	"time"
 
+	"github.com/mylxsw/adanos-alert/configs"
 	"github.com/mylxsw/adanos-alert/internal/extension"
 	"github.com/mylxsw/adanos-alert/internal/repository"
 	"github.com/mylxsw/asteria/log"
@@ -14,13 +14,16 @@ import (
 
 type EventService interface {
 	// Add add a new event to repository
+	// 事件的 Meta 字段数量或者标签数量超过限制时，返回的错误包含 extension.ErrEventTooLarge
+	// 事件在抑制周期内被去重丢弃时，返回 primitive.NilObjectID 和 nil 错误
 	Add(ctx context.Context, msg extension.CommonEvent) (primitive.ObjectID, error)
 }
 
 type eventService struct {
-	cc      container.Container
-	kvRepo  repository.KVRepo    `autowire:"@"`
-	msgRepo repository.EventRepo `autowire:"@"`
+	cc          container.Container
+	conf        *configs.Config        `autowire:"@"`
+	inhibitRepo repository.InhibitRepo `autowire:"@"`
+	msgRepo     repository.EventRepo   `autowire:"@"`
 }
 
 func NewEventService(cc container.Container) EventService {
@@ -30,6 +33,11 @@ func NewEventService(cc container.Container) EventService {
 }
 
 func (m *eventService) Add(ctx context.Context, msg extension.CommonEvent) (primitive.ObjectID, error) {
+	limits := extension.IngestLimits{MaxMetaKeys: m.conf.IngestMaxMetaKeys, MaxTags: m.conf.IngestMaxTags}
+	if err := limits.Validate(msg); err != nil {
+		return primitive.NilObjectID, err
+	}
+
 	controlMessage := msg.GetControl()
 
 	var msgID primitive.ObjectID
@@ -46,27 +54,23 @@ func (m *eventService) Add(ctx context.Context, msg extension.CommonEvent) (prim
 		}
 	}()
 
-	if controlMessage.ID != "" {
-		key := fmt.Sprintf("msgctl:inhibit:%s", controlMessage.ID)
-		// 事件抑制
-		inhibitInterval := controlMessage.GetInhibitInterval()
-		if inhibitInterval > 0 {
-			if _, err := m.kvRepo.Get(key); err != nil {
-				if err := m.kvRepo.SetWithTTL(key, time.Now().String(), inhibitInterval); err != nil {
-					log.Errorf("set inhibit interval for %s failed: %v", key, err)
-				}
-			} else {
-				// 事件被抑制，直接丢弃
-				if log.DebugEnabled() {
-					log.WithFields(log.Fields{
-						"key": key,
-						"ctl": msg.GetControl(),
-						"msg": msg.CreateRepoEvent(),
-					}).Debugf("event is discard because it's been inhibited")
-				}
+	// 事件去重：抑制周期内相同 ID 的事件只保留第一个，其余直接丢弃
+	inhibitInterval := controlMessage.GetInhibitInterval()
+	if controlMessage.ID != "" && inhibitInterval > 0 {
+		inhibited, err := m.inhibitRepo.Inhibit(ctx, controlMessage.ID, inhibitInterval)
+		if err != nil {
+			log.Errorf("check inhibit interval for %s failed: %v", controlMessage.ID, err)
+		}
 
-				return primitive.NilObjectID, nil
+		if inhibited {
+			if log.DebugEnabled() {
+				log.WithFields(log.Fields{
+					"ctl": msg.GetControl(),
+					"msg": msg.CreateRepoEvent(),
+				}).Debugf("event is discard because it's been inhibited")
 			}
+
+			return primitive.NilObjectID, nil
 		}
 	}
 
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/mylxsw/adanos-alert/internal/repository"
 	"github.com/mylxsw/container"
@@ -13,6 +14,8 @@ import (
 type EventGroupService interface {
 	// CutGroup 缩减分组中 event 的数量，只保留  keepCount 条（relation_ids 不为空的 events 不能删除）
 	CutGroup(ctx context.Context, groupID primitive.ObjectID, keepCount int64) (int64, error)
+	// CutGroupBefore 删除分组中创建时间早于 before 的 event，不影响分组的 MessageCount
+	CutGroupBefore(ctx context.Context, groupID primitive.ObjectID, before time.Time) (int64, error)
 }
 
 type eventGroupService struct {
@@ -50,3 +53,22 @@ func (eg *eventGroupService) CutGroup(ctx context.Context, groupID primitive.Obj
 
 	return allEventCount - keepCount, eg.evtRepo.Delete(bson.M{"group_ids": groupID, "_id": bson.M{"$nin": keepEventIDs}})
 }
+
+// CutGroupBefore 实现 EventGroupService 接口
+func (eg *eventGroupService) CutGroupBefore(ctx context.Context, groupID primitive.ObjectID, before time.Time) (int64, error) {
+	if groupID.IsZero() {
+		return 0, nil
+	}
+
+	filter := bson.M{"group_ids": groupID, "created_at": bson.M{"$lt": before}}
+	deleteCount, err := eg.evtRepo.Count(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	if deleteCount == 0 {
+		return 0, nil
+	}
+
+	return deleteCount, eg.evtRepo.Delete(filter)
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type EventRelationRepo struct {
+	lock      sync.Mutex
+	Relations []repository.EventRelation
+}
+
+func NewEventRelationRepo() repository.EventRelationRepo {
+	return &EventRelationRepo{Relations: make([]repository.EventRelation, 0)}
+}
+
+func (m *EventRelationRepo) EnsureEventRelation(ctx context.Context, summary string, matchedRuleID primitive.ObjectID) (repository.EventRelation, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, rel := range m.Relations {
+		if rel.Summary == summary && rel.MatchedRuleID == matchedRuleID {
+			return rel, nil
+		}
+	}
+
+	rel := repository.EventRelation{
+		ID:            primitive.NewObjectID(),
+		MatchedRuleID: matchedRuleID,
+		Summary:       summary,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	m.Relations = append(m.Relations, rel)
+
+	return rel, nil
+}
+
+func (m *EventRelationRepo) IncrEventCount(ctx context.Context, counts map[primitive.ObjectID]int64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for i, rel := range m.Relations {
+		if count, ok := counts[rel.ID]; ok {
+			m.Relations[i].EventCount += count
+			m.Relations[i].UpdatedAt = time.Now()
+		}
+	}
+
+	return nil
+}
+
+func (m *EventRelationRepo) Get(ctx context.Context, id primitive.ObjectID) (eventRel repository.EventRelation, err error) {
+	panic("implement me")
+}
+
+func (m *EventRelationRepo) Paginate(ctx context.Context, filter interface{}, offset, limit int64) (eventRels []repository.EventRelation, next int64, err error) {
+	panic("implement me")
+}
+
+func (m *EventRelationRepo) Count(ctx context.Context, filter interface{}) (int64, error) {
+	panic("implement me")
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type LockRepo struct {
+	lock  sync.Mutex
+	Locks map[string]repository.Lock
+}
+
+func NewLockRepo() repository.LockRepo {
+	return &LockRepo{Locks: make(map[string]repository.Lock)}
+}
+
+func (m *LockRepo) Lock(resource string, owner string, ttl uint) (*repository.Lock, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := time.Now()
+	if lock, ok := m.Locks[resource]; ok && lock.ExpiredAt.After(now) {
+		return nil, repository.ErrAlreadyLocked
+	}
+
+	lock := repository.Lock{
+		LockID:    primitive.NewObjectID(),
+		Resource:  resource,
+		Acquired:  true,
+		Owner:     owner,
+		TTL:       ttl,
+		CreatedAt: now,
+		RenewedAt: now,
+		ExpiredAt: now.Add(time.Duration(ttl) * time.Second),
+	}
+	m.Locks[resource] = lock
+
+	return &lock, nil
+}
+
+func (m *LockRepo) Renew(lockID primitive.ObjectID, ttl uint) (*repository.Lock, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for resource, lock := range m.Locks {
+		if lock.LockID == lockID {
+			now := time.Now()
+			lock.TTL = ttl
+			lock.RenewedAt = now
+			lock.ExpiredAt = now.Add(time.Duration(ttl) * time.Second)
+			m.Locks[resource] = lock
+
+			return &lock, nil
+		}
+	}
+
+	return nil, repository.ErrLockNotFound
+}
+
+func (m *LockRepo) UnLock(lockID primitive.ObjectID) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for resource, lock := range m.Locks {
+		if lock.LockID == lockID {
+			delete(m.Locks, resource)
+			return nil
+		}
+	}
+
+	return repository.ErrLockNotFound
+}
+
+func (m *LockRepo) Remove(resource string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.Locks, resource)
+	return nil
+}
+
+func (m *LockRepo) CurrentOwner(resource string) (string, time.Time, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	lock, ok := m.Locks[resource]
+	if !ok || !lock.ExpiredAt.After(time.Now()) {
+		return "", time.Time{}, nil
+	}
+
+	return lock.Owner, lock.ExpiredAt, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/mylxsw/adanos-alert/internal/repository"
@@ -11,11 +12,18 @@ import (
 )
 
 type MessageRepo struct {
+	lock     sync.RWMutex
 	Messages []repository.Event
+	claims   map[primitive.ObjectID]eventClaim
+}
+
+type eventClaim struct {
+	claimer   string
+	claimedAt time.Time
 }
 
 func (m *MessageRepo) AddWithContext(ctx context.Context, msg repository.Event) (id primitive.ObjectID, err error) {
-	panic("implement me")
+	return m.Add(msg)
 }
 
 func NewMessageRepo() repository.EventRepo {
@@ -23,6 +31,9 @@ func NewMessageRepo() repository.EventRepo {
 }
 
 func (m *MessageRepo) Add(msg repository.Event) (id primitive.ObjectID, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	msg.ID = primitive.NewObjectID()
 	msg.CreatedAt = time.Now()
 
@@ -31,6 +42,9 @@ func (m *MessageRepo) Add(msg repository.Event) (id primitive.ObjectID, err erro
 }
 
 func (m *MessageRepo) Get(id primitive.ObjectID) (msg repository.Event, err error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
 	for _, msg := range m.Messages {
 		if msg.ID == id {
 			return msg, nil
@@ -44,11 +58,39 @@ func (m *MessageRepo) Find(filter interface{}) (messages []repository.Event, err
 	panic("implement me")
 }
 
+func (m *MessageRepo) FindIDs(ctx context.Context, filter interface{}, limit int64) ([]primitive.ObjectID, error) {
+	panic("implement me")
+}
+
 func (m *MessageRepo) Paginate(filter interface{}, offset, limit int64) (messages []repository.Event, next int64, err error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	messages = m.filter(filter)
+	if offset >= int64(len(messages)) {
+		return []repository.Event{}, 0, nil
+	}
+
+	messages = messages[offset:]
+	if int64(len(messages)) > limit {
+		return messages[:limit], offset + limit, nil
+	}
+
+	return messages, 0, nil
+}
+
+func (m *MessageRepo) PaginateAfter(filter interface{}, afterID primitive.ObjectID, limit int64) (messages []repository.Event, next primitive.ObjectID, err error) {
+	panic("implement me")
+}
+
+func (m *MessageRepo) Search(text string, filter bson.M, offset, limit int64) (messages []repository.Event, total int64, err error) {
 	panic("implement me")
 }
 
 func (m *MessageRepo) Delete(filter interface{}) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	m.Messages = m.filter(filter)
 	return nil
 }
@@ -58,7 +100,19 @@ func (m *MessageRepo) DeleteID(id primitive.ObjectID) error {
 }
 
 func (m *MessageRepo) Traverse(filter interface{}, cb func(msg repository.Event) error) error {
-	for _, msg := range m.filter(filter) {
+	return m.TraverseCtx(context.TODO(), filter, cb)
+}
+
+func (m *MessageRepo) TraverseCtx(ctx context.Context, filter interface{}, cb func(msg repository.Event) error) error {
+	m.lock.RLock()
+	messages := m.filter(filter)
+	m.lock.RUnlock()
+
+	for _, msg := range messages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		if err := cb(msg); err != nil {
 			return err
 		}
@@ -68,6 +122,9 @@ func (m *MessageRepo) Traverse(filter interface{}, cb func(msg repository.Event)
 }
 
 func (m *MessageRepo) UpdateID(id primitive.ObjectID, update repository.Event) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	for i, msg := range m.Messages {
 		if msg.ID == id {
 			m.Messages[i] = update
@@ -78,10 +135,75 @@ func (m *MessageRepo) UpdateID(id primitive.ObjectID, update repository.Event) e
 	return nil
 }
 
+func (m *MessageRepo) ClaimPending(ctx context.Context, id primitive.ObjectID, claimer string, expiredBefore time.Time) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, msg := range m.Messages {
+		if msg.ID != id {
+			continue
+		}
+
+		if msg.Status != repository.EventStatusPending {
+			return false, nil
+		}
+
+		if m.claims == nil {
+			m.claims = make(map[primitive.ObjectID]eventClaim)
+		}
+
+		if c, ok := m.claims[id]; ok && c.claimer != claimer && !c.claimedAt.Before(expiredBefore) {
+			return false, nil
+		}
+
+		m.claims[id] = eventClaim{claimer: claimer, claimedAt: time.Now()}
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (m *MessageRepo) BulkUpdateStatus(updates []repository.EventStatusUpdate) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	failed := make([]repository.EventStatusUpdateFailure, 0)
+	for _, u := range updates {
+		applied := false
+		for i, msg := range m.Messages {
+			if msg.ID == u.ID {
+				m.Messages[i].Status = u.Status
+				m.Messages[i].GroupID = u.GroupID
+				m.Messages[i].RelationID = u.RelationID
+				delete(m.claims, u.ID)
+				applied = true
+				break
+			}
+		}
+
+		if !applied {
+			failed = append(failed, repository.EventStatusUpdateFailure{ID: u.ID, Reason: "not found"})
+		}
+	}
+
+	if len(failed) > 0 {
+		return repository.EventBulkUpdateError{Total: len(updates), Failed: failed}
+	}
+
+	return nil
+}
+
 func (m *MessageRepo) Count(filter interface{}) (int64, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
 	return int64(len(m.filter(filter))), nil
 }
 
+func (m *MessageRepo) CountByDatetime(ctx context.Context, filter bson.M, startTime, endTime time.Time, hour int64) ([]repository.EventByDatetimeCount, error) {
+	panic("implement me")
+}
+
 func (m *MessageRepo) filter(filter interface{}) (messages []repository.Event) {
 	err := coll.MustNew(m.Messages).Filter(func(msg repository.Event) bool {
 		if status, ok := filter.(bson.M)["status"]; ok && msg.Status != status {
@@ -92,6 +214,20 @@ func (m *MessageRepo) filter(filter interface{}) (messages []repository.Event) {
 			return false
 		}
 
+		if groupID, ok := filter.(bson.M)["group_ids"]; ok {
+			matched := false
+			for _, gid := range msg.GroupID {
+				if gid == groupID {
+					matched = true
+					break
+				}
+			}
+
+			if !matched {
+				return false
+			}
+		}
+
 		return true
 	}).All(&messages)
 
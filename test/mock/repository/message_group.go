@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/mylxsw/adanos-alert/internal/repository"
@@ -11,6 +12,7 @@ import (
 )
 
 type EventGroupRepo struct {
+	lock   sync.RWMutex
 	Groups []repository.EventGroup
 }
 
@@ -22,7 +24,11 @@ func (m *EventGroupRepo) StatByUserCount(ctx context.Context, startTime, endTime
 	panic("implement me")
 }
 
-func (m *EventGroupRepo) StatByDatetimeCount(ctx context.Context, startTime, endTime time.Time, hour int64) ([]repository.EventGroupByDatetimeCount, error) {
+func (m *EventGroupRepo) StatByDatetimeCount(ctx context.Context, filter bson.M, startTime, endTime time.Time, hour int64) ([]repository.EventGroupByDatetimeCount, error) {
+	panic("implement me")
+}
+
+func (m *EventGroupRepo) StatByTimeBucket(ctx context.Context, filter bson.M, interval string) ([]repository.EventGroupBucket, error) {
 	panic("implement me")
 }
 
@@ -50,7 +56,14 @@ func (m *EventGroupRepo) Paginate(filter bson.M, offset, limit int64) (grps []re
 	panic("implement me")
 }
 
+func (m *EventGroupRepo) PaginateAfter(filter bson.M, afterID primitive.ObjectID, limit int64) (grps []repository.EventGroup, next primitive.ObjectID, err error) {
+	panic("implement me")
+}
+
 func (m *EventGroupRepo) Delete(filter bson.M) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	m.Groups = m.filter(filter)
 	return nil
 }
@@ -60,7 +73,19 @@ func (m *EventGroupRepo) DeleteID(id primitive.ObjectID) error {
 }
 
 func (m *EventGroupRepo) Traverse(filter bson.M, cb func(grp repository.EventGroup) error) error {
-	for _, grp := range m.filter(filter) {
+	return m.TraverseCtx(context.TODO(), filter, cb)
+}
+
+func (m *EventGroupRepo) TraverseCtx(ctx context.Context, filter bson.M, cb func(grp repository.EventGroup) error) error {
+	m.lock.RLock()
+	groups := m.filter(filter)
+	m.lock.RUnlock()
+
+	for _, grp := range groups {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		if err := cb(grp); err != nil {
 			return err
 		}
@@ -70,6 +95,9 @@ func (m *EventGroupRepo) Traverse(filter bson.M, cb func(grp repository.EventGro
 }
 
 func (m *EventGroupRepo) UpdateID(id primitive.ObjectID, grp repository.EventGroup) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	for i, g := range m.Groups {
 		if g.ID == id {
 			m.Groups[i] = grp
@@ -80,11 +108,99 @@ func (m *EventGroupRepo) UpdateID(id primitive.ObjectID, grp repository.EventGro
 	return nil
 }
 
+func (m *EventGroupRepo) UpdateStatusMany(ids []primitive.ObjectID, from []repository.EventGroupStatus, status string) (int64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	allowed := make(map[repository.EventGroupStatus]bool)
+	for _, st := range from {
+		allowed[st] = true
+	}
+
+	var modified int64
+	for i, g := range m.Groups {
+		if !allowed[g.Status] {
+			continue
+		}
+
+		for _, id := range ids {
+			if g.ID == id {
+				m.Groups[i].Status = repository.EventGroupStatus(status)
+				m.Groups[i].UpdatedAt = time.Now()
+				modified++
+				break
+			}
+		}
+	}
+
+	return modified, nil
+}
+
+func (m *EventGroupRepo) Ack(id primitive.ObjectID, userID primitive.ObjectID) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for i, g := range m.Groups {
+		if g.ID == id {
+			if g.AckedAt != nil {
+				return repository.ErrAlreadyAcked
+			}
+
+			now := time.Now()
+			m.Groups[i].AckedAt = &now
+			m.Groups[i].AckedBy = userID
+			m.Groups[i].UpdatedAt = now
+			return nil
+		}
+	}
+
+	return repository.ErrNotFound
+}
+
+func (m *EventGroupRepo) MarkEscalated(id primitive.ObjectID, actionIndex int, escalatedSteps int) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for i, g := range m.Groups {
+		if g.ID != id {
+			continue
+		}
+
+		if g.AckedAt != nil || actionIndex >= len(g.Actions) || g.Actions[actionIndex].EscalatedSteps != escalatedSteps {
+			return false, nil
+		}
+
+		m.Groups[i].Actions[actionIndex].EscalatedSteps++
+		m.Groups[i].UpdatedAt = time.Now()
+		return true, nil
+	}
+
+	return false, nil
+}
+
 func (m *EventGroupRepo) Count(filter bson.M) (int64, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
 	return int64(len(m.filter(filter))), nil
 }
 
+func (m *EventGroupRepo) CountByStatus(filter bson.M) (map[string]int64, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	results := make(map[string]int64)
+	for _, grp := range m.filter(filter) {
+		results[string(grp.Status)]++
+	}
+
+	return results, nil
+}
+
 func (m *EventGroupRepo) CollectingGroup(rule repository.EventGroupRule) (group repository.EventGroup, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	groups := m.filter(bson.M{"rule._id": rule.ID, "status": repository.EventGroupStatusCollecting})
 	if len(groups) == 0 {
 		group = repository.EventGroup{
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mylxsw/adanos-alert/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RecoveryRepo struct {
+	lock       sync.Mutex
+	Recoveries map[string]repository.Recovery
+}
+
+func NewRecoveryRepo() repository.RecoveryRepo {
+	return &RecoveryRepo{Recoveries: make(map[string]repository.Recovery)}
+}
+
+func (m *RecoveryRepo) Register(ctx context.Context, recoveryAt time.Time, recoveryID string, refID primitive.ObjectID) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	rec, ok := m.Recoveries[recoveryID]
+	if !ok {
+		rec = repository.Recovery{RecoveryID: recoveryID, RefIDs: []primitive.ObjectID{}, CreatedAt: time.Now()}
+	}
+
+	if refID != primitive.NilObjectID {
+		rec.RefIDs = append(rec.RefIDs, refID)
+	}
+
+	rec.RecoveryAt = recoveryAt
+	rec.UpdatedAt = time.Now()
+	m.Recoveries[recoveryID] = rec
+
+	return nil
+}
+
+func (m *RecoveryRepo) RecoverableEvents(ctx context.Context, deadline time.Time) ([]repository.Recovery, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	results := make([]repository.Recovery, 0)
+	for _, rec := range m.Recoveries {
+		if rec.RecoveryAt.Before(deadline) {
+			results = append(results, rec)
+		}
+	}
+
+	return results, nil
+}
+
+func (m *RecoveryRepo) FindByIdentifier(ctx context.Context, recoveryID string) ([]repository.Recovery, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if rec, ok := m.Recoveries[recoveryID]; ok {
+		return []repository.Recovery{rec}, nil
+	}
+
+	return []repository.Recovery{}, nil
+}
+
+func (m *RecoveryRepo) Delete(ctx context.Context, recoveryID string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.Recoveries, recoveryID)
+	return nil
+}
+
+func (m *RecoveryRepo) DeleteBefore(ctx context.Context, before time.Time, limit int64) (int64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var deleted int64
+	for id, rec := range m.Recoveries {
+		if deleted >= limit {
+			break
+		}
+
+		if rec.RecoveryAt.Before(before) {
+			delete(m.Recoveries, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
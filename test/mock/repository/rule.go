@@ -41,7 +41,13 @@ func (r *RuleRepo) Add(rule repository.Rule) (id primitive.ObjectID, err error)
 }
 
 func (r *RuleRepo) Get(id primitive.ObjectID) (rule repository.Rule, err error) {
-	panic("implement me")
+	for _, rule := range r.Rules {
+		if rule.ID == id {
+			return rule, nil
+		}
+	}
+
+	return rule, repository.ErrNotFound
 }
 
 func (r *RuleRepo) Find(filter bson.M) (rules []repository.Rule, err error) {
@@ -56,6 +62,24 @@ func (r *RuleRepo) UpdateID(id primitive.ObjectID, rule repository.Rule) error {
 	panic("implement me")
 }
 
+func (r *RuleRepo) SetStatus(id primitive.ObjectID, status repository.RuleStatus, operator string, reason string) error {
+	for i, rule := range r.Rules {
+		if rule.ID == id {
+			r.Rules[i].Status = status
+			r.Rules[i].UpdatedAt = time.Now()
+			r.Rules[i].LastStatusChange = &repository.RuleStatusChange{
+				Status:    status,
+				Operator:  operator,
+				Reason:    reason,
+				CreatedAt: r.Rules[i].UpdatedAt,
+			}
+			return nil
+		}
+	}
+
+	return repository.ErrNotFound
+}
+
 func (r *RuleRepo) Count(filter bson.M) (int64, error) {
 	return int64(len(r.filter(filter))), nil
 }